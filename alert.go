@@ -21,12 +21,12 @@ import (
 	"github.com/thingnario/kapacitor/services/httppost"
 	"github.com/thingnario/kapacitor/services/kafka"
 	"github.com/thingnario/kapacitor/services/mqtt"
+	"github.com/thingnario/kapacitor/services/nodestate"
 	"github.com/thingnario/kapacitor/services/opsgenie"
 	"github.com/thingnario/kapacitor/services/opsgenie2"
 	"github.com/thingnario/kapacitor/services/pagerduty"
 	"github.com/thingnario/kapacitor/services/pagerduty2"
 	"github.com/thingnario/kapacitor/services/pushover"
-	"github.com/thingnario/kapacitor/services/redis"
 	"github.com/thingnario/kapacitor/services/sensu"
 	"github.com/thingnario/kapacitor/services/slack"
 	"github.com/thingnario/kapacitor/services/smtp"
@@ -611,8 +611,12 @@ func (n *AlertNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.R
 
 func (n *AlertNode) restoreEventState(id string, t time.Time, tags models.Tags) *alertState {
 	state := n.newAlertState(tags)
-	key := fmt.Sprintf("topics|%s|%s", n.anonTopic, id)
-	exists, _ := redis.KeyExists(key)
+	key := n.eventStateKey(id)
+	state.stateKey = key
+	exists, err := n.et.tm.StateStore.Exists(key)
+	if err != nil {
+		n.diag.Error("failed to check for saved event state", err, keyvalue.KV("key", key))
+	}
 	if exists {
 		state.initialized = true
 	}
@@ -626,6 +630,11 @@ func (n *AlertNode) restoreEventState(id string, t time.Time, tags models.Tags)
 	return state
 }
 
+// eventStateKey returns the key under which the alert service persists the state of an event.
+func (n *AlertNode) eventStateKey(id string) string {
+	return nodestate.EventStatePrefix + n.anonTopic + "|" + id
+}
+
 func (n *AlertNode) newAlertState(tags models.Tags) *alertState {
 	inhibitors := make([]*alert.Inhibitor, len(n.a.Inhibitors))
 	for i, in := range n.a.Inhibitors {
@@ -836,6 +845,9 @@ type alertState struct {
 	initialized   bool
 
 	inhibitors []*alert.Inhibitor
+
+	// Key of the persisted event state of the group.
+	stateKey string
 }
 
 func (a *alertState) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
@@ -1026,6 +1038,9 @@ func (a *alertState) Barrier(b edge.BarrierMessage) (edge.Message, error) {
 }
 
 func (a *alertState) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	if err := a.n.et.tm.StateStore.Delete(a.stateKey); err != nil {
+		a.n.diag.Error("failed to delete saved event state", err, keyvalue.KV("key", a.stateKey))
+	}
 	return d, nil
 }
 func (a *alertState) Done() {
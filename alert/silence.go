@@ -0,0 +1,131 @@
+package alert
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Silence suppresses the handlers of matching events during a maintenance window.
+// The state of silenced events is still recorded.
+type Silence struct {
+	ID string
+	// Pattern of the topics to silence, empty matches all topics.
+	Topic string
+	// Pattern of the event IDs to silence, empty matches all events.
+	EventID string
+	// Patterns the tags of the event must match, a missing tag has an empty value.
+	Tags map[string]string
+
+	Start time.Time
+	End   time.Time
+
+	Creator string
+	Comment string
+}
+
+// Active reports whether the silence applies at time t.
+func (s Silence) Active(t time.Time) bool {
+	return !t.Before(s.Start) && t.Before(s.End)
+}
+
+// Expired reports whether the silence no longer applies after time t.
+func (s Silence) Expired(t time.Time) bool {
+	return !t.Before(s.End)
+}
+
+// Matches reports whether the event is matched by the silence, regardless of time.
+func (s Silence) Matches(event Event) bool {
+	if !PatternMatch(s.Topic, event.Topic) || !PatternMatch(s.EventID, event.State.ID) {
+		return false
+	}
+	for k, pattern := range s.Tags {
+		if !PatternMatch(pattern, event.Data.Tags[k]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Silences is a set of silences.
+type Silences struct {
+	mu       sync.RWMutex
+	silences map[string]Silence
+
+	// now returns the current time to expire silences, replaceable for testing.
+	now func() time.Time
+}
+
+func NewSilences() *Silences {
+	return &Silences{
+		silences: make(map[string]Silence),
+		now:      time.Now,
+	}
+}
+
+// Set adds or replaces a silence.
+func (s *Silences) Set(silence Silence) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.silences[silence.ID] = silence
+}
+
+func (s *Silences) Delete(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.silences, id)
+}
+
+// Silence returns the silence with the ID.
+func (s *Silences) Silence(id string) (Silence, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	silence, ok := s.silences[id]
+	return silence, ok
+}
+
+// List returns all silences sorted by start time and ID.
+func (s *Silences) List() []Silence {
+	s.mu.RLock()
+	list := make([]Silence, 0, len(s.silences))
+	for _, silence := range s.silences {
+		list = append(list, silence)
+	}
+	s.mu.RUnlock()
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].Start.Equal(list[j].Start) {
+			return list[i].Start.Before(list[j].Start)
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+// IsSilenced reports whether a silence that was active at the time of the event matches the event.
+// The time of the event is used so that replayed and late events are silenced by when they happened.
+func (s *Silences) IsSilenced(event Event) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, silence := range s.silences {
+		if silence.Active(event.State.Time) && silence.Matches(event) {
+			return true
+		}
+	}
+	return false
+}
+
+// Expire removes all expired silences and returns their IDs.
+func (s *Silences) Expire() []string {
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var expired []string
+	for id, silence := range s.silences {
+		if silence.Expired(now) {
+			expired = append(expired, id)
+			delete(s.silences, id)
+		}
+	}
+	sort.Strings(expired)
+	return expired
+}
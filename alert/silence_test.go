@@ -0,0 +1,191 @@
+package alert_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/thingnario/kapacitor/alert"
+	"github.com/thingnario/kapacitor/models"
+)
+
+func newSilencedEvent(topic, id string, tags models.Tags) alert.Event {
+	return alert.Event{
+		Topic: topic,
+		State: alert.EventState{
+			ID:    id,
+			Level: alert.Critical,
+			Time:  time.Now(),
+		},
+		Data: alert.EventData{
+			Tags: tags,
+		},
+	}
+}
+
+func TestSilence_Matches(t *testing.T) {
+	event := newSilencedEvent("cpu", "serverA:cpu", models.Tags{"host": "serverA", "dc": "east"})
+	testCases := []struct {
+		name    string
+		silence alert.Silence
+		want    bool
+	}{
+		{
+			name:    "empty matches all",
+			silence: alert.Silence{},
+			want:    true,
+		},
+		{
+			name:    "topic pattern",
+			silence: alert.Silence{Topic: "c*"},
+			want:    true,
+		},
+		{
+			name:    "other topic",
+			silence: alert.Silence{Topic: "mem"},
+			want:    false,
+		},
+		{
+			name:    "event pattern",
+			silence: alert.Silence{EventID: "serverA:*"},
+			want:    true,
+		},
+		{
+			name:    "other event",
+			silence: alert.Silence{EventID: "serverB:*"},
+			want:    false,
+		},
+		{
+			name:    "tag patterns",
+			silence: alert.Silence{Tags: map[string]string{"host": "server?", "dc": "east"}},
+			want:    true,
+		},
+		{
+			name:    "other tag value",
+			silence: alert.Silence{Tags: map[string]string{"host": "serverA", "dc": "west"}},
+			want:    false,
+		},
+		{
+			name:    "missing tag",
+			silence: alert.Silence{Tags: map[string]string{"rack": "r1"}},
+			want:    false,
+		},
+	}
+	for _, tc := range testCases {
+		if got := tc.silence.Matches(event); got != tc.want {
+			t.Errorf("%s: unexpected match got %t exp %t", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestSilence_Active(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := alert.Silence{Start: start, End: start.Add(time.Hour)}
+	testCases := []struct {
+		t       time.Time
+		active  bool
+		expired bool
+	}{
+		{t: start.Add(-time.Second), active: false, expired: false},
+		{t: start, active: true, expired: false},
+		{t: start.Add(30 * time.Minute), active: true, expired: false},
+		{t: start.Add(time.Hour), active: false, expired: true},
+	}
+	for _, tc := range testCases {
+		if got := s.Active(tc.t); got != tc.active {
+			t.Errorf("%v: unexpected active got %t exp %t", tc.t, got, tc.active)
+		}
+		if got := s.Expired(tc.t); got != tc.expired {
+			t.Errorf("%v: unexpected expired got %t exp %t", tc.t, got, tc.expired)
+		}
+	}
+}
+
+func TestSilences_Expire(t *testing.T) {
+	now := time.Now()
+	silences := alert.NewSilences()
+	silences.Set(alert.Silence{ID: "past", Start: now.Add(-2 * time.Hour), End: now.Add(-time.Hour)})
+	silences.Set(alert.Silence{ID: "current", Start: now.Add(-time.Hour), End: now.Add(time.Hour)})
+	silences.Set(alert.Silence{ID: "future", Start: now.Add(time.Hour), End: now.Add(2 * time.Hour)})
+
+	event := newSilencedEvent("cpu", "serverA", nil)
+	if !silences.IsSilenced(event) {
+		t.Error("expected event to be silenced by the current silence")
+	}
+	if got, exp := silences.Expire(), []string{"past"}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected expired silences got %v exp %v", got, exp)
+	}
+	var ids []string
+	for _, s := range silences.List() {
+		ids = append(ids, s.ID)
+	}
+	if exp := []string{"current", "future"}; !reflect.DeepEqual(ids, exp) {
+		t.Errorf("unexpected silences got %v exp %v", ids, exp)
+	}
+
+	silences.Delete("current")
+	if silences.IsSilenced(event) {
+		t.Error("expected event not to be silenced by a future silence")
+	}
+}
+
+func TestSilences_IsSilencedAtEventTime(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	silences := alert.NewSilences()
+	silences.Set(alert.Silence{ID: "window", Start: start, End: start.Add(time.Hour)})
+
+	// A replayed event is silenced by the silence active when it happened.
+	event := newSilencedEvent("cpu", "serverA", nil)
+	event.State.Time = start.Add(30 * time.Minute)
+	if !silences.IsSilenced(event) {
+		t.Error("expected event during the silence to be silenced")
+	}
+	event.State.Time = start.Add(time.Hour)
+	if silences.IsSilenced(event) {
+		t.Error("expected event after the silence not to be silenced")
+	}
+}
+
+type chanHandler chan alert.Event
+
+func (h chanHandler) Handle(event alert.Event) {
+	h <- event
+}
+
+func TestTopics_Silenced(t *testing.T) {
+	topics := alert.NewTopics()
+	h := make(chanHandler, 1)
+	topics.RegisterHandler("silenced", h)
+	defer topics.DeleteTopic("silenced")
+
+	now := time.Now()
+	topics.Silences().Set(alert.Silence{
+		ID:    "maintenance",
+		Tags:  map[string]string{"host": "serverA"},
+		Start: now.Add(-time.Minute),
+		End:   now.Add(time.Hour),
+	})
+
+	if err := topics.Collect(newSilencedEvent("silenced", "serverA", models.Tags{"host": "serverA"})); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := topics.EventState("silenced", "serverA"); !ok {
+		t.Error("expected the state of the silenced event to be recorded")
+	}
+	if err := topics.Collect(newSilencedEvent("silenced", "serverB", models.Tags{"host": "serverB"})); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case event := <-h:
+		if event.State.ID != "serverB" {
+			t.Errorf("unexpected event handled got %s exp serverB", event.State.ID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the event to be handled")
+	}
+	select {
+	case event := <-h:
+		t.Errorf("unexpected event handled %s", event.State.ID)
+	default:
+	}
+}
@@ -19,15 +19,23 @@ type Topics struct {
 	mu sync.RWMutex
 
 	topics map[string]*Topic
+
+	silences *Silences
 }
 
 func NewTopics() *Topics {
 	s := &Topics{
-		topics: make(map[string]*Topic),
+		topics:   make(map[string]*Topic),
+		silences: NewSilences(),
 	}
 	return s
 }
 
+// Silences returns the silences applied to the events of all topics.
+func (s *Topics) Silences() *Silences {
+	return s.silences
+}
+
 func (s *Topics) Open() error {
 	return nil
 }
@@ -54,7 +62,7 @@ func (s *Topics) RestoreTopic(id string, eventStates map[string]EventState) {
 	defer s.mu.Unlock()
 	t, ok := s.topics[id]
 	if !ok {
-		t = newTopic(id)
+		t = newTopic(id, s.silences)
 		s.topics[id] = t
 	}
 	t.restoreEventStates(eventStates)
@@ -65,7 +73,7 @@ func (s *Topics) UpdateEvent(id string, event EventState) {
 	defer s.mu.Unlock()
 	t, ok := s.topics[id]
 	if !ok {
-		t = newTopic(id)
+		t = newTopic(id, s.silences)
 		s.topics[id] = t
 	}
 	t.updateEvent(event)
@@ -93,7 +101,7 @@ func (s *Topics) Collect(event Event) error {
 		// Check again if the topic was created, now that we have the write lock
 		topic = s.topics[event.Topic]
 		if topic == nil {
-			topic = newTopic(event.Topic)
+			topic = newTopic(event.Topic, s.silences)
 			s.topics[event.Topic] = topic
 		}
 		s.mu.Unlock()
@@ -122,7 +130,7 @@ func (s *Topics) RegisterHandler(topic string, h Handler) {
 
 	t, ok := s.topics[topic]
 	if !ok {
-		t = newTopic(topic)
+		t = newTopic(topic, s.silences)
 		s.topics[topic] = t
 	}
 	t.addHandler(h)
@@ -147,7 +155,7 @@ func (s *Topics) ReplaceHandler(topic string, oldH, newH Handler) {
 
 	t, ok := s.topics[topic]
 	if !ok {
-		t = newTopic(topic)
+		t = newTopic(topic, s.silences)
 		s.topics[topic] = t
 	}
 
@@ -193,21 +201,27 @@ type Topic struct {
 	sorted []*EventState
 
 	collected *expvar.Int
+	silenced  *expvar.Int
 	statsKey  string
 
 	handlers []*bufHandler
+
+	silences *Silences
 }
 
-func newTopic(id string) *Topic {
+func newTopic(id string, silences *Silences) *Topic {
 	t := &Topic{
 		id:        id,
 		events:    make(map[string]*EventState),
 		collected: new(expvar.Int),
+		silenced:  new(expvar.Int),
+		silences:  silences,
 	}
 	statsKey, statsMap := vars.NewStatistic("topics", map[string]string{
 		"id": id,
 	})
 	statsMap.Set("collected", t.collected)
+	statsMap.Set("silenced", t.silenced)
 	t.statsKey = statsKey
 	return t
 }
@@ -319,6 +333,12 @@ func (t *Topic) collect(event Event) error {
 }
 
 func (t *Topic) handleEvent(event Event) error {
+	// The state of the event has already been recorded, only the handlers are skipped.
+	if t.silences != nil && t.silences.IsSilenced(event) {
+		t.silenced.Add(1)
+		return nil
+	}
+
 	t.mu.RLock()
 	defer t.mu.RUnlock()
 
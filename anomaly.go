@@ -0,0 +1,287 @@
+package kapacitor
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/thingnario/kapacitor/edge"
+	"github.com/thingnario/kapacitor/keyvalue"
+	"github.com/thingnario/kapacitor/pipeline"
+)
+
+const (
+	anomalyScoreField     = "score"
+	anomalyIsAnomalyField = "is_anomaly"
+	anomalyExpectedField  = "expected"
+	anomalyLowerField     = "lower"
+	anomalyUpperField     = "upper"
+
+	// Scales the median absolute deviation to the standard deviation of normally distributed values.
+	madScale = 1.4826
+	// Minimal spread relative to the expected value, so that deviations from a flat signal are anomalies.
+	minSpread = 1e-9
+
+	hoursPerWeek = 7 * 24
+)
+
+type AnomalyNode struct {
+	node
+	a *pipeline.AnomalyNode
+
+	groups groupCheckpoint
+}
+
+// Create a new AnomalyNode, which scores the values of a field against a model of each group.
+func newAnomalyNode(et *ExecutingTask, a *pipeline.AnomalyNode, d NodeDiagnostic) (*AnomalyNode, error) {
+	switch a.Model {
+	case "ewma", "mad", "seasonal":
+	default:
+		return nil, fmt.Errorf("unexpected anomaly model %q", a.Model)
+	}
+	n := &AnomalyNode{
+		node: node{Node: a, et: et, diag: d},
+		a:    a,
+	}
+	n.node.runF = n.runAnomaly
+	return n, nil
+}
+
+func (n *AnomalyNode) runAnomaly(snapshot []byte) error {
+	if err := n.groups.restore(snapshot); err != nil {
+		n.diag.Error("failed to restore node snapshot", err)
+	}
+	consumer := edge.NewGroupedConsumer(n.ins[0], n)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *AnomalyNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	g, err := n.groups.add(group.ID, n.newGroup())
+	if err != nil {
+		n.diag.Error("failed to restore group state", err, keyvalue.KV("group", string(group.ID)))
+	}
+	return edge.NewReceiverFromForwardReceiverWithStats(
+		n.outs,
+		edge.NewTimedForwardReceiver(n.timer, g),
+	), nil
+}
+
+func (n *AnomalyNode) snapshot() ([]byte, error) {
+	return n.groups.snapshot()
+}
+
+func (n *AnomalyNode) newGroup() *anomalyGroup {
+	g := &anomalyGroup{n: n}
+	switch n.a.Model {
+	case "mad":
+		g.model = &madModel{size: int(n.a.WindowSize)}
+	case "seasonal":
+		g.model = &seasonalModel{alpha: n.a.Alpha}
+	default:
+		g.model = &ewmaModel{alpha: n.a.Alpha}
+	}
+	return g
+}
+
+type anomalyGroup struct {
+	n     *AnomalyNode
+	model anomalyModel
+}
+
+func (g *anomalyGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	return begin, nil
+}
+
+func (g *anomalyGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	np := bp.ShallowCopy()
+	if g.doAnomaly(bp, np) {
+		return np, nil
+	}
+	return nil, nil
+}
+
+func (g *anomalyGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	return end, nil
+}
+
+func (g *anomalyGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	np := p.ShallowCopy()
+	if g.doAnomaly(p, np) {
+		return np, nil
+	}
+	return nil, nil
+}
+
+// doAnomaly scores the value of p and adds it to the model.
+// The resulting fields will be set on n.
+func (g *anomalyGroup) doAnomaly(p edge.FieldsTagsTimeGetter, n edge.FieldsTagsTimeSetter) bool {
+	field := g.n.a.Field
+	value, ok := numToFloat(p.Fields()[field])
+	if !ok {
+		g.n.diag.Error("cannot score anomaly",
+			errors.New("field is missing or the wrong type"),
+			keyvalue.KV("field", field),
+			keyvalue.KV("type", fmt.Sprintf("%T", p.Fields()[field])),
+		)
+		return false
+	}
+
+	e := g.model.estimate(p.Time())
+	g.model.observe(p.Time(), value)
+	if e.count == 0 {
+		e.expected = value
+	}
+	// A flat signal has no spread, any deviation from it is scored against a minimal spread.
+	spread := math.Max(e.spread, minSpread*math.Max(math.Abs(e.expected), 1))
+	score := (value - e.expected) / spread
+	bound := g.n.a.Threshold * spread
+
+	fields := n.Fields().Copy()
+	fields[anomalyScoreField] = score
+	fields[anomalyIsAnomalyField] = e.count >= g.n.a.Warmup && math.Abs(score) > g.n.a.Threshold
+	fields[anomalyExpectedField] = e.expected
+	fields[anomalyLowerField] = e.expected - bound
+	fields[anomalyUpperField] = e.expected + bound
+	n.SetFields(fields)
+	return true
+}
+
+func (g *anomalyGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (g *anomalyGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (g *anomalyGroup) Done() {}
+
+func (g *anomalyGroup) snapshotState() ([]byte, error) {
+	return encodeState(g.model)
+}
+
+func (g *anomalyGroup) restoreState(data []byte) error {
+	return decodeState(data, g.model)
+}
+
+// anomalyEstimate is the expected value of a model and the spread of the values around it.
+type anomalyEstimate struct {
+	expected float64
+	spread   float64
+	// Number of values the estimate is based on.
+	count int64
+}
+
+// anomalyModel is an online model of the values of a group.
+// The exported fields of a model are its state.
+type anomalyModel interface {
+	// estimate returns the estimate for a value at time t.
+	estimate(t time.Time) anomalyEstimate
+	// observe adds a value at time t to the model.
+	observe(t time.Time, value float64)
+}
+
+// ewmaStats is an exponentially weighted moving mean and variance.
+type ewmaStats struct {
+	Mean     float64
+	Variance float64
+	Count    int64
+}
+
+func (s *ewmaStats) estimate() anomalyEstimate {
+	return anomalyEstimate{
+		expected: s.Mean,
+		spread:   math.Sqrt(s.Variance),
+		count:    s.Count,
+	}
+}
+
+func (s *ewmaStats) observe(alpha, value float64) {
+	s.Count++
+	if s.Count == 1 {
+		s.Mean = value
+		return
+	}
+	diff := value - s.Mean
+	incr := alpha * diff
+	s.Mean += incr
+	s.Variance = (1 - alpha) * (s.Variance + diff*incr)
+}
+
+type ewmaModel struct {
+	Stats ewmaStats
+
+	alpha float64
+}
+
+func (m *ewmaModel) estimate(time.Time) anomalyEstimate {
+	return m.Stats.estimate()
+}
+
+func (m *ewmaModel) observe(_ time.Time, value float64) {
+	m.Stats.observe(m.alpha, value)
+}
+
+// seasonalModel keeps ewma statistics per hour of the week.
+type seasonalModel struct {
+	Hours [hoursPerWeek]ewmaStats
+
+	alpha float64
+}
+
+func hourOfWeek(t time.Time) int {
+	t = t.UTC()
+	return int(t.Weekday())*24 + t.Hour()
+}
+
+func (m *seasonalModel) estimate(t time.Time) anomalyEstimate {
+	return m.Hours[hourOfWeek(t)].estimate()
+}
+
+func (m *seasonalModel) observe(t time.Time, value float64) {
+	m.Hours[hourOfWeek(t)].observe(m.alpha, value)
+}
+
+// madModel keeps the last values to compute their median and median absolute deviation.
+type madModel struct {
+	Values []float64
+	// Number of observed values, including those that left the window.
+	Count int64
+
+	size int
+}
+
+func (m *madModel) estimate(time.Time) anomalyEstimate {
+	if len(m.Values) == 0 {
+		return anomalyEstimate{}
+	}
+	median := medianOf(append([]float64(nil), m.Values...))
+	deviations := make([]float64, len(m.Values))
+	for i, v := range m.Values {
+		deviations[i] = math.Abs(v - median)
+	}
+	return anomalyEstimate{
+		expected: median,
+		spread:   madScale * medianOf(deviations),
+		count:    m.Count,
+	}
+}
+
+func (m *madModel) observe(_ time.Time, value float64) {
+	m.Count++
+	m.Values = append(m.Values, value)
+	if len(m.Values) > m.size {
+		m.Values = append(m.Values[:0], m.Values[len(m.Values)-m.size:]...)
+	}
+}
+
+// medianOf returns the median of the values, the values are sorted in place.
+func medianOf(values []float64) float64 {
+	sort.Float64s(values)
+	l := len(values)
+	if l%2 == 0 {
+		return (values[l/2-1] + values[l/2]) / 2
+	}
+	return values[l/2]
+}
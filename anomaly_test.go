@@ -0,0 +1,127 @@
+package kapacitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/thingnario/kapacitor/edge"
+	"github.com/thingnario/kapacitor/pipeline"
+)
+
+func TestAnomaly_EWMAModel(t *testing.T) {
+	m := &ewmaModel{alpha: 0.5}
+	if e := m.estimate(time.Time{}); e.count != 0 {
+		t.Fatalf("unexpected count of empty model %d", e.count)
+	}
+	for _, v := range []float64{10, 12} {
+		m.observe(time.Time{}, v)
+	}
+	// The mean moves halfway to 12 and the variance is (1-alpha) * diff * alpha * diff.
+	e := m.estimate(time.Time{})
+	if e.expected != 11 || e.spread != 1 || e.count != 2 {
+		t.Errorf("unexpected estimate got %v %v %d exp 11 1 2", e.expected, e.spread, e.count)
+	}
+}
+
+func TestAnomaly_MADModel(t *testing.T) {
+	m := &madModel{size: 5}
+	for _, v := range []float64{100, 10, 12, 11, 9, 10} {
+		m.observe(time.Time{}, v)
+	}
+	// The first value is outside of the window, but counts as observed.
+	e := m.estimate(time.Time{})
+	if e.expected != 10 || e.spread != madScale || e.count != 6 {
+		t.Errorf("unexpected estimate got %v %v %d exp 10 %v 6", e.expected, e.spread, e.count, madScale)
+	}
+}
+
+func TestAnomaly_MADWarmupLargerThanWindow(t *testing.T) {
+	n, err := newAnomalyNode(nil, &pipeline.AnomalyNode{
+		Field:      "value",
+		Model:      "mad",
+		WindowSize: 5,
+		Threshold:  3,
+		Warmup:     10,
+	}, newWindowNodeDiagnostic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := n.newGroup()
+	for i := int64(0); i < 11; i++ {
+		v := 10.0
+		if i == 10 {
+			v = 100
+		}
+		m, err := g.Point(newCheckpointPoint(i, v))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Only the spike after the warmup is an anomaly.
+		if got, exp := m.(edge.PointMessage).Fields()[anomalyIsAnomalyField], i == 10; got != exp {
+			t.Errorf("point %d: unexpected is_anomaly got %v exp %v", i, got, exp)
+		}
+	}
+}
+
+func TestAnomaly_SeasonalModel(t *testing.T) {
+	m := &seasonalModel{alpha: 0.5}
+	monday := time.Date(2018, 1, 1, 9, 30, 0, 0, time.UTC)
+	m.observe(monday, 100)
+	m.observe(monday.Add(time.Hour), 10)
+	m.observe(monday.Add(7*24*time.Hour), 200)
+
+	if e := m.estimate(monday.Add(14 * 24 * time.Hour)); e.expected != 150 || e.count != 2 {
+		t.Errorf("unexpected estimate of the same hour got %v %d exp 150 2", e.expected, e.count)
+	}
+	if e := m.estimate(monday.Add(time.Hour + 10*time.Minute)); e.expected != 10 || e.count != 1 {
+		t.Errorf("unexpected estimate of the next hour got %v %d exp 10 1", e.expected, e.count)
+	}
+	if e := m.estimate(monday.Add(-time.Hour)); e.count != 0 {
+		t.Errorf("unexpected count of an hour without values %d", e.count)
+	}
+}
+
+func TestAnomaly_MedianOf(t *testing.T) {
+	if got := medianOf([]float64{3, 1, 2}); got != 2 {
+		t.Errorf("unexpected median got %v exp 2", got)
+	}
+	if got := medianOf([]float64{4, 1, 3, 2}); got != 2.5 {
+		t.Errorf("unexpected median got %v exp 2.5", got)
+	}
+	if got := medianOf([]float64{1}); got != 1 {
+		t.Errorf("unexpected median got %v exp 1", got)
+	}
+}
+
+func TestAnomaly_FlatSignal(t *testing.T) {
+	for _, model := range []string{"ewma", "mad", "seasonal"} {
+		n, err := newAnomalyNode(nil, &pipeline.AnomalyNode{
+			Field:      "value",
+			Model:      model,
+			Alpha:      0.2,
+			WindowSize: 10,
+			Threshold:  3,
+			Warmup:     3,
+		}, newWindowNodeDiagnostic())
+		if err != nil {
+			t.Fatal(err)
+		}
+		g := n.newGroup()
+		var last edge.Message
+		for i, v := range []float64{10, 10, 10, 10, 10, 11} {
+			last, err = g.Point(newCheckpointPoint(int64(i), v))
+			if err != nil {
+				t.Fatal(err)
+			}
+			fields := last.(edge.PointMessage).Fields()
+			if v == 10 && (fields[anomalyScoreField] != 0.0 || fields[anomalyIsAnomalyField] != false) {
+				t.Errorf("%s: unexpected score of the flat signal %v %v", model, fields[anomalyScoreField], fields[anomalyIsAnomalyField])
+			}
+		}
+		// Any deviation from a flat signal is an anomaly.
+		fields := last.(edge.PointMessage).Fields()
+		if fields[anomalyIsAnomalyField] != true || fields[anomalyScoreField].(float64) <= 3 {
+			t.Errorf("%s: expected the spike to be an anomaly got %v %v", model, fields[anomalyScoreField], fields[anomalyIsAnomalyField])
+		}
+	}
+}
@@ -45,6 +45,21 @@ func Test_Privilege_String(t *testing.T) {
 	}
 }
 
+func Test_ParsePrivilege(t *testing.T) {
+	for _, p := range auth.PrivilegeList {
+		got, err := auth.ParsePrivilege(p.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != p {
+			t.Errorf("unexpected privilege: got %v exp %v", got, p)
+		}
+	}
+	if _, err := auth.ParsePrivilege("unknown"); err == nil {
+		t.Error("expected error parsing unknown privilege")
+	}
+}
+
 func Test_NewUser(t *testing.T) {
 	privs := map[string][]auth.Privilege{
 		"/simple/path/":               []auth.Privilege{auth.ReadPrivilege, auth.WritePrivilege},
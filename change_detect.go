@@ -2,12 +2,15 @@ package kapacitor
 
 import (
 	"fmt"
+	"math"
+	"sort"
+	"strings"
 
 	"github.com/thingnario/kapacitor/edge"
 	"github.com/thingnario/kapacitor/keyvalue"
 	"github.com/thingnario/kapacitor/models"
 	"github.com/thingnario/kapacitor/pipeline"
-	"github.com/thingnario/kapacitor/services/redis"
+	"github.com/thingnario/kapacitor/services/nodestate"
 )
 
 type ChangeDetectNode struct {
@@ -38,19 +41,25 @@ func (n *ChangeDetectNode) runChangeDetect([]byte) error {
 func (n *ChangeDetectNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
 	return edge.NewReceiverFromForwardReceiverWithStats(
 		n.outs,
-		edge.NewTimedForwardReceiver(n.timer, n.newGroup()),
+		edge.NewTimedForwardReceiver(n.timer, n.newGroup(group.ID)),
 	), nil
 }
 
-func (n *ChangeDetectNode) newGroup() *changeDetectGroup {
+func (n *ChangeDetectNode) newGroup(group models.GroupID) *changeDetectGroup {
 	return &changeDetectGroup{
-		n: n,
+		n:   n,
+		key: n.stateKey(group),
 	}
 }
 
 type changeDetectGroup struct {
-	n        *ChangeDetectNode
-	previous edge.FieldsTagsTimeGetter
+	n *ChangeDetectNode
+	// previous are the fields of the last emitted point,
+	// nil until the first point of the group is seen.
+	previous models.Fields
+	// loaded is true once the persisted state of the group has been loaded.
+	loaded bool
+	key    string
 }
 
 func (g *changeDetectGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
@@ -58,16 +67,19 @@ func (g *changeDetectGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Messa
 		begin = begin.ShallowCopy()
 		begin.SetSizeHint(0)
 	}
-	g.previous = nil
 	return begin, nil
 }
 
 func (g *changeDetectGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
-	changed := g.doChangeDetect(bp)
-	if changed {
-		return bp, nil
+	changed, emit := g.doChangeDetect(bp)
+	if !emit {
+		return nil, nil
 	}
-	return nil, nil
+	if g.n.augments() {
+		bp = bp.ShallowCopy()
+		g.n.augment(bp, changed)
+	}
+	return bp, nil
 }
 
 func (g *changeDetectGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
@@ -75,56 +87,70 @@ func (g *changeDetectGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, er
 }
 
 func (g *changeDetectGroup) Point(p edge.PointMessage) (edge.Message, error) {
-	if g.previous == nil {
-		previous := p.ShallowCopy()
-		g.previous = previous
-		key := fmt.Sprintf("changeDetectNode:%s:%s", g.n.et.Task.ID, p.GroupID())
-		stored, err := redis.ReadFromRedis(key)
-		if stored != nil {
-			previous.SetFields(stored)
-		} else {
-			err = redis.WriteToRedis(key, g.previous.Fields())
-			if err != nil {
-				return nil, err
-			}
-		}
+	changed, emit := g.doChangeDetect(p)
+	if !emit {
+		return nil, nil
 	}
-	changed := g.doChangeDetect(p)
-	if changed {
-		key := fmt.Sprintf("changeDetectNode:%s:%s", g.n.et.Task.ID, p.GroupID())
-		redis.WriteToRedis(key, p.Fields())
-		return p, nil
+	if g.n.augments() {
+		p = p.ShallowCopy()
+		g.n.augment(p, changed)
 	}
-	return nil, nil
+	return p, nil
 }
 
-// doChangeDetect computes the changeDetect with respect to g.previous and p.
-// The resulting changeDetect value will be set on n.
-func (g *changeDetectGroup) doChangeDetect(p edge.FieldsTagsTimeGetter) bool {
-	var prevFields, currFields models.Fields
-	if g.previous != nil {
-		prevFields = g.previous.Fields()
+// doChangeDetect compares p with the last emitted point of the group.
+// It returns the names of the fields that changed and whether p should be emitted.
+func (g *changeDetectGroup) doChangeDetect(p edge.FieldsTagsTimeGetter) ([]string, bool) {
+	if !g.loaded {
+		g.loaded = true
+		stored, err := g.n.loadFields(g.key)
+		switch err {
+		case nil:
+			g.previous = stored
+		case nodestate.ErrNoKeyExists:
+		default:
+			g.n.diag.Error("failed to load change detect state", err, keyvalue.KV("key", g.key))
+		}
 	}
-	currFields = p.Fields()
-	changed := g.n.changeDetect(prevFields, currFields)
 
-	if !changed {
-		return false
+	curr := p.Fields()
+	if g.previous == nil {
+		// First point of the group
+		g.previous = curr
+		if err := g.n.saveFields(g.key, curr); err != nil {
+			g.n.diag.Error("failed to save change detect state", err, keyvalue.KV("key", g.key))
+		}
+		if !g.n.d.EmitFirstFlag {
+			return nil, false
+		}
+		return g.n.presentFields(curr), true
+	}
+
+	changed := g.n.changeDetect(g.previous, curr)
+	if len(changed) == 0 {
+		return nil, false
 	}
-	g.previous = p
-	return true
+	g.previous = g.n.nextPrevious(g.previous, curr, changed)
+	if err := g.n.saveFields(g.key, g.previous); err != nil {
+		g.n.diag.Error("failed to save change detect state", err, keyvalue.KV("key", g.key))
+	}
+	return changed, true
 }
 
 func (g *changeDetectGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
 	return b, nil
 }
 func (g *changeDetectGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	if err := g.n.et.tm.StateStore.Delete(g.key); err != nil {
+		g.n.diag.Error("failed to delete change detect state", err, keyvalue.KV("key", g.key))
+	}
 	return d, nil
 }
 func (g *changeDetectGroup) Done() {}
 
-// changeDetect reports whether there was a change between prev and cur.
-func (n *ChangeDetectNode) changeDetect(prev, curr models.Fields) bool {
+// changeDetect returns the sorted names of the fields that changed between prev and cur.
+func (n *ChangeDetectNode) changeDetect(prev, curr models.Fields) []string {
+	var changed []string
 	for _, field := range n.d.Fields {
 		value, ok := curr[field]
 		if !ok {
@@ -133,12 +159,117 @@ func (n *ChangeDetectNode) changeDetect(prev, curr models.Fields) bool {
 				keyvalue.KV("field", field))
 			continue
 		}
-		if prev[field] == nil {
+		if n.fieldChanged(field, prev[field], value) {
+			changed = append(changed, field)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// fieldChanged reports whether the value of a field changed,
+// taking the tolerances of the field into account.
+// A field without a previous value has not changed.
+func (n *ChangeDetectNode) fieldChanged(field string, prev, curr interface{}) bool {
+	if prev == nil {
+		return false
+	}
+	tolerance, hasTolerance := n.d.Tolerances[field]
+	percent, hasPercent := n.d.PercentTolerances[field]
+	if !hasTolerance && !hasPercent {
+		return prev != curr
+	}
+	p, pok := numericValue(prev)
+	c, cok := numericValue(curr)
+	if !pok || !cok {
+		return prev != curr
+	}
+	diff := math.Abs(c - p)
+	if hasTolerance && diff <= tolerance {
+		return false
+	}
+	if hasPercent && diff <= math.Abs(p)*percent/100 {
+		return false
+	}
+	return diff != 0
+}
+
+func numericValue(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// nextPrevious returns the fields that later points are compared with after curr was emitted.
+// Fields that did not change keep their previous value,
+// so that changes accumulating within the tolerance are eventually detected.
+func (n *ChangeDetectNode) nextPrevious(prev, curr models.Fields, changed []string) models.Fields {
+	next := curr.Copy()
+	for _, field := range n.d.Fields {
+		if i := sort.SearchStrings(changed, field); i < len(changed) && changed[i] == field {
 			continue
 		}
-		if prev[field] != value {
-			return true
+		if v, ok := prev[field]; ok {
+			next[field] = v
+		}
+	}
+	return next
+}
+
+// presentFields returns the sorted names of the change detect fields present in fields.
+func (n *ChangeDetectNode) presentFields(fields models.Fields) []string {
+	present := make([]string, 0, len(n.d.Fields))
+	for _, field := range n.d.Fields {
+		if _, ok := fields[field]; ok {
+			present = append(present, field)
 		}
 	}
-	return false
+	sort.Strings(present)
+	return present
+}
+
+// augments reports whether the changed fields are added to emitted points.
+func (n *ChangeDetectNode) augments() bool {
+	return n.d.ChangedFieldsTag != "" || n.d.ChangedFieldsField != ""
+}
+
+// augment adds the names of the changed fields to the tags and fields of p.
+func (n *ChangeDetectNode) augment(p edge.FieldsTagsTimeSetter, changed []string) {
+	value := strings.Join(changed, ",")
+	if n.d.ChangedFieldsTag != "" {
+		tags := p.Tags().Copy()
+		tags[n.d.ChangedFieldsTag] = value
+		p.SetTags(tags)
+	}
+	if n.d.ChangedFieldsField != "" {
+		fields := p.Fields().Copy()
+		fields[n.d.ChangedFieldsField] = value
+		p.SetFields(fields)
+	}
+}
+
+// stateKey returns the key under which the last changed fields of a group are persisted.
+func (n *ChangeDetectNode) stateKey(group models.GroupID) string {
+	return nodestate.ChangeDetectTaskPrefix(n.et.Task.ID) + string(group)
+}
+
+func (n *ChangeDetectNode) loadFields(key string) (models.Fields, error) {
+	data, err := n.et.tm.StateStore.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return nodestate.DecodeFields(data)
+}
+
+func (n *ChangeDetectNode) saveFields(key string, fields models.Fields) error {
+	data, err := nodestate.EncodeFields(fields)
+	if err != nil {
+		return err
+	}
+	return n.et.tm.StateStore.Put(key, data)
 }
@@ -0,0 +1,191 @@
+package kapacitor
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/thingnario/kapacitor/edge"
+	"github.com/thingnario/kapacitor/models"
+	"github.com/thingnario/kapacitor/pipeline"
+	"github.com/thingnario/kapacitor/services/nodestate"
+)
+
+func newTestChangeDetectNode(d *pipeline.ChangeDetectNode, store nodestate.Store) *ChangeDetectNode {
+	return &ChangeDetectNode{
+		node: node{
+			diag: newWindowNodeDiagnostic(),
+			et: &ExecutingTask{
+				tm:   &TaskMaster{StateStore: store},
+				Task: &Task{ID: "test"},
+			},
+		},
+		d: d,
+	}
+}
+
+func newChangeDetectPoint(sec int64, fields models.Fields) edge.PointMessage {
+	return edge.NewPointMessage(
+		"sensors", "db", "rp",
+		models.Dimensions{TagNames: []string{"host"}},
+		fields,
+		models.Tags{"host": "a"},
+		time.Unix(sec, 0).UTC(),
+	)
+}
+
+// emittedChangeDetectPoints returns the points emitted by the group.
+func emittedChangeDetectPoints(t *testing.T, g *changeDetectGroup, points []edge.PointMessage) []edge.PointMessage {
+	t.Helper()
+	var emitted []edge.PointMessage
+	for _, p := range points {
+		m, err := g.Point(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m != nil {
+			emitted = append(emitted, m.(edge.PointMessage))
+		}
+	}
+	return emitted
+}
+
+func TestChangeDetect_FirstPoint(t *testing.T) {
+	points := []edge.PointMessage{
+		newChangeDetectPoint(0, models.Fields{"value": "on"}),
+		newChangeDetectPoint(1, models.Fields{"value": "on"}),
+		newChangeDetectPoint(2, models.Fields{"value": "off"}),
+	}
+	testCases := []struct {
+		name      string
+		emitFirst bool
+		stored    string
+		exp       []int64
+	}{
+		{
+			name: "not emitted by default",
+			exp:  []int64{2},
+		},
+		{
+			name:      "emit first",
+			emitFirst: true,
+			exp:       []int64{0, 2},
+		},
+		{
+			name:   "unchanged from persisted state",
+			stored: `{"value":"on"}`,
+			exp:    []int64{2},
+		},
+		{
+			name:   "changed from persisted state",
+			stored: `{"value":"off"}`,
+			exp:    []int64{0, 2},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := nodestate.NewMemStore()
+			if tc.stored != "" {
+				if err := store.Put(nodestate.ChangeDetectPrefix+"test:sensors,host=a", []byte(tc.stored)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			d := &pipeline.ChangeDetectNode{
+				Fields:        []string{"value"},
+				EmitFirstFlag: tc.emitFirst,
+			}
+			g := newTestChangeDetectNode(d, store).newGroup("sensors,host=a")
+			var got []int64
+			for _, p := range emittedChangeDetectPoints(t, g, points) {
+				got = append(got, p.Time().Unix())
+			}
+			if !reflect.DeepEqual(got, tc.exp) {
+				t.Errorf("unexpected emitted points got %v exp %v", got, tc.exp)
+			}
+		})
+	}
+}
+
+func TestChangeDetect_Tolerance(t *testing.T) {
+	d := &pipeline.ChangeDetectNode{
+		Fields:            []string{"temperature", "pressure"},
+		Tolerances:        map[string]float64{"temperature": 0.5},
+		PercentTolerances: map[string]float64{"pressure": 10},
+	}
+	n := newTestChangeDetectNode(d, nodestate.NewMemStore())
+	g := n.newGroup("sensors,host=a")
+	emitted := emittedChangeDetectPoints(t, g, []edge.PointMessage{
+		newChangeDetectPoint(0, models.Fields{"temperature": 20.0, "pressure": int64(100)}),
+		// Within tolerance
+		newChangeDetectPoint(1, models.Fields{"temperature": 20.3, "pressure": int64(105)}),
+		newChangeDetectPoint(2, models.Fields{"temperature": 20.5, "pressure": int64(95)}),
+		// Drifted beyond the tolerance of the last emitted value
+		newChangeDetectPoint(3, models.Fields{"temperature": 20.6, "pressure": int64(100)}),
+		newChangeDetectPoint(4, models.Fields{"temperature": 20.6, "pressure": int64(111)}),
+	})
+	var got []int64
+	for _, p := range emitted {
+		got = append(got, p.Time().Unix())
+	}
+	if exp := []int64{0, 3, 4}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected emitted points got %v exp %v", got, exp)
+	}
+}
+
+func TestChangeDetect_ChangedFields(t *testing.T) {
+	d := &pipeline.ChangeDetectNode{
+		Fields:             []string{"b", "a"},
+		ChangedFieldsTag:   "changed_tag",
+		ChangedFieldsField: "changed_field",
+	}
+	n := newTestChangeDetectNode(d, nodestate.NewMemStore())
+	g := n.newGroup("sensors,host=a")
+	emitted := emittedChangeDetectPoints(t, g, []edge.PointMessage{
+		newChangeDetectPoint(0, models.Fields{"a": "x", "b": "x"}),
+		newChangeDetectPoint(1, models.Fields{"a": "y", "b": "x"}),
+		newChangeDetectPoint(2, models.Fields{"a": "z", "b": "z"}),
+	})
+	if len(emitted) != 2 {
+		t.Fatalf("unexpected number of emitted points got %d exp 2", len(emitted))
+	}
+	for i, exp := range []string{"a", "a,b"} {
+		if got := emitted[i].Tags()["changed_tag"]; got != exp {
+			t.Errorf("point %d: unexpected changed fields tag got %q exp %q", i, got, exp)
+		}
+		if got := emitted[i].Fields()["changed_field"]; got != exp {
+			t.Errorf("point %d: unexpected changed fields field got %v exp %q", i, got, exp)
+		}
+	}
+}
+
+func TestChangeDetect_BatchKeepsPrevious(t *testing.T) {
+	d := &pipeline.ChangeDetectNode{
+		Fields: []string{"value"},
+	}
+	store := nodestate.NewMemStore()
+	n := newTestChangeDetectNode(d, store)
+	batch := func(values ...string) []edge.Message {
+		g := n.newGroup("sensors")
+		var emitted []edge.Message
+		if _, err := g.BeginBatch(edge.NewBeginBatchMessage("sensors", nil, false, time.Unix(0, 0), len(values))); err != nil {
+			t.Fatal(err)
+		}
+		for i, v := range values {
+			m, err := g.BatchPoint(edge.NewBatchPointMessage(models.Fields{"value": v}, nil, time.Unix(int64(i), 0)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m != nil {
+				emitted = append(emitted, m)
+			}
+		}
+		return emitted
+	}
+	if got := len(batch("bad", "bad", "good")); got != 1 {
+		t.Errorf("unexpected number of points emitted from first batch got %d exp 1", got)
+	}
+	// A new group restores the last emitted value, like a restart does.
+	if got := len(batch("good", "bad")); got != 1 {
+		t.Errorf("unexpected number of points emitted from second batch got %d exp 1", got)
+	}
+}
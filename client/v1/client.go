@@ -37,6 +37,7 @@ const (
 	logsPath          = basePreviewPath + "/logs"
 	debugVarsPath     = basePath + "/debug/vars"
 	tasksPath         = basePath + "/tasks"
+	tasksValidatePath = tasksPath + "/validate"
 	templatesPath     = basePath + "/templates"
 	recordingsPath    = basePath + "/recordings"
 	recordStreamPath  = basePath + "/recordings/stream"
@@ -51,9 +52,22 @@ const (
 	topicsPath        = alertsPath + "/topics"
 	topicEventsPath   = "events"
 	topicHandlersPath = "handlers"
+	silencesPath      = alertsPath + "/silences"
 	storagePath       = basePath + "/storage"
 	storesPath        = storagePath + "/stores"
 	backupPath        = storagePath + "/backup"
+	nodeStatePath     = basePath + "/node-state"
+	validateStatePath = nodeStatePath + "/validate"
+	blobsPath         = basePath + "/blobs"
+	blobTagsPath      = blobsPath + "/tags"
+	blobDataPath      = "data"
+	revisionsPath     = "revisions"
+	rollbackPath      = "rollback"
+	usersPath         = basePath + "/users"
+	apiTokensPath     = basePath + "/tokens"
+	auditPath         = basePath + "/audit"
+	applyPath         = basePath + "/apply"
+	graphPath         = basePath + "/graph"
 )
 
 // HTTP configuration for connecting to Kapacitor
@@ -276,6 +290,8 @@ type TaskStatus int
 const (
 	Disabled TaskStatus = 1
 	Enabled  TaskStatus = 2
+	// Paused tasks keep running but their incoming points are held back until the task is enabled again.
+	Paused TaskStatus = 3
 )
 
 func (ts TaskStatus) MarshalText() ([]byte, error) {
@@ -284,6 +300,8 @@ func (ts TaskStatus) MarshalText() ([]byte, error) {
 		return []byte("disabled"), nil
 	case Enabled:
 		return []byte("enabled"), nil
+	case Paused:
+		return []byte("paused"), nil
 	default:
 		return nil, fmt.Errorf("unknown TaskStatus %d", ts)
 	}
@@ -295,6 +313,8 @@ func (ts *TaskStatus) UnmarshalText(text []byte) error {
 		*ts = Enabled
 	case "disabled":
 		*ts = Disabled
+	case "paused":
+		*ts = Paused
 	default:
 		return fmt.Errorf("unknown TaskStatus %s", s)
 	}
@@ -569,6 +589,20 @@ type Task struct {
 	Created        time.Time      `json:"created"`
 	Modified       time.Time      `json:"modified"`
 	LastEnabled    time.Time      `json:"last-enabled,omitempty"`
+	// Points held back while the task is paused, only set for paused tasks.
+	Pause *PauseStats `json:"pause,omitempty"`
+	// Warnings about the task definition, only set in the response of a create or update.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// PauseStats describes the points that arrived while a task was paused.
+type PauseStats struct {
+	// Either "spool" or "drop".
+	Mode string `json:"mode"`
+	// Number of points waiting to be processed once the task is enabled.
+	Buffered int64 `json:"buffered"`
+	// Number of points dropped because of the mode or because the spool was full.
+	Dropped int64 `json:"dropped"`
 }
 
 // A Template plus its read-only attributes.
@@ -584,6 +618,20 @@ type Template struct {
 	Modified   time.Time `json:"modified"`
 }
 
+// An immutable revision of the definition of a task or template.
+type Revision struct {
+	Link       Link      `json:"link"`
+	ID         string    `json:"id"`
+	Number     int       `json:"number"`
+	Type       TaskType  `json:"type"`
+	TemplateID string    `json:"template-id,omitempty"`
+	DBRPs      []DBRP    `json:"dbrps,omitempty"`
+	TICKscript string    `json:"script"`
+	Vars       Vars      `json:"vars,omitempty"`
+	User       string    `json:"user"`
+	Created    time.Time `json:"created"`
+}
+
 // Information about a recording.
 type Recording struct {
 	Link     Link      `json:"link"`
@@ -800,6 +848,9 @@ func (c *Client) TopicHandlersLink(topic string) Link {
 func (c *Client) TopicHandlerLink(topic, id string) Link {
 	return Link{Relation: Self, Href: path.Join(topicsPath, topic, topicHandlersPath, id)}
 }
+func (c *Client) SilenceLink(id string) Link {
+	return Link{Relation: Self, Href: path.Join(silencesPath, id)}
+}
 func (c *Client) StorageLink(name string) Link {
 	return Link{Relation: Self, Href: path.Join(storesPath, name)}
 }
@@ -838,6 +889,55 @@ func (c *Client) CreateTask(opt CreateTaskOptions) (Task, error) {
 	return t, err
 }
 
+type ValidateTaskOptions struct {
+	// Optional ID, it is only used as the name of the DOT graph.
+	ID         string `json:"id,omitempty"`
+	TemplateID string `json:"template-id,omitempty"`
+	DBRPs      []DBRP `json:"dbrps,omitempty"`
+	TICKscript string `json:"script,omitempty"`
+	Vars       Vars   `json:"vars,omitempty"`
+}
+
+// ValidationError is an error in a TICKscript.
+// Line and Char are zero if the error has no position.
+type ValidationError struct {
+	Message string `json:"message"`
+	Line    int    `json:"line,omitempty"`
+	Char    int    `json:"char,omitempty"`
+}
+
+type ValidateTaskResult struct {
+	Valid  bool              `json:"valid"`
+	Errors []ValidationError `json:"errors,omitempty"`
+	Type   TaskType          `json:"type,omitempty"`
+	DBRPs  []DBRP            `json:"dbrps,omitempty"`
+	Dot    string            `json:"dot,omitempty"`
+}
+
+// ValidateTask compiles a task definition without creating the task.
+// An invalid definition is not an error, its errors are part of the result.
+func (c *Client) ValidateTask(opt ValidateTaskOptions) (ValidateTaskResult, error) {
+	r := ValidateTaskResult{}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	err := enc.Encode(opt)
+	if err != nil {
+		return r, err
+	}
+
+	u := *c.url
+	u.Path = tasksValidatePath
+
+	req, err := http.NewRequest("POST", u.String(), &buf)
+	if err != nil {
+		return r, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err = c.Do(req, &r, http.StatusOK)
+	return r, err
+}
+
 type UpdateTaskOptions struct {
 	ID         string     `json:"id,omitempty" yaml:"id"`
 	TemplateID string     `json:"template-id,omitempty" yaml:"template-id"`
@@ -1210,6 +1310,123 @@ func (c *Client) ListTemplates(opt *ListTemplatesOptions) ([]Template, error) {
 	return r.Templates, nil
 }
 
+type ListRevisionsOptions struct {
+	Offset int
+	Limit  int
+}
+
+func (o *ListRevisionsOptions) Default() {
+	if o.Limit == 0 {
+		o.Limit = 100
+	}
+}
+
+func (o *ListRevisionsOptions) Values() *url.Values {
+	v := &url.Values{}
+	v.Set("offset", strconv.FormatInt(int64(o.Offset), 10))
+	v.Set("limit", strconv.FormatInt(int64(o.Limit), 10))
+	return v
+}
+
+// ListRevisions returns the revisions of a task or template, oldest first.
+// The link is the link of the task or template.
+func (c *Client) ListRevisions(link Link, opt *ListRevisionsOptions) ([]Revision, error) {
+	if link.Href == "" {
+		return nil, fmt.Errorf("invalid link %v", link)
+	}
+	if opt == nil {
+		opt = new(ListRevisionsOptions)
+	}
+	opt.Default()
+
+	u := *c.url
+	u.Path = path.Join(link.Href, revisionsPath)
+	u.RawQuery = opt.Values().Encode()
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Response type
+	type response struct {
+		Revisions []Revision `json:"revisions"`
+	}
+
+	r := &response{}
+
+	_, err = c.Do(req, r, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return r.Revisions, nil
+}
+
+// Revision returns a single revision of a task or template.
+// The link is the link of the task or template.
+func (c *Client) Revision(link Link, number int) (Revision, error) {
+	rev := Revision{}
+	if link.Href == "" {
+		return rev, fmt.Errorf("invalid link %v", link)
+	}
+
+	u := *c.url
+	u.Path = path.Join(link.Href, revisionsPath, strconv.Itoa(number))
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return rev, err
+	}
+
+	_, err = c.Do(req, &rev, http.StatusOK)
+	return rev, err
+}
+
+type RollbackOptions struct {
+	Revision int `json:"revision"`
+}
+
+func (c *Client) rollback(link Link, number int, result interface{}) error {
+	if link.Href == "" {
+		return fmt.Errorf("invalid link %v", link)
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	err := enc.Encode(RollbackOptions{Revision: number})
+	if err != nil {
+		return err
+	}
+
+	u := *c.url
+	u.Path = path.Join(link.Href, rollbackPath)
+
+	req, err := http.NewRequest("POST", u.String(), &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err = c.Do(req, result, http.StatusOK)
+	return err
+}
+
+// RollbackTask restores the definition of a task from one of its revisions.
+// The rollback is recorded as a new revision.
+func (c *Client) RollbackTask(link Link, number int) (Task, error) {
+	t := Task{}
+	err := c.rollback(link, number, &t)
+	return t, err
+}
+
+// RollbackTemplate restores the definition of a template from one of its revisions.
+// The rollback is recorded as a new revision and all tasks of the template are updated.
+func (c *Client) RollbackTemplate(link Link, number int) (Template, error) {
+	t := Template{}
+	err := c.rollback(link, number, &t)
+	return t, err
+}
+
 // Get information about a recording.
 func (c *Client) Recording(link Link) (Recording, error) {
 	r := Recording{}
@@ -2178,6 +2395,121 @@ func (c *Client) ListTopicHandlers(link Link, opt *ListTopicHandlersOptions) (To
 	return handlers, nil
 }
 
+type Silences struct {
+	Link     Link      `json:"link"`
+	Silences []Silence `json:"silences"`
+}
+
+// Silence suppresses the topic handlers of matching alert events between Start and End.
+// The state of silenced events is still recorded.
+type Silence struct {
+	Link    Link              `json:"link"`
+	ID      string            `json:"id"`
+	Topic   string            `json:"topic"`
+	EventID string            `json:"event-id"`
+	Tags    map[string]string `json:"tags"`
+	Start   time.Time         `json:"start"`
+	End     time.Time         `json:"end"`
+	Creator string            `json:"creator"`
+	Comment string            `json:"comment"`
+	// Whether the silence currently applies.
+	Active bool `json:"active"`
+}
+
+type SilenceOptions struct {
+	// ID of the silence, a random ID is used if empty.
+	ID string `json:"id,omitempty"`
+	// Glob pattern of the topics to silence, empty matches all topics.
+	Topic string `json:"topic,omitempty"`
+	// Glob pattern of the event IDs to silence, empty matches all events.
+	EventID string `json:"event-id,omitempty"`
+	// Glob patterns the tags of the events must match.
+	Tags map[string]string `json:"tags,omitempty"`
+	// Start of the silence, defaults to now.
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// Creator of the silence, defaults to the authenticated user.
+	Creator string `json:"creator,omitempty"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// CreateSilence creates a new silence.
+// Errors if a silence with the same ID already exists.
+func (c *Client) CreateSilence(opt SilenceOptions) (Silence, error) {
+	s := Silence{}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	err := enc.Encode(opt)
+	if err != nil {
+		return s, err
+	}
+
+	u := *c.url
+	u.Path = silencesPath
+
+	req, err := http.NewRequest("POST", u.String(), &buf)
+	if err != nil {
+		return s, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err = c.Do(req, &s, http.StatusOK)
+	return s, err
+}
+
+// Silence retrieves a silence.
+// Errors if no silence exists, expired silences are deleted.
+func (c *Client) Silence(link Link) (Silence, error) {
+	s := Silence{}
+	if link.Href == "" {
+		return s, fmt.Errorf("invalid link %v", link)
+	}
+
+	u := *c.url
+	u.Path = link.Href
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return s, err
+	}
+
+	_, err = c.Do(req, &s, http.StatusOK)
+	return s, err
+}
+
+// ListSilences returns all silences that have not expired.
+func (c *Client) ListSilences() (Silences, error) {
+	silences := Silences{}
+
+	u := *c.url
+	u.Path = silencesPath
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return silences, err
+	}
+
+	_, err = c.Do(req, &silences, http.StatusOK)
+	return silences, err
+}
+
+// DeleteSilence deletes a silence, matching events are handled again.
+func (c *Client) DeleteSilence(link Link) error {
+	if link.Href == "" {
+		return fmt.Errorf("invalid link %v", link)
+	}
+	u := *c.url
+	u.Path = link.Href
+
+	req, err := http.NewRequest("DELETE", u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.Do(req, nil, http.StatusNoContent)
+	return err
+}
+
 type StorageList struct {
 	Link    Link      `json:"link"`
 	Storage []Storage `json:"storage"`
@@ -2266,6 +2598,836 @@ func (c *Client) DoStorageAction(l Link, opt StorageActionOptions) error {
 	return nil
 }
 
+type NodeStateValidateOptions struct {
+	// Migrate outdated state to the current encoding and delete invalid state.
+	Repair bool `json:"repair"`
+}
+
+type NodeStateValidateResult struct {
+	Link     Link               `json:"link"`
+	Checked  int                `json:"checked"`
+	Outdated []string           `json:"outdated"`
+	Invalid  []NodeStateProblem `json:"invalid"`
+	Migrated int                `json:"migrated"`
+	Deleted  int                `json:"deleted"`
+}
+
+type NodeStateProblem struct {
+	Key   string `json:"key"`
+	Error string `json:"error"`
+}
+
+// ValidateNodeState checks that all persisted node state can be decoded,
+// optionally repairing outdated and invalid state.
+func (c *Client) ValidateNodeState(opt NodeStateValidateOptions) (NodeStateValidateResult, error) {
+	r := NodeStateValidateResult{}
+	u := *c.url
+	u.Path = validateStatePath
+
+	var buf bytes.Buffer
+	err := json.NewEncoder(&buf).Encode(opt)
+	if err != nil {
+		return r, err
+	}
+	req, err := http.NewRequest("POST", u.String(), &buf)
+	if err != nil {
+		return r, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err = c.Do(req, &r, http.StatusOK)
+	if err != nil {
+		return r, err
+	}
+	return r, nil
+}
+
+type Blob struct {
+	Link Link `json:"link"`
+	// ID is the hex encoded SHA256 sum of the content of the blob.
+	ID      string    `json:"id"`
+	Size    int64     `json:"size"`
+	Created time.Time `json:"created"`
+}
+
+type BlobTag struct {
+	Link Link   `json:"link"`
+	Name string `json:"name"`
+	// Blob is the ID of the blob the tag is currently associated with.
+	Blob string `json:"blob"`
+	// History of the blobs associated with the tag, oldest first.
+	History []BlobTagEntry `json:"history"`
+}
+
+type BlobTagEntry struct {
+	Blob string    `json:"blob"`
+	Time time.Time `json:"time"`
+}
+
+func (c *Client) BlobLink(id string) Link {
+	return Link{Relation: Self, Href: path.Join(blobsPath, id)}
+}
+
+func (c *Client) BlobTagLink(name string) Link {
+	return Link{Relation: Self, Href: path.Join(blobTagsPath, name)}
+}
+
+type CreateBlobOptions struct {
+	// Optional name of a tag to associate with the blob.
+	Tag string
+}
+
+// CreateBlob stores the content read from r as a new blob.
+// Storing content that already exists in the store returns the existing blob.
+func (c *Client) CreateBlob(r io.Reader, opt CreateBlobOptions) (Blob, error) {
+	b := Blob{}
+	u := *c.url
+	u.Path = blobsPath
+	if opt.Tag != "" {
+		v := url.Values{}
+		v.Set("tag", opt.Tag)
+		u.RawQuery = v.Encode()
+	}
+
+	req, err := http.NewRequest("POST", u.String(), r)
+	if err != nil {
+		return b, err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	_, err = c.Do(req, &b, http.StatusOK)
+	if err != nil {
+		return b, err
+	}
+	return b, nil
+}
+
+// Blob returns the metadata of a blob.
+func (c *Client) Blob(link Link) (Blob, error) {
+	b := Blob{}
+	if link.Href == "" {
+		return b, fmt.Errorf("invalid link %v", link)
+	}
+	u := *c.url
+	u.Path = link.Href
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return b, err
+	}
+
+	_, err = c.Do(req, &b, http.StatusOK)
+	if err != nil {
+		return b, err
+	}
+	return b, nil
+}
+
+// ReadBlob streams the content of a blob or of the blob currently associated with a tag.
+// The link may be either a blob link or a blob tag link.
+// The returned reader must be closed.
+func (c *Client) ReadBlob(link Link) (io.ReadCloser, error) {
+	if link.Href == "" {
+		return nil, fmt.Errorf("invalid link %v", link)
+	}
+	u := *c.url
+	u.Path = path.Join(link.Href, blobDataPath)
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	err = c.prepRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, c.decodeError(resp)
+	}
+	return resp.Body, nil
+}
+
+// DeleteBlob deletes a blob and removes it from the history of all tags.
+func (c *Client) DeleteBlob(link Link) error {
+	if link.Href == "" {
+		return fmt.Errorf("invalid link %v", link)
+	}
+	u := *c.url
+	u.Path = link.Href
+
+	req, err := http.NewRequest("DELETE", u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.Do(req, nil, http.StatusNoContent)
+	return err
+}
+
+type ListBlobsOptions struct {
+	Pattern string
+	Offset  int
+	Limit   int
+}
+
+func (o *ListBlobsOptions) Default() {
+	if o.Limit == 0 {
+		o.Limit = 100
+	}
+}
+
+func (o *ListBlobsOptions) Values() *url.Values {
+	v := &url.Values{}
+	v.Set("pattern", o.Pattern)
+	v.Set("offset", strconv.FormatInt(int64(o.Offset), 10))
+	v.Set("limit", strconv.FormatInt(int64(o.Limit), 10))
+	return v
+}
+
+// ListBlobs returns the metadata of blobs whose ID matches the pattern.
+func (c *Client) ListBlobs(opt *ListBlobsOptions) ([]Blob, error) {
+	if opt == nil {
+		opt = new(ListBlobsOptions)
+	}
+	opt.Default()
+	u := *c.url
+	u.Path = blobsPath
+	u.RawQuery = opt.Values().Encode()
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	// Decode valid response
+	type response struct {
+		Blobs []Blob `json:"blobs"`
+	}
+
+	r := &response{}
+
+	_, err = c.Do(req, r, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return r.Blobs, nil
+}
+
+type TagBlobOptions struct {
+	// ID of the blob to associate with the tag.
+	Blob string `json:"blob"`
+}
+
+// TagBlob associates the tag with a blob, creating the tag if it does not exist.
+func (c *Client) TagBlob(link Link, opt TagBlobOptions) (BlobTag, error) {
+	t := BlobTag{}
+	if link.Href == "" {
+		return t, fmt.Errorf("invalid link %v", link)
+	}
+	u := *c.url
+	u.Path = link.Href
+
+	var buf bytes.Buffer
+	err := json.NewEncoder(&buf).Encode(opt)
+	if err != nil {
+		return t, err
+	}
+	req, err := http.NewRequest("PUT", u.String(), &buf)
+	if err != nil {
+		return t, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err = c.Do(req, &t, http.StatusOK)
+	if err != nil {
+		return t, err
+	}
+	return t, nil
+}
+
+// BlobTag returns a tag and its history.
+func (c *Client) BlobTag(link Link) (BlobTag, error) {
+	t := BlobTag{}
+	if link.Href == "" {
+		return t, fmt.Errorf("invalid link %v", link)
+	}
+	u := *c.url
+	u.Path = link.Href
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return t, err
+	}
+
+	_, err = c.Do(req, &t, http.StatusOK)
+	if err != nil {
+		return t, err
+	}
+	return t, nil
+}
+
+// DeleteBlobTag deletes a tag, the blobs associated with the tag are not deleted.
+func (c *Client) DeleteBlobTag(link Link) error {
+	if link.Href == "" {
+		return fmt.Errorf("invalid link %v", link)
+	}
+	u := *c.url
+	u.Path = link.Href
+
+	req, err := http.NewRequest("DELETE", u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.Do(req, nil, http.StatusNoContent)
+	return err
+}
+
+type ListBlobTagsOptions struct {
+	Pattern string
+	Offset  int
+	Limit   int
+}
+
+func (o *ListBlobTagsOptions) Default() {
+	if o.Limit == 0 {
+		o.Limit = 100
+	}
+}
+
+func (o *ListBlobTagsOptions) Values() *url.Values {
+	v := &url.Values{}
+	v.Set("pattern", o.Pattern)
+	v.Set("offset", strconv.FormatInt(int64(o.Offset), 10))
+	v.Set("limit", strconv.FormatInt(int64(o.Limit), 10))
+	return v
+}
+
+// ListBlobTags returns the tags whose name matches the pattern.
+func (c *Client) ListBlobTags(opt *ListBlobTagsOptions) ([]BlobTag, error) {
+	if opt == nil {
+		opt = new(ListBlobTagsOptions)
+	}
+	opt.Default()
+	u := *c.url
+	u.Path = blobTagsPath
+	u.RawQuery = opt.Values().Encode()
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	// Decode valid response
+	type response struct {
+		Tags []BlobTag `json:"tags"`
+	}
+
+	r := &response{}
+
+	_, err = c.Do(req, r, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return r.Tags, nil
+}
+
+// User is a user of the local authentication service.
+type User struct {
+	Link  Link   `json:"link"`
+	Name  string `json:"name"`
+	Admin bool   `json:"admin"`
+	// Privileges maps resources to the names of the privileges the user has on them,
+	// i.e. "/api/tasks": ["read", "write"].
+	Privileges map[string][]string `json:"privileges"`
+}
+
+func (c *Client) UserLink(name string) Link {
+	return Link{Relation: Self, Href: path.Join(usersPath, name)}
+}
+
+type CreateUserOptions struct {
+	Name       string              `json:"name"`
+	Password   string              `json:"password"`
+	Admin      bool                `json:"admin"`
+	Privileges map[string][]string `json:"privileges,omitempty"`
+}
+
+// CreateUser creates a new user.
+func (c *Client) CreateUser(opt CreateUserOptions) (User, error) {
+	var buf bytes.Buffer
+	err := json.NewEncoder(&buf).Encode(opt)
+	if err != nil {
+		return User{}, err
+	}
+
+	u := *c.url
+	u.Path = usersPath
+
+	req, err := http.NewRequest("POST", u.String(), &buf)
+	if err != nil {
+		return User{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	user := User{}
+	_, err = c.Do(req, &user, http.StatusOK)
+	return user, err
+}
+
+type UpdateUserOptions struct {
+	Password string `json:"password,omitempty"`
+	Admin    *bool  `json:"admin,omitempty"`
+	// Privileges replace all privileges of the user if not nil.
+	Privileges map[string][]string `json:"privileges,omitempty"`
+}
+
+// UpdateUser updates an existing user.
+// Only fields that are not their default value will be updated.
+func (c *Client) UpdateUser(link Link, opt UpdateUserOptions) (User, error) {
+	user := User{}
+	if link.Href == "" {
+		return user, fmt.Errorf("invalid link %v", link)
+	}
+
+	var buf bytes.Buffer
+	err := json.NewEncoder(&buf).Encode(opt)
+	if err != nil {
+		return user, err
+	}
+
+	u := *c.url
+	u.Path = link.Href
+
+	req, err := http.NewRequest("PATCH", u.String(), &buf)
+	if err != nil {
+		return user, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err = c.Do(req, &user, http.StatusOK)
+	if err != nil {
+		return user, err
+	}
+	return user, nil
+}
+
+// User returns a user, the password hash of users is never returned.
+func (c *Client) User(link Link) (User, error) {
+	user := User{}
+	if link.Href == "" {
+		return user, fmt.Errorf("invalid link %v", link)
+	}
+	u := *c.url
+	u.Path = link.Href
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return user, err
+	}
+
+	_, err = c.Do(req, &user, http.StatusOK)
+	if err != nil {
+		return user, err
+	}
+	return user, nil
+}
+
+// DeleteUser deletes a user.
+func (c *Client) DeleteUser(link Link) error {
+	if link.Href == "" {
+		return fmt.Errorf("invalid link %v", link)
+	}
+	u := *c.url
+	u.Path = link.Href
+
+	req, err := http.NewRequest("DELETE", u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.Do(req, nil, http.StatusNoContent)
+	return err
+}
+
+type ListUsersOptions struct {
+	Pattern string
+	Offset  int
+	Limit   int
+}
+
+func (o *ListUsersOptions) Default() {
+	if o.Limit == 0 {
+		o.Limit = 100
+	}
+}
+
+func (o *ListUsersOptions) Values() *url.Values {
+	v := &url.Values{}
+	v.Set("pattern", o.Pattern)
+	v.Set("offset", strconv.FormatInt(int64(o.Offset), 10))
+	v.Set("limit", strconv.FormatInt(int64(o.Limit), 10))
+	return v
+}
+
+// ListUsers returns the users whose name matches the pattern.
+func (c *Client) ListUsers(opt *ListUsersOptions) ([]User, error) {
+	if opt == nil {
+		opt = new(ListUsersOptions)
+	}
+	opt.Default()
+	u := *c.url
+	u.Path = usersPath
+	u.RawQuery = opt.Values().Encode()
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	// Decode valid response
+	type response struct {
+		Users []User `json:"users"`
+	}
+
+	r := &response{}
+
+	_, err = c.Do(req, r, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return r.Users, nil
+}
+
+type APIToken struct {
+	Link        Link   `json:"link"`
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	// Token is the secret bearer token, it is only returned when the token is created.
+	Token string `json:"token,omitempty"`
+	// Scopes maps resources to the names of the privileges the token grants on them,
+	// i.e. "/api/tasks": ["read"].
+	Scopes    map[string][]string `json:"scopes"`
+	CreatedBy string              `json:"created-by"`
+	Created   time.Time           `json:"created"`
+	// Expires is zero if the token never expires.
+	Expires time.Time `json:"expires"`
+}
+
+func (c *Client) APITokenLink(id string) Link {
+	return Link{Relation: Self, Href: path.Join(apiTokensPath, id)}
+}
+
+type CreateAPITokenOptions struct {
+	Description string              `json:"description"`
+	Scopes      map[string][]string `json:"scopes"`
+	// Expires is when the token expires, the token never expires if it is zero.
+	Expires time.Time `json:"expires"`
+}
+
+// CreateAPIToken creates a new API token.
+// The returned token contains the secret bearer token, which cannot be retrieved later.
+func (c *Client) CreateAPIToken(opt CreateAPITokenOptions) (APIToken, error) {
+	var buf bytes.Buffer
+	err := json.NewEncoder(&buf).Encode(opt)
+	if err != nil {
+		return APIToken{}, err
+	}
+
+	u := *c.url
+	u.Path = apiTokensPath
+
+	req, err := http.NewRequest("POST", u.String(), &buf)
+	if err != nil {
+		return APIToken{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	token := APIToken{}
+	_, err = c.Do(req, &token, http.StatusOK)
+	return token, err
+}
+
+// APIToken returns an API token without its secret.
+func (c *Client) APIToken(link Link) (APIToken, error) {
+	token := APIToken{}
+	if link.Href == "" {
+		return token, fmt.Errorf("invalid link %v", link)
+	}
+	u := *c.url
+	u.Path = link.Href
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return token, err
+	}
+
+	_, err = c.Do(req, &token, http.StatusOK)
+	if err != nil {
+		return token, err
+	}
+	return token, nil
+}
+
+// RevokeAPIToken deletes an API token, requests using the token are no longer authenticated.
+func (c *Client) RevokeAPIToken(link Link) error {
+	if link.Href == "" {
+		return fmt.Errorf("invalid link %v", link)
+	}
+	u := *c.url
+	u.Path = link.Href
+
+	req, err := http.NewRequest("DELETE", u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.Do(req, nil, http.StatusNoContent)
+	return err
+}
+
+type ListAPITokensOptions struct {
+	Offset int
+	Limit  int
+}
+
+func (o *ListAPITokensOptions) Default() {
+	if o.Limit == 0 {
+		o.Limit = 100
+	}
+}
+
+func (o *ListAPITokensOptions) Values() *url.Values {
+	v := &url.Values{}
+	v.Set("offset", strconv.FormatInt(int64(o.Offset), 10))
+	v.Set("limit", strconv.FormatInt(int64(o.Limit), 10))
+	return v
+}
+
+// ListAPITokens returns the API tokens without their secrets.
+func (c *Client) ListAPITokens(opt *ListAPITokensOptions) ([]APIToken, error) {
+	if opt == nil {
+		opt = new(ListAPITokensOptions)
+	}
+	opt.Default()
+	u := *c.url
+	u.Path = apiTokensPath
+	u.RawQuery = opt.Values().Encode()
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	// Decode valid response
+	type response struct {
+		Tokens []APIToken `json:"tokens"`
+	}
+
+	r := &response{}
+
+	_, err = c.Do(req, r, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return r.Tokens, nil
+}
+
+// AuditEntry records a mutating request of the API.
+type AuditEntry struct {
+	ID      string    `json:"id"`
+	Time    time.Time `json:"time"`
+	User    string    `json:"user"`
+	TokenID string    `json:"token-id"`
+	Method  string    `json:"method"`
+	Path    string    `json:"path"`
+	Status  int       `json:"status"`
+	// ResourceType is the first element of the path, i.e. tasks.
+	ResourceType string `json:"resource-type"`
+	ResourceID   string `json:"resource-id"`
+	// Diff is a unified diff of the TICKscript and vars of a changed task or template.
+	Diff string `json:"diff"`
+}
+
+type ListAuditEntriesOptions struct {
+	// Start and Stop bound the time of entries, zero values are unbounded.
+	Start time.Time
+	Stop  time.Time
+	// ResourceType, i.e. tasks, templates or config.
+	ResourceType string
+	// ResourceID is a glob pattern matched against the resource ID.
+	ResourceID string
+	User       string
+	Offset     int
+	Limit      int
+}
+
+func (o *ListAuditEntriesOptions) Default() {
+	if o.Limit == 0 {
+		o.Limit = 100
+	}
+}
+
+func (o *ListAuditEntriesOptions) Values() *url.Values {
+	v := &url.Values{}
+	if !o.Start.IsZero() {
+		v.Set("start", o.Start.Format(time.RFC3339Nano))
+	}
+	if !o.Stop.IsZero() {
+		v.Set("stop", o.Stop.Format(time.RFC3339Nano))
+	}
+	if o.ResourceType != "" {
+		v.Set("type", o.ResourceType)
+	}
+	if o.ResourceID != "" {
+		v.Set("resource", o.ResourceID)
+	}
+	if o.User != "" {
+		v.Set("user", o.User)
+	}
+	v.Set("offset", strconv.FormatInt(int64(o.Offset), 10))
+	v.Set("limit", strconv.FormatInt(int64(o.Limit), 10))
+	return v
+}
+
+// ListAuditEntries returns the audit entries matching the options, newest first.
+func (c *Client) ListAuditEntries(opt *ListAuditEntriesOptions) ([]AuditEntry, error) {
+	if opt == nil {
+		opt = new(ListAuditEntriesOptions)
+	}
+	opt.Default()
+	u := *c.url
+	u.Path = auditPath
+	u.RawQuery = opt.Values().Encode()
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	// Decode valid response
+	type response struct {
+		Entries []AuditEntry `json:"entries"`
+	}
+
+	r := &response{}
+
+	_, err = c.Do(req, r, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	return r.Entries, nil
+}
+
+// ApplyOptions is a manifest set of tasks, templates and topic handlers
+// that is applied to Kapacitor as a whole.
+type ApplyOptions struct {
+	Tasks         []CreateTaskOptions     `json:"tasks"`
+	Templates     []CreateTemplateOptions `json:"templates"`
+	TopicHandlers []TopicHandlerOptions   `json:"topic-handlers"`
+	// DryRun only computes the plan without applying it.
+	DryRun bool `json:"dry-run"`
+	// Prune deletes all tasks, templates and topic handlers that are not part of the manifest set.
+	Prune bool `json:"prune"`
+}
+
+// ApplyAction is a single change of the plan of an apply.
+type ApplyAction struct {
+	// Kind is one of task, template or topic-handler.
+	Kind string `json:"kind"`
+	// ID of the resource, topic handlers are identified as <topic>/<handler>.
+	ID string `json:"id"`
+	// Action is one of create, update or delete.
+	Action string `json:"action"`
+}
+
+type ApplyResult struct {
+	Actions []ApplyAction `json:"actions"`
+	// Applied is false for dry runs.
+	Applied bool `json:"applied"`
+}
+
+// Apply computes the plan of creates, updates and deletes needed to
+// make Kapacitor match the manifest set and applies it.
+// Either all changes of the plan are applied or none.
+func (c *Client) Apply(opt ApplyOptions) (ApplyResult, error) {
+	r := ApplyResult{}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	err := enc.Encode(opt)
+	if err != nil {
+		return r, err
+	}
+
+	u := *c.url
+	u.Path = applyPath
+
+	req, err := http.NewRequest("POST", u.String(), &buf)
+	if err != nil {
+		return r, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err = c.Do(req, &r, http.StatusOK)
+	return r, err
+}
+
+// GraphNode is a task, data stream, alert topic or topic handler in the task graph.
+type GraphNode struct {
+	// ID is the kind and name of the node, i.e. task:cpu_alert.
+	ID string `json:"id"`
+	// Kind is one of task, stream, topic or handler.
+	Kind string `json:"kind"`
+	// Name is the task ID, the "db"."rp".measurement of a stream,
+	// the topic ID or the <topic>/<handler> ID of a topic handler.
+	// Streams of any measurement use * as measurement.
+	Name string `json:"name"`
+	// Status of a task.
+	Status TaskStatus `json:"status,omitempty"`
+	// Error is set if the TICKscript of a task could not be compiled.
+	Error string `json:"error,omitempty"`
+}
+
+// GraphEdge is a directed link between two nodes of the task graph.
+type GraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Kind is one of:
+	//   reads      a task reads a stream,
+	//   loopback   a task writes a stream with kapacitorLoopback,
+	//   feeds      a written stream matches a stream read by a task,
+	//   alerts     a task sends alerts to a topic,
+	//   handles    a topic handler handles the events of a topic,
+	//   publishes  a topic handler publishes events to a topic.
+	Kind string `json:"kind"`
+}
+
+// Graph links tasks to each other through the streams they read and write with kapacitorLoopback,
+// alert topics and topic handlers.
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+	// Cycles are groups of node IDs that depend on each other.
+	Cycles [][]string `json:"cycles"`
+	// Dot is the DOT representation of the graph.
+	Dot string `json:"dot"`
+}
+
+// Graph returns the graph of all tasks, alert topics and topic handlers.
+func (c *Client) Graph() (Graph, error) {
+	g := Graph{}
+	u := *c.url
+	u.Path = graphPath
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return g, err
+	}
+
+	_, err = c.Do(req, &g, http.StatusOK)
+	return g, err
+}
+
 // Backup requests a backup of all storage from Kapacitor.
 // A short read is possible, to verify that the backup was successful
 // check that the number of bytes read matches the returned size.
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -13,6 +14,7 @@ import (
 	"os"
 	"os/signal"
 	"path"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
@@ -25,6 +27,8 @@ import (
 	"github.com/influxdata/influxdb/influxql"
 	"github.com/thingnario/kapacitor/client/v1"
 	"github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/thingnario/kapacitor/ticktest"
 )
 
 // These variables are populated via the Go linker.
@@ -54,14 +58,20 @@ Commands:
 	record                Record the result of a query or a snapshot of the current stream data.
 	define                Create/update a task.
 	define-template       Create/update a template.
+	validate              Check that a TICKscript or template with vars compiles without defining a task.
+	test                  Run a TICKscript offline against fixture points and compare its alerts and outputs with expectations.
 	define-topic-handler  Create/update an alert handler for a topic.
+	apply                 Create, update and delete tasks, templates and topic handlers to match a directory.
 	replay                Replay a recording to a task.
 	replay-live           Replay data against a task without recording it.
 	watch                 Watch logs for a task.
 	logs                  Follow arbitrary Kapacitor logs.
 	enable                Enable and start running a task with live data.
 	disable               Stop running a task.
+	pause                 Hold back the points of a running task without losing its state.
+	resume                Process the points held back while a task was paused and keep running it.
 	reload                Reload a running task with an updated task definition.
+	rollback              Restore a task or template to one of its previous revisions.
 	push                  Publish a task definition to another Kapacitor instance. Not implemented yet.
 	delete                Delete tasks, templates, recordings, replays, topics or topic-handlers.
 	list                  List information about tasks, templates, recordings, replays, topics, topic-handlers or service-tests.
@@ -69,7 +79,14 @@ Commands:
 	show-template         Display detailed information about a template.
 	show-topic-handler    Display detailed information about an alert handler for a topic.
 	show-topic            Display detailed information about an alert topic.
+	graph                 Display the graph of tasks linked by kapacitorLoopback, alert topics and topic handlers.
 	backup                Backup the Kapacitor database.
+	node-state            Validate and repair the state persisted by changeDetect and alert nodes.
+	blob                  Create, tag, read and delete blobs in the blob store.
+	user                  Create, update and delete users of the local authentication service.
+	token                 Create, list and revoke API tokens of the local authentication service.
+	silence               Create, list and delete silences of alert topic handlers, i.e. for maintenance windows.
+	audit                 List the audit log of changes made via the API.
 	level                 Sets the logging level on the kapacitord server.
 	stats                 Display various stats about Kapacitor.
 	version               Displays the Kapacitor version info.
@@ -146,6 +163,18 @@ func main() {
 	case "define-template":
 		commandArgs = args
 		commandF = doDefineTemplate
+	case "validate":
+		validateFlags.Parse(args)
+		commandArgs = validateFlags.Args()
+		commandF = doValidate
+	case "test":
+		testFlags.Parse(args)
+		commandArgs = testFlags.Args()
+		commandF = doTest
+	case "apply":
+		applyFlags.Parse(args)
+		commandArgs = applyFlags.Args()
+		commandF = doApply
 	case "define-topic-handler":
 		commandArgs = args
 		commandF = doDefineTopicHandler
@@ -172,9 +201,23 @@ func main() {
 	case "disable":
 		commandArgs = args
 		commandF = doDisable
+	case "pause":
+		commandArgs = args
+		commandF = doPause
+	case "resume":
+		commandArgs = args
+		commandF = doResume
 	case "reload":
 		commandArgs = args
 		commandF = doReload
+	case "rollback":
+		rollbackFlags.Parse(args)
+		commandArgs = rollbackFlags.Args()
+		commandF = doRollback
+	case "graph":
+		graphFlags.Parse(args)
+		commandArgs = graphFlags.Args()
+		commandF = doGraph
 	case "delete":
 		commandArgs = args
 		commandF = doDelete
@@ -186,7 +229,8 @@ func main() {
 		commandArgs = showFlags.Args()
 		commandF = doShow
 	case "show-template":
-		commandArgs = args
+		showTemplateFlags.Parse(args)
+		commandArgs = showTemplateFlags.Args()
 		commandF = doShowTemplate
 	case "show-topic-handler":
 		commandArgs = args
@@ -197,6 +241,25 @@ func main() {
 	case "backup":
 		commandArgs = args
 		commandF = doBackup
+	case "node-state":
+		commandArgs = args
+		commandF = doNodeState
+	case "blob":
+		commandArgs = args
+		commandF = doBlob
+	case "user":
+		commandArgs = args
+		commandF = doUser
+	case "token":
+		commandArgs = args
+		commandF = doToken
+	case "silence":
+		commandArgs = args
+		commandF = doSilence
+	case "audit":
+		auditFlags.Parse(args)
+		commandArgs = auditFlags.Args()
+		commandF = doAudit
 	case "level":
 		commandArgs = args
 		commandF = doLevel
@@ -230,6 +293,20 @@ func init() {
 	defineFlags.Usage = defineUsage
 	defineTemplateFlags.Usage = defineTemplateUsage
 	showFlags.Usage = showUsage
+	applyFlags.Usage = applyUsage
+	validateFlags.Usage = validateUsage
+	testFlags.Usage = testUsage
+	showTemplateFlags.Usage = showTemplateUsage
+	rollbackFlags.Usage = rollbackUsage
+	graphFlags.Usage = graphUsage
+	nodeStateValidateFlags.Usage = nodeStateUsage
+	blobCreateFlags.Usage = blobUsage
+	blobGetFlags.Usage = blobUsage
+	userCreateFlags.Usage = userUsage
+	userUpdateFlags.Usage = userUsage
+	tokenCreateFlags.Usage = tokenUsage
+	silenceCreateFlags.Usage = silenceUsage
+	auditFlags.Usage = auditUsage
 
 	recordStreamFlags.Usage = recordStreamUsage
 	recordBatchFlags.Usage = recordBatchUsage
@@ -250,9 +327,23 @@ func (e responseError) Error() string {
 }
 
 func connect(url string, skipSSL bool) (*client.Client, error) {
+	var credentials *client.Credentials
+	if token := os.Getenv("KAPACITOR_TOKEN"); token != "" {
+		credentials = &client.Credentials{
+			Method: client.BearerAuthentication,
+			Token:  token,
+		}
+	} else if username := os.Getenv("KAPACITOR_USERNAME"); username != "" {
+		credentials = &client.Credentials{
+			Method:   client.UserAuthentication,
+			Username: username,
+			Password: os.Getenv("KAPACITOR_PASSWORD"),
+		}
+	}
 	return client.New(client.Config{
 		URL:                url,
 		InsecureSkipVerify: skipSSL,
+		Credentials:        credentials,
 	})
 }
 
@@ -275,18 +366,32 @@ func doHelp(args []string) error {
 			defineTemplateFlags.Usage()
 		case "define-topic-handler":
 			defineTopicHandlerUsage()
+		case "apply":
+			applyUsage()
+		case "validate":
+			validateUsage()
+		case "test":
+			testUsage()
 		case "replay":
 			replayFlags.Usage()
 		case "enable":
 			enableUsage()
 		case "disable":
 			disableUsage()
+		case "pause":
+			pauseUsage()
+		case "resume":
+			resumeUsage()
 		case "reload":
 			reloadUsage()
 		case "delete":
 			deleteUsage()
 		case "list":
 			listUsage()
+		case "rollback":
+			rollbackUsage()
+		case "graph":
+			graphUsage()
 		case "show":
 			showUsage()
 		case "show-template":
@@ -297,6 +402,18 @@ func doHelp(args []string) error {
 			showTopicUsage()
 		case "backup":
 			backupUsage()
+		case "node-state":
+			nodeStateUsage()
+		case "blob":
+			blobUsage()
+		case "user":
+			userUsage()
+		case "token":
+			tokenUsage()
+		case "silence":
+			silenceUsage()
+		case "audit":
+			auditUsage()
 		case "watch":
 			watchUsage()
 		case "logs":
@@ -735,7 +852,6 @@ func doDefine(args []string) error {
 
 	l := cli.TaskLink(id)
 	task, _ := cli.Task(l, nil)
-	var err error
 	if task.ID == "" {
 		if *dfile != "" {
 			o, err := fileVars.CreateTaskOptions()
@@ -747,10 +863,11 @@ func doDefine(args []string) error {
 			if err != nil {
 				return err
 			}
-			_, err = cli.CreateTask(o)
+			t, err := cli.CreateTask(o)
 			if err != nil {
 				return err
 			}
+			printTaskWarnings(t)
 		} else {
 			o := client.CreateTaskOptions{
 				ID:         id,
@@ -761,10 +878,11 @@ func doDefine(args []string) error {
 				Vars:       vars,
 				Status:     client.Disabled,
 			}
-			_, err = cli.CreateTask(o)
+			t, err := cli.CreateTask(o)
 			if err != nil {
 				return err
 			}
+			printTaskWarnings(t)
 		}
 	} else {
 		if *dfile != "" {
@@ -779,13 +897,14 @@ func doDefine(args []string) error {
 				return errors.New("Task id given on command line does not match id in " + *dfile)
 			}
 
-			_, err = cli.UpdateTask(
+			t, err := cli.UpdateTask(
 				l,
 				o,
 			)
 			if err != nil {
 				return err
 			}
+			printTaskWarnings(t)
 		} else {
 			o := client.UpdateTaskOptions{
 				TemplateID: *dtemplate,
@@ -794,13 +913,14 @@ func doDefine(args []string) error {
 				TICKscript: script,
 				Vars:       vars,
 			}
-			_, err = cli.UpdateTask(
+			t, err := cli.UpdateTask(
 				l,
 				o,
 			)
 			if err != nil {
 				return err
 			}
+			printTaskWarnings(t)
 		}
 	}
 
@@ -817,6 +937,12 @@ func doDefine(args []string) error {
 	return nil
 }
 
+func printTaskWarnings(t client.Task) {
+	for _, w := range t.Warnings {
+		fmt.Fprintf(os.Stderr, "warning: task %s: %s\n", t.ID, w)
+	}
+}
+
 // DefineTemplate
 var (
 	defineTemplateFlags = flag.NewFlagSet("define-template", flag.ExitOnError)
@@ -967,6 +1093,421 @@ func doDefineTopicHandler(args []string) error {
 	return err
 }
 
+// Validate
+var (
+	validateFlags    = flag.NewFlagSet("validate", flag.ExitOnError)
+	validateTemplate = validateFlags.String("template", "", "Validate the template with this ID instead of TICKscript files.")
+	validateVars     = validateFlags.String("vars", "", "Optional path to a JSON vars file")
+	validateFile     = validateFlags.String("file", "", "Optional path to a YAML or JSON template task file, its template ID, dbrps and vars are validated.")
+	validateDot      = validateFlags.Bool("dot", false, "Print the DOT graph of valid tasks.")
+	validateDBRPs    = make(dbrps, 0)
+)
+
+func init() {
+	validateFlags.Var(&validateDBRPs, "dbrp", `A database and retention policy pair of the form "db"."rp" the quotes are optional. The flag can be specified multiple times.`)
+}
+
+func validateUsage() {
+	var u = `Usage: kapacitor validate [options] [TICKscript files...]
+
+	Check that TICKscripts or a template with vars compile the same way they would when defining a task,
+	without creating the task. Errors are printed as <file>:<line>:<char>: <message>.
+	The command exits with a non zero status if any script is invalid.
+
+	Examples:
+
+		$ kapacitor validate -dbrp telegraf.autogen cpu_alert.tick mem_alert.tick
+		$ kapacitor validate -template generic_mean_alert -vars cpu_vars.json -dbrp telegraf.autogen
+		$ kapacitor validate -file cpu_alert.yaml
+
+Options:
+`
+	fmt.Fprintln(os.Stderr, u)
+	validateFlags.PrintDefaults()
+}
+
+func doValidate(args []string) error {
+	opt := client.ValidateTaskOptions{
+		TemplateID: *validateTemplate,
+		DBRPs:      validateDBRPs,
+	}
+	if *validateVars != "" {
+		data, err := ioutil.ReadFile(*validateVars)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read file %s", *validateVars)
+		}
+		if err := json.Unmarshal(data, &opt.Vars); err != nil {
+			return errors.Wrapf(err, "invalid JSON in file %s", *validateVars)
+		}
+	}
+	if *validateFile != "" {
+		data, err := ioutil.ReadFile(*validateFile)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read task vars file %q", *validateFile)
+		}
+		fileVars := client.TaskVars{}
+		if err := unmarshalManifest(*validateFile, data, &fileVars); err != nil {
+			return err
+		}
+		opt.ID = fileVars.ID
+		opt.TemplateID = fileVars.TemplateID
+		opt.DBRPs = fileVars.DBRPs
+		opt.Vars = fileVars.Vars
+	}
+
+	if len(args) == 0 {
+		if opt.TemplateID == "" {
+			fmt.Fprintln(os.Stderr, "Must provide TICKscript files, a template ID or a template task file.")
+			validateUsage()
+			os.Exit(2)
+		}
+		return printValidation("template "+opt.TemplateID, opt)
+	}
+
+	var invalid bool
+	for _, f := range args {
+		data, err := ioutil.ReadFile(f)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read TICKscript %q", f)
+		}
+		o := opt
+		o.ID = strings.TrimSuffix(filepath.Base(f), filepath.Ext(f))
+		o.TICKscript = string(data)
+		o.TemplateID = ""
+		if err := printValidation(f, o); err != nil {
+			if _, ok := err.(invalidTaskError); !ok {
+				return err
+			}
+			invalid = true
+		}
+	}
+	if invalid {
+		return invalidTaskError("")
+	}
+	return nil
+}
+
+// invalidTaskError is returned when a task does not compile, the errors have already been printed.
+type invalidTaskError string
+
+func (e invalidTaskError) Error() string {
+	if e == "" {
+		return "invalid TICKscripts"
+	}
+	return "invalid TICKscript " + string(e)
+}
+
+func printValidation(name string, opt client.ValidateTaskOptions) error {
+	result, err := cli.ValidateTask(opt)
+	if err != nil {
+		return err
+	}
+	if !result.Valid {
+		for _, e := range result.Errors {
+			if e.Line > 0 {
+				fmt.Fprintf(os.Stdout, "%s:%d:%d: %s\n", name, e.Line, e.Char, e.Message)
+			} else {
+				fmt.Fprintf(os.Stdout, "%s: %s\n", name, e.Message)
+			}
+		}
+		return invalidTaskError(name)
+	}
+	fmt.Fprintf(os.Stdout, "%s: OK %v task, dbrps %v\n", name, result.Type, result.DBRPs)
+	if *validateDot {
+		fmt.Fprintln(os.Stdout, result.Dot)
+	}
+	return nil
+}
+
+// Test
+var (
+	testFlags      = flag.NewFlagSet("test", flag.ExitOnError)
+	testTICKscript = testFlags.String("tick", "", "Path to the TICKscript of the task.")
+	testFixture    = testFlags.String("fixture", "", "Path to a file of line protocol points with timestamps the task is run against.")
+	testExpect     = testFlags.String("expect", "", "Path to a YAML or JSON file of the expected alerts, httpOut results and influxDBOut writes.")
+	testVars       = testFlags.String("vars", "", "Optional path to a JSON vars file")
+	testPrecision  = testFlags.String("precision", "ns", "The precision of the fixture timestamps, one of: ns, u, ms, s, m, h.")
+	testPrint      = testFlags.Bool("print", false, "Print the alerts and outputs of the task in the format of the expectations file instead of comparing them.")
+	testDBRPs      = make(dbrps, 0)
+)
+
+func init() {
+	testFlags.Var(&testDBRPs, "dbrp", `A database and retention policy pair of the form "db"."rp" the quotes are optional. The flag can be specified multiple times.`)
+}
+
+func testUsage() {
+	var u = `Usage: kapacitor test -tick <TICKscript> -fixture <points> (-expect <expectations> | -print) [options]
+
+	Run a stream TICKscript offline against fixture points and compare its alerts, httpOut results
+	and influxDBOut writes with an expectations file. A unified diff is printed for every section
+	of the expectations that does not match and the command exits with a non zero status.
+	No connection to a Kapacitor server is needed and alert handlers do not send notifications.
+
+	The fixture contains one line protocol point per line, every point must have a timestamp.
+	The points are written to the first dbrp of the task.
+
+	The expectations file has the sections below, sections that are left out are not compared:
+
+		alerts:
+		  - id: cpu_alert:serverA
+		    level: CRITICAL
+		    message: cpu_alert:serverA is CRITICAL
+		http-out:
+		  <endpoint>:
+		    series: [...]
+		influxdb-out:
+		  - db: downsampled
+		    rp: autogen
+		    points:
+		      - cpu_mean,host=serverA mean=95 20
+		precision: s
+
+	Examples:
+
+		$ kapacitor test -tick cpu_alert.tick -fixture cpu.lp -precision s -expect cpu_alert.yaml
+		$ kapacitor test -tick cpu_alert.tick -fixture cpu.lp -precision s -print > cpu_alert.yaml
+
+Options:
+`
+	fmt.Fprintln(os.Stderr, u)
+	testFlags.PrintDefaults()
+}
+
+func doTest(args []string) error {
+	if *testTICKscript == "" || *testFixture == "" || (*testExpect == "" && !*testPrint) || len(args) > 0 {
+		testUsage()
+		os.Exit(2)
+	}
+	script, err := ioutil.ReadFile(*testTICKscript)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read TICKscript %q", *testTICKscript)
+	}
+	fixture, err := os.Open(*testFixture)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open fixture %q", *testFixture)
+	}
+	defer fixture.Close()
+	t := ticktest.Test{
+		ID:         strings.TrimSuffix(filepath.Base(*testTICKscript), filepath.Ext(*testTICKscript)),
+		TICKscript: string(script),
+		Fixture:    fixture,
+		DBRPs:      testDBRPs,
+		Precision:  *testPrecision,
+	}
+	if *testVars != "" {
+		data, err := ioutil.ReadFile(*testVars)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read file %s", *testVars)
+		}
+		if err := json.Unmarshal(data, &t.Vars); err != nil {
+			return errors.Wrapf(err, "invalid JSON in file %s", *testVars)
+		}
+	}
+
+	var exp ticktest.Expectations
+	if !*testPrint {
+		data, err := ioutil.ReadFile(*testExpect)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read expectations %q", *testExpect)
+		}
+		exp, err = ticktest.ReadExpectations(data)
+		if err != nil {
+			return errors.Wrapf(err, "invalid expectations file %q", *testExpect)
+		}
+	}
+
+	result, err := ticktest.Run(t)
+	if err != nil {
+		return errors.Wrapf(err, "failed to run %s", *testTICKscript)
+	}
+	for _, e := range result.Errors {
+		fmt.Fprintln(os.Stderr, "task error:", e)
+	}
+	if *testPrint {
+		out, err := yaml.Marshal(result)
+		if err != nil {
+			return err
+		}
+		os.Stdout.Write(out)
+		return nil
+	}
+	diff, err := result.Diff(exp)
+	if err != nil {
+		return err
+	}
+	if diff != "" {
+		fmt.Fprint(os.Stdout, diff)
+		return fmt.Errorf("%s: FAIL", *testTICKscript)
+	}
+	fmt.Fprintf(os.Stdout, "%s: PASS\n", *testTICKscript)
+	return nil
+}
+
+// Apply
+var (
+	applyFlags  = flag.NewFlagSet("apply", flag.ExitOnError)
+	applyDir    = applyFlags.String("f", "", "The directory of the manifest set.")
+	applyDryRun = applyFlags.Bool("dry-run", false, "Only display the plan without applying it.")
+	applyPrune  = applyFlags.Bool("prune", false, "Delete all tasks, templates and topic handlers that are not part of the manifest set.")
+)
+
+func applyUsage() {
+	var u = `Usage: kapacitor apply -f <dir> [-dry-run] [-prune]
+
+	Create, update and delete tasks, templates and topic handlers so that Kapacitor matches
+	the manifest set in a directory. Either all changes of the plan are applied or none.
+
+	The directory uses the same layout as the [load] service:
+
+		<dir>/templates/<id>.tick           Templates.
+		<dir>/tasks/<id>.tick               Tasks, the TICKscript must declare its dbrps.
+		<dir>/tasks/<id>.{yaml,yml,json}    Templated tasks, see 'kapacitor define -file'.
+		<dir>/handlers/*.{yaml,yml,json}    Topic handlers, see 'kapacitor define-topic-handler'.
+
+	All tasks of the manifest set are enabled.
+
+	Examples:
+
+		$ kapacitor apply -f ./alerting -dry-run -prune
+		$ kapacitor apply -f ./alerting -prune
+
+Options:
+`
+	fmt.Fprintln(os.Stderr, u)
+	applyFlags.PrintDefaults()
+}
+
+func doApply(args []string) error {
+	if *applyDir == "" || len(args) != 0 {
+		fmt.Fprintln(os.Stderr, "Must provide a directory with -f.")
+		applyUsage()
+		os.Exit(2)
+	}
+	opt, err := readManifests(*applyDir)
+	if err != nil {
+		return err
+	}
+	opt.DryRun = *applyDryRun
+	opt.Prune = *applyPrune
+	result, err := cli.Apply(opt)
+	if err != nil {
+		return err
+	}
+	if len(result.Actions) == 0 {
+		fmt.Println("No changes.")
+		return nil
+	}
+	outFmt := "%-8s%-15s%s\n"
+	fmt.Fprintf(os.Stdout, outFmt, "Action", "Kind", "ID")
+	for _, a := range result.Actions {
+		fmt.Fprintf(os.Stdout, outFmt, a.Action, a.Kind, a.ID)
+	}
+	if !result.Applied {
+		fmt.Println("Dry run, no changes were applied.")
+	}
+	return nil
+}
+
+// readManifests reads the tasks, templates and topic handlers of a directory.
+// Missing sub directories are treated as empty.
+func readManifests(dir string) (client.ApplyOptions, error) {
+	opt := client.ApplyOptions{}
+	err := readManifestDir(filepath.Join(dir, "templates"), func(p, id string, data []byte) error {
+		if path.Ext(p) != ".tick" {
+			return nil
+		}
+		opt.Templates = append(opt.Templates, client.CreateTemplateOptions{
+			ID:         id,
+			TICKscript: string(data),
+		})
+		return nil
+	})
+	if err != nil {
+		return opt, err
+	}
+	err = readManifestDir(filepath.Join(dir, "tasks"), func(p, id string, data []byte) error {
+		var o client.CreateTaskOptions
+		switch ext := path.Ext(p); ext {
+		case ".tick":
+			o.TICKscript = string(data)
+		case ".yaml", ".yml", ".json":
+			fileVars := client.TaskVars{}
+			if err := unmarshalManifest(p, data, &fileVars); err != nil {
+				return err
+			}
+			var err error
+			o, err = fileVars.CreateTaskOptions()
+			if err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
+		o.ID = id
+		o.Status = client.Enabled
+		opt.Tasks = append(opt.Tasks, o)
+		return nil
+	})
+	if err != nil {
+		return opt, err
+	}
+	err = readManifestDir(filepath.Join(dir, "handlers"), func(p, id string, data []byte) error {
+		switch path.Ext(p) {
+		case ".yaml", ".yml", ".json":
+		default:
+			return nil
+		}
+		var ho client.TopicHandlerOptions
+		if err := unmarshalManifest(p, data, &ho); err != nil {
+			return err
+		}
+		opt.TopicHandlers = append(opt.TopicHandlers, ho)
+		return nil
+	})
+	return opt, err
+}
+
+// readManifestDir calls f with the path, ID and contents of every file in a directory.
+func readManifestDir(dir string, f func(p, id string, data []byte) error) error {
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		p := filepath.Join(dir, file.Name())
+		data, err := ioutil.ReadFile(p)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read file %q", p)
+		}
+		id := strings.TrimSuffix(file.Name(), filepath.Ext(file.Name()))
+		if err := f(p, id, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func unmarshalManifest(p string, data []byte, v interface{}) error {
+	switch ext := path.Ext(p); ext {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, v); err != nil {
+			return errors.Wrapf(err, "failed to unmarshal yaml file %q", p)
+		}
+	case ".json":
+		if err := json.Unmarshal(data, v); err != nil {
+			return errors.Wrapf(err, "failed to unmarshal json file %q", p)
+		}
+	default:
+		return errors.New("bad file extension. Must be YAML or JSON")
+	}
+	return nil
+}
+
 // Replay
 var (
 	replayFlags = flag.NewFlagSet("replay", flag.ExitOnError)
@@ -1250,14 +1791,19 @@ func doEnable(args []string) error {
 		enableUsage()
 		os.Exit(2)
 	}
+	return setTasksStatus(args, client.Enabled, "enabling")
+}
 
+// setTasksStatus sets the status of all tasks matching the patterns.
+// If from is not empty only the tasks with one of those statuses are updated.
+func setTasksStatus(patterns []string, status client.TaskStatus, action string, from ...client.TaskStatus) error {
 	limit := 100
-	for _, pattern := range args {
+	for _, pattern := range patterns {
 		offset := 0
 		for {
 			tasks, err := cli.ListTasks(&client.ListTasksOptions{
 				Pattern: pattern,
-				Fields:  []string{"link"},
+				Fields:  []string{"link", "status"},
 				Offset:  offset,
 				Limit:   limit,
 			})
@@ -1265,12 +1811,15 @@ func doEnable(args []string) error {
 				return errors.Wrap(err, "listing tasks")
 			}
 			for _, task := range tasks {
+				if len(from) > 0 && !hasTaskStatus(task, from) {
+					continue
+				}
 				_, err := cli.UpdateTask(
 					task.Link,
-					client.UpdateTaskOptions{Status: client.Enabled},
+					client.UpdateTaskOptions{Status: status},
 				)
 				if err != nil {
-					return errors.Wrapf(err, "enabling task %s", task.ID)
+					return errors.Wrapf(err, "%s task %s", action, task.ID)
 				}
 			}
 			if len(tasks) != limit {
@@ -1282,6 +1831,15 @@ func doEnable(args []string) error {
 	return nil
 }
 
+func hasTaskStatus(task client.Task, statuses []client.TaskStatus) bool {
+	for _, s := range statuses {
+		if task.Status == s {
+			return true
+		}
+	}
+	return false
+}
+
 // Disable
 
 func disableUsage() {
@@ -1308,88 +1866,130 @@ func doDisable(args []string) error {
 		disableUsage()
 		os.Exit(2)
 	}
-
-	limit := 100
-	for _, pattern := range args {
-		offset := 0
-		for {
-			tasks, err := cli.ListTasks(&client.ListTasksOptions{
-				Pattern: pattern,
-				Fields:  []string{"link"},
-				Offset:  offset,
-				Limit:   limit,
-			})
-			if err != nil {
-				return errors.Wrap(err, "listing tasks")
-			}
-			for _, task := range tasks {
-				_, err := cli.UpdateTask(
-					task.Link,
-					client.UpdateTaskOptions{Status: client.Disabled},
-				)
-				if err != nil {
-					return errors.Wrapf(err, "disabling task %s", task.ID)
-				}
-			}
-			if len(tasks) != limit {
-				break
-			}
-			offset += limit
-		}
-	}
-	return nil
+	return setTasksStatus(args, client.Disabled, "disabling")
 }
 
-// Reload
+// Pause
 
-func reloadUsage() {
-	var u = `Usage: kapacitor reload [task ID...]
+func pauseUsage() {
+	var u = `Usage: kapacitor pause [task ID...]
 
-	Disable then enable a running task.
+	Pause a stream task. The task keeps running and keeps its state,
+	but the points that arrive for it are held back until it is resumed.
+	Depending on the pause-mode of the [task] configuration the points
+	are spooled to disk or dropped. A disabled task is started paused.
 
 For example:
 
-	You can reload by specific task ID.
+	You can pause by specific task ID.
 
-		$ kapacitor reload cpu_alert
+		$ kapacitor pause cpu_alert
 
-	Or, you can reload by glob:
+	Or, you can pause by glob:
 
-		$ kapacitor reload *_alert
+		$ kapacitor pause *_alert
 `
 	fmt.Fprintln(os.Stderr, u)
 }
 
-func doReload(args []string) error {
+func doPause(args []string) error {
 	if len(args) < 1 {
 		fmt.Fprintln(os.Stderr, "Must pass at least one task ID")
-		reloadUsage()
+		pauseUsage()
 		os.Exit(2)
 	}
-	err := doDisable(args)
-	if err != nil {
-		return err
-	}
-
-	return doEnable(args)
+	return setTasksStatus(args, client.Paused, "pausing")
 }
 
-// Show
-var (
-	showFlags = flag.NewFlagSet("show", flag.ExitOnError)
-	sReplayId = showFlags.String("replay", "", "Optional replay ID. If set the task information is in the context of the running replay.")
-)
+// Resume
 
-func showUsage() {
-	var u = `Usage: kapacitor show [-replay] [task ID]
+func resumeUsage() {
+	var u = `Usage: kapacitor resume [task ID...]
 
-	Show details about a specific task.
+	Resume a paused task. The points held back while the task was paused
+	are processed in order before any new points.
+	Tasks that are not paused are left untouched.
 
-Options:
-`
-	fmt.Fprintln(os.Stderr, u)
-	showFlags.PrintDefaults()
-}
+For example:
+
+	You can resume by specific task ID.
+
+		$ kapacitor resume cpu_alert
+
+	Or, you can resume by glob:
+
+		$ kapacitor resume *_alert
+`
+	fmt.Fprintln(os.Stderr, u)
+}
+
+func doResume(args []string) error {
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "Must pass at least one task ID")
+		resumeUsage()
+		os.Exit(2)
+	}
+	return setTasksStatus(args, client.Enabled, "resuming", client.Paused)
+}
+
+// Reload
+
+func reloadUsage() {
+	var u = `Usage: kapacitor reload [task ID...]
+
+	Disable then enable a running task.
+
+For example:
+
+	You can reload by specific task ID.
+
+		$ kapacitor reload cpu_alert
+
+	Or, you can reload by glob:
+
+		$ kapacitor reload *_alert
+`
+	fmt.Fprintln(os.Stderr, u)
+}
+
+func doReload(args []string) error {
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "Must pass at least one task ID")
+		reloadUsage()
+		os.Exit(2)
+	}
+	err := doDisable(args)
+	if err != nil {
+		return err
+	}
+
+	return doEnable(args)
+}
+
+// Show
+var (
+	showFlags      = flag.NewFlagSet("show", flag.ExitOnError)
+	sReplayId      = showFlags.String("replay", "", "Optional replay ID. If set the task information is in the context of the running replay.")
+	sRevisions     = showFlags.Bool("revisions", false, "List the revisions of the task.")
+	sRevision      = showFlags.Int("revision", 0, "Show a revision of the task instead of its current definition.")
+	sRevisionsDiff = showFlags.Int("diff", 0, "Show the differences from this revision to the revision given by -revision.")
+)
+
+func showUsage() {
+	var u = `Usage: kapacitor show [-replay] [-revisions] [-revision N [-diff M]] [task ID]
+
+	Show details about a specific task.
+
+	Examples:
+
+		$ kapacitor show -revisions cpu_alert
+		$ kapacitor show -revision 3 -diff 2 cpu_alert
+
+Options:
+`
+	fmt.Fprintln(os.Stderr, u)
+	showFlags.PrintDefaults()
+}
 
 func doShow(args []string) error {
 	if len(args) != 1 {
@@ -1398,6 +1998,10 @@ func doShow(args []string) error {
 		os.Exit(2)
 	}
 
+	if *sRevisions || *sRevision != 0 || *sRevisionsDiff != 0 {
+		return showRevisions(cli.TaskLink(args[0]), *sRevisions, *sRevision, *sRevisionsDiff)
+	}
+
 	t, err := cli.Task(
 		cli.TaskLink(args[0]),
 		&client.TaskOptions{ReplayID: *sReplayId},
@@ -1412,6 +2016,11 @@ func doShow(args []string) error {
 	fmt.Println("Type:", t.Type)
 	fmt.Println("Status:", t.Status)
 	fmt.Println("Executing:", t.Executing)
+	if t.Pause != nil {
+		fmt.Println("Pause Mode:", t.Pause.Mode)
+		fmt.Println("Buffered Points:", t.Pause.Buffered)
+		fmt.Println("Dropped Points:", t.Pause.Dropped)
+	}
 	fmt.Println("Created:", t.Created.Format(time.RFC822))
 	fmt.Println("Modified:", t.Modified.Format(time.RFC822))
 	fmt.Println("LastEnabled:", t.LastEnabled.Format(time.RFC822))
@@ -1465,14 +2074,100 @@ func varListToStr(list []client.Var) (string, error) {
 	return "[" + strings.Join(values, ", ") + "]", nil
 }
 
+// Show revisions of a task or template.
+func showRevisions(link client.Link, list bool, number, diff int) error {
+	if list {
+		revisions, err := cli.ListRevisions(link, &client.ListRevisionsOptions{Limit: -1})
+		if err != nil {
+			return err
+		}
+		outFmt := "%-10s%-20s%-24s%-8s%s\n"
+		fmt.Fprintf(os.Stdout, outFmt, "Revision", "User", "Created", "Type", "Template")
+		for _, r := range revisions {
+			fmt.Fprintf(os.Stdout, outFmt, strconv.Itoa(r.Number), r.User, r.Created.Local().Format(time.RFC3339), r.Type, r.TemplateID)
+		}
+		return nil
+	}
+	if number == 0 {
+		return errors.New("must specify -revision")
+	}
+	r, err := cli.Revision(link, number)
+	if err != nil {
+		return err
+	}
+	if diff == 0 {
+		fmt.Println("ID:", r.ID)
+		fmt.Println("Revision:", r.Number)
+		fmt.Println("User:", r.User)
+		fmt.Println("Created:", r.Created.Format(time.RFC822))
+		fmt.Println("Template:", r.TemplateID)
+		fmt.Println("Type:", r.Type)
+		fmt.Println("Databases Retention Policies:", r.DBRPs)
+		fmt.Printf("TICKscript:\n%s\n", r.TICKscript)
+		if len(r.Vars) > 0 {
+			vars, err := json.MarshalIndent(r.Vars, "", "    ")
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Vars:\n%s\n", vars)
+		}
+		return nil
+	}
+	from, err := cli.Revision(link, diff)
+	if err != nil {
+		return err
+	}
+	d, err := revisionDiff(from, r)
+	if err != nil {
+		return err
+	}
+	fmt.Print(d)
+	return nil
+}
+
+// revisionDiff returns a unified diff of the definitions of two revisions.
+func revisionDiff(from, to client.Revision) (string, error) {
+	text := func(r client.Revision) (string, error) {
+		vars, err := json.MarshalIndent(r.Vars, "", "    ")
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("type: %v\ntemplate-id: %s\ndbrps: %v\n\n%s\n\nvars: %s\n", r.Type, r.TemplateID, r.DBRPs, r.TICKscript, vars), nil
+	}
+	a, err := text(from)
+	if err != nil {
+		return "", err
+	}
+	b, err := text(to)
+	if err != nil {
+		return "", err
+	}
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(a),
+		B:        difflib.SplitLines(b),
+		FromFile: fmt.Sprintf("revision %d", from.Number),
+		ToFile:   fmt.Sprintf("revision %d", to.Number),
+		Context:  3,
+	})
+}
+
 // Show Template
+var (
+	showTemplateFlags = flag.NewFlagSet("show-template", flag.ExitOnError)
+	stRevisions       = showTemplateFlags.Bool("revisions", false, "List the revisions of the template.")
+	stRevision        = showTemplateFlags.Int("revision", 0, "Show a revision of the template instead of its current definition.")
+	stRevisionsDiff   = showTemplateFlags.Int("diff", 0, "Show the differences from this revision to the revision given by -revision.")
+)
 
 func showTemplateUsage() {
-	var u = `Usage: kapacitor show-template [template ID]
+	var u = `Usage: kapacitor show-template [-revisions] [-revision N [-diff M]] [template ID]
 
 	Show details about a specific template.
+
+Options:
 `
 	fmt.Fprintln(os.Stderr, u)
+	showTemplateFlags.PrintDefaults()
 }
 
 func doShowTemplate(args []string) error {
@@ -1482,6 +2177,10 @@ func doShowTemplate(args []string) error {
 		os.Exit(2)
 	}
 
+	if *stRevisions || *stRevision != 0 || *stRevisionsDiff != 0 {
+		return showRevisions(cli.TemplateLink(args[0]), *stRevisions, *stRevision, *stRevisionsDiff)
+	}
+
 	t, err := cli.Template(cli.TemplateLink(args[0]), nil)
 	if err != nil {
 		return err
@@ -1520,6 +2219,97 @@ func doShowTemplate(args []string) error {
 	return nil
 }
 
+// Rollback
+var (
+	rollbackFlags    = flag.NewFlagSet("rollback", flag.ExitOnError)
+	rollbackTemplate = rollbackFlags.Bool("template", false, "Rollback a template instead of a task.")
+)
+
+func rollbackUsage() {
+	var u = `Usage: kapacitor rollback [-template] [task or template ID] [revision]
+
+	Restore a task or template to the definition of one of its revisions.
+	The rollback is recorded as a new revision, enabled tasks are reloaded.
+	Use 'kapacitor show -revisions' to list the revisions of a task.
+
+	Examples:
+
+		$ kapacitor rollback cpu_alert 2
+		$ kapacitor rollback -template generic_mean_alert 4
+
+Options:
+`
+	fmt.Fprintln(os.Stderr, u)
+	rollbackFlags.PrintDefaults()
+}
+
+func doRollback(args []string) error {
+	if len(args) != 2 {
+		fmt.Fprintln(os.Stderr, "Must specify an ID and a revision")
+		rollbackUsage()
+		os.Exit(2)
+	}
+	number, err := strconv.Atoi(args[1])
+	if err != nil {
+		return errors.Wrapf(err, "invalid revision %q", args[1])
+	}
+	if *rollbackTemplate {
+		_, err = cli.RollbackTemplate(cli.TemplateLink(args[0]), number)
+	} else {
+		_, err = cli.RollbackTask(cli.TaskLink(args[0]), number)
+	}
+	return err
+}
+
+// Graph
+var (
+	graphFlags = flag.NewFlagSet("graph", flag.ExitOnError)
+	graphJSON  = graphFlags.Bool("json", false, "Print the graph as JSON instead of DOT.")
+)
+
+func graphUsage() {
+	var u = `Usage: kapacitor graph [-json]
+
+	Display the graph of all tasks, the streams they read and write with kapacitorLoopback,
+	the alert topics they send events to and the topic handlers of those topics.
+	The graph is printed in the DOT format, nodes that are part of a cycle are colored red
+	and the cycles are listed on stderr.
+
+	Examples:
+
+		$ kapacitor graph | dot -Tsvg > tasks.svg
+		$ kapacitor graph -json
+
+Options:
+`
+	fmt.Fprintln(os.Stderr, u)
+	graphFlags.PrintDefaults()
+}
+
+func doGraph(args []string) error {
+	if len(args) != 0 {
+		graphUsage()
+		os.Exit(2)
+	}
+	g, err := cli.Graph()
+	if err != nil {
+		return err
+	}
+	if *graphJSON {
+		b, err := json.MarshalIndent(g, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stdout, string(b))
+		return nil
+	}
+	fmt.Fprintln(os.Stdout, g.Dot)
+	for _, c := range g.Cycles {
+		fmt.Fprintln(os.Stderr, "cycle:", strings.Join(c, ", "))
+	}
+	return nil
+}
+
 // Show Handler
 
 func showTopicHandlerUsage() {
@@ -2333,6 +3123,927 @@ func doBackup(args []string) error {
 	return nil
 }
 
+// Node State
+var (
+	nodeStateValidateFlags = flag.NewFlagSet("node-state-validate", flag.ExitOnError)
+	nsRepair               = nodeStateValidateFlags.Bool("repair", false, "Migrate outdated state to the current encoding and delete invalid state.")
+)
+
+func nodeStateUsage() {
+	var u = `Usage: kapacitor node-state validate [-repair]
+
+	Check that all state persisted by changeDetect and alert nodes can be decoded.
+
+	State written by older versions of Kapacitor is reported as outdated,
+	state that cannot be decoded is reported as invalid.
+	With -repair outdated state is rewritten using the current encoding
+	and invalid state is deleted. Keys not written by Kapacitor are never modified.
+
+Options:
+`
+	fmt.Fprintln(os.Stderr, u)
+	nodeStateValidateFlags.PrintDefaults()
+}
+
+func doNodeState(args []string) error {
+	if len(args) == 0 || args[0] != "validate" {
+		fmt.Fprintln(os.Stderr, "Must specify the validate action")
+		nodeStateUsage()
+		os.Exit(2)
+	}
+	nodeStateValidateFlags.Parse(args[1:])
+	r, err := cli.ValidateNodeState(client.NodeStateValidateOptions{
+		Repair: *nsRepair,
+	})
+	if err != nil {
+		return err
+	}
+	for _, key := range r.Outdated {
+		fmt.Fprintf(os.Stdout, "outdated: %s\n", key)
+	}
+	for _, p := range r.Invalid {
+		fmt.Fprintf(os.Stdout, "invalid:  %s: %s\n", p.Key, p.Error)
+	}
+	fmt.Fprintf(os.Stdout, "Checked %d keys, %d outdated, %d invalid.\n", r.Checked, len(r.Outdated), len(r.Invalid))
+	if *nsRepair {
+		fmt.Fprintf(os.Stdout, "Migrated %d keys, deleted %d keys.\n", r.Migrated, r.Deleted)
+	}
+	return nil
+}
+
+// Blob
+var (
+	blobCreateFlags = flag.NewFlagSet("blob-create", flag.ExitOnError)
+	bcTag           = blobCreateFlags.String("tag", "", "Tag the created blob with the given name.")
+
+	blobGetFlags = flag.NewFlagSet("blob-get", flag.ExitOnError)
+	bgTag        = blobGetFlags.String("tag", "", "Get the blob currently associated with the tag instead of a blob ID.")
+	bgOut        = blobGetFlags.String("o", "", "Write the content of the blob to the file instead of STDOUT.")
+)
+
+func blobUsage() {
+	var u = `Usage: kapacitor blob <action> [options] [args]
+
+	Manage the content of the blob store.
+	Blobs are immutable and identified by the SHA256 sum of their content.
+	Tags are names associated with a blob, the history of each tag is preserved.
+
+	Actions:
+
+		create [-tag <name>] [<file>]       Create a blob from the file, or STDIN if no file is given. Prints the blob ID.
+		tag <name> <blob ID>                Associate the tag with the blob.
+		get [-o <file>] <blob ID>           Write the content of the blob to STDOUT or the file.
+		get [-o <file>] -tag <name>         Write the content of the blob currently associated with the tag.
+		delete <blob ID>...                 Delete blobs and remove them from the history of all tags.
+		delete-tag <name>...                Delete tags, the blobs they are associated with are kept.
+		list [<pattern>...]                 List blobs, optionally only those whose ID matches a pattern.
+		list-tags [<pattern>...]            List tags, optionally only those whose name matches a pattern.
+		show-tag <name>                     Display the history of a tag.
+
+	Examples:
+
+		$ kapacitor blob create -tag model ./model.bin
+		$ kapacitor blob get -tag model -o ./model.bin
+		$ kapacitor blob tag model 2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824
+
+Options:
+`
+	fmt.Fprintln(os.Stderr, u)
+	fmt.Fprintln(os.Stderr, "create:")
+	blobCreateFlags.PrintDefaults()
+	fmt.Fprintln(os.Stderr, "get:")
+	blobGetFlags.PrintDefaults()
+}
+
+func doBlob(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Must specify an action")
+		blobUsage()
+		os.Exit(2)
+	}
+	action := args[0]
+	args = args[1:]
+	switch action {
+	case "create":
+		return doBlobCreate(args)
+	case "tag":
+		if len(args) != 2 {
+			return errors.New("must provide a tag name and a blob ID.")
+		}
+		_, err := cli.TagBlob(cli.BlobTagLink(args[0]), client.TagBlobOptions{
+			Blob: args[1],
+		})
+		return err
+	case "get":
+		return doBlobGet(args)
+	case "delete":
+		if len(args) == 0 {
+			return errors.New("must provide at least one blob ID.")
+		}
+		for _, id := range args {
+			if err := cli.DeleteBlob(cli.BlobLink(id)); err != nil {
+				return err
+			}
+		}
+	case "delete-tag":
+		if len(args) == 0 {
+			return errors.New("must provide at least one tag name.")
+		}
+		for _, name := range args {
+			if err := cli.DeleteBlobTag(cli.BlobTagLink(name)); err != nil {
+				return err
+			}
+		}
+	case "list":
+		return doBlobList(args)
+	case "list-tags":
+		return doBlobListTags(args)
+	case "show-tag":
+		if len(args) != 1 {
+			return errors.New("must provide exactly one tag name.")
+		}
+		t, err := cli.BlobTag(cli.BlobTagLink(args[0]))
+		if err != nil {
+			return err
+		}
+		fmt.Println("Name:", t.Name)
+		fmt.Println("Blob:", t.Blob)
+		fmt.Println("History:")
+		outFmt := "%-25s%s\n"
+		fmt.Fprintf(os.Stdout, outFmt, "Time", "Blob")
+		for _, e := range t.History {
+			fmt.Fprintf(os.Stdout, outFmt, e.Time.Local().Format(time.RFC822), e.Blob)
+		}
+	default:
+		fmt.Fprintln(os.Stderr, "Unknown blob action", action)
+		blobUsage()
+		os.Exit(2)
+	}
+	return nil
+}
+
+func doBlobCreate(args []string) error {
+	blobCreateFlags.Parse(args)
+	args = blobCreateFlags.Args()
+	if len(args) > 1 {
+		return errors.New("must provide at most one file.")
+	}
+	var r io.Reader = os.Stdin
+	if len(args) == 1 && args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return errors.Wrapf(err, "failed to open file %s", args[0])
+		}
+		defer f.Close()
+		r = f
+	}
+	b, err := cli.CreateBlob(r, client.CreateBlobOptions{
+		Tag: *bcTag,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Println(b.ID)
+	return nil
+}
+
+func doBlobGet(args []string) error {
+	blobGetFlags.Parse(args)
+	args = blobGetFlags.Args()
+	var link client.Link
+	switch {
+	case *bgTag != "" && len(args) == 0:
+		link = cli.BlobTagLink(*bgTag)
+	case *bgTag == "" && len(args) == 1:
+		link = cli.BlobLink(args[0])
+	default:
+		return errors.New("must provide either a blob ID or a tag.")
+	}
+	r, err := cli.ReadBlob(link)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	var w io.Writer = os.Stdout
+	if *bgOut != "" {
+		f, err := os.Create(*bgOut)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create file %s", *bgOut)
+		}
+		defer f.Close()
+		w = f
+	}
+	_, err = io.Copy(w, r)
+	return err
+}
+
+func doBlobList(patterns []string) error {
+	if len(patterns) == 0 {
+		patterns = []string{""}
+	}
+	limit := 100
+	var allBlobs []client.Blob
+	for _, pattern := range patterns {
+		offset := 0
+		for {
+			blobs, err := cli.ListBlobs(&client.ListBlobsOptions{
+				Pattern: pattern,
+				Offset:  offset,
+				Limit:   limit,
+			})
+			if err != nil {
+				return err
+			}
+			allBlobs = append(allBlobs, blobs...)
+			if len(blobs) != limit {
+				break
+			}
+			offset += limit
+		}
+	}
+	outFmt := "%-65s%-10s%-23s\n"
+	fmt.Fprintf(os.Stdout, outFmt, "ID", "Size", "Created")
+	for _, b := range allBlobs {
+		fmt.Fprintf(os.Stdout, outFmt, b.ID, humanize.Bytes(uint64(b.Size)), b.Created.Local().Format(time.RFC822))
+	}
+	return nil
+}
+
+func doBlobListTags(patterns []string) error {
+	if len(patterns) == 0 {
+		patterns = []string{""}
+	}
+	limit := 100
+	maxName := 4 // len("Name")
+	var allTags []client.BlobTag
+	for _, pattern := range patterns {
+		offset := 0
+		for {
+			tags, err := cli.ListBlobTags(&client.ListBlobTagsOptions{
+				Pattern: pattern,
+				Offset:  offset,
+				Limit:   limit,
+			})
+			if err != nil {
+				return err
+			}
+			allTags = append(allTags, tags...)
+			for _, t := range tags {
+				if l := len(t.Name); l > maxName {
+					maxName = l
+				}
+			}
+			if len(tags) != limit {
+				break
+			}
+			offset += limit
+		}
+	}
+	outFmt := fmt.Sprintf("%%-%ds%%-65s%%s\n", maxName+1)
+	fmt.Fprintf(os.Stdout, outFmt, "Name", "Blob", "Versions")
+	for _, t := range allTags {
+		fmt.Fprintf(os.Stdout, outFmt, t.Name, t.Blob, strconv.Itoa(len(t.History)))
+	}
+	return nil
+}
+
+// User
+
+// privilegesFlag collects resource privileges of the form <resource>=<privilege>[,<privilege>...].
+type privilegesFlag map[string][]string
+
+func (f privilegesFlag) String() string {
+	return fmt.Sprint(map[string][]string(f))
+}
+
+func (f privilegesFlag) Set(s string) error {
+	parts := strings.SplitN(s, "=", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return fmt.Errorf("invalid privilege %q, must be of the form <resource>=<privilege>[,<privilege>...]", s)
+	}
+	f[parts[0]] = append(f[parts[0]], strings.Split(parts[1], ",")...)
+	return nil
+}
+
+var (
+	userCreateFlags = flag.NewFlagSet("user-create", flag.ExitOnError)
+	ucAdmin         = userCreateFlags.Bool("admin", false, "Create an admin user, admins have all privileges.")
+	ucPassword      = userCreateFlags.String("password", "", "Password of the user, read from STDIN if not set.")
+	ucPrivileges    = make(privilegesFlag)
+
+	userUpdateFlags = flag.NewFlagSet("user-update", flag.ExitOnError)
+	uuAdmin         = userUpdateFlags.String("admin", "", "Set whether the user is an admin, either true or false.")
+	uuPassword      = userUpdateFlags.String("password", "", "New password of the user.")
+	uuPrivileges    = make(privilegesFlag)
+)
+
+func init() {
+	userCreateFlags.Var(ucPrivileges, "privilege", "Privileges of the user on a resource, of the form <resource>=<privilege>[,<privilege>...]. May be repeated.")
+	userUpdateFlags.Var(uuPrivileges, "privilege", "Privileges of the user on a resource, replacing all existing privileges. May be repeated.")
+}
+
+func userUsage() {
+	var u = `Usage: kapacitor user <action> [options] [args]
+
+	Manage the users of the local authentication service.
+	Only admins may manage users, other users may only change their own password.
+	The credentials of requests are read from the KAPACITOR_USERNAME and KAPACITOR_PASSWORD environment variables,
+	or an API or JWT bearer token from the KAPACITOR_TOKEN environment variable.
+
+	Privileges are one of "read", "write", "delete" or "all".
+	API resources are of the form /api/<path>, i.e. /api/tasks grants access to /kapacitor/v1/tasks.
+	Database resources grant access to write to the database, i.e. /database/telegraf_clean for the telegraf database.
+
+	Actions:
+
+		create [-admin] [-privilege <resource>=<privileges>]... [-password <password>] <name>
+		                                   Create a user.
+		update [-admin <bool>] [-privilege <resource>=<privileges>]... [-password <password>] <name>
+		                                   Update a user.
+		passwd <name>                      Change the password of a user, the password is read from STDIN.
+		delete <name>...                   Delete users.
+		list [<pattern>...]                List users, optionally only those whose name matches a pattern.
+		show <name>                        Display the privileges of a user.
+
+	Examples:
+
+		$ kapacitor user create -privilege /api/tasks=read,write -privilege /database/telegraf_clean=write operator
+		$ kapacitor user update -admin true operator
+
+Options:
+`
+	fmt.Fprintln(os.Stderr, u)
+	fmt.Fprintln(os.Stderr, "create:")
+	userCreateFlags.PrintDefaults()
+	fmt.Fprintln(os.Stderr, "update:")
+	userUpdateFlags.PrintDefaults()
+}
+
+func doUser(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Must specify an action")
+		userUsage()
+		os.Exit(2)
+	}
+	action := args[0]
+	args = args[1:]
+	switch action {
+	case "create":
+		return doUserCreate(args)
+	case "update":
+		return doUserUpdate(args)
+	case "passwd":
+		if len(args) != 1 {
+			return errors.New("must provide exactly one user name.")
+		}
+		password, err := readPassword()
+		if err != nil {
+			return err
+		}
+		_, err = cli.UpdateUser(cli.UserLink(args[0]), client.UpdateUserOptions{
+			Password: password,
+		})
+		return err
+	case "delete":
+		if len(args) == 0 {
+			return errors.New("must provide at least one user name.")
+		}
+		for _, name := range args {
+			if err := cli.DeleteUser(cli.UserLink(name)); err != nil {
+				return err
+			}
+		}
+	case "list":
+		return doUserList(args)
+	case "show":
+		if len(args) != 1 {
+			return errors.New("must provide exactly one user name.")
+		}
+		u, err := cli.User(cli.UserLink(args[0]))
+		if err != nil {
+			return err
+		}
+		fmt.Println("Name:", u.Name)
+		fmt.Println("Admin:", u.Admin)
+		fmt.Println("Privileges:")
+		resources := make([]string, 0, len(u.Privileges))
+		for r := range u.Privileges {
+			resources = append(resources, r)
+		}
+		sort.Strings(resources)
+		outFmt := "%-40s%s\n"
+		fmt.Fprintf(os.Stdout, outFmt, "Resource", "Privileges")
+		for _, r := range resources {
+			fmt.Fprintf(os.Stdout, outFmt, r, strings.Join(u.Privileges[r], ","))
+		}
+	default:
+		fmt.Fprintln(os.Stderr, "Unknown user action", action)
+		userUsage()
+		os.Exit(2)
+	}
+	return nil
+}
+
+// readPassword reads a password from the first line of STDIN.
+func readPassword() (string, error) {
+	fmt.Fprint(os.Stderr, "Password: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", errors.Wrap(err, "failed to read password")
+	}
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", errors.New("password must not be empty.")
+	}
+	return password, nil
+}
+
+func doUserCreate(args []string) error {
+	userCreateFlags.Parse(args)
+	args = userCreateFlags.Args()
+	if len(args) != 1 {
+		return errors.New("must provide exactly one user name.")
+	}
+	password := *ucPassword
+	if password == "" {
+		var err error
+		password, err = readPassword()
+		if err != nil {
+			return err
+		}
+	}
+	_, err := cli.CreateUser(client.CreateUserOptions{
+		Name:       args[0],
+		Password:   password,
+		Admin:      *ucAdmin,
+		Privileges: ucPrivileges,
+	})
+	return err
+}
+
+func doUserUpdate(args []string) error {
+	userUpdateFlags.Parse(args)
+	args = userUpdateFlags.Args()
+	if len(args) != 1 {
+		return errors.New("must provide exactly one user name.")
+	}
+	opt := client.UpdateUserOptions{
+		Password: *uuPassword,
+	}
+	if *uuAdmin != "" {
+		admin, err := strconv.ParseBool(*uuAdmin)
+		if err != nil {
+			return errors.Wrapf(err, "invalid admin value %q", *uuAdmin)
+		}
+		opt.Admin = &admin
+	}
+	if len(uuPrivileges) > 0 {
+		opt.Privileges = uuPrivileges
+	}
+	_, err := cli.UpdateUser(cli.UserLink(args[0]), opt)
+	return err
+}
+
+func doUserList(patterns []string) error {
+	if len(patterns) == 0 {
+		patterns = []string{""}
+	}
+	limit := 100
+	maxName := 4 // len("Name")
+	var allUsers []client.User
+	for _, pattern := range patterns {
+		offset := 0
+		for {
+			users, err := cli.ListUsers(&client.ListUsersOptions{
+				Pattern: pattern,
+				Offset:  offset,
+				Limit:   limit,
+			})
+			if err != nil {
+				return err
+			}
+			allUsers = append(allUsers, users...)
+			for _, u := range users {
+				if l := len(u.Name); l > maxName {
+					maxName = l
+				}
+			}
+			if len(users) != limit {
+				break
+			}
+			offset += limit
+		}
+	}
+	outFmt := fmt.Sprintf("%%-%ds%%-7s%%s\n", maxName+1)
+	fmt.Fprintf(os.Stdout, outFmt, "Name", "Admin", "Resources")
+	for _, u := range allUsers {
+		fmt.Fprintf(os.Stdout, outFmt, u.Name, strconv.FormatBool(u.Admin), strconv.Itoa(len(u.Privileges)))
+	}
+	return nil
+}
+
+// Token
+
+var (
+	tokenCreateFlags = flag.NewFlagSet("token-create", flag.ExitOnError)
+	tcDescription    = tokenCreateFlags.String("description", "", "Description of the token.")
+	tcExpires        = tokenCreateFlags.Duration("expires", 0, "Duration after which the token expires, the token never expires if 0.")
+	tcScopes         = make(privilegesFlag)
+)
+
+func init() {
+	tokenCreateFlags.Var(tcScopes, "scope", "Privileges of the token on a resource, of the form <resource>=<privilege>[,<privilege>...]. May be repeated.")
+}
+
+func tokenUsage() {
+	var u = `Usage: kapacitor token <action> [options] [args]
+
+	Manage the API tokens of the local authentication service.
+	API tokens are sent as bearer tokens, i.e. by setting the KAPACITOR_TOKEN environment variable.
+	A token only has the privileges of its scopes, scopes map resources to privileges like the privileges of users.
+	Only admins may manage tokens.
+
+	Actions:
+
+		create -scope <resource>=<privileges>... [-description <description>] [-expires <duration>]
+		                                   Create a token, the token is only displayed once.
+		list                               List tokens.
+		show <id>                          Display the scopes of a token.
+		revoke <id>...                     Revoke tokens.
+
+	Examples:
+
+		$ kapacitor token create -description deploy -scope /api/tasks=read,write -scope /api/templates=read -expires 720h
+
+Options:
+`
+	fmt.Fprintln(os.Stderr, u)
+	tokenCreateFlags.PrintDefaults()
+}
+
+func doToken(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Must specify an action")
+		tokenUsage()
+		os.Exit(2)
+	}
+	action := args[0]
+	args = args[1:]
+	switch action {
+	case "create":
+		return doTokenCreate(args)
+	case "list":
+		return doTokenList()
+	case "show":
+		if len(args) != 1 {
+			return errors.New("must provide exactly one token ID.")
+		}
+		t, err := cli.APIToken(cli.APITokenLink(args[0]))
+		if err != nil {
+			return err
+		}
+		fmt.Println("ID:", t.ID)
+		fmt.Println("Description:", t.Description)
+		fmt.Println("Created By:", t.CreatedBy)
+		fmt.Println("Created:", t.Created.Local().Format(time.RFC822))
+		fmt.Println("Expires:", formatExpires(t.Expires))
+		fmt.Println("Scopes:")
+		resources := make([]string, 0, len(t.Scopes))
+		for r := range t.Scopes {
+			resources = append(resources, r)
+		}
+		sort.Strings(resources)
+		outFmt := "%-40s%s\n"
+		fmt.Fprintf(os.Stdout, outFmt, "Resource", "Privileges")
+		for _, r := range resources {
+			fmt.Fprintf(os.Stdout, outFmt, r, strings.Join(t.Scopes[r], ","))
+		}
+	case "revoke":
+		if len(args) == 0 {
+			return errors.New("must provide at least one token ID.")
+		}
+		for _, id := range args {
+			if err := cli.RevokeAPIToken(cli.APITokenLink(id)); err != nil {
+				return err
+			}
+		}
+	default:
+		fmt.Fprintln(os.Stderr, "Unknown token action", action)
+		tokenUsage()
+		os.Exit(2)
+	}
+	return nil
+}
+
+func formatExpires(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return t.Local().Format(time.RFC822)
+}
+
+func doTokenCreate(args []string) error {
+	tokenCreateFlags.Parse(args)
+	if tokenCreateFlags.NArg() != 0 {
+		return errors.New("unexpected arguments, use -scope to specify the scopes of the token.")
+	}
+	if len(tcScopes) == 0 {
+		return errors.New("must provide at least one scope.")
+	}
+	opt := client.CreateAPITokenOptions{
+		Description: *tcDescription,
+		Scopes:      tcScopes,
+	}
+	if *tcExpires != 0 {
+		opt.Expires = time.Now().Add(*tcExpires)
+	}
+	t, err := cli.CreateAPIToken(opt)
+	if err != nil {
+		return err
+	}
+	fmt.Println("ID:", t.ID)
+	fmt.Println("Expires:", formatExpires(t.Expires))
+	fmt.Println("Token:", t.Token)
+	return nil
+}
+
+func doTokenList() error {
+	limit := 100
+	outFmt := "%-18s%-16s%-20s%-20s%s\n"
+	fmt.Fprintf(os.Stdout, outFmt, "ID", "Created By", "Created", "Expires", "Description")
+	for offset := 0; ; offset += limit {
+		tokens, err := cli.ListAPITokens(&client.ListAPITokensOptions{
+			Offset: offset,
+			Limit:  limit,
+		})
+		if err != nil {
+			return err
+		}
+		for _, t := range tokens {
+			fmt.Fprintf(os.Stdout, outFmt, t.ID, t.CreatedBy, t.Created.Local().Format(time.RFC822), formatExpires(t.Expires), t.Description)
+		}
+		if len(tokens) != limit {
+			return nil
+		}
+	}
+}
+
+// Silence
+
+// tagsFlag collects tag patterns of the form <tag>=<pattern>.
+type tagsFlag map[string]string
+
+func (f tagsFlag) String() string {
+	return fmt.Sprint(map[string]string(f))
+}
+
+func (f tagsFlag) Set(s string) error {
+	parts := strings.SplitN(s, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return fmt.Errorf("invalid tag %q, must be of the form <tag>=<pattern>", s)
+	}
+	f[parts[0]] = parts[1]
+	return nil
+}
+
+var (
+	silenceCreateFlags = flag.NewFlagSet("silence-create", flag.ExitOnError)
+	scID               = silenceCreateFlags.String("id", "", "ID of the silence, a random ID is used if not set.")
+	scTopic            = silenceCreateFlags.String("topic", "", "Pattern of the topics to silence, all topics if not set.")
+	scEvent            = silenceCreateFlags.String("event", "", "Pattern of the event IDs to silence, all events if not set.")
+	scStart            = silenceCreateFlags.String("start", "", "RFC3339 start time of the silence, now if not set.")
+	scEnd              = silenceCreateFlags.String("end", "", "RFC3339 end time of the silence.")
+	scDuration         = silenceCreateFlags.Duration("duration", 0, "Duration of the silence from its start, overrides -end.")
+	scCreator          = silenceCreateFlags.String("creator", "", "Creator of the silence, the authenticated user if not set.")
+	scComment          = silenceCreateFlags.String("comment", "", "Comment describing the reason of the silence.")
+	scTags             = make(tagsFlag)
+)
+
+func init() {
+	silenceCreateFlags.Var(scTags, "tag", "Pattern the tag of the events must match, of the form <tag>=<pattern>. May be repeated.")
+}
+
+func silenceUsage() {
+	var u = `Usage: kapacitor silence <action> [options] [args]
+
+	Manage silences of alert topic handlers, i.e. for maintenance windows.
+	While a silence is active the handlers of matching events are not run,
+	the state of the events is still recorded. Expired silences are deleted automatically.
+	Topics, event IDs and tags are matched by glob patterns, empty patterns match everything.
+
+	Actions:
+
+		create (-end <time> | -duration <duration>) [-start <time>] [-topic <pattern>] [-event <pattern>] [-tag <tag>=<pattern>...] [-comment <comment>]
+		                                   Create a silence.
+		list                               List silences.
+		show <id>                          Display a silence.
+		delete <id>...                     Delete silences.
+
+	Examples:
+
+		$ kapacitor silence create -topic cpu -tag host=serverA -duration 2h -comment "kernel upgrade"
+
+Options:
+`
+	fmt.Fprintln(os.Stderr, u)
+	silenceCreateFlags.PrintDefaults()
+}
+
+func doSilence(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Must specify an action")
+		silenceUsage()
+		os.Exit(2)
+	}
+	action := args[0]
+	args = args[1:]
+	switch action {
+	case "create":
+		return doSilenceCreate(args)
+	case "list":
+		return doSilenceList()
+	case "show":
+		if len(args) != 1 {
+			return errors.New("must provide exactly one silence ID.")
+		}
+		s, err := cli.Silence(cli.SilenceLink(args[0]))
+		if err != nil {
+			return err
+		}
+		fmt.Println("ID:", s.ID)
+		fmt.Println("Topic:", s.Topic)
+		fmt.Println("Event ID:", s.EventID)
+		fmt.Println("Tags:", formatSilenceTags(s.Tags))
+		fmt.Println("Start:", s.Start.Local().Format(time.RFC822))
+		fmt.Println("End:", s.End.Local().Format(time.RFC822))
+		fmt.Println("Active:", s.Active)
+		fmt.Println("Creator:", s.Creator)
+		fmt.Println("Comment:", s.Comment)
+	case "delete":
+		if len(args) == 0 {
+			return errors.New("must provide at least one silence ID.")
+		}
+		for _, id := range args {
+			if err := cli.DeleteSilence(cli.SilenceLink(id)); err != nil {
+				return err
+			}
+		}
+	default:
+		fmt.Fprintln(os.Stderr, "Unknown silence action", action)
+		silenceUsage()
+		os.Exit(2)
+	}
+	return nil
+}
+
+func doSilenceCreate(args []string) error {
+	silenceCreateFlags.Parse(args)
+	if silenceCreateFlags.NArg() != 0 {
+		return errors.New("unexpected arguments, use -topic, -event and -tag to specify the silenced events.")
+	}
+	opt := client.SilenceOptions{
+		ID:      *scID,
+		Topic:   *scTopic,
+		EventID: *scEvent,
+		Tags:    scTags,
+		Creator: *scCreator,
+		Comment: *scComment,
+	}
+	start := time.Now()
+	if *scStart != "" {
+		t, err := time.Parse(time.RFC3339, *scStart)
+		if err != nil {
+			return errors.Wrap(err, "invalid start time")
+		}
+		start = t
+	}
+	opt.Start = start
+	switch {
+	case *scDuration > 0:
+		opt.End = start.Add(*scDuration)
+	case *scEnd != "":
+		t, err := time.Parse(time.RFC3339, *scEnd)
+		if err != nil {
+			return errors.Wrap(err, "invalid end time")
+		}
+		opt.End = t
+	default:
+		return errors.New("must provide either -end or -duration.")
+	}
+	s, err := cli.CreateSilence(opt)
+	if err != nil {
+		return err
+	}
+	fmt.Println("ID:", s.ID)
+	fmt.Println("Active:", s.Active)
+	return nil
+}
+
+func doSilenceList() error {
+	silences, err := cli.ListSilences()
+	if err != nil {
+		return err
+	}
+	outFmt := "%-38s%-7s%-20s%-20s%-16s%-16s%-30s%s\n"
+	fmt.Fprintf(os.Stdout, outFmt, "ID", "Active", "Start", "End", "Topic", "Event ID", "Tags", "Comment")
+	for _, s := range silences.Silences {
+		fmt.Fprintf(os.Stdout, outFmt,
+			s.ID,
+			strconv.FormatBool(s.Active),
+			s.Start.Local().Format(time.RFC822),
+			s.End.Local().Format(time.RFC822),
+			s.Topic,
+			s.EventID,
+			formatSilenceTags(s.Tags),
+			s.Comment,
+		)
+	}
+	return nil
+}
+
+func formatSilenceTags(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + tags[k]
+	}
+	return strings.Join(pairs, ",")
+}
+
+// Audit
+
+var (
+	auditFlags    = flag.NewFlagSet("audit", flag.ExitOnError)
+	aStart        = auditFlags.String("start", "", "Only list entries recorded at or after the RFC3339 time.")
+	aStop         = auditFlags.String("stop", "", "Only list entries recorded at or before the RFC3339 time.")
+	aSince        = auditFlags.Duration("since", 0, "Only list entries recorded within the duration, overrides -start.")
+	aResourceType = auditFlags.String("type", "", "Only list entries of the resource type, i.e. tasks, templates or config.")
+	aResource     = auditFlags.String("resource", "", "Only list entries whose resource ID matches the pattern.")
+	aUser         = auditFlags.String("user", "", "Only list entries of the user.")
+	aLimit        = auditFlags.Int("limit", 100, "Maximum number of entries to list.")
+	aDiff         = auditFlags.Bool("diff", false, "Display the diffs of changed tasks and templates.")
+)
+
+func auditUsage() {
+	var u = `Usage: kapacitor audit [options]
+
+	List the audit log of POST, PATCH, PUT and DELETE requests made via the API, newest first.
+
+	Examples:
+
+		$ kapacitor audit -type tasks -resource cpu* -since 24h -diff
+
+Options:
+`
+	fmt.Fprintln(os.Stderr, u)
+	auditFlags.PrintDefaults()
+}
+
+func doAudit(args []string) error {
+	if len(args) != 0 {
+		return errors.New("unexpected arguments, use flags to filter the audit log.")
+	}
+	opt := &client.ListAuditEntriesOptions{
+		ResourceType: *aResourceType,
+		ResourceID:   *aResource,
+		User:         *aUser,
+		Limit:        *aLimit,
+	}
+	var err error
+	if *aStart != "" {
+		opt.Start, err = time.Parse(time.RFC3339Nano, *aStart)
+		if err != nil {
+			return errors.Wrap(err, "invalid start time")
+		}
+	}
+	if *aSince != 0 {
+		opt.Start = time.Now().Add(-*aSince)
+	}
+	if *aStop != "" {
+		opt.Stop, err = time.Parse(time.RFC3339Nano, *aStop)
+		if err != nil {
+			return errors.Wrap(err, "invalid stop time")
+		}
+	}
+	entries, err := cli.ListAuditEntries(opt)
+	if err != nil {
+		return err
+	}
+	outFmt := "%-24s%-20s%-8s%-8s%s\n"
+	fmt.Fprintf(os.Stdout, outFmt, "Time", "User", "Method", "Status", "Path")
+	for _, e := range entries {
+		fmt.Fprintf(os.Stdout, outFmt, e.Time.Local().Format(time.RFC3339), e.User, e.Method, strconv.Itoa(e.Status), e.Path)
+		if *aDiff && e.Diff != "" {
+			fmt.Fprintln(os.Stdout, e.Diff)
+		}
+	}
+	return nil
+}
+
 func watchUsage() {
 	var u = `Usage: kapacitor watch <task id> [<tags> ...]
 
@@ -14,6 +14,8 @@ import (
 type DerivativeNode struct {
 	node
 	d *pipeline.DerivativeNode
+
+	groups groupCheckpoint
 }
 
 // Create a new derivative node.
@@ -27,7 +29,10 @@ func newDerivativeNode(et *ExecutingTask, n *pipeline.DerivativeNode, d NodeDiag
 	return dn, nil
 }
 
-func (n *DerivativeNode) runDerivative([]byte) error {
+func (n *DerivativeNode) runDerivative(snapshot []byte) error {
+	if err := n.groups.restore(snapshot); err != nil {
+		n.diag.Error("failed to restore node snapshot", err)
+	}
 	consumer := edge.NewGroupedConsumer(
 		n.ins[0],
 		n,
@@ -37,12 +42,20 @@ func (n *DerivativeNode) runDerivative([]byte) error {
 }
 
 func (n *DerivativeNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	g, err := n.groups.add(group.ID, n.newGroup())
+	if err != nil {
+		n.diag.Error("failed to restore group state", err, keyvalue.KV("group", string(group.ID)))
+	}
 	return edge.NewReceiverFromForwardReceiverWithStats(
 		n.outs,
-		edge.NewTimedForwardReceiver(n.timer, n.newGroup()),
+		edge.NewTimedForwardReceiver(n.timer, g),
 	), nil
 }
 
+func (n *DerivativeNode) snapshot() ([]byte, error) {
+	return n.groups.snapshot()
+}
+
 func (n *DerivativeNode) newGroup() *derivativeGroup {
 	return &derivativeGroup{
 		n: n,
@@ -121,6 +134,30 @@ func (g *derivativeGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message,
 }
 func (g *derivativeGroup) Done() {}
 
+type derivativeState struct {
+	Previous *batchPointState
+}
+
+func (g *derivativeGroup) snapshotState() ([]byte, error) {
+	var state derivativeState
+	if g.previous != nil {
+		p := newBatchPointState(g.previous)
+		state.Previous = &p
+	}
+	return encodeState(state)
+}
+
+func (g *derivativeGroup) restoreState(data []byte) error {
+	var state derivativeState
+	if err := decodeState(data, &state); err != nil {
+		return err
+	}
+	if state.Previous != nil {
+		g.previous = state.Previous.batchPoint()
+	}
+	return nil
+}
+
 // derivative calculates the derivative between prev and cur.
 // Return is the resulting derivative, whether the current point should be
 // stored as previous, and whether the point result should be emitted.
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/thingnario/kapacitor/edge"
+	"github.com/thingnario/kapacitor/keyvalue"
 	"github.com/thingnario/kapacitor/models"
 	"github.com/thingnario/kapacitor/pipeline"
 	"github.com/pkg/errors"
@@ -23,6 +24,8 @@ type InfluxQLNode struct {
 	isStreamTransformation bool
 
 	currentKind reflect.Kind
+
+	groups groupCheckpoint
 }
 
 func newInfluxQLNode(et *ExecutingTask, n *pipeline.InfluxQLNode, d NodeDiagnostic) (*InfluxQLNode, error) {
@@ -51,7 +54,10 @@ type baseReduceContext struct {
 	topBottomInfo *pipeline.TopBottomCallInfo
 }
 
-func (n *InfluxQLNode) runInfluxQL([]byte) error {
+func (n *InfluxQLNode) runInfluxQL(snapshot []byte) error {
+	if err := n.groups.restore(snapshot); err != nil {
+		n.diag.Error("failed to restore node snapshot", err)
+	}
 	consumer := edge.NewGroupedConsumer(
 		n.ins[0],
 		n,
@@ -61,12 +67,25 @@ func (n *InfluxQLNode) runInfluxQL([]byte) error {
 }
 
 func (n *InfluxQLNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	g := n.newGroup(first)
+	// Only streaming transformations keep state across points.
+	if t, ok := g.(*influxqlStreamingTransformGroup); ok {
+		var err error
+		g, err = n.groups.add(group.ID, t)
+		if err != nil {
+			n.diag.Error("failed to restore group state", err, keyvalue.KV("group", string(group.ID)))
+		}
+	}
 	return edge.NewReceiverFromForwardReceiverWithStats(
 		n.outs,
-		edge.NewTimedForwardReceiver(n.timer, n.newGroup(first)),
+		edge.NewTimedForwardReceiver(n.timer, g),
 	), nil
 }
 
+func (n *InfluxQLNode) snapshot() ([]byte, error) {
+	return n.groups.snapshot()
+}
+
 func (n *InfluxQLNode) newGroup(first edge.PointMeta) edge.ForwardReceiver {
 	bc := baseReduceContext{
 		as:         n.n.As,
@@ -215,6 +234,10 @@ func (g *influxqlGroup) Done() {}
 
 type influxqlStreamingTransformGroup struct {
 	influxqlGroup
+
+	// history holds the most recent points of a stream the state of the transformation depends on.
+	// The reduce context cannot be encoded, so it is restored by aggregating these points again.
+	history []pointState
 }
 
 func (g *influxqlStreamingTransformGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
@@ -222,6 +245,7 @@ func (g *influxqlStreamingTransformGroup) BeginBatch(begin edge.BeginBatchMessag
 	g.begin.SetSizeHint(0)
 	g.bc.time = begin.Time()
 	g.rc = nil
+	g.history = nil
 	return begin, nil
 }
 
@@ -259,9 +283,9 @@ func (g *influxqlStreamingTransformGroup) Point(p edge.PointMessage) (edge.Messa
 			return nil, nil
 		}
 	}
-	err := g.rc.AggregatePoint(p.Name(), p)
-	if err != nil {
-		g.n.diag.Error("failed to aggregate point", err)
+	aggErr := g.rc.AggregatePoint(p.Name(), p)
+	if aggErr != nil {
+		g.n.diag.Error("failed to aggregate point", aggErr)
 	}
 
 	m, err := g.n.emit(g.rc)
@@ -269,9 +293,81 @@ func (g *influxqlStreamingTransformGroup) Point(p edge.PointMessage) (edge.Messa
 		g.n.diag.Error("failed to emit stream", err)
 		return nil, nil
 	}
+	if aggErr == nil {
+		g.record(p, m)
+	}
 	return m, nil
 }
 
+// record adds the aggregated point to the history, keeping only the points the transformation still depends on.
+func (g *influxqlStreamingTransformGroup) record(p edge.PointMessage, emitted edge.Message) {
+	switch g.n.n.Method {
+	case "cumulativeSum":
+		// The sum so far is restored by aggregating it as a single point.
+		ep, ok := emitted.(edge.PointMessage)
+		if !ok {
+			return
+		}
+		s := newPointState(p)
+		s.Fields = models.Fields{g.bc.field: ep.Fields()[g.bc.as]}
+		g.history = append(g.history[:0], s)
+		return
+	case "difference":
+		// Points that do not advance the stream are skipped by the reducer.
+		if l := len(g.history); l > 0 && g.history[l-1].Time.Equal(p.Time()) {
+			return
+		}
+	}
+	g.history = append(g.history, newPointState(p))
+	if size := g.historySize(); len(g.history) > size {
+		g.history = g.history[len(g.history)-size:]
+	}
+}
+
+// historySize returns the number of most recent points the transformation depends on.
+func (g *influxqlStreamingTransformGroup) historySize() int {
+	if g.n.n.Method == "movingAverage" && len(g.n.n.Args) > 0 {
+		if window, ok := g.n.n.Args[0].(int64); ok && window > 0 {
+			return int(window)
+		}
+	}
+	return 1
+}
+
+type influxqlTransformState struct {
+	History []pointState
+}
+
+func (g *influxqlStreamingTransformGroup) snapshotState() ([]byte, error) {
+	return encodeState(influxqlTransformState{History: g.history})
+}
+
+// restoreState aggregates the points of the history into a new reduce context,
+// the points emitted meanwhile were already emitted before the restart and are dropped.
+func (g *influxqlStreamingTransformGroup) restoreState(data []byte) error {
+	var s influxqlTransformState
+	if err := decodeState(data, &s); err != nil {
+		return err
+	}
+	if len(s.History) == 0 {
+		return nil
+	}
+	if err := g.realizeReduceContextFromFields(s.History[0].Fields); err != nil {
+		return err
+	}
+	for _, ps := range s.History {
+		p := ps.point()
+		if err := g.rc.AggregatePoint(p.Name(), p); err != nil {
+			return err
+		}
+		if _, err := g.n.emit(g.rc); err != nil {
+			return err
+		}
+	}
+	g.history = s.History
+	return nil
+}
+
 func (g *influxqlStreamingTransformGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
 	return b, nil
 }
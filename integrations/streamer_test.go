@@ -899,6 +899,52 @@ stream
 	testStreamerWithOutput(t, "TestStream_Window", script, 13*time.Second, er, false, nil)
 }
 
+func TestStream_Window_Session(t *testing.T) {
+
+	var script = `
+stream
+	|from()
+		.database('dbname')
+		.retentionPolicy('rpname')
+		.measurement('cpu')
+		.groupBy('host')
+	|window()
+		.sessionGap(5s)
+		.maxSessionLength(10s)
+	|httpOut('TestStream_Window_Session')
+`
+
+	// The second session of serverA is closed by the points of serverB,
+	// the sessions of serverB are closed by the max session length.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "type", "value"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC), "idle", 11.0},
+					{time.Date(1971, 1, 1, 0, 0, 11, 0, time.UTC), "idle", 12.0},
+				},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverB"},
+				Columns: []string{"time", "type", "value"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 10, 0, time.UTC), "idle", 6.0},
+					{time.Date(1971, 1, 1, 0, 0, 12, 0, time.UTC), "idle", 7.0},
+					{time.Date(1971, 1, 1, 0, 0, 14, 0, time.UTC), "idle", 8.0},
+					{time.Date(1971, 1, 1, 0, 0, 16, 0, time.UTC), "idle", 9.0},
+					{time.Date(1971, 1, 1, 0, 0, 18, 0, time.UTC), "idle", 10.0},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Window_Session", script, 30*time.Second, er, false, nil)
+}
+
 func TestStream_Window_Count(t *testing.T) {
 
 	var script = `
@@ -5384,6 +5430,52 @@ building
 	testStreamerWithOutput(t, "TestStream_JoinOn_AcrossMeasurement", script, 13*time.Second, er, true, nil)
 }
 
+func TestStream_Join_AsOf(t *testing.T) {
+	var script = `
+var meter = stream
+	|from()
+		.measurement('meter')
+		.groupBy('building')
+
+stream
+	|from()
+		.measurement('sensor')
+		.groupBy('building', 'room')
+	|join(meter)
+		.as('sensor', 'meter')
+		.on('building')
+		.asOf()
+		.staleness(1s)
+		.fill(0.0)
+		.streamName('sensor_meter')
+	|window()
+		.period(6s)
+		.every(6s)
+	|httpOut('TestStream_Join_AsOf')
+`
+	// The meter of building A reports at 0s and 3s,
+	// its readings are joined with the sensor points at most one second later.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "sensor_meter",
+				Tags:    map[string]string{"building": "A", "room": "1"},
+				Columns: []string{"time", "meter.kwh", "sensor.temp"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 10.0, 20.0},
+					{time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC), 10.0, 21.0},
+					{time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC), 0.0, 22.0},
+					{time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC), 13.0, 23.0},
+					{time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC), 13.0, 24.0},
+					{time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC), 0.0, 25.0},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Join_AsOf", script, 8*time.Second, er, false, nil)
+}
+
 func TestStream_JoinOn_Fill_Num(t *testing.T) {
 	var script = `
 var maintlock = stream
@@ -12534,6 +12626,116 @@ func testStreamerCardinality(
 	}
 }
 
+func TestStream_StateWindow(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+	|stateWindow()
+		.openWhen(lambda: "value" > 95)
+		.closeWhen(lambda: "value" < 90)
+		.timeout(3s)
+	|httpOut('TestStream_StateWindow')
+`
+	// The state of serverA is closed by its expression,
+	// the state of serverB times out and a new state is opened by the point after the timeout.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 1, 0, time.UTC), 97.0},
+					{time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC), 98.0},
+					{time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC), 80.0},
+				},
+			},
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverB"},
+				Columns: []string{"time", "value"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC), 96.0},
+					{time.Date(1971, 1, 1, 0, 0, 3, 0, time.UTC), 97.0},
+					{time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC), 98.0},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_StateWindow", script, 8*time.Second, er, false, nil)
+}
+
+func TestStream_Resample(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('meter')
+		.groupBy('building')
+	|resample(2s)
+		.align()
+		.aggregate('last')
+		.fill('linear')
+	|window()
+		.period(10s)
+		.every(10s)
+	|httpOut('TestStream_Resample')
+`
+	// The intervals between 0s and 8s are interpolated between the last readings of those intervals.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "meter",
+				Tags:    map[string]string{"building": "A"},
+				Columns: []string{"time", "kwh"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 0, 0, time.UTC), 2.0},
+					{time.Date(1971, 1, 1, 0, 0, 2, 0, time.UTC), 4.25},
+					{time.Date(1971, 1, 1, 0, 0, 4, 0, time.UTC), 6.5},
+					{time.Date(1971, 1, 1, 0, 0, 6, 0, time.UTC), 8.75},
+					{time.Date(1971, 1, 1, 0, 0, 8, 0, time.UTC), 11.0},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Resample", script, 15*time.Second, er, false, nil)
+}
+
+func TestStream_Anomaly(t *testing.T) {
+	var script = `
+stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+	|anomaly('value')
+		.model('mad')
+		.windowSize(5)
+		.warmup(3)
+	|where(lambda: "is_anomaly")
+	|eval(lambda: "value", lambda: "expected")
+		.as('value', 'expected')
+	|httpOut('TestStream_Anomaly')
+`
+	// Only the spike at 5s deviates from the median of the previous five values.
+	er := models.Result{
+		Series: models.Rows{
+			{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "serverA"},
+				Columns: []string{"time", "expected", "value"},
+				Values: [][]interface{}{
+					{time.Date(1971, 1, 1, 0, 0, 5, 0, time.UTC), 10.0, 20.0},
+				},
+			},
+		},
+	}
+
+	testStreamerWithOutput(t, "TestStream_Anomaly", script, 8*time.Second, er, false, nil)
+}
+
 func TestStream_StateDuration(t *testing.T) {
 	var script = `
 var data = stream
@@ -32,6 +32,9 @@ type JoinNode struct {
 
 	reported    map[int]bool
 	allReported bool
+
+	// Set if the join is an as-of join.
+	asOf *asOfJoin
 }
 
 // Create a new JoinNode, which takes pairs from parent streams combines them into a single point.
@@ -62,6 +65,9 @@ func newJoinNode(et *ExecutingTask, n *pipeline.JoinNode, d NodeDiagnostic) (*Jo
 	default:
 		jn.fill = influxql.NoFill
 	}
+	if n.AsOfFlag {
+		jn.asOf = newAsOfJoin(jn)
+	}
 	jn.node.runF = jn.runJoin
 	return jn, nil
 }
@@ -69,6 +75,9 @@ func newJoinNode(et *ExecutingTask, n *pipeline.JoinNode, d NodeDiagnostic) (*Jo
 func (n *JoinNode) runJoin([]byte) error {
 	consumer := edge.NewMultiConsumerWithStats(n.ins, n)
 	valueF := func() int64 {
+		if n.asOf != nil {
+			return n.asOf.cardinality()
+		}
 		n.groupsMu.RLock()
 		l := len(n.groups)
 		n.groupsMu.RUnlock()
@@ -88,12 +97,21 @@ func (n *JoinNode) Point(src int, p edge.PointMessage) error {
 }
 
 func (n *JoinNode) Barrier(src int, b edge.BarrierMessage) error {
+	if n.asOf != nil {
+		if err := n.asOf.Barrier(src, b.Time()); err != nil {
+			return err
+		}
+		return edge.Forward(n.outs, b)
+	}
 	g := n.getOrCreateGroup(b.GroupID())
 	g.Barrier(src, b.Time())
 	return edge.Forward(n.outs, b)
 }
 
 func (n *JoinNode) Finish() error {
+	if n.asOf != nil {
+		return n.asOf.Finish()
+	}
 	// No more points are coming signal all groups to finish up.
 	for _, group := range n.groups {
 		if err := group.Finish(); err != nil {
@@ -115,6 +133,13 @@ type srcPoint struct {
 func (n *JoinNode) doMessage(src int, m messageMeta) error {
 	n.timer.Start()
 	defer n.timer.Stop()
+	if n.asOf != nil {
+		p, ok := m.(edge.PointMessage)
+		if !ok {
+			return fmt.Errorf("as-of joins do not support %T messages", m)
+		}
+		return n.asOf.Collect(src, p)
+	}
 	if len(n.j.Dimensions) > 0 {
 		// Match points with their group based on join dimensions.
 		n.matchPoints(srcPoint{Src: src, Msg: m})
@@ -501,6 +526,9 @@ type joinset struct {
 
 	first int
 
+	// Fields of each parent used to fill missing values, the fields of the first value if nil.
+	fillFields []models.Fields
+
 	diag NodeDiagnostic
 }
 
@@ -564,13 +592,17 @@ func (js *joinset) JoinIntoPoint() (edge.PointMessage, error) {
 	fields := make(models.Fields, js.size*len(firstFields))
 	for i, v := range js.values {
 		if v == nil {
+			fillFields := firstFields
+			if js.fillFields != nil && js.fillFields[i] != nil {
+				fillFields = js.fillFields[i]
+			}
 			switch js.fill {
 			case influxql.NullFill:
-				for k := range firstFields {
+				for k := range fillFields {
 					fields[js.prefixes[i]+js.delimiter+k] = nil
 				}
 			case influxql.NumberFill:
-				for k := range firstFields {
+				for k := range fillFields {
 					fields[js.prefixes[i]+js.delimiter+k] = js.fillValue
 				}
 			default:
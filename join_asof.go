@@ -0,0 +1,317 @@
+package kapacitor
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/thingnario/kapacitor/edge"
+	"github.com/thingnario/kapacitor/models"
+)
+
+// asOfJoin joins each point of the first parent with the most recent,
+// or nearest, point of the same key from each other parent.
+type asOfJoin struct {
+	n *JoinNode
+
+	// Time of the newest point or barrier of each parent.
+	heads []time.Time
+
+	// Points of the first parent waiting for the other parents to pass their time.
+	pending []asOfPoint
+
+	// Fields of the newest point of each parent, used to fill missing values.
+	fields []models.Fields
+
+	keysMu sync.RWMutex
+	keys   map[models.GroupID]*asOfKey
+	// Time of the oldest point of the first parent at the next check for expired keys.
+	nextPrune time.Time
+}
+
+type asOfPoint struct {
+	key models.GroupID
+	p   edge.PointMessage
+}
+
+// asOfKey buffers the points of the other parents for a key.
+type asOfKey struct {
+	// Points of each parent sorted by time, the first parent is never buffered.
+	points [][]edge.PointMessage
+}
+
+func newAsOfJoin(n *JoinNode) *asOfJoin {
+	return &asOfJoin{
+		n:      n,
+		heads:  make([]time.Time, len(n.j.Parents())),
+		fields: make([]models.Fields, len(n.j.Parents())),
+		keys:   make(map[models.GroupID]*asOfKey),
+	}
+}
+
+func (a *asOfJoin) cardinality() int64 {
+	a.keysMu.RLock()
+	defer a.keysMu.RUnlock()
+	return int64(len(a.keys))
+}
+
+// key returns the key of the point, its group reduced to the join dimensions if set.
+func (a *asOfJoin) key(p edge.PointMessage) models.GroupID {
+	if len(a.n.j.Dimensions) == 0 {
+		return p.GroupID()
+	}
+	return models.ToGroupID(
+		p.Name(),
+		p.GroupInfo().Tags,
+		models.Dimensions{
+			ByName:   p.Dimensions().ByName,
+			TagNames: a.n.j.Dimensions,
+		},
+	)
+}
+
+func (a *asOfJoin) getOrCreateKey(key models.GroupID) *asOfKey {
+	a.keysMu.RLock()
+	k := a.keys[key]
+	a.keysMu.RUnlock()
+	if k == nil {
+		k = &asOfKey{
+			points: make([][]edge.PointMessage, len(a.heads)),
+		}
+		a.keysMu.Lock()
+		a.keys[key] = k
+		a.keysMu.Unlock()
+	}
+	return k
+}
+
+// Collect a point from a given parent and emit the points of the first parent that are ready.
+func (a *asOfJoin) Collect(src int, p edge.PointMessage) error {
+	key := a.key(p)
+	a.advance(src, p.Time())
+	a.fields[src] = p.Fields()
+	if src == 0 {
+		a.pending = append(a.pending, asOfPoint{key: key, p: p})
+	} else {
+		a.getOrCreateKey(key).insert(src, p, a.low())
+	}
+	return a.emitReady()
+}
+
+// Barrier signals a parent will not produce points older than time.
+func (a *asOfJoin) Barrier(src int, t time.Time) error {
+	a.advance(src, t)
+	return a.emitReady()
+}
+
+// Finish emits all pending points, the other parents will not produce more points.
+func (a *asOfJoin) Finish() error {
+	for len(a.pending) > 0 {
+		if err := a.emitNext(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *asOfJoin) advance(src int, t time.Time) {
+	if t.After(a.heads[src]) {
+		a.heads[src] = t
+	}
+}
+
+// oldest returns the time of the oldest point of the first parent that is not yet joined.
+func (a *asOfJoin) oldest() time.Time {
+	if len(a.pending) > 0 {
+		return a.pending[0].p.Time()
+	}
+	return a.heads[0]
+}
+
+// low returns the time before which points of the other parents are no longer needed,
+// except for the most recent one.
+func (a *asOfJoin) low() time.Time {
+	return a.oldest().Add(-a.n.j.Nearest)
+}
+
+// emitReady emits the pending points that are ready to be joined and evicts the expired keys.
+func (a *asOfJoin) emitReady() error {
+	for len(a.pending) > 0 && a.ready(a.pending[0].p.Time()) {
+		if err := a.emitNext(); err != nil {
+			return err
+		}
+	}
+	a.prune()
+	return nil
+}
+
+// ready reports whether a point of the first parent at time t can be joined.
+// Without a nearest duration points are joined on arrival with the points received so far.
+// Otherwise the join waits for each other parent to pass t plus the nearest duration,
+// but not longer than until the first parent passes that time by the staleness of the other parent,
+// or by the nearest duration if the staleness is unlimited, so that a silent parent does not hold back the join.
+func (a *asOfJoin) ready(t time.Time) bool {
+	nearest := a.n.j.Nearest
+	if nearest == 0 {
+		return true
+	}
+	t = t.Add(nearest)
+	for src := 1; src < len(a.heads); src++ {
+		if a.heads[src].After(t) {
+			continue
+		}
+		wait := a.staleness(src)
+		if wait == 0 {
+			wait = nearest
+		}
+		if !a.heads[0].After(t.Add(wait)) {
+			return false
+		}
+	}
+	return true
+}
+
+// prune evicts the keys whose points are all too stale to be matched with the points of the first parent that are not yet joined.
+// Keys are only evicted if the staleness of the parents is limited, the keys are checked at most once per staleness duration.
+func (a *asOfJoin) prune() {
+	var interval time.Duration
+	for src := 1; src < len(a.heads); src++ {
+		if s := a.staleness(src); s > 0 && (interval == 0 || s < interval) {
+			interval = s
+		}
+	}
+	oldest := a.oldest()
+	if interval == 0 || oldest.Before(a.nextPrune) {
+		return
+	}
+	a.nextPrune = oldest.Add(interval)
+
+	a.keysMu.Lock()
+	defer a.keysMu.Unlock()
+	for key, k := range a.keys {
+		if k.expired(oldest, a.staleness) {
+			delete(a.keys, key)
+		}
+	}
+}
+
+// emitNext joins the oldest pending point with its matches and emits it.
+func (a *asOfJoin) emitNext() error {
+	next := a.pending[0]
+	a.pending = a.pending[1:]
+
+	set := newJoinset(
+		a.n,
+		a.n.j.StreamName,
+		a.n.fill,
+		a.n.fillValue,
+		a.n.j.Names,
+		a.n.j.Delimiter,
+		0,
+		next.p.Time(),
+		a.n.diag,
+	)
+	if set.name == "" {
+		set.name = next.p.Name()
+	}
+	set.fillFields = a.fields
+	set.Set(0, next.p)
+	a.keysMu.RLock()
+	k := a.keys[next.key]
+	a.keysMu.RUnlock()
+	if k != nil {
+		for src := 1; src < len(a.heads); src++ {
+			if m := k.match(src, next.p.Time(), a.n.j.Nearest, a.staleness(src)); m != nil {
+				set.Set(src, m)
+			}
+		}
+	}
+
+	p, err := set.JoinIntoPoint()
+	if err != nil {
+		return errors.Wrap(err, "failed to join into point")
+	}
+	if p != nil {
+		return edge.Forward(a.n.outs, p)
+	}
+	return nil
+}
+
+// staleness returns the maximum age of the matched points of a parent, zero if unlimited.
+func (a *asOfJoin) staleness(src int) time.Duration {
+	switch durations := a.n.j.StalenessDurations; len(durations) {
+	case 0:
+		return 0
+	case 1:
+		return durations[0]
+	default:
+		return durations[src]
+	}
+}
+
+// insert a point of a parent and drop the points before low that can no longer be matched.
+func (k *asOfKey) insert(src int, p edge.PointMessage, low time.Time) {
+	points := k.points[src]
+	i := sort.Search(len(points), func(i int) bool {
+		return points[i].Time().After(p.Time())
+	})
+	points = append(points, nil)
+	copy(points[i+1:], points[i:])
+	points[i] = p
+
+	// Keep the most recent point at or before low.
+	drop := sort.Search(len(points), func(i int) bool {
+		return points[i].Time().After(low)
+	}) - 1
+	if drop > 0 {
+		points = append(points[:0], points[drop:]...)
+	}
+	k.points[src] = points
+}
+
+// expired reports whether none of the points of the key can be matched with a point at or after t.
+// Points of a parent with unlimited staleness never expire.
+func (k *asOfKey) expired(t time.Time, staleness func(src int) time.Duration) bool {
+	for src := 1; src < len(k.points); src++ {
+		points := k.points[src]
+		if len(points) == 0 {
+			continue
+		}
+		s := staleness(src)
+		if s == 0 || t.Sub(points[len(points)-1].Time()) <= s {
+			return false
+		}
+	}
+	return true
+}
+
+// match returns the point of a parent to join with a point at time t, or nil if there is none.
+// Without a nearest duration the most recent point at or before t is matched,
+// otherwise the point nearest to t within the duration, preferring the earlier point on ties.
+func (k *asOfKey) match(src int, t time.Time, nearest, staleness time.Duration) edge.PointMessage {
+	points := k.points[src]
+	i := sort.Search(len(points), func(i int) bool {
+		return points[i].Time().After(t)
+	})
+	var m edge.PointMessage
+	if i > 0 {
+		m = points[i-1]
+	}
+	if nearest > 0 {
+		if m != nil && t.Sub(m.Time()) > nearest {
+			m = nil
+		}
+		if i < len(points) {
+			after := points[i]
+			d := after.Time().Sub(t)
+			if d <= nearest && (m == nil || d < t.Sub(m.Time())) {
+				m = after
+			}
+		}
+	}
+	if m != nil && staleness > 0 && t.Sub(m.Time()) > staleness {
+		return nil
+	}
+	return m
+}
@@ -0,0 +1,129 @@
+package kapacitor
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/thingnario/kapacitor/edge"
+	"github.com/thingnario/kapacitor/models"
+	"github.com/thingnario/kapacitor/pipeline"
+)
+
+func TestAsOfKey_Match(t *testing.T) {
+	k := &asOfKey{points: make([][]edge.PointMessage, 2)}
+	for _, sec := range []int64{10, 20, 30} {
+		k.insert(1, newCheckpointPoint(sec, float64(sec)), time.Unix(0, 0))
+	}
+	testCases := []struct {
+		name      string
+		t         int64
+		nearest   time.Duration
+		staleness time.Duration
+		exp       int64
+	}{
+		{name: "before all points", t: 5, exp: -1},
+		{name: "same time", t: 20, exp: 20},
+		{name: "most recent", t: 29, exp: 20},
+		{name: "stale", t: 29, staleness: 5 * time.Second, exp: -1},
+		{name: "not stale", t: 25, staleness: 5 * time.Second, exp: 20},
+		{name: "nearest after", t: 27, nearest: 5 * time.Second, exp: 30},
+		{name: "nearest before", t: 23, nearest: 5 * time.Second, exp: 20},
+		{name: "nearest tie", t: 25, nearest: 5 * time.Second, exp: 20},
+		{name: "nearest out of range", t: 5, nearest: 4 * time.Second, exp: -1},
+		{name: "nearest after all points", t: 34, nearest: 5 * time.Second, exp: 30},
+	}
+	for _, tc := range testCases {
+		m := k.match(1, time.Unix(tc.t, 0).UTC(), tc.nearest, tc.staleness)
+		got := int64(-1)
+		if m != nil {
+			got = m.Time().Unix()
+		}
+		if got != tc.exp {
+			t.Errorf("%s: unexpected match got %d exp %d", tc.name, got, tc.exp)
+		}
+	}
+}
+
+func TestAsOfKey_Insert(t *testing.T) {
+	k := &asOfKey{points: make([][]edge.PointMessage, 2)}
+	for _, sec := range []int64{10, 30, 20, 40} {
+		k.insert(1, newCheckpointPoint(sec, float64(sec)), time.Unix(25, 0))
+	}
+	// Points are kept sorted and only the most recent point before low is kept.
+	var got []int64
+	for _, p := range k.points[1] {
+		got = append(got, p.Time().Unix())
+	}
+	if exp := []int64{20, 30, 40}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected points got %v exp %v", got, exp)
+	}
+}
+
+func newTestAsOfJoin(nearest time.Duration, staleness ...time.Duration) *asOfJoin {
+	j := &pipeline.JoinNode{
+		Nearest:            nearest,
+		StalenessDurations: staleness,
+	}
+	return &asOfJoin{
+		n:     &JoinNode{j: j},
+		heads: make([]time.Time, 2),
+		keys:  make(map[models.GroupID]*asOfKey),
+	}
+}
+
+func TestAsOfJoin_Ready(t *testing.T) {
+	testCases := []struct {
+		name      string
+		nearest   time.Duration
+		staleness time.Duration
+		heads     []int64
+		exp       bool
+	}{
+		{name: "no nearest", heads: []int64{20, 0}, exp: true},
+		{name: "waiting", nearest: 5 * time.Second, heads: []int64{20, 14}, exp: false},
+		{name: "passed", nearest: 5 * time.Second, heads: []int64{20, 16}, exp: true},
+		{name: "silent parent", nearest: 5 * time.Second, heads: []int64{21, 0}, exp: true},
+		{name: "silent parent within nearest", nearest: 5 * time.Second, heads: []int64{20, 0}, exp: false},
+		{name: "silent parent within staleness", nearest: 5 * time.Second, staleness: time.Minute, heads: []int64{21, 0}, exp: false},
+		{name: "silent parent after staleness", nearest: 5 * time.Second, staleness: time.Minute, heads: []int64{76, 0}, exp: true},
+	}
+	for _, tc := range testCases {
+		var a *asOfJoin
+		if tc.staleness > 0 {
+			a = newTestAsOfJoin(tc.nearest, tc.staleness)
+		} else {
+			a = newTestAsOfJoin(tc.nearest)
+		}
+		for src, sec := range tc.heads {
+			a.heads[src] = time.Unix(sec, 0).UTC()
+		}
+		if got := a.ready(time.Unix(10, 0).UTC()); got != tc.exp {
+			t.Errorf("%s: unexpected ready got %t exp %t", tc.name, got, tc.exp)
+		}
+	}
+}
+
+func TestAsOfJoin_Prune(t *testing.T) {
+	a := newTestAsOfJoin(0, 10*time.Second)
+	a.getOrCreateKey("old").insert(1, newCheckpointPoint(10, 1.0), time.Unix(0, 0))
+	a.getOrCreateKey("recent").insert(1, newCheckpointPoint(25, 1.0), time.Unix(0, 0))
+
+	a.heads[0] = time.Unix(30, 0).UTC()
+	a.prune()
+	if got := a.cardinality(); got != 1 {
+		t.Fatalf("unexpected cardinality got %d exp 1", got)
+	}
+	if _, ok := a.keys["recent"]; !ok {
+		t.Error("expected recent key to be kept")
+	}
+
+	// Without staleness the most recent point of each key is kept.
+	a = newTestAsOfJoin(0)
+	a.getOrCreateKey("old").insert(1, newCheckpointPoint(10, 1.0), time.Unix(0, 0))
+	a.heads[0] = time.Unix(3600, 0).UTC()
+	a.prune()
+	if got := a.cardinality(); got != 1 {
+		t.Errorf("unexpected cardinality got %d exp 1", got)
+	}
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	imodels "github.com/influxdata/influxdb/models"
+	"github.com/pkg/errors"
+)
+
+// ParseJSONTime parses the time of a point decoded from JSON with numbers decoded as json.Number.
+// A string is parsed with the layout, RFC3339 if empty, and a number
+// is the number of units of the precision since the epoch, nanoseconds if empty.
+func ParseJSONTime(v interface{}, layout, precision string) (time.Time, error) {
+	switch v := v.(type) {
+	case string:
+		if layout == "" {
+			layout = time.RFC3339Nano
+		}
+		t, err := time.Parse(layout, v)
+		if err != nil {
+			return time.Time{}, errors.Wrapf(err, "invalid time %q", v)
+		}
+		return t.UTC(), nil
+	case json.Number:
+		n, err := v.Int64()
+		if err != nil {
+			return time.Time{}, errors.Wrapf(err, "invalid time %q", v)
+		}
+		return time.Unix(0, n*imodels.GetPrecisionMultiplier(precision)).UTC(), nil
+	default:
+		return time.Time{}, fmt.Errorf("invalid time %v, must be a string or a number", v)
+	}
+}
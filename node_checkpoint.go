@@ -0,0 +1,233 @@
+package kapacitor
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/thingnario/kapacitor/edge"
+	"github.com/thingnario/kapacitor/models"
+)
+
+// Version of the encoding of node group snapshots.
+const groupsSnapshotVersion = 1
+
+// groupState is implemented by the group receivers of nodes that checkpoint their state.
+type groupState interface {
+	edge.ForwardReceiver
+	snapshotState() ([]byte, error)
+	restoreState(data []byte) error
+}
+
+type groupsSnapshot struct {
+	Version int
+	Groups  map[models.GroupID][]byte
+}
+
+// groupCheckpoint tracks the state of each group of a node so that it can be
+// snapshotted while the node is running and restored when the task is restarted.
+//
+// The groups process messages while holding the checkpoint lock,
+// the lock is released before any resulting message is forwarded to the children.
+type groupCheckpoint struct {
+	mu     sync.Mutex
+	groups map[models.GroupID]groupState
+	// Snapshots of restored groups that have not received any messages yet.
+	restored map[models.GroupID][]byte
+}
+
+// restore loads a snapshot created by snapshot.
+// The state of each group is restored once the group is added,
+// usually when it receives its first message.
+func (c *groupCheckpoint) restore(snapshot []byte) error {
+	if len(snapshot) == 0 {
+		return nil
+	}
+	var s groupsSnapshot
+	if err := gob.NewDecoder(bytes.NewReader(snapshot)).Decode(&s); err != nil {
+		return err
+	}
+	if s.Version != groupsSnapshotVersion {
+		return fmt.Errorf("unsupported node snapshot version %d", s.Version)
+	}
+	c.mu.Lock()
+	c.restored = s.Groups
+	c.mu.Unlock()
+	return nil
+}
+
+// add registers the state of a new group and returns the receiver to use for the group.
+// If an error is returned the receiver is still valid, but the group state was not restored.
+func (c *groupCheckpoint) add(group models.GroupID, g groupState) (edge.ForwardReceiver, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.groups == nil {
+		c.groups = make(map[models.GroupID]groupState)
+	}
+	c.groups[group] = g
+	r := &checkpointedGroup{c: c, group: group, g: g}
+	data, ok := c.restored[group]
+	if !ok {
+		return r, nil
+	}
+	delete(c.restored, group)
+	if err := g.restoreState(data); err != nil {
+		return r, err
+	}
+	return r, nil
+}
+
+// restoredGroups returns the state of the restored groups that have not received any messages yet.
+func (c *groupCheckpoint) restoredGroups() map[models.GroupID][]byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	groups := make(map[models.GroupID][]byte, len(c.restored))
+	for group, data := range c.restored {
+		groups[group] = data
+	}
+	return groups
+}
+
+// snapshot encodes the state of all groups.
+func (c *groupCheckpoint) snapshot() ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.groups) == 0 && len(c.restored) == 0 {
+		return nil, nil
+	}
+	s := groupsSnapshot{
+		Version: groupsSnapshotVersion,
+		Groups:  make(map[models.GroupID][]byte, len(c.groups)+len(c.restored)),
+	}
+	// Keep the state of restored groups that have not received data since the restart.
+	for group, data := range c.restored {
+		s.Groups[group] = data
+	}
+	for group, g := range c.groups {
+		data, err := g.snapshotState()
+		if err != nil {
+			return nil, err
+		}
+		s.Groups[group] = data
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(s); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c *groupCheckpoint) delete(group models.GroupID) {
+	delete(c.groups, group)
+	delete(c.restored, group)
+}
+
+// checkpointedGroup forwards messages to the group while holding the checkpoint lock.
+type checkpointedGroup struct {
+	c     *groupCheckpoint
+	group models.GroupID
+	g     groupState
+}
+
+func (r *checkpointedGroup) BeginBatch(begin edge.BeginBatchMessage) (edge.Message, error) {
+	r.c.mu.Lock()
+	defer r.c.mu.Unlock()
+	return r.g.BeginBatch(begin)
+}
+
+func (r *checkpointedGroup) BatchPoint(bp edge.BatchPointMessage) (edge.Message, error) {
+	r.c.mu.Lock()
+	defer r.c.mu.Unlock()
+	return r.g.BatchPoint(bp)
+}
+
+func (r *checkpointedGroup) EndBatch(end edge.EndBatchMessage) (edge.Message, error) {
+	r.c.mu.Lock()
+	defer r.c.mu.Unlock()
+	return r.g.EndBatch(end)
+}
+
+func (r *checkpointedGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	r.c.mu.Lock()
+	defer r.c.mu.Unlock()
+	return r.g.Point(p)
+}
+
+func (r *checkpointedGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	r.c.mu.Lock()
+	defer r.c.mu.Unlock()
+	return r.g.Barrier(b)
+}
+
+func (r *checkpointedGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	r.c.mu.Lock()
+	defer r.c.mu.Unlock()
+	r.c.delete(r.group)
+	return r.g.DeleteGroup(d)
+}
+
+func (r *checkpointedGroup) Done() {
+	r.c.mu.Lock()
+	defer r.c.mu.Unlock()
+	r.g.Done()
+}
+
+// pointState is the encodable form of a point held in the state of a node.
+type pointState struct {
+	Name            string
+	Database        string
+	RetentionPolicy string
+	Dimensions      models.Dimensions
+	Tags            models.Tags
+	Fields          models.Fields
+	Time            time.Time
+}
+
+func newPointState(p edge.PointMessage) pointState {
+	return pointState{
+		Name:            p.Name(),
+		Database:        p.Database(),
+		RetentionPolicy: p.RetentionPolicy(),
+		Dimensions:      p.Dimensions(),
+		Tags:            p.Tags(),
+		Fields:          p.Fields(),
+		Time:            p.Time(),
+	}
+}
+
+func (s pointState) point() edge.PointMessage {
+	return edge.NewPointMessage(s.Name, s.Database, s.RetentionPolicy, s.Dimensions, s.Fields, s.Tags, s.Time)
+}
+
+// batchPointState is the encodable form of a batch point held in the state of a node.
+type batchPointState struct {
+	Tags   models.Tags
+	Fields models.Fields
+	Time   time.Time
+}
+
+func newBatchPointState(p edge.FieldsTagsTimeGetter) batchPointState {
+	return batchPointState{
+		Tags:   p.Tags(),
+		Fields: p.Fields(),
+		Time:   p.Time(),
+	}
+}
+
+func (s batchPointState) batchPoint() edge.BatchPointMessage {
+	return edge.NewBatchPointMessage(s.Fields, s.Tags, s.Time)
+}
+
+func encodeState(state interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(state); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeState(data []byte, state interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(state)
+}
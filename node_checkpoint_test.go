@@ -0,0 +1,596 @@
+package kapacitor
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/thingnario/kapacitor/edge"
+	"github.com/thingnario/kapacitor/models"
+	"github.com/thingnario/kapacitor/pipeline"
+	"github.com/thingnario/kapacitor/tick/ast"
+	"github.com/thingnario/kapacitor/timer"
+)
+
+func newCheckpointPoint(sec int64, value interface{}) edge.PointMessage {
+	return edge.NewPointMessage(
+		"cpu", "db", "rp",
+		models.Dimensions{TagNames: []string{"host"}},
+		models.Fields{"value": value},
+		models.Tags{"host": "a"},
+		time.Unix(sec, 0).UTC(),
+	)
+}
+
+// snapshotAndRestore snapshots the state of the group from into the group to,
+// using a groupCheckpoint like a node does across a restart.
+func snapshotAndRestore(t *testing.T, from, to groupState) {
+	t.Helper()
+	var before groupCheckpoint
+	if _, err := before.add("cpu,host=a", from); err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := before.snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var after groupCheckpoint
+	if err := after.restore(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := after.add("cpu,host=a", to); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGroupCheckpoint_KeepsUnseenGroups(t *testing.T) {
+	var before groupCheckpoint
+	if _, err := before.add("cpu,host=a", &stateTrackingGroup{tracker: &stateCountTracker{count: 3}}); err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := before.snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Restore and snapshot again without the group receiving any data.
+	var after groupCheckpoint
+	if err := after.restore(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	again, err := after.snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var restored groupCheckpoint
+	if err := restored.restore(again); err != nil {
+		t.Fatal(err)
+	}
+	tracker := &stateCountTracker{}
+	if _, err := restored.add("cpu,host=a", &stateTrackingGroup{tracker: tracker}); err != nil {
+		t.Fatal(err)
+	}
+	if tracker.count != 3 {
+		t.Errorf("unexpected count got %d exp 3", tracker.count)
+	}
+}
+
+func TestPipelineHash_OmitsZeroValues(t *testing.T) {
+	hash := func(p *pipeline.Pipeline) string {
+		et := &ExecutingTask{Task: &Task{ID: "test", Pipeline: p}}
+		return et.pipelineHash()
+	}
+	newPipeline := func(period time.Duration) *pipeline.Pipeline {
+		stream := &pipeline.StreamNode{}
+		p := pipeline.CreatePipelineSources(stream)
+		w := stream.From().Window()
+		w.Period = period
+		w.Every = 10 * time.Second
+		return p
+	}
+	if h1, h2 := hash(newPipeline(10*time.Second)), hash(newPipeline(10*time.Second)); h1 != h2 {
+		t.Errorf("expected equal hashes for equal pipelines, got %s and %s", h1, h2)
+	}
+	if h1, h2 := hash(newPipeline(10*time.Second)), hash(newPipeline(20*time.Second)); h1 == h2 {
+		t.Error("expected different hashes for different pipelines")
+	}
+
+	// A new property with a zero value must not change the hash.
+	got := omitZeroValues(map[string]interface{}{
+		"typeOf": "window",
+		"period": "10s",
+		"every":  "0s",
+		"align":  false,
+		"fill":   nil,
+		"offset": 0.0,
+		"nested": map[string]interface{}{"name": ""},
+		"groups": []interface{}{},
+	})
+	exp := map[string]interface{}{
+		"typeOf": "window",
+		"period": "10s",
+	}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected values got %v exp %v", got, exp)
+	}
+}
+
+func TestWindowByTime_SnapshotRestore(t *testing.T) {
+	group := edge.GroupInfo{ID: "cpu,host=a", Tags: models.Tags{"host": "a"}}
+	newWindow := func() *windowByTime {
+		return newWindowByTime("cpu", time.Unix(0, 0).UTC(), group, 10*time.Second, 10*time.Second, false, false, newWindowNodeDiagnostic())
+	}
+	w := newWindow()
+	for i := int64(0); i < 15; i++ {
+		if _, err := w.Point(newCheckpointPoint(i, i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	restored := newWindow()
+	snapshotAndRestore(t, w, restored)
+	if !restored.nextEmit.Equal(w.nextEmit) {
+		t.Errorf("unexpected next emit got %v exp %v", restored.nextEmit, w.nextEmit)
+	}
+
+	// Both windows must emit the same batch for the next point.
+	exp, err := w.Point(newCheckpointPoint(20, int64(20)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := restored.Point(newCheckpointPoint(20, int64(20)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp == nil || got == nil {
+		t.Fatalf("expected both windows to emit, got %v exp %v", got, exp)
+	}
+	expPoints := exp.(edge.BufferedBatchMessage).Points()
+	gotPoints := got.(edge.BufferedBatchMessage).Points()
+	if len(gotPoints) != len(expPoints) {
+		t.Fatalf("unexpected number of points got %d exp %d", len(gotPoints), len(expPoints))
+	}
+	for i := range expPoints {
+		if !gotPoints[i].Time().Equal(expPoints[i].Time()) || !reflect.DeepEqual(gotPoints[i].Fields(), expPoints[i].Fields()) {
+			t.Errorf("unexpected point %d got %v %v exp %v %v", i, gotPoints[i].Time(), gotPoints[i].Fields(), expPoints[i].Time(), expPoints[i].Fields())
+		}
+	}
+}
+
+func TestWindowByCount_SnapshotRestore(t *testing.T) {
+	group := edge.GroupInfo{ID: "cpu,host=a", Tags: models.Tags{"host": "a"}}
+	newWindow := func() *windowByCount {
+		return newWindowByCount("cpu", group, 3, 2, false, newWindowNodeDiagnostic())
+	}
+	w := newWindow()
+	for i := int64(0); i < 5; i++ {
+		if _, err := w.Point(newCheckpointPoint(i, i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	restored := newWindow()
+	snapshotAndRestore(t, w, restored)
+
+	exp, err := w.Point(newCheckpointPoint(5, int64(5)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := restored.Point(newCheckpointPoint(5, int64(5)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp == nil || got == nil {
+		t.Fatalf("expected both windows to emit, got %v exp %v", got, exp)
+	}
+	expPoints := exp.(edge.BufferedBatchMessage).Points()
+	gotPoints := got.(edge.BufferedBatchMessage).Points()
+	if len(gotPoints) != len(expPoints) {
+		t.Fatalf("unexpected number of points got %d exp %d", len(gotPoints), len(expPoints))
+	}
+	for i := range expPoints {
+		if !gotPoints[i].Time().Equal(expPoints[i].Time()) {
+			t.Errorf("unexpected point %d got %v exp %v", i, gotPoints[i].Time(), expPoints[i].Time())
+		}
+	}
+}
+
+func TestWindowBySession_SnapshotRestore(t *testing.T) {
+	group := edge.GroupInfo{ID: "cpu,host=a", Tags: models.Tags{"host": "a"}}
+	newWindow := func() *windowBySession {
+		return newWindowBySession("cpu", group, 5*time.Second, time.Minute)
+	}
+	w := newWindow()
+	for i := int64(0); i < 3; i++ {
+		if _, err := w.Point(newCheckpointPoint(i, i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	restored := newWindow()
+	snapshotAndRestore(t, w, restored)
+	if !restored.deadline().Equal(w.deadline()) {
+		t.Errorf("unexpected deadline got %v exp %v", restored.deadline(), w.deadline())
+	}
+
+	exp, _ := w.close()
+	got, ok := restored.close()
+	if !ok {
+		t.Fatal("expected restored session to be open")
+	}
+	if !got.Begin().Time().Equal(exp.Begin().Time()) {
+		t.Errorf("unexpected batch time got %v exp %v", got.Begin().Time(), exp.Begin().Time())
+	}
+	if !reflect.DeepEqual(got.Points(), exp.Points()) {
+		t.Errorf("unexpected points got %v exp %v", got.Points(), exp.Points())
+	}
+}
+
+func TestWindowSessions_RestoreSilentGroup(t *testing.T) {
+	group := edge.GroupInfo{
+		ID:         "cpu,host=a",
+		Tags:       models.Tags{"host": "a"},
+		Dimensions: models.Dimensions{TagNames: []string{"host"}},
+	}
+	w := newWindowBySession("cpu", group, 5*time.Second, 0)
+	for i := int64(0); i < 3; i++ {
+		if _, err := w.Point(newCheckpointPoint(i, i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var before groupCheckpoint
+	if _, err := before.add(group.ID, w); err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := before.snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := &WindowNode{
+		node: node{diag: newWindowNodeDiagnostic()},
+		w:    &pipeline.WindowNode{SessionGap: 5 * time.Second},
+	}
+	if err := n.groups.restore(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	s := newWindowSessions(n)
+	s.restore()
+
+	// The task clock closes the session without the group receiving data.
+	if msgs := s.advance(time.Unix(6, 0).UTC()); len(msgs) != 0 {
+		t.Fatalf("unexpected closed sessions before the deadline %v", msgs)
+	}
+	msgs := s.advance(time.Unix(7, 0).UTC())
+	if len(msgs) != 1 {
+		t.Fatalf("unexpected number of closed sessions got %d exp 1", len(msgs))
+	}
+	batch := msgs[0].(edge.BufferedBatchMessage)
+	if batch.Name() != "cpu" || !reflect.DeepEqual(batch.Tags(), group.Tags) || len(batch.Points()) != 3 {
+		t.Errorf("unexpected batch %s %v with %d points", batch.Name(), batch.Tags(), len(batch.Points()))
+	}
+	if len(n.groups.restoredGroups()) != 0 {
+		t.Error("expected no groups left to restore")
+	}
+}
+
+func TestStateWindow_SnapshotRestore(t *testing.T) {
+	n, err := newStateWindowNode(nil, &pipeline.StateWindowNode{
+		OpenWhen:  &ast.LambdaNode{Expression: &ast.BoolNode{Bool: true}},
+		CloseWhen: &ast.LambdaNode{Expression: &ast.BoolNode{Bool: false}},
+		Timeout:   time.Minute,
+	}, newWindowNodeDiagnostic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	group := edge.GroupInfo{ID: "cpu,host=a", Tags: models.Tags{"host": "a"}}
+	g := n.newGroup("cpu", group)
+	g.start = time.Unix(1, 0).UTC()
+	for i := int64(1); i < 4; i++ {
+		g.add(newCheckpointPoint(i, i))
+	}
+
+	restored := n.newGroup("cpu", group)
+	snapshotAndRestore(t, g, restored)
+	if !restored.open() || !restored.start.Equal(g.start) {
+		t.Fatalf("unexpected restored state open %t start %v", restored.open(), restored.start)
+	}
+
+	// Both groups must emit the same batch once the state times out.
+	timeout := time.Unix(61, 0).UTC()
+	if !g.timedOut(timeout) || !restored.timedOut(timeout) {
+		t.Fatal("expected both states to time out")
+	}
+	exp, got := g.close(), restored.close()
+	if !reflect.DeepEqual(got.Points(), exp.Points()) {
+		t.Errorf("unexpected points got %v exp %v", got.Points(), exp.Points())
+	}
+}
+
+func newTestStateWindowNode(t *testing.T, outs ...edge.StatsEdge) *StateWindowNode {
+	t.Helper()
+	n, err := newStateWindowNode(nil, &pipeline.StateWindowNode{
+		OpenWhen:  &ast.LambdaNode{Expression: &ast.BoolNode{Bool: true}},
+		CloseWhen: &ast.LambdaNode{Expression: &ast.BoolNode{Bool: false}},
+		Timeout:   time.Minute,
+	}, newWindowNodeDiagnostic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.timer = timer.NewNoOp()
+	n.outs = outs
+	return n
+}
+
+func TestStateWindows_RestoreSilentGroup(t *testing.T) {
+	group := edge.GroupInfo{
+		ID:         "cpu,host=a",
+		Tags:       models.Tags{"host": "a"},
+		Dimensions: models.Dimensions{TagNames: []string{"host"}},
+	}
+	g := newTestStateWindowNode(t).newGroup("cpu", group)
+	g.start = time.Unix(1, 0).UTC()
+	for i := int64(1); i < 4; i++ {
+		g.add(newCheckpointPoint(i, i))
+	}
+	var before groupCheckpoint
+	if _, err := before.add(group.ID, g); err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := before.snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := newTestStateWindowNode(t)
+	if err := n.groups.restore(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	s := newStateWindows(n)
+	s.restore()
+
+	// A barrier of another group emits the state once it timed out.
+	if msgs := s.closeTimedOut(time.Unix(60, 0).UTC()); len(msgs) != 0 {
+		t.Fatalf("unexpected closed states before the timeout %v", msgs)
+	}
+	msgs := s.closeTimedOut(time.Unix(61, 0).UTC())
+	if len(msgs) != 1 {
+		t.Fatalf("unexpected number of closed states got %d exp 1", len(msgs))
+	}
+	batch := msgs[0].(edge.BufferedBatchMessage)
+	if batch.Name() != "cpu" || !reflect.DeepEqual(batch.Tags(), group.Tags) || len(batch.Points()) != 3 {
+		t.Errorf("unexpected batch %s %v with %d points", batch.Name(), batch.Tags(), len(batch.Points()))
+	}
+	if len(n.groups.restoredGroups()) != 0 {
+		t.Error("expected no groups left to restore")
+	}
+}
+
+func TestStateWindows_DeleteGroup(t *testing.T) {
+	out := edge.NewChannelEdge(pipeline.BatchEdge, 10)
+	s := newStateWindows(newTestStateWindowNode(t, edge.NewStatsEdge(out)))
+	for i := int64(1); i < 3; i++ {
+		if err := s.Point(newCheckpointPoint(i, i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d := edge.NewDeleteGroupMessage(newCheckpointPoint(0, 0).GroupID())
+	if err := s.DeleteGroup(d); err != nil {
+		t.Fatal(err)
+	}
+	out.Close()
+
+	// The open state is emitted before the delete.
+	var types []edge.MessageType
+	for m, ok := out.Emit(); ok; m, ok = out.Emit() {
+		types = append(types, m.Type())
+	}
+	if exp := []edge.MessageType{edge.BufferedBatch, edge.DeleteGroup}; !reflect.DeepEqual(types, exp) {
+		t.Errorf("unexpected messages got %v exp %v", types, exp)
+	}
+}
+
+func TestResample_SnapshotRestore(t *testing.T) {
+	n, err := newResampleNode(nil, &pipeline.ResampleNode{
+		Interval:  10 * time.Second,
+		Aggregate: "mean",
+		Fill:      "previous",
+		MaxFill:   100,
+	}, newWindowNodeDiagnostic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	group := edge.GroupInfo{ID: "cpu,host=a", Tags: models.Tags{"host": "a"}}
+	g := n.newGroup("cpu", group)
+	for _, sec := range []int64{0, 5, 10, 15} {
+		if _, err := g.Point(newCheckpointPoint(sec, float64(sec))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	g.emitted = nil
+
+	restored := n.newGroup("cpu", group)
+	snapshotAndRestore(t, g, restored)
+	if !restored.start.Equal(g.start) || !reflect.DeepEqual(restored.last, g.last) {
+		t.Fatalf("unexpected restored state start %v last %v", restored.start, restored.last)
+	}
+
+	// Both groups must emit the same interval and filled interval after the barrier.
+	b := edge.NewBarrierMessage(group, time.Unix(30, 0).UTC())
+	if _, err := g.Barrier(b); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := restored.Barrier(b); err != nil {
+		t.Fatal(err)
+	}
+	if len(g.emitted) != 2 {
+		t.Fatalf("unexpected number of emitted points got %d exp 2", len(g.emitted))
+	}
+	for i := range g.emitted {
+		got, exp := restored.emitted[i], g.emitted[i]
+		if !got.Time().Equal(exp.Time()) || !reflect.DeepEqual(got.Fields(), exp.Fields()) {
+			t.Errorf("unexpected point %d got %v %v exp %v %v", i, got.Time(), got.Fields(), exp.Time(), exp.Fields())
+		}
+	}
+}
+
+func TestAnomaly_SnapshotRestore(t *testing.T) {
+	for _, model := range []string{"ewma", "mad", "seasonal"} {
+		n, err := newAnomalyNode(nil, &pipeline.AnomalyNode{
+			Field:      "value",
+			Model:      model,
+			Alpha:      0.2,
+			WindowSize: 10,
+			Threshold:  3,
+			Warmup:     2,
+		}, newWindowNodeDiagnostic())
+		if err != nil {
+			t.Fatal(err)
+		}
+		g := n.newGroup()
+		for i := int64(0); i < 5; i++ {
+			if _, err := g.Point(newCheckpointPoint(i, float64(i%2))); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		restored := n.newGroup()
+		snapshotAndRestore(t, g, restored)
+
+		// Both groups must score the next point the same.
+		p := newCheckpointPoint(5, 10.0)
+		exp, err := g.Point(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := restored.Point(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got.(edge.PointMessage).Fields(), exp.(edge.PointMessage).Fields()) {
+			t.Errorf("%s: unexpected fields got %v exp %v", model, got.(edge.PointMessage).Fields(), exp.(edge.PointMessage).Fields())
+		}
+	}
+}
+
+func TestDerivative_SnapshotRestore(t *testing.T) {
+	n := &DerivativeNode{
+		node: node{diag: newWindowNodeDiagnostic()},
+		d: &pipeline.DerivativeNode{
+			Field: "value",
+			As:    "derivative",
+			Unit:  time.Second,
+		},
+	}
+	g := n.newGroup()
+	if _, err := g.Point(newCheckpointPoint(0, 10.0)); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := n.newGroup()
+	snapshotAndRestore(t, g, restored)
+
+	msg, err := restored.Point(newCheckpointPoint(2, 14.0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg == nil {
+		t.Fatal("expected restored derivative to emit a point")
+	}
+	if got, exp := msg.(edge.PointMessage).Fields()["derivative"], 2.0; got != exp {
+		t.Errorf("unexpected derivative got %v exp %v", got, exp)
+	}
+}
+
+func TestStateTrackers_SnapshotRestore(t *testing.T) {
+	start := time.Unix(10, 0).UTC()
+	duration := &stateDurationTracker{sd: &pipeline.StateDurationNode{Unit: time.Second}}
+	duration.track(start, true)
+	restoredDuration := &stateDurationTracker{sd: duration.sd}
+	snapshotAndRestore(t, &stateTrackingGroup{tracker: duration}, &stateTrackingGroup{tracker: restoredDuration})
+	if got := restoredDuration.track(start.Add(5*time.Second), true); got != 5.0 {
+		t.Errorf("unexpected state duration got %v exp 5", got)
+	}
+
+	count := &stateCountTracker{}
+	count.track(start, true)
+	count.track(start, true)
+	restoredCount := &stateCountTracker{}
+	snapshotAndRestore(t, &stateTrackingGroup{tracker: count}, &stateTrackingGroup{tracker: restoredCount})
+	if got := restoredCount.track(start, true); got != int64(3) {
+		t.Errorf("unexpected state count got %v exp 3", got)
+	}
+}
+
+func TestInfluxQLStreamingTransform_SnapshotRestore(t *testing.T) {
+	stream := &pipeline.StreamNode{}
+	pipeline.CreatePipelineSources(stream)
+	from := stream.From()
+	testCases := []struct {
+		name  string
+		n     *pipeline.InfluxQLNode
+		value func(sec int64) interface{}
+	}{
+		{
+			name:  "movingAverage",
+			n:     from.MovingAverage("value", 3),
+			value: func(sec int64) interface{} { return float64(sec * sec) },
+		},
+		{
+			name:  "cumulativeSum float",
+			n:     from.CumulativeSum("value"),
+			value: func(sec int64) interface{} { return float64(sec) + 0.5 },
+		},
+		{
+			name:  "cumulativeSum integer",
+			n:     from.CumulativeSum("value"),
+			value: func(sec int64) interface{} { return sec },
+		},
+		{
+			name:  "difference",
+			n:     from.Difference("value"),
+			value: func(sec int64) interface{} { return float64(sec * sec) },
+		},
+		{
+			name:  "elapsed",
+			n:     from.Elapsed("value", time.Second),
+			value: func(sec int64) interface{} { return float64(sec) },
+		},
+	}
+	for _, tc := range testCases {
+		n, err := newInfluxQLNode(nil, tc.n, newWindowNodeDiagnostic())
+		if err != nil {
+			t.Fatal(err)
+		}
+		first := newCheckpointPoint(0, tc.value(0))
+		g := n.newGroup(first).(*influxqlStreamingTransformGroup)
+		for _, sec := range []int64{0, 1, 3, 3, 6} {
+			if _, err := g.Point(newCheckpointPoint(sec, tc.value(sec))); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		restored := n.newGroup(first).(*influxqlStreamingTransformGroup)
+		snapshotAndRestore(t, g, restored)
+
+		// The restored group must continue the transformation as if it had not been restarted.
+		for _, sec := range []int64{7, 9, 12} {
+			p := newCheckpointPoint(sec, tc.value(sec))
+			exp, err := g.Point(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := restored.Point(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if exp == nil || got == nil {
+				t.Fatalf("%s: expected both groups to emit a point at %d got %v exp %v", tc.name, sec, got, exp)
+			}
+			gp, ep := got.(edge.PointMessage), exp.(edge.PointMessage)
+			if !gp.Time().Equal(ep.Time()) || !reflect.DeepEqual(gp.Fields(), ep.Fields()) {
+				t.Errorf("%s: unexpected point at %d got %v %v exp %v %v", tc.name, sec, gp.Time(), gp.Fields(), ep.Time(), ep.Fields())
+			}
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// An `anomaly` node scores the values of a field against an online model of each group
+// and adds the result to each point in the fields:
+//
+//   - score - the deviation of the value from the expected value, in units of the spread of the model
+//   - is_anomaly - whether the absolute score exceeds the threshold
+//   - expected - the expected value
+//   - lower - the lower bound of the expected range, the expected value minus threshold times the spread
+//   - upper - the upper bound of the expected range, the expected value plus threshold times the spread
+//
+// Each value is scored before it is added to the model.
+// The spread is at least a billionth of the expected value, or of one if the expected value is smaller,
+// so that any deviation from a flat signal scores as an anomaly.
+// Available models are:
+//
+//   - ewma - the default, an exponentially weighted moving mean and variance, the spread is the standard deviation
+//   - mad - the median and median absolute deviation of the last values, the spread is the scaled deviation
+//   - seasonal - an ewma model per hour of the week, in UTC
+//
+// Points are not reported as anomalies until the model, or the hour of the week
+// of a seasonal model, has observed the warmup number of values.
+// Points without a numerical value of the field are dropped.
+//
+// The models of the groups are included in the state of the task,
+// so that they survive restarts.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('requests')
+//            .groupBy('service')
+//        |anomaly('rate')
+//            .model('seasonal')
+//            .alpha(0.2)
+//            .threshold(4.0)
+//        |alert()
+//            .crit(lambda: "is_anomaly")
+//
+// This example alerts when the request rate of a service is unusual for the hour of the week.
+type AnomalyNode struct {
+	chainnode `json:"-"`
+
+	// The field to score.
+	// tick:ignore
+	Field string `json:"field"`
+
+	// The model of the values of each group, one of ewma, mad or seasonal.
+	// Default: ewma
+	Model string `json:"model"`
+
+	// The weight of each new value in the ewma and seasonal models, between 0 and 1.
+	// Default: 0.1
+	Alpha float64 `json:"alpha"`
+
+	// The number of last values of the mad model.
+	// Default: 100
+	WindowSize int64 `json:"windowSize"`
+
+	// The absolute score above which a value is an anomaly.
+	// Default: 3.0
+	Threshold float64 `json:"threshold"`
+
+	// The number of values the model must have observed before values are reported as anomalies.
+	// Default: 10
+	Warmup int64 `json:"warmup"`
+}
+
+func newAnomalyNode(wants EdgeType, field string) *AnomalyNode {
+	return &AnomalyNode{
+		chainnode:  newBasicChainNode("anomaly", wants, wants),
+		Field:      field,
+		Model:      "ewma",
+		Alpha:      0.1,
+		WindowSize: 100,
+		Threshold:  3.0,
+		Warmup:     10,
+	}
+}
+
+// MarshalJSON converts AnomalyNode to JSON
+// tick:ignore
+func (n *AnomalyNode) MarshalJSON() ([]byte, error) {
+	type Alias AnomalyNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		TypeOf: TypeOf{
+			Type: "anomaly",
+			ID:   n.ID(),
+		},
+		Alias: (*Alias)(n),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an AnomalyNode
+// tick:ignore
+func (n *AnomalyNode) UnmarshalJSON(data []byte) error {
+	type Alias AnomalyNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "anomaly" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as AnomalyNode", raw.ID, raw.Type)
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *AnomalyNode) validate() error {
+	if n.Field == "" {
+		return errors.New("anomaly requires a field")
+	}
+	switch n.Model {
+	case "ewma", "mad", "seasonal":
+	default:
+		return fmt.Errorf("unexpected model %q, must be one of ewma, mad or seasonal", n.Model)
+	}
+	if n.Alpha <= 0 || n.Alpha > 1 {
+		return fmt.Errorf("alpha must be greater than 0 and at most 1, got %v", n.Alpha)
+	}
+	if n.WindowSize < 1 {
+		return fmt.Errorf("windowSize must be >= 1, got %d", n.WindowSize)
+	}
+	if n.Threshold <= 0 {
+		return fmt.Errorf("threshold must be > 0, got %v", n.Threshold)
+	}
+	if n.Warmup < 0 {
+		return fmt.Errorf("warmup must be >= 0, got %d", n.Warmup)
+	}
+	return nil
+}
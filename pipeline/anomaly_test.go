@@ -0,0 +1,98 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestAnomalyNode_MarshalJSON(t *testing.T) {
+	a := newAnomalyNode(StreamEdge, "value")
+	a.Model = "mad"
+	MarshalTestHelper(t, a, false, `{"typeOf":"anomaly","id":"0","field":"value","model":"mad","alpha":0.1,"windowSize":100,"threshold":3,"warmup":10}`)
+}
+
+func TestAnomalyNode_UnmarshalJSON(t *testing.T) {
+	a := &AnomalyNode{}
+	input := `{"typeOf":"anomaly","id":"3","field":"value","model":"seasonal","alpha":0.2,"windowSize":50,"threshold":4,"warmup":5}`
+	if err := json.Unmarshal([]byte(input), a); err != nil {
+		t.Fatal(err)
+	}
+	if a.ID() != 3 {
+		t.Errorf("unexpected id got %v exp 3", a.ID())
+	}
+	if a.Field != "value" || a.Model != "seasonal" || a.Alpha != 0.2 || a.WindowSize != 50 || a.Threshold != 4 || a.Warmup != 5 {
+		t.Errorf("unexpected anomaly node %+v", a)
+	}
+	if err := a.validate(); err != nil {
+		t.Errorf("unexpected validation error %v", err)
+	}
+
+	if err := json.Unmarshal([]byte(`{"typeOf":"window","id":"0"}`), &AnomalyNode{}); err == nil {
+		t.Error("expected error unmarshaling node of another type")
+	}
+}
+
+func TestAnomalyNode_Validate(t *testing.T) {
+	testCases := []struct {
+		name   string
+		modify func(a *AnomalyNode)
+		err    bool
+	}{
+		{
+			name:   "defaults",
+			modify: func(a *AnomalyNode) {},
+		},
+		{
+			name: "unknown model",
+			modify: func(a *AnomalyNode) {
+				a.Model = "prophet"
+			},
+			err: true,
+		},
+		{
+			name: "zero alpha",
+			modify: func(a *AnomalyNode) {
+				a.Alpha = 0
+			},
+			err: true,
+		},
+		{
+			name: "alpha above one",
+			modify: func(a *AnomalyNode) {
+				a.Alpha = 1.5
+			},
+			err: true,
+		},
+		{
+			name: "empty window",
+			modify: func(a *AnomalyNode) {
+				a.WindowSize = 0
+			},
+			err: true,
+		},
+		{
+			name: "zero threshold",
+			modify: func(a *AnomalyNode) {
+				a.Threshold = 0
+			},
+			err: true,
+		},
+		{
+			name: "negative warmup",
+			modify: func(a *AnomalyNode) {
+				a.Warmup = -1
+			},
+			err: true,
+		},
+	}
+	for _, tc := range testCases {
+		a := newAnomalyNode(StreamEdge, "value")
+		tc.modify(a)
+		err := a.validate()
+		if tc.err && err == nil {
+			t.Errorf("%s: expected validation error", tc.name)
+		} else if !tc.err && err != nil {
+			t.Errorf("%s: unexpected validation error %v", tc.name, err)
+		}
+	}
+}
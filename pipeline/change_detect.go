@@ -59,19 +59,97 @@ import (
 // packets in=1,out=0 0000000001
 // packets in=1,out=1 0000000002
 // packets in=2,out=1 0000000004
+//
+// Numeric fields that jitter around a value can be given a tolerance,
+// changes within the tolerance of the last emitted value are not considered a change.
+// The names of the fields that changed can be added to the emitted points.
+//
+// Example:
+//     stream
+//         |from()
+//             .measurement('sensors')
+//         |changeDetect('temperature', 'pressure')
+//             .tolerance('temperature', 0.5)
+//             .percentTolerance('pressure', 2.0)
+//             .changedFieldsTag('changed_fields')
+//         ...
+//
+// The first point of each group is only emitted with `.emitFirst()` and only when no state of the group was persisted.
+// Batch data is compared with the last emitted values across batches, the same as stream data.
 type ChangeDetectNode struct {
 	chainnode `json:"-"`
 
 	// The field to use when calculating the changeDetect
 	// tick:ignore
 	Fields []string `json:"fields"`
+
+	// Absolute tolerance of numeric fields.
+	// tick:ignore
+	Tolerances map[string]float64 `tick:"Tolerance" json:"tolerances"`
+
+	// Tolerance of numeric fields as a percentage of the last emitted value.
+	// tick:ignore
+	PercentTolerances map[string]float64 `tick:"PercentTolerance" json:"percentTolerances"`
+
+	// Emit the first point of a group.
+	// tick:ignore
+	EmitFirstFlag bool `tick:"EmitFirst" json:"emitFirst"`
+
+	// Optional tag key to add to emitted points,
+	// containing the comma separated sorted names of the fields that changed.
+	ChangedFieldsTag string `json:"changedFieldsTag"`
+	// Optional field key to add to emitted points,
+	// containing the comma separated sorted names of the fields that changed.
+	ChangedFieldsField string `json:"changedFieldsField"`
 }
 
 func newChangeDetectNode(wants EdgeType, fields []string) *ChangeDetectNode {
 	return &ChangeDetectNode{
-		chainnode: newBasicChainNode("changeDetect", wants, wants),
-		Fields:    fields,
+		chainnode:         newBasicChainNode("changeDetect", wants, wants),
+		Fields:            fields,
+		Tolerances:        make(map[string]float64),
+		PercentTolerances: make(map[string]float64),
+	}
+}
+
+// Tolerance sets the absolute tolerance of a numeric field.
+// A change of the field is only detected if its value differs
+// from the last emitted value by more than the tolerance.
+// tick:property
+func (n *ChangeDetectNode) Tolerance(field string, tolerance float64) *ChangeDetectNode {
+	n.Tolerances[field] = tolerance
+	return n
+}
+
+// PercentTolerance sets the tolerance of a numeric field as a percentage of the last emitted value.
+// A change of the field is only detected if its value differs
+// from the last emitted value by more than the percentage of the last emitted value.
+// tick:property
+func (n *ChangeDetectNode) PercentTolerance(field string, percent float64) *ChangeDetectNode {
+	n.PercentTolerances[field] = percent
+	return n
+}
+
+// EmitFirst emits the first point of a group,
+// by default the point only becomes the value that later points are compared with.
+// tick:property
+func (n *ChangeDetectNode) EmitFirst() *ChangeDetectNode {
+	n.EmitFirstFlag = true
+	return n
+}
+
+func (n *ChangeDetectNode) validate() error {
+	for field, tolerance := range n.Tolerances {
+		if tolerance < 0 {
+			return fmt.Errorf("tolerance of field %q must be >= 0, got %v", field, tolerance)
+		}
+	}
+	for field, percent := range n.PercentTolerances {
+		if percent < 0 {
+			return fmt.Errorf("percent tolerance of field %q must be >= 0, got %v", field, percent)
+		}
 	}
+	return nil
 }
 
 // MarshalJSON converts ChangeDetectNode to JSON
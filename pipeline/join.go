@@ -53,6 +53,9 @@ const (
 //
 // In the above example the `errors` and `requests` streams are joined
 // and then transformed to calculate a combined field.
+//
+// An as-of join, see the JoinNode.AsOf property, joins each point of the first parent
+// with the most recent point of each other parent instead of points with the same time.
 type JoinNode struct {
 	chainnode `json:"-"`
 	// The alias names of the two parents.
@@ -123,7 +126,22 @@ type JoinNode struct {
 	//        // drop any points that are in maintenance mode.
 	//        |where(lambda: "maintlock.mode")
 	//        |...
+	//
+	// For as-of joins a numerical or null fill implies a left outer join,
+	// every point of the first parent is joined and the fields of parents without a match are filled.
 	Fill interface{} `json:"fill"`
+
+	// Whether the join is an as-of join.
+	// tick:ignore
+	AsOfFlag bool `tick:"AsOf" json:"asOf"`
+
+	// The maximum distance in time of a nearest match of an as-of join.
+	// If zero the most recent point at or before the time of the point of the first parent is matched.
+	Nearest time.Duration `json:"nearest"`
+
+	// The maximum age of the matched points of each parent of an as-of join.
+	// tick:ignore
+	StalenessDurations []time.Duration `tick:"Staleness" json:"staleness"`
 }
 
 func newJoinNode(e EdgeType, parents []Node) *JoinNode {
@@ -144,7 +162,9 @@ func (n *JoinNode) MarshalJSON() ([]byte, error) {
 	var raw = &struct {
 		TypeOf
 		*Alias
-		Tolerance string `json:"tolerance"`
+		Tolerance string   `json:"tolerance"`
+		Nearest   string   `json:"nearest"`
+		Staleness []string `json:"staleness"`
 	}{
 		TypeOf: TypeOf{
 			Type: "join",
@@ -152,6 +172,10 @@ func (n *JoinNode) MarshalJSON() ([]byte, error) {
 		},
 		Alias:     (*Alias)(n),
 		Tolerance: influxql.FormatDuration(n.Tolerance),
+		Nearest:   influxql.FormatDuration(n.Nearest),
+	}
+	for _, d := range n.StalenessDurations {
+		raw.Staleness = append(raw.Staleness, influxql.FormatDuration(d))
 	}
 	return json.Marshal(raw)
 }
@@ -163,7 +187,9 @@ func (n *JoinNode) UnmarshalJSON(data []byte) error {
 	var raw = &struct {
 		TypeOf
 		*Alias
-		Tolerance string `json:"tolerance"`
+		Tolerance string   `json:"tolerance"`
+		Nearest   string   `json:"nearest"`
+		Staleness []string `json:"staleness"`
 	}{
 		Alias: (*Alias)(n),
 	}
@@ -178,6 +204,21 @@ func (n *JoinNode) UnmarshalJSON(data []byte) error {
 	if err != nil {
 		return err
 	}
+	// As-of joins are optional, joins encoded before they existed do not set them.
+	if raw.Nearest != "" {
+		n.Nearest, err = influxql.ParseDuration(raw.Nearest)
+		if err != nil {
+			return err
+		}
+	}
+	n.StalenessDurations = nil
+	for _, s := range raw.Staleness {
+		d, err := influxql.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		n.StalenessDurations = append(n.StalenessDurations, d)
+	}
 	n.setID(raw.ID)
 	return nil
 }
@@ -232,6 +273,62 @@ func (j *JoinNode) On(dims ...string) *JoinNode {
 	return j
 }
 
+// Join each point of the first parent with the most recent point of each other parent,
+// keyed by the group, or the `on` dimensions if set.
+// The joined point has the time, tags and dimensions of the point of the first parent.
+// Points of the other parents are matched as they are received,
+// so the points of the first parent are joined with the data known at the time they arrive.
+//
+// Without the `nearest` property points of the first parent are joined as they arrive.
+// With the `nearest` property the point nearest in time within the duration is matched,
+// this may be a point after the point of the first parent.
+// The join waits for the other parents to pass the time of the point plus the nearest duration,
+// but once the first parent is ahead of that time by the staleness of a parent, or by the nearest
+// duration if the staleness is unlimited, the point is joined with the points that parent sent so far.
+//
+// The `staleness` property limits the age of matched points.
+// Keys are forgotten once all their points are older than the staleness,
+// without staleness the most recent point of every key is kept.
+// Without a numerical or null fill points of the first parent without a match from every other parent are dropped.
+//
+// As-of joins can only be applied to stream edges and do not use the tolerance property.
+//
+// Example:
+//    var meter = stream
+//        |from()
+//            .measurement('meter')
+//            .groupBy('building')
+//    var sensor = stream
+//        |from()
+//            .measurement('sensor')
+//            .groupBy('building', 'room')
+//    sensor
+//        |join(meter)
+//            .as('sensor', 'meter')
+//            .on('building')
+//            .asOf()
+//            // meter readings older than 15 minutes are not matched
+//            .staleness(15m)
+//            // keep the sensor points without a recent meter reading
+//            .fill('null')
+//
+// tick:property
+func (j *JoinNode) AsOf() *JoinNode {
+	j.AsOfFlag = true
+	return j
+}
+
+// The maximum age of the points matched by an as-of join.
+// Either a single duration for all parents, or one duration per parent,
+// where the duration of the first parent is ignored.
+// A zero duration does not limit the age of the matched points.
+//
+// tick:property
+func (j *JoinNode) Staleness(durations ...time.Duration) *JoinNode {
+	j.StalenessDurations = durations
+	return j
+}
+
 // Validate that the as() specification is consistent with the number of join arms.
 func (j *JoinNode) validate() error {
 	if len(j.Names) == 0 {
@@ -258,5 +355,26 @@ func (j *JoinNode) validate() error {
 		names[name] = true
 	}
 
+	if !j.AsOfFlag {
+		if j.Nearest != 0 || len(j.StalenessDurations) != 0 {
+			return fmt.Errorf("nearest and staleness can only be used with an as-of join, see .asOf() property method")
+		}
+		return nil
+	}
+	if j.Wants() != StreamEdge {
+		return fmt.Errorf("as-of joins can only be applied to stream edges")
+	}
+	if j.Nearest < 0 {
+		return fmt.Errorf("nearest must not be negative")
+	}
+	if l := len(j.StalenessDurations); l > 1 && l != len(j.Parents()) {
+		return fmt.Errorf("number of staleness durations must be one or match the number of joined streams")
+	}
+	for _, d := range j.StalenessDurations {
+		if d < 0 {
+			return fmt.Errorf("staleness must not be negative")
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,133 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newAsOfJoinNode() *JoinNode {
+	stream1 := &StreamNode{}
+	stream2 := &StreamNode{}
+	CreatePipelineSources(stream1, stream2)
+	j := stream1.From().Join(stream2.From())
+	j.As("sensor", "meter").On("building").AsOf()
+	return j
+}
+
+func TestJoinNode_MarshalJSON_AsOf(t *testing.T) {
+	j := newAsOfJoinNode()
+	j.Nearest = time.Minute
+	j.Staleness(0, 15*time.Minute)
+	MarshalTestHelper(t, j, false, `{"typeOf":"join","id":"5","as":["sensor","meter"],"on":["building"],"delimiter":".","streamName":"","fill":null,"asOf":true,"tolerance":"0s","nearest":"1m","staleness":["0s","15m"]}`)
+}
+
+func TestJoinNode_UnmarshalJSON_AsOf(t *testing.T) {
+	j := &JoinNode{}
+	input := `{"typeOf":"join","id":"2","as":["sensor","meter"],"on":["building"],"delimiter":".","streamName":"","tolerance":"0s","fill":null,"asOf":true,"nearest":"1m","staleness":["0s","15m"]}`
+	if err := json.Unmarshal([]byte(input), j); err != nil {
+		t.Fatal(err)
+	}
+	if !j.AsOfFlag {
+		t.Error("expected an as-of join")
+	}
+	if j.Nearest != time.Minute {
+		t.Errorf("unexpected nearest got %v exp 1m", j.Nearest)
+	}
+	if exp := []time.Duration{0, 15 * time.Minute}; !reflect.DeepEqual(j.StalenessDurations, exp) {
+		t.Errorf("unexpected staleness got %v exp %v", j.StalenessDurations, exp)
+	}
+
+	// Joins encoded before as-of joins existed.
+	j = &JoinNode{}
+	input = `{"typeOf":"join","id":"2","as":["a","b"],"on":null,"delimiter":".","streamName":"","tolerance":"1s","fill":null}`
+	if err := json.Unmarshal([]byte(input), j); err != nil {
+		t.Fatal(err)
+	}
+	if j.AsOfFlag || j.Nearest != 0 || j.StalenessDurations != nil {
+		t.Errorf("unexpected as-of properties %t %v %v", j.AsOfFlag, j.Nearest, j.StalenessDurations)
+	}
+}
+
+func TestJoinNode_ValidateAsOf(t *testing.T) {
+	testCases := []struct {
+		name   string
+		modify func(j *JoinNode)
+		err    bool
+	}{
+		{
+			name:   "as-of",
+			modify: func(j *JoinNode) {},
+		},
+		{
+			name: "nearest and staleness per parent",
+			modify: func(j *JoinNode) {
+				j.Nearest = time.Minute
+				j.Staleness(0, time.Hour)
+			},
+		},
+		{
+			name: "staleness for all parents",
+			modify: func(j *JoinNode) {
+				j.Staleness(time.Hour)
+			},
+		},
+		{
+			name: "nearest without as-of",
+			modify: func(j *JoinNode) {
+				j.AsOfFlag = false
+				j.Nearest = time.Minute
+			},
+			err: true,
+		},
+		{
+			name: "staleness without as-of",
+			modify: func(j *JoinNode) {
+				j.AsOfFlag = false
+				j.Staleness(time.Minute)
+			},
+			err: true,
+		},
+		{
+			name: "negative nearest",
+			modify: func(j *JoinNode) {
+				j.Nearest = -time.Minute
+			},
+			err: true,
+		},
+		{
+			name: "negative staleness",
+			modify: func(j *JoinNode) {
+				j.Staleness(-time.Minute)
+			},
+			err: true,
+		},
+		{
+			name: "staleness count",
+			modify: func(j *JoinNode) {
+				j.Staleness(0, time.Minute, time.Hour)
+			},
+			err: true,
+		},
+	}
+	for _, tc := range testCases {
+		j := newAsOfJoinNode()
+		tc.modify(j)
+		err := j.validate()
+		if tc.err && err == nil {
+			t.Errorf("%s: expected validation error", tc.name)
+		} else if !tc.err && err != nil {
+			t.Errorf("%s: unexpected validation error %v", tc.name, err)
+		}
+	}
+
+	batch1 := &BatchNode{}
+	batch2 := &BatchNode{}
+	CreatePipelineSources(batch1, batch2)
+	j := batch1.Query("SELECT value FROM a").Join(batch2.Query("SELECT value FROM b"))
+	j.As("a", "b").AsOf()
+	if err := j.validate(); err == nil {
+		t.Error("expected validation error for as-of join of batch edges")
+	}
+}
@@ -183,7 +183,10 @@ func init() {
 		"stats":             func(parent chainnodeAlias) Node { return parent.Stats(0) },
 		"stateDuration":     func(parent chainnodeAlias) Node { return parent.StateDuration(nil) },
 		"stateCount":        func(parent chainnodeAlias) Node { return parent.StateCount(nil) },
+		"stateWindow":       func(parent chainnodeAlias) Node { return parent.StateWindow() },
 		"shift":             func(parent chainnodeAlias) Node { return parent.Shift(0) },
+		"resample":          func(parent chainnodeAlias) Node { return parent.Resample(0) },
+		"anomaly":           func(parent chainnodeAlias) Node { return parent.Anomaly("") },
 		"sideload":          func(parent chainnodeAlias) Node { return parent.Sideload() },
 		"sample":            func(parent chainnodeAlias) Node { return parent.Sample(0) },
 		"log":               func(parent chainnodeAlias) Node { return parent.Log() },
@@ -512,6 +515,7 @@ func isChainNode(node Node) (chainnodeAlias, bool) {
 // chainnodeAlias is used to check for the presence of a chain node
 type chainnodeAlias interface {
 	Alert() *AlertNode
+	Anomaly(string) *AnomalyNode
 	Bottom(int64, string, ...string) *InfluxQLNode
 	Children() []Node
 	Combine(...*ast.LambdaNode) *CombineNode
@@ -550,6 +554,7 @@ type chainnodeAlias interface {
 	Parents() []Node
 	Percentile(string, float64) *InfluxQLNode
 	Provides() EdgeType
+	Resample(time.Duration) *ResampleNode
 	Sample(interface{}) *SampleNode
 	SetName(string)
 	Shift(time.Duration) *ShiftNode
@@ -557,6 +562,7 @@ type chainnodeAlias interface {
 	Spread(string) *InfluxQLNode
 	StateCount(*ast.LambdaNode) *StateCountNode
 	StateDuration(*ast.LambdaNode) *StateDurationNode
+	StateWindow() *StateWindowNode
 	Stats(time.Duration) *StatsNode
 	Stddev(string) *InfluxQLNode
 	Sum(string) *InfluxQLNode
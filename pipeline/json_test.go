@@ -55,7 +55,9 @@ func TestPipeline_MarshalJSON(t *testing.T) {
             "periodCount": 0,
             "everyCount": 0,
             "period": "10s",
-            "every": "1s"
+            "every": "1s",
+            "sessionGap": "0s",
+            "maxSessionLength": "0s"
         }
     ],
     "edges": [
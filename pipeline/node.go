@@ -529,6 +529,37 @@ func (n *chainnode) StateCount(expression *ast.LambdaNode) *StateCountNode {
 	return sc
 }
 
+// Create a node that windows the stream by a state defined by lambda expressions.
+//
+// NOTE: StateWindow can only be applied to stream edges.
+func (n *chainnode) StateWindow() *StateWindowNode {
+	if n.Provides() != StreamEdge {
+		panic("cannot StateWindow batch edge")
+	}
+	s := newStateWindowNode()
+	n.linkChild(s)
+	return s
+}
+
+// Create a node that emits one point per group per interval.
+//
+// NOTE: Resample can only be applied to stream edges.
+func (n *chainnode) Resample(interval time.Duration) *ResampleNode {
+	if n.Provides() != StreamEdge {
+		panic("cannot Resample batch edge")
+	}
+	r := newResampleNode(interval)
+	n.linkChild(r)
+	return r
+}
+
+// Create a node that scores the values of a field against an online model of each group.
+func (n *chainnode) Anomaly(field string) *AnomalyNode {
+	a := newAnomalyNode(n.Provides(), field)
+	n.linkChild(a)
+	return a
+}
+
 // Create a node that can load data from external sources
 func (n *chainnode) Sideload() *SideloadNode {
 	s := newSideloadNode(n.provides)
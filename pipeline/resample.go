@@ -0,0 +1,167 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/influxql"
+)
+
+// A `resample` node emits exactly one point per group per interval.
+// The fields of the points of an interval are aggregated into the point of the interval,
+// intervals without points are filled according to the `fill` property.
+// The time of each emitted point is the start of its interval.
+//
+// Intervals start with the first point of each group, use the `align` property
+// to align the intervals with multiples of the interval instead.
+// An interval is emitted once the group receives a point or barrier at or after its end.
+// Use a `barrier` node to emit the intervals of groups that no longer receive data.
+//
+// The regularly spaced output is suited for nodes that assume regular spacing,
+// such as `derivative`, `join` and `holtWinters`.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('meter')
+//            .groupBy('building')
+//        |resample(1m)
+//            .align()
+//            .aggregate('last')
+//            .fill('linear')
+//        |derivative('kwh')
+//            .unit(1m)
+//
+// This example computes the energy used per minute from irregularly reported meter readings.
+type ResampleNode struct {
+	chainnode `json:"-"`
+
+	// The interval of the emitted points.
+	// tick:ignore
+	Interval time.Duration `json:"interval"`
+
+	// Whether to align the intervals with multiples of the interval.
+	// tick:ignore
+	AlignFlag bool `tick:"Align" json:"align"`
+
+	// The aggregation of the values of a field within an interval.
+	// Options are:
+	//
+	//   - mean - the default
+	//   - first
+	//   - last
+	//   - min
+	//   - max
+	//   - sum
+	//
+	// Fields that are not numerical always use the first or last value.
+	Aggregate string `json:"aggregate"`
+
+	// Fill the intervals without points.
+	// Options are:
+	//
+	//   - Any numerical value
+	//   - null - the default, fields are set to null
+	//   - previous - reports the values of the previous interval
+	//   - linear - reports the results of linear interpolation
+	//
+	// Filled points have the fields of the previous interval with points.
+	// Linear interpolation is between the aggregated values of the previous and the next interval with points,
+	// fields that are not numerical or are missing from the next interval report the previous value.
+	// With linear fill the intervals without points are emitted once the next interval with points ends.
+	Fill interface{} `json:"fill"`
+
+	// The maximum number of intervals filled after an interval with points,
+	// the remaining intervals of a longer gap are not emitted.
+	// Default: 100
+	MaxFill int64 `json:"maxFill"`
+}
+
+func newResampleNode(interval time.Duration) *ResampleNode {
+	return &ResampleNode{
+		chainnode: newBasicChainNode("resample", StreamEdge, StreamEdge),
+		Interval:  interval,
+		Aggregate: "mean",
+		MaxFill:   100,
+	}
+}
+
+// MarshalJSON converts ResampleNode to JSON
+// tick:ignore
+func (n *ResampleNode) MarshalJSON() ([]byte, error) {
+	type Alias ResampleNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Interval string `json:"interval"`
+	}{
+		TypeOf: TypeOf{
+			Type: "resample",
+			ID:   n.ID(),
+		},
+		Alias:    (*Alias)(n),
+		Interval: influxql.FormatDuration(n.Interval),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an ResampleNode
+// tick:ignore
+func (n *ResampleNode) UnmarshalJSON(data []byte) error {
+	type Alias ResampleNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Interval string `json:"interval"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "resample" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as ResampleNode", raw.ID, raw.Type)
+	}
+	n.Interval, err = influxql.ParseDuration(raw.Interval)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+// Align the intervals with multiples of the interval.
+// tick:property
+func (n *ResampleNode) Align() *ResampleNode {
+	n.AlignFlag = true
+	return n
+}
+
+func (n *ResampleNode) validate() error {
+	if n.Interval <= 0 {
+		return errors.New("resample interval must be positive")
+	}
+	switch n.Aggregate {
+	case "mean", "first", "last", "min", "max", "sum":
+	default:
+		return fmt.Errorf("unexpected aggregate %q, must be one of mean, first, last, min, max or sum", n.Aggregate)
+	}
+	if n.MaxFill < 0 {
+		return fmt.Errorf("maxFill must be >= 0, got %d", n.MaxFill)
+	}
+	switch fill := n.Fill.(type) {
+	case nil, int64, float64:
+	case string:
+		switch fill {
+		case "null", "previous", "linear":
+		default:
+			return fmt.Errorf("unexpected fill option %s", fill)
+		}
+	default:
+		return fmt.Errorf("unexpected fill type %T", fill)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestResampleNode_MarshalJSON(t *testing.T) {
+	r := newResampleNode(time.Minute)
+	r.Align()
+	r.Fill = "previous"
+	MarshalTestHelper(t, r, false, `{"typeOf":"resample","id":"0","align":true,"aggregate":"mean","fill":"previous","maxFill":100,"interval":"1m"}`)
+}
+
+func TestResampleNode_UnmarshalJSON(t *testing.T) {
+	r := newResampleNode(0)
+	input := `{"typeOf":"resample","id":"3","interval":"1m","align":true,"aggregate":"max","fill":0.5}`
+	if err := json.Unmarshal([]byte(input), r); err != nil {
+		t.Fatal(err)
+	}
+	if r.ID() != 3 {
+		t.Errorf("unexpected id got %v exp 3", r.ID())
+	}
+	if r.Interval != time.Minute || !r.AlignFlag || r.Aggregate != "max" || r.Fill != 0.5 || r.MaxFill != 100 {
+		t.Errorf("unexpected resample node %v %t %s %v %d", r.Interval, r.AlignFlag, r.Aggregate, r.Fill, r.MaxFill)
+	}
+	if err := r.validate(); err != nil {
+		t.Errorf("unexpected validation error %v", err)
+	}
+
+	if err := json.Unmarshal([]byte(`{"typeOf":"window","id":"0"}`), &ResampleNode{}); err == nil {
+		t.Error("expected error unmarshaling node of another type")
+	}
+}
+
+func TestResampleNode_Validate(t *testing.T) {
+	testCases := []struct {
+		name   string
+		modify func(r *ResampleNode)
+		err    bool
+	}{
+		{
+			name:   "defaults",
+			modify: func(r *ResampleNode) {},
+		},
+		{
+			name: "constant fill",
+			modify: func(r *ResampleNode) {
+				r.Fill = int64(0)
+			},
+		},
+		{
+			name: "zero interval",
+			modify: func(r *ResampleNode) {
+				r.Interval = 0
+			},
+			err: true,
+		},
+		{
+			name: "unknown aggregate",
+			modify: func(r *ResampleNode) {
+				r.Aggregate = "median"
+			},
+			err: true,
+		},
+		{
+			name: "negative maxFill",
+			modify: func(r *ResampleNode) {
+				r.MaxFill = -1
+			},
+			err: true,
+		},
+		{
+			name: "unknown fill",
+			modify: func(r *ResampleNode) {
+				r.Fill = "none"
+			},
+			err: true,
+		},
+	}
+	for _, tc := range testCases {
+		r := newResampleNode(time.Minute)
+		tc.modify(r)
+		err := r.validate()
+		if tc.err && err == nil {
+			t.Errorf("%s: expected validation error", tc.name)
+		} else if !tc.err && err != nil {
+			t.Errorf("%s: unexpected validation error %v", tc.name, err)
+		}
+	}
+}
@@ -17,10 +17,23 @@ import (
 // Add a field `cpu_threshold` and a tag `foo` to each point based on the value loaded from the hierarchical source.
 // The list of templates in the `.order()` property are evaluated using the points tags.
 // The files paths are checked then checked in order for the specified keys and the first value that is found is used.
+//
+// Values can also be loaded from Redis, where each path of the order list is a key holding a hash
+// or a JSON object, or from an HTTP server returning a JSON object for each path.
+//
+// Example:
+//        |sideload()
+//             .source('redis://?prefix=thresholds:&notify=true')
+//             .order('device:{{.device}}', 'default')
+//             .field('cpu_threshold', 0.0)
+//
+// Values stored as strings, for example in Redis hashes, are converted to the type of the default value.
 type SideloadNode struct {
 	chainnode
 
-	// Source for the data, currently only `file://` based sources are supported
+	// Source for the data, `file://`, `redis://` and `http(s)://` based sources are supported.
+	// The values of `redis://` and `http(s)://` sources are cached for the duration of the `ttl` query parameter,
+	// and `redis://?notify=true` sources are invalidated by Redis keyspace notifications.
 	Source string `json:"source"`
 
 	// Order is a list of paths that indicate the hierarchical order.
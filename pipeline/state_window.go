@@ -0,0 +1,117 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/influxql"
+	"github.com/thingnario/kapacitor/tick/ast"
+)
+
+// A `stateWindow` node buffers the points of a group while the group is in a state,
+// and emits the points of the state as a batch once the state ends.
+//
+// The state of a group starts with the first point for which the `openWhen` expression is true.
+// It ends with the first following point for which the `closeWhen` expression is true,
+// both points are part of the emitted batch.
+// If the `timeout` property is set the state also ends once it lasted for the timeout,
+// the batch is emitted when the group receives a point after the timeout, or when the node receives any barrier after the timeout.
+// Use a `barrier` node to emit timed out states of groups that no longer receive data.
+//
+// The open states are kept when the task is restarted.
+// When a group is deleted its open state is emitted.
+//
+// Points for which an expression generates an error are discarded.
+// The time of the emitted batch is the time of its last point.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('breaker')
+//            .groupBy('breaker')
+//        |stateWindow()
+//            .openWhen(lambda: "tripped" == TRUE)
+//            .closeWhen(lambda: "tripped" == FALSE)
+//            .timeout(1h)
+//        |max('current')
+//
+// This example emits the maximum current of each span from when a breaker trips until it resets,
+// or of the first hour of the span if the breaker does not reset within the hour.
+type StateWindowNode struct {
+	chainnode `json:"-"`
+
+	// Expression that starts the state of a group.
+	OpenWhen *ast.LambdaNode `json:"openWhen"`
+
+	// Expression that ends the state of a group.
+	CloseWhen *ast.LambdaNode `json:"closeWhen"`
+
+	// Maximum duration of a state, if zero states only end by the closeWhen expression.
+	Timeout time.Duration `json:"timeout"`
+}
+
+func newStateWindowNode() *StateWindowNode {
+	return &StateWindowNode{
+		chainnode: newBasicChainNode("state_window", StreamEdge, BatchEdge),
+	}
+}
+
+// MarshalJSON converts StateWindowNode to JSON
+// tick:ignore
+func (n *StateWindowNode) MarshalJSON() ([]byte, error) {
+	type Alias StateWindowNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Timeout string `json:"timeout"`
+	}{
+		TypeOf: TypeOf{
+			Type: "stateWindow",
+			ID:   n.ID(),
+		},
+		Alias:   (*Alias)(n),
+		Timeout: influxql.FormatDuration(n.Timeout),
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON converts JSON to an StateWindowNode
+// tick:ignore
+func (n *StateWindowNode) UnmarshalJSON(data []byte) error {
+	type Alias StateWindowNode
+	var raw = &struct {
+		TypeOf
+		*Alias
+		Timeout string `json:"timeout"`
+	}{
+		Alias: (*Alias)(n),
+	}
+	err := json.Unmarshal(data, raw)
+	if err != nil {
+		return err
+	}
+	if raw.Type != "stateWindow" {
+		return fmt.Errorf("error unmarshaling node %d of type %s as StateWindowNode", raw.ID, raw.Type)
+	}
+	n.Timeout, err = influxql.ParseDuration(raw.Timeout)
+	if err != nil {
+		return err
+	}
+	n.setID(raw.ID)
+	return nil
+}
+
+func (n *StateWindowNode) validate() error {
+	if n.OpenWhen == nil {
+		return errors.New("stateWindow requires an openWhen expression")
+	}
+	if n.CloseWhen == nil {
+		return errors.New("stateWindow requires a closeWhen expression")
+	}
+	if n.Timeout < 0 {
+		return errors.New("timeout must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/thingnario/kapacitor/tick/ast"
+)
+
+func TestStateWindowNode_MarshalJSON(t *testing.T) {
+	w := newStateWindowNode()
+	w.OpenWhen = &ast.LambdaNode{Expression: &ast.BoolNode{Bool: true}}
+	w.CloseWhen = &ast.LambdaNode{Expression: &ast.BoolNode{Bool: false}}
+	w.Timeout = time.Minute
+	MarshalTestHelper(t, w, false, `{"typeOf":"stateWindow","id":"0","openWhen":{"expression":{"bool":true,"typeOf":"bool"},"typeOf":"lambda"},"closeWhen":{"expression":{"bool":false,"typeOf":"bool"},"typeOf":"lambda"},"timeout":"1m"}`)
+}
+
+func TestStateWindowNode_UnmarshalJSON(t *testing.T) {
+	w := &StateWindowNode{}
+	input := `{"typeOf":"stateWindow","id":"3","openWhen":{"expression":{"bool":true,"typeOf":"bool"},"typeOf":"lambda"},"closeWhen":{"expression":{"bool":false,"typeOf":"bool"},"typeOf":"lambda"},"timeout":"1m"}`
+	if err := json.Unmarshal([]byte(input), w); err != nil {
+		t.Fatal(err)
+	}
+	if w.ID() != 3 {
+		t.Errorf("unexpected id got %v exp 3", w.ID())
+	}
+	if w.Timeout != time.Minute {
+		t.Errorf("unexpected timeout got %v exp 1m", w.Timeout)
+	}
+	if w.OpenWhen == nil || w.CloseWhen == nil {
+		t.Fatal("expected openWhen and closeWhen expressions")
+	}
+	if err := w.validate(); err != nil {
+		t.Errorf("unexpected validation error %v", err)
+	}
+
+	if err := json.Unmarshal([]byte(`{"typeOf":"window","id":"0"}`), &StateWindowNode{}); err == nil {
+		t.Error("expected error unmarshaling node of another type")
+	}
+}
@@ -0,0 +1,31 @@
+package tick
+
+import (
+	"github.com/thingnario/kapacitor/pipeline"
+	"github.com/thingnario/kapacitor/tick/ast"
+)
+
+// AnomalyNode converts the AnomalyNode pipeline node into the TICKScript AST
+type AnomalyNode struct {
+	Function
+}
+
+// NewAnomaly creates an AnomalyNode function builder
+func NewAnomaly(parents []ast.Node) *AnomalyNode {
+	return &AnomalyNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates an AnomalyNode ast.Node
+func (n *AnomalyNode) Build(a *pipeline.AnomalyNode) (ast.Node, error) {
+	n.Pipe("anomaly", a.Field).
+		Dot("model", a.Model).
+		Dot("alpha", a.Alpha).
+		Dot("windowSize", a.WindowSize).
+		Dot("threshold", a.Threshold).
+		DotZeroValueOK("warmup", a.Warmup)
+	return n.prev, n.err
+}
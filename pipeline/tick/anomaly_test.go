@@ -0,0 +1,23 @@
+package tick_test
+
+import "testing"
+
+func TestAnomaly(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	a := from.Anomaly("value")
+	a.Model = "seasonal"
+	a.Alpha = 0.2
+	a.Threshold = 4
+	a.Warmup = 0
+
+	want := `stream
+    |from()
+    |anomaly('value')
+        .model('seasonal')
+        .alpha(0.2)
+        .windowSize(100)
+        .threshold(4.0)
+        .warmup(0)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -136,6 +136,12 @@ func (a *AST) Create(n pipeline.Node, parents []ast.Node) (ast.Node, error) {
 		return NewStateCount(parents).Build(node)
 	case *pipeline.StateDurationNode:
 		return NewStateDuration(parents).Build(node)
+	case *pipeline.StateWindowNode:
+		return NewStateWindow(parents).Build(node)
+	case *pipeline.ResampleNode:
+		return NewResample(parents).Build(node)
+	case *pipeline.AnomalyNode:
+		return NewAnomaly(parents).Build(node)
 	case *pipeline.SwarmAutoscaleNode:
 		return NewSwarmAutoscale(parents).Build(node)
 	case *pipeline.UDFNode:
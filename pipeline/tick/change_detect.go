@@ -1,6 +1,8 @@
 package tick
 
 import (
+	"sort"
+
 	"github.com/thingnario/kapacitor/pipeline"
 	"github.com/thingnario/kapacitor/tick/ast"
 )
@@ -26,5 +28,27 @@ func (n *ChangeDetectNode) Build(d *pipeline.ChangeDetectNode) (ast.Node, error)
 		fields[i] = f
 	}
 	n.Pipe("changeDetect", fields...)
+
+	var toleranceKeys []string
+	for k := range d.Tolerances {
+		toleranceKeys = append(toleranceKeys, k)
+	}
+	sort.Strings(toleranceKeys)
+	for _, k := range toleranceKeys {
+		n.Dot("tolerance", k, d.Tolerances[k])
+	}
+
+	var percentKeys []string
+	for k := range d.PercentTolerances {
+		percentKeys = append(percentKeys, k)
+	}
+	sort.Strings(percentKeys)
+	for _, k := range percentKeys {
+		n.Dot("percentTolerance", k, d.PercentTolerances[k])
+	}
+
+	n.DotIf("emitFirst", d.EmitFirstFlag)
+	n.Dot("changedFieldsTag", d.ChangedFieldsTag)
+	n.Dot("changedFieldsField", d.ChangedFieldsField)
 	return n.prev, n.err
 }
@@ -14,3 +14,22 @@ func TestChangeDetect(t *testing.T) {
 `
 	PipelineTickTestHelper(t, pipe, want)
 }
+
+func TestChangeDetectWithOptions(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	cd := from.ChangeDetect("temperature", "pressure")
+	cd.Tolerance("temperature", 0.5)
+	cd.PercentTolerance("pressure", 2.0)
+	cd.EmitFirst()
+	cd.ChangedFieldsTag = "changed_fields"
+
+	want := `stream
+    |from()
+    |changeDetect('temperature', 'pressure')
+        .tolerance('temperature', 0.5)
+        .percentTolerance('pressure', 2.0)
+        .emitFirst()
+        .changedFieldsTag('changed_fields')
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -2,6 +2,7 @@ package tick
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/thingnario/kapacitor/tick/ast"
 )
@@ -327,6 +328,14 @@ func args(a []string) []interface{} {
 	return r
 }
 
+func dargs(a []time.Duration) []interface{} {
+	r := make([]interface{}, len(a))
+	for i := range a {
+		r[i] = a[i]
+	}
+	return r
+}
+
 func largs(a []*ast.LambdaNode) []interface{} {
 	r := make([]interface{}, len(a))
 	for i := range a {
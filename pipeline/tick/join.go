@@ -31,6 +31,12 @@ func (n *JoinNode) Build(j *pipeline.JoinNode) (ast.Node, error) {
 		Dot("delimiter", j.Delimiter).
 		Dot("streamName", j.StreamName).
 		Dot("tolerance", j.Tolerance).
-		DotNotNil("fill", j.Fill)
+		DotNotNil("fill", j.Fill).
+		DotIf("asOf", j.AsOfFlag).
+		Dot("nearest", j.Nearest)
+	// Zero staleness durations are positional and must be kept.
+	if len(j.StalenessDurations) > 0 {
+		n.DotZeroValueOK("staleness", dargs(j.StalenessDurations)...)
+	}
 	return n.prev, n.err
 }
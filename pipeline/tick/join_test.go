@@ -43,3 +43,42 @@ stream
 `
 	PipelineTickTestHelper(t, pipe, want)
 }
+
+func TestJoinAsOf(t *testing.T) {
+	stream1 := &pipeline.StreamNode{}
+	stream2 := &pipeline.StreamNode{}
+	pipe := pipeline.CreatePipelineSources(stream1, stream2)
+
+	from1 := stream1.From()
+	from1.Measurement = "sensor"
+	from1.GroupBy("building", "room")
+
+	from2 := stream2.From()
+	from2.Measurement = "meter"
+	from2.GroupBy("building")
+
+	join := from1.Join(from2)
+	join.As("sensor", "meter").On("building").AsOf().Staleness(0, 15*time.Minute)
+	join.Nearest = time.Minute
+	join.Fill = "null"
+
+	want := `var from3 = stream
+    |from()
+        .measurement('meter')
+        .groupBy('building')
+
+stream
+    |from()
+        .measurement('sensor')
+        .groupBy('building', 'room')
+    |join(from3)
+        .as('sensor', 'meter')
+        .on('building')
+        .delimiter('.')
+        .fill('null')
+        .asOf()
+        .nearest(1m)
+        .staleness(0s, 15m)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
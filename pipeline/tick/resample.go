@@ -0,0 +1,33 @@
+package tick
+
+import (
+	"github.com/thingnario/kapacitor/pipeline"
+	"github.com/thingnario/kapacitor/tick/ast"
+)
+
+// ResampleNode converts the ResampleNode pipeline node into the TICKScript AST
+type ResampleNode struct {
+	Function
+}
+
+// NewResample creates a ResampleNode function builder
+func NewResample(parents []ast.Node) *ResampleNode {
+	return &ResampleNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a ResampleNode ast.Node
+func (n *ResampleNode) Build(r *pipeline.ResampleNode) (ast.Node, error) {
+	n.Pipe("resample", r.Interval).
+		DotIf("align", r.AlignFlag).
+		Dot("aggregate", r.Aggregate)
+	// A zero fill value is a constant fill and must be kept.
+	if r.Fill != nil {
+		n.DotZeroValueOK("fill", r.Fill)
+	}
+	n.DotZeroValueOK("maxFill", r.MaxFill)
+	return n.prev, n.err
+}
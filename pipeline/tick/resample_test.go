@@ -0,0 +1,38 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResample(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	r := from.Resample(time.Minute).Align()
+	r.Aggregate = "last"
+	r.Fill = "linear"
+
+	want := `stream
+    |from()
+    |resample(1m)
+        .align()
+        .aggregate('last')
+        .fill('linear')
+        .maxFill(100)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
+
+func TestResampleFillZero(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	r := from.Resample(time.Minute)
+	r.Fill = int64(0)
+
+	want := `stream
+    |from()
+    |resample(1m)
+        .aggregate('mean')
+        .fill(0)
+        .maxFill(100)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
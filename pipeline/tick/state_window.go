@@ -0,0 +1,29 @@
+package tick
+
+import (
+	"github.com/thingnario/kapacitor/pipeline"
+	"github.com/thingnario/kapacitor/tick/ast"
+)
+
+// StateWindowNode converts the StateWindowNode pipeline node into the TICKScript AST
+type StateWindowNode struct {
+	Function
+}
+
+// NewStateWindow creates a StateWindowNode function builder
+func NewStateWindow(parents []ast.Node) *StateWindowNode {
+	return &StateWindowNode{
+		Function{
+			Parents: parents,
+		},
+	}
+}
+
+// Build creates a StateWindowNode ast.Node
+func (n *StateWindowNode) Build(s *pipeline.StateWindowNode) (ast.Node, error) {
+	n.Pipe("stateWindow").
+		Dot("openWhen", s.OpenWhen).
+		Dot("closeWhen", s.CloseWhen).
+		Dot("timeout", s.Timeout)
+	return n.prev, n.err
+}
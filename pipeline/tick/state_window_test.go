@@ -0,0 +1,48 @@
+package tick_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/thingnario/kapacitor/tick/ast"
+)
+
+func TestStateWindow(t *testing.T) {
+	pipe, _, from := StreamFrom()
+	opened := &ast.LambdaNode{
+		Expression: &ast.BinaryNode{
+			Left: &ast.ReferenceNode{
+				Reference: "tripped",
+			},
+			Right: &ast.BoolNode{
+				Bool: true,
+			},
+			Operator: ast.TokenEqual,
+		},
+	}
+	closed := &ast.LambdaNode{
+		Expression: &ast.BinaryNode{
+			Left: &ast.ReferenceNode{
+				Reference: "tripped",
+			},
+			Right: &ast.BoolNode{
+				Bool: false,
+			},
+			Operator: ast.TokenEqual,
+		},
+	}
+
+	sw := from.StateWindow()
+	sw.OpenWhen = opened
+	sw.CloseWhen = closed
+	sw.Timeout = time.Hour
+
+	want := `stream
+    |from()
+    |stateWindow()
+        .openWhen(lambda: "tripped" == TRUE)
+        .closeWhen(lambda: "tripped" == FALSE)
+        .timeout(1h)
+`
+	PipelineTickTestHelper(t, pipe, want)
+}
@@ -26,6 +26,8 @@ func (n *WindowNode) Build(w *pipeline.WindowNode) (ast.Node, error) {
 		Dot("every", w.Every).
 		Dot("periodCount", w.PeriodCount).
 		Dot("everyCount", w.EveryCount).
+		Dot("sessionGap", w.SessionGap).
+		Dot("maxSessionLength", w.MaxSessionLength).
 		DotIf("align", w.AlignFlag).
 		DotIf("fillPeriod", w.FillPeriodFlag)
 	return n.prev, n.err
@@ -15,6 +15,8 @@ func TestWindowNode(t *testing.T) {
 		fillPeriod  bool
 		periodCount int64
 		everyCount  int64
+		sessionGap  time.Duration
+		maxSession  time.Duration
 	}
 	tests := []struct {
 		name string
@@ -50,6 +52,19 @@ func TestWindowNode(t *testing.T) {
     |window()
         .periodCount(10)
         .everyCount(15)
+`,
+		},
+		{
+			name: "window with session gap",
+			args: args{
+				sessionGap: 5 * time.Minute,
+				maxSession: time.Hour,
+			},
+			want: `stream
+    |from()
+    |window()
+        .sessionGap(5m)
+        .maxSessionLength(1h)
 `,
 		},
 	}
@@ -65,6 +80,8 @@ func TestWindowNode(t *testing.T) {
 			w.FillPeriodFlag = tt.args.fillPeriod
 			w.PeriodCount = tt.args.periodCount
 			w.EveryCount = tt.args.everyCount
+			w.SessionGap = tt.args.sessionGap
+			w.MaxSessionLength = tt.args.maxSession
 
 			got, err := PipelineTick(pipe)
 			if err != nil {
@@ -31,6 +31,27 @@ import (
 // new data and `5 minutes` of the previous period's data.
 //
 // NOTE: Because no `align` property is defined, the `window` edge is defined relative to the first data point.
+//
+// The `sessionGap` property of `window` windows data by sessions instead of fixed periods.
+// The window of a group stays open until no point arrived for the gap duration,
+// then the window closes and is emitted to the pipeline.
+// The `maxSessionLength` property closes sessions that stay active for longer than the duration.
+//
+// Sessions are closed based on the time of the data the task receives, not the system time,
+// so replaying data produces the same sessions.
+//
+// Example:
+//    stream
+//        |from()
+//            .measurement('machine')
+//            .groupBy('machine')
+//        |window()
+//            .sessionGap(5m)
+//            .maxSessionLength(12h)
+//        |count('value')
+//
+// This example counts the points of each run of a machine,
+// where a run ends once the machine did not report for `5 minutes` or after `12 hours`.
 type WindowNode struct {
 	chainnode `json:"-"`
 	// The period, or length in time, of the window.
@@ -50,6 +71,12 @@ type WindowNode struct {
 	// EveryCount determines how often the window is emitted based on the count of points.
 	// A value of 1 means that every new point will emit the window.
 	EveryCount int64 `json:"everyCount"`
+
+	// SessionGap is the duration without points after which the session window of a group closes.
+	SessionGap time.Duration `json:"sessionGap"`
+	// MaxSessionLength is the maximum duration of a session window.
+	// If zero sessions are only closed by the session gap.
+	MaxSessionLength time.Duration `json:"maxSessionLength"`
 }
 
 func newWindowNode() *WindowNode {
@@ -65,16 +92,20 @@ func (n *WindowNode) MarshalJSON() ([]byte, error) {
 	var raw = &struct {
 		TypeOf
 		*Alias
-		Period string `json:"period"`
-		Every  string `json:"every"`
+		Period           string `json:"period"`
+		Every            string `json:"every"`
+		SessionGap       string `json:"sessionGap"`
+		MaxSessionLength string `json:"maxSessionLength"`
 	}{
 		TypeOf: TypeOf{
 			Type: "window",
 			ID:   n.ID(),
 		},
-		Alias:  (*Alias)(n),
-		Period: influxql.FormatDuration(n.Period),
-		Every:  influxql.FormatDuration(n.Every),
+		Alias:            (*Alias)(n),
+		Period:           influxql.FormatDuration(n.Period),
+		Every:            influxql.FormatDuration(n.Every),
+		SessionGap:       influxql.FormatDuration(n.SessionGap),
+		MaxSessionLength: influxql.FormatDuration(n.MaxSessionLength),
 	}
 	return json.Marshal(raw)
 }
@@ -86,8 +117,10 @@ func (n *WindowNode) UnmarshalJSON(data []byte) error {
 	var raw = &struct {
 		TypeOf
 		*Alias
-		Period string `json:"period"`
-		Every  string `json:"every"`
+		Period           string `json:"period"`
+		Every            string `json:"every"`
+		SessionGap       string `json:"sessionGap"`
+		MaxSessionLength string `json:"maxSessionLength"`
 	}{
 		Alias: (*Alias)(n),
 	}
@@ -109,6 +142,20 @@ func (n *WindowNode) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
+	// Session windows are optional, pipelines encoded before they existed do not set them.
+	if raw.SessionGap != "" {
+		n.SessionGap, err = influxql.ParseDuration(raw.SessionGap)
+		if err != nil {
+			return err
+		}
+	}
+	if raw.MaxSessionLength != "" {
+		n.MaxSessionLength, err = influxql.ParseDuration(raw.MaxSessionLength)
+		if err != nil {
+			return err
+		}
+	}
+
 	n.setID(raw.ID)
 	return nil
 }
@@ -143,5 +190,21 @@ func (w *WindowNode) validate() error {
 	if w.PeriodCount != 0 && w.EveryCount <= 0 {
 		return errors.New("everyCount must be greater than zero")
 	}
+	if w.SessionGap < 0 {
+		return errors.New("sessionGap must not be negative")
+	}
+	if w.MaxSessionLength < 0 {
+		return errors.New("maxSessionLength must not be negative")
+	}
+	if w.SessionGap != 0 {
+		if w.Period != 0 || w.PeriodCount != 0 {
+			return errors.New("cannot specify both sessionGap and period or periodCount")
+		}
+		if w.Every != 0 || w.EveryCount != 0 || w.AlignFlag || w.FillPeriodFlag {
+			return errors.New("session windows are emitted when they close, cannot specify every, everyCount, align or fillPeriod")
+		}
+	} else if w.MaxSessionLength != 0 {
+		return errors.New("maxSessionLength requires sessionGap")
+	}
 	return nil
 }
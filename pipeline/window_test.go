@@ -9,12 +9,14 @@ import (
 
 func TestWindowNode_MarshalJSON(t *testing.T) {
 	type fields struct {
-		Period         time.Duration
-		Every          time.Duration
-		AlignFlag      bool
-		FillPeriodFlag bool
-		PeriodCount    int64
-		EveryCount     int64
+		Period           time.Duration
+		Every            time.Duration
+		AlignFlag        bool
+		FillPeriodFlag   bool
+		PeriodCount      int64
+		EveryCount       int64
+		SessionGap       time.Duration
+		MaxSessionLength time.Duration
 	}
 	tests := []struct {
 		name    string
@@ -32,7 +34,7 @@ func TestWindowNode_MarshalJSON(t *testing.T) {
 				PeriodCount:    1,
 				EveryCount:     2,
 			},
-			want: `{"typeOf":"window","id":"0","align":true,"fillPeriod":true,"periodCount":1,"everyCount":2,"period":"1h","every":"1m"}`,
+			want: `{"typeOf":"window","id":"0","align":true,"fillPeriod":true,"periodCount":1,"everyCount":2,"period":"1h","every":"1m","sessionGap":"0s","maxSessionLength":"0s"}`,
 		},
 		{
 			name: "only period and every",
@@ -40,7 +42,15 @@ func TestWindowNode_MarshalJSON(t *testing.T) {
 				Period: time.Hour,
 				Every:  time.Minute,
 			},
-			want: `{"typeOf":"window","id":"0","align":false,"fillPeriod":false,"periodCount":0,"everyCount":0,"period":"1h","every":"1m"}`,
+			want: `{"typeOf":"window","id":"0","align":false,"fillPeriod":false,"periodCount":0,"everyCount":0,"period":"1h","every":"1m","sessionGap":"0s","maxSessionLength":"0s"}`,
+		},
+		{
+			name: "session gap",
+			fields: fields{
+				SessionGap:       5 * time.Minute,
+				MaxSessionLength: time.Hour,
+			},
+			want: `{"typeOf":"window","id":"0","align":false,"fillPeriod":false,"periodCount":0,"everyCount":0,"period":"0s","every":"0s","sessionGap":"5m","maxSessionLength":"1h"}`,
 		},
 	}
 	for _, tt := range tests {
@@ -52,6 +62,8 @@ func TestWindowNode_MarshalJSON(t *testing.T) {
 			w.FillPeriodFlag = tt.fields.FillPeriodFlag
 			w.PeriodCount = tt.fields.PeriodCount
 			w.EveryCount = tt.fields.EveryCount
+			w.SessionGap = tt.fields.SessionGap
+			w.MaxSessionLength = tt.fields.MaxSessionLength
 			MarshalTestHelper(t, w, tt.wantErr, tt.want)
 		})
 	}
@@ -84,6 +96,14 @@ func TestWindowNode_UnmarshalJSON(t *testing.T) {
 				Every:  time.Minute,
 			},
 		},
+		{
+			name:  "session gap",
+			input: `{"typeOf":"window","id":"0","period":"0s","every":"0s","sessionGap":"5m","maxSessionLength":"1h"}`,
+			want: &WindowNode{
+				SessionGap:       5 * time.Minute,
+				MaxSessionLength: time.Hour,
+			},
+		},
 		{
 			name:  "set id correctly",
 			input: `{"typeOf":"window","id":"5","period":"1h","every":"1m","align":false,"fillPeriod":false,"periodCount":0,"everyCount":0}`,
@@ -0,0 +1,383 @@
+package kapacitor
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/influxql"
+	"github.com/thingnario/kapacitor/edge"
+	"github.com/thingnario/kapacitor/keyvalue"
+	"github.com/thingnario/kapacitor/models"
+	"github.com/thingnario/kapacitor/pipeline"
+)
+
+type ResampleNode struct {
+	node
+	r *pipeline.ResampleNode
+
+	fill      influxql.FillOption
+	fillValue interface{}
+
+	groups groupCheckpoint
+}
+
+// Create a new ResampleNode, which emits one point per group per interval.
+func newResampleNode(et *ExecutingTask, r *pipeline.ResampleNode, d NodeDiagnostic) (*ResampleNode, error) {
+	if r.Interval <= 0 {
+		return nil, errors.New("resample interval must be positive")
+	}
+	n := &ResampleNode{
+		node: node{Node: r, et: et, diag: d},
+		r:    r,
+	}
+	switch fill := r.Fill.(type) {
+	case nil:
+		n.fill = influxql.NullFill
+	case string:
+		switch fill {
+		case "null":
+			n.fill = influxql.NullFill
+		case "previous":
+			n.fill = influxql.PreviousFill
+		case "linear":
+			n.fill = influxql.LinearFill
+		default:
+			return nil, fmt.Errorf("unexpected fill option %s", fill)
+		}
+	case int64, float64:
+		n.fill = influxql.NumberFill
+		n.fillValue = fill
+	default:
+		return nil, fmt.Errorf("unexpected fill type %T", fill)
+	}
+	n.node.runF = n.runResample
+	return n, nil
+}
+
+func (n *ResampleNode) runResample(snapshot []byte) error {
+	if err := n.groups.restore(snapshot); err != nil {
+		n.diag.Error("failed to restore node snapshot", err)
+	}
+	consumer := edge.NewGroupedConsumer(n.ins[0], n)
+	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
+	return consumer.Consume()
+}
+
+func (n *ResampleNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	g := n.newGroup(first.Name(), group)
+	r, err := n.groups.add(group.ID, g)
+	if err != nil {
+		n.diag.Error("failed to restore group state", err, keyvalue.KV("group", string(group.ID)))
+	}
+	return &resampleReceiver{
+		n: n,
+		r: edge.NewTimedForwardReceiver(n.timer, r),
+		g: g,
+	}, nil
+}
+
+func (n *ResampleNode) snapshot() ([]byte, error) {
+	return n.groups.snapshot()
+}
+
+func (n *ResampleNode) DeleteGroup(group models.GroupID) {
+	// Nothing to do
+}
+
+func (n *ResampleNode) newGroup(name string, group edge.GroupInfo) *resampleGroup {
+	return &resampleGroup{
+		n:     n,
+		name:  name,
+		group: group,
+	}
+}
+
+// resampleReceiver forwards the points emitted by a group before the message returned by the group.
+// A group may emit any number of points for a single message, one for each interval it passed.
+type resampleReceiver struct {
+	n *ResampleNode
+	r edge.ForwardReceiver
+	g *resampleGroup
+}
+
+func (r *resampleReceiver) BeginBatch(begin edge.BeginBatchMessage) error {
+	return r.forward(r.r.BeginBatch(begin))
+}
+func (r *resampleReceiver) BatchPoint(bp edge.BatchPointMessage) error {
+	return r.forward(r.r.BatchPoint(bp))
+}
+func (r *resampleReceiver) EndBatch(end edge.EndBatchMessage) error {
+	return r.forward(r.r.EndBatch(end))
+}
+func (r *resampleReceiver) Point(p edge.PointMessage) error {
+	return r.forward(r.r.Point(p))
+}
+func (r *resampleReceiver) Barrier(b edge.BarrierMessage) error {
+	return r.forward(r.r.Barrier(b))
+}
+func (r *resampleReceiver) DeleteGroup(d edge.DeleteGroupMessage) error {
+	return r.forward(r.r.DeleteGroup(d))
+}
+func (r *resampleReceiver) Done() {
+	r.r.Done()
+}
+
+func (r *resampleReceiver) forward(msg edge.Message, err error) error {
+	if err != nil {
+		return err
+	}
+	points := r.g.emitted
+	r.g.emitted = nil
+	for _, p := range points {
+		if err := edge.Forward(r.n.outs, p); err != nil {
+			return err
+		}
+	}
+	if msg != nil {
+		return edge.Forward(r.n.outs, msg)
+	}
+	return nil
+}
+
+// resampleGroup aggregates the points of a group per interval and fills the intervals without points.
+type resampleGroup struct {
+	n     *ResampleNode
+	name  string
+	group edge.GroupInfo
+
+	// Start of the current interval, zero until the first point.
+	start time.Time
+	// Points of the current interval.
+	points []edge.BatchPointMessage
+
+	// Fields and start of the last interval with points, used to fill the following intervals.
+	last     models.Fields
+	lastTime time.Time
+
+	// Points emitted while handling the current message.
+	emitted []edge.PointMessage
+}
+
+func (g *resampleGroup) BeginBatch(edge.BeginBatchMessage) (edge.Message, error) {
+	return nil, errors.New("resample does not support batch data")
+}
+func (g *resampleGroup) BatchPoint(edge.BatchPointMessage) (edge.Message, error) {
+	return nil, errors.New("resample does not support batch data")
+}
+func (g *resampleGroup) EndBatch(edge.EndBatchMessage) (edge.Message, error) {
+	return nil, errors.New("resample does not support batch data")
+}
+
+func (g *resampleGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	t := p.Time()
+	if g.start.IsZero() {
+		g.start = t
+		if g.n.r.AlignFlag {
+			g.start = t.Truncate(g.n.r.Interval)
+		}
+	}
+	if t.Before(g.start) {
+		// The interval of the point was already emitted.
+		return nil, nil
+	}
+	g.advance(t)
+	g.points = append(g.points, edge.BatchPointFromPoint(p))
+	return nil, nil
+}
+
+// Barrier emits the intervals that ended before the barrier.
+func (g *resampleGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	if !g.start.IsZero() {
+		g.advance(b.Time())
+	}
+	return b, nil
+}
+func (g *resampleGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (g *resampleGroup) Done() {}
+
+// advance emits the intervals that end at or before t.
+// With linear fill the intervals without points are emitted once the next interval with points ends,
+// since the interpolation needs its aggregated values.
+func (g *resampleGroup) advance(t time.Time) {
+	interval := g.n.r.Interval
+	for !t.Before(g.start.Add(interval)) {
+		if len(g.points) > 0 {
+			fields := g.aggregate()
+			g.points = nil
+			if g.n.fill == influxql.LinearFill {
+				g.fillLinear(fields)
+			}
+			g.emit(fields, g.start)
+			g.last = fields
+			g.lastTime = g.start
+		} else if g.n.fill != influxql.LinearFill && g.filled(g.start) {
+			g.emit(g.fillFields(), g.start)
+		} else {
+			// Skip the intervals up to the interval of t, none of them are emitted now.
+			g.start = g.start.Add(t.Sub(g.start) / interval * interval)
+			continue
+		}
+		g.start = g.start.Add(interval)
+	}
+}
+
+func (g *resampleGroup) emit(fields models.Fields, t time.Time) {
+	g.emitted = append(g.emitted, edge.NewPointMessage(
+		g.name, "", "",
+		g.group.Dimensions,
+		fields,
+		g.group.Tags,
+		t,
+	))
+}
+
+// filled reports whether the interval starting at t is filled,
+// at most MaxFill intervals after the last interval with points are filled.
+func (g *resampleGroup) filled(t time.Time) bool {
+	if g.last == nil {
+		return false
+	}
+	return t.Sub(g.lastTime) <= time.Duration(g.n.r.MaxFill)*g.n.r.Interval
+}
+
+// aggregate the fields of the points of the current interval.
+func (g *resampleGroup) aggregate() models.Fields {
+	values := make(map[string][]interface{})
+	for _, p := range g.points {
+		for k, v := range p.Fields() {
+			values[k] = append(values[k], v)
+		}
+	}
+	fields := make(models.Fields, len(values))
+	for k, vs := range values {
+		fields[k] = aggregateValues(g.n.r.Aggregate, vs)
+	}
+	return fields
+}
+
+// fillFields returns the fields of an interval without points.
+func (g *resampleGroup) fillFields() models.Fields {
+	fields := make(models.Fields, len(g.last))
+	for k, v := range g.last {
+		switch g.n.fill {
+		case influxql.NullFill:
+			fields[k] = nil
+		case influxql.NumberFill:
+			fields[k] = g.n.fillValue
+		case influxql.PreviousFill:
+			fields[k] = v
+		}
+	}
+	return fields
+}
+
+// fillLinear emits the intervals without points between the last interval with points and the current interval,
+// interpolating between the aggregated values of the two intervals.
+// Fields that are not numerical or are missing from the current interval report the previous value.
+func (g *resampleGroup) fillLinear(next models.Fields) {
+	span := float64(g.start.Sub(g.lastTime))
+	for t := g.lastTime.Add(g.n.r.Interval); t.Before(g.start) && g.filled(t); t = t.Add(g.n.r.Interval) {
+		ratio := float64(t.Sub(g.lastTime)) / span
+		fields := make(models.Fields, len(g.last))
+		for k, v := range g.last {
+			fields[k] = v
+			prev, ok := numToFloat(v)
+			if !ok {
+				continue
+			}
+			nextValue, ok := numToFloat(next[k])
+			if !ok {
+				continue
+			}
+			fields[k] = prev + (nextValue-prev)*ratio
+		}
+		g.emit(fields, t)
+	}
+}
+
+// aggregateValues aggregates the values of a field in the order of their points.
+// Values that are not numerical are aggregated as the first or last value.
+func aggregateValues(aggregate string, values []interface{}) interface{} {
+	switch aggregate {
+	case "first":
+		return values[0]
+	case "last":
+		return values[len(values)-1]
+	}
+	var (
+		result    interface{}
+		resultF   float64
+		sum       float64
+		sumInt    int64
+		allInts   = true
+		numerical int
+	)
+	for _, v := range values {
+		f, ok := numToFloat(v)
+		if !ok {
+			continue
+		}
+		i, isInt := v.(int64)
+		allInts = allInts && isInt
+		sum += f
+		sumInt += i
+		switch {
+		case numerical == 0,
+			aggregate == "min" && f < resultF,
+			aggregate == "max" && f > resultF:
+			result, resultF = v, f
+		}
+		numerical++
+	}
+	if numerical == 0 {
+		return values[len(values)-1]
+	}
+	switch aggregate {
+	case "mean":
+		return sum / float64(numerical)
+	case "sum":
+		if allInts {
+			return sumInt
+		}
+		return sum
+	default:
+		return result
+	}
+}
+
+type resampleState struct {
+	Start    time.Time
+	Points   []batchPointState
+	Last     models.Fields
+	LastTime time.Time
+}
+
+func (g *resampleGroup) snapshotState() ([]byte, error) {
+	state := resampleState{
+		Start:    g.start,
+		Last:     g.last,
+		LastTime: g.lastTime,
+	}
+	for _, p := range g.points {
+		state.Points = append(state.Points, newBatchPointState(p))
+	}
+	return encodeState(state)
+}
+
+func (g *resampleGroup) restoreState(data []byte) error {
+	var state resampleState
+	if err := decodeState(data, &state); err != nil {
+		return err
+	}
+	g.start = state.Start
+	g.last = state.Last
+	g.lastTime = state.LastTime
+	g.points = make([]edge.BatchPointMessage, len(state.Points))
+	for i, p := range state.Points {
+		g.points[i] = p.batchPoint()
+	}
+	return nil
+}
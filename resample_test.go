@@ -0,0 +1,85 @@
+package kapacitor
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/influxql"
+	"github.com/thingnario/kapacitor/pipeline"
+)
+
+func TestResample_AggregateValues(t *testing.T) {
+	testCases := []struct {
+		aggregate string
+		values    []interface{}
+		exp       interface{}
+	}{
+		{aggregate: "mean", values: []interface{}{1.0, int64(2), 6.0}, exp: 3.0},
+		{aggregate: "first", values: []interface{}{1.0, 2.0}, exp: 1.0},
+		{aggregate: "last", values: []interface{}{1.0, 2.0}, exp: 2.0},
+		{aggregate: "min", values: []interface{}{int64(3), 1.5, int64(2)}, exp: 1.5},
+		{aggregate: "max", values: []interface{}{int64(3), 1.5, int64(2)}, exp: int64(3)},
+		{aggregate: "sum", values: []interface{}{int64(3), int64(2)}, exp: int64(5)},
+		{aggregate: "sum", values: []interface{}{int64(3), 0.5}, exp: 3.5},
+		{aggregate: "mean", values: []interface{}{"a", "b"}, exp: "b"},
+		{aggregate: "max", values: []interface{}{true, 1.0, false}, exp: 1.0},
+	}
+	for _, tc := range testCases {
+		if got := aggregateValues(tc.aggregate, tc.values); got != tc.exp {
+			t.Errorf("%s of %v: unexpected value got %v exp %v", tc.aggregate, tc.values, got, tc.exp)
+		}
+	}
+}
+
+func newTestResampleGroup(fill influxql.FillOption, maxFill int64) *resampleGroup {
+	r := &pipeline.ResampleNode{
+		Interval:  10 * time.Second,
+		AlignFlag: true,
+		Aggregate: "mean",
+		MaxFill:   maxFill,
+	}
+	return &resampleGroup{
+		n: &ResampleNode{r: r, fill: fill},
+	}
+}
+
+// resamplePoints sends points at the given seconds with the given values and returns the emitted values by second.
+func resamplePoints(t *testing.T, g *resampleGroup, points [][2]float64) map[int64]interface{} {
+	got := make(map[int64]interface{})
+	for _, p := range points {
+		if _, err := g.Point(newCheckpointPoint(int64(p[0]), p[1])); err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range g.emitted {
+			got[e.Time().Unix()] = e.Fields()["value"]
+		}
+		g.emitted = nil
+	}
+	return got
+}
+
+func TestResample_LinearFill(t *testing.T) {
+	g := newTestResampleGroup(influxql.LinearFill, 100)
+	// The interval at 30s is interpolated from the mean of the intervals at 0s and 40s.
+	got := resamplePoints(t, g, [][2]float64{{0, 0}, {5, 10}, {41, 40}, {45, 60}, {50, 0}})
+	exp := map[int64]interface{}{0: 5.0, 10: 16.25, 20: 27.5, 30: 38.75, 40: 50.0}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected points got %v exp %v", got, exp)
+	}
+}
+
+func TestResample_MaxFill(t *testing.T) {
+	for _, fill := range []influxql.FillOption{influxql.LinearFill, influxql.PreviousFill} {
+		g := newTestResampleGroup(fill, 2)
+		got := resamplePoints(t, g, [][2]float64{{0, 0}, {1e6, 10}, {1e6 + 10, 10}})
+		exp := map[int64]interface{}{0: 0.0, 10: 0.0, 20: 0.0, 1e6: 10.0}
+		if fill == influxql.LinearFill {
+			exp[10] = 1e-4
+			exp[20] = 2e-4
+		}
+		if !reflect.DeepEqual(got, exp) {
+			t.Errorf("%v: unexpected points got %v exp %v", fill, got, exp)
+		}
+	}
+}
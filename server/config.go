@@ -14,6 +14,7 @@ import (
 	"github.com/thingnario/kapacitor/command"
 	"github.com/thingnario/kapacitor/services/alert"
 	"github.com/thingnario/kapacitor/services/alerta"
+	"github.com/thingnario/kapacitor/services/audit"
 	"github.com/thingnario/kapacitor/services/azure"
 	"github.com/thingnario/kapacitor/services/config"
 	"github.com/thingnario/kapacitor/services/consul"
@@ -30,14 +31,18 @@ import (
 	"github.com/thingnario/kapacitor/services/k8s"
 	"github.com/thingnario/kapacitor/services/kafka"
 	"github.com/thingnario/kapacitor/services/load"
+	"github.com/thingnario/kapacitor/services/localauth"
 	"github.com/thingnario/kapacitor/services/marathon"
 	"github.com/thingnario/kapacitor/services/mqtt"
 	"github.com/thingnario/kapacitor/services/nerve"
+	"github.com/thingnario/kapacitor/services/nodestate"
 	"github.com/thingnario/kapacitor/services/opsgenie"
 	"github.com/thingnario/kapacitor/services/opsgenie2"
 	"github.com/thingnario/kapacitor/services/pagerduty"
 	"github.com/thingnario/kapacitor/services/pagerduty2"
 	"github.com/thingnario/kapacitor/services/pushover"
+	"github.com/thingnario/kapacitor/services/redis"
+	"github.com/thingnario/kapacitor/services/redis_ingest"
 	"github.com/thingnario/kapacitor/services/replay"
 	"github.com/thingnario/kapacitor/services/reporting"
 	"github.com/thingnario/kapacitor/services/scraper"
@@ -71,6 +76,10 @@ type Config struct {
 	HTTP           httpd.Config      `toml:"http"`
 	Replay         replay.Config     `toml:"replay"`
 	Storage        storage.Config    `toml:"storage"`
+	LocalAuth      localauth.Config  `toml:"local-auth"`
+	Audit          audit.Config      `toml:"audit"`
+	NodeState      nodestate.Config  `toml:"node-state"`
+	Redis          redis.Config      `toml:"redis" override:"redis"`
 	Task           task_store.Config `toml:"task"`
 	Load           load.Config       `toml:"load"`
 	InfluxDB       []influxdb.Config `toml:"influxdb" override:"influxdb,element-key=name"`
@@ -84,6 +93,8 @@ type Config struct {
 	OpenTSDB opentsdb.Config   `toml:"opentsdb"`
 	UDP      []udp.Config      `toml:"udp"`
 
+	RedisIngest redis_ingest.Configs `toml:"redis-ingest"`
+
 	// Alert handlers
 	Alerta     alerta.Config     `toml:"alerta" override:"alerta"`
 	HipChat    hipchat.Config    `toml:"hipchat" override:"hipchat"`
@@ -144,6 +155,10 @@ func NewConfig() *Config {
 	c.Alert = alert.NewConfig()
 	c.HTTP = httpd.NewConfig()
 	c.Storage = storage.NewConfig()
+	c.LocalAuth = localauth.NewConfig()
+	c.Audit = audit.NewConfig()
+	c.NodeState = nodestate.NewConfig()
+	c.Redis = redis.NewConfig()
 	c.Replay = replay.NewConfig()
 	c.Task = task_store.NewConfig()
 	c.InfluxDB = []influxdb.Config{influxdb.NewConfig()}
@@ -198,6 +213,7 @@ func NewDemoConfig() (*Config, error) {
 
 	c.Replay.Dir = filepath.Join(homeDir, ".kapacitor", c.Replay.Dir)
 	c.Task.Dir = filepath.Join(homeDir, ".kapacitor", c.Task.Dir)
+	c.Task.SpoolDir = filepath.Join(homeDir, ".kapacitor", c.Task.SpoolDir)
 	c.Storage.BoltDBPath = filepath.Join(homeDir, ".kapacitor", c.Storage.BoltDBPath)
 	c.DataDir = filepath.Join(homeDir, ".kapacitor", c.DataDir)
 	c.Load.Dir = filepath.Join(homeDir, ".kapacitor", c.Load.Dir)
@@ -219,6 +235,23 @@ func (c *Config) Validate() error {
 	if err := c.Storage.Validate(); err != nil {
 		return errors.Wrap(err, "storage")
 	}
+	if err := c.LocalAuth.Validate(); err != nil {
+		return errors.Wrap(err, "local-auth")
+	}
+	// Without authentication every request is served as an admin,
+	// which would leave the users and tokens open to anyone.
+	if c.LocalAuth.Enabled && !c.HTTP.AuthEnabled {
+		return errors.New("local-auth: requires auth-enabled in the [http] section")
+	}
+	if err := c.Audit.Validate(); err != nil {
+		return errors.Wrap(err, "audit")
+	}
+	if err := c.NodeState.Validate(); err != nil {
+		return errors.Wrap(err, "node-state")
+	}
+	if err := c.Redis.Validate(); err != nil {
+		return errors.Wrap(err, "redis")
+	}
 	if err := c.HTTP.Validate(); err != nil {
 		return errors.Wrap(err, "http")
 	}
@@ -267,6 +300,9 @@ func (c *Config) Validate() error {
 			return errors.Wrap(err, "graphite")
 		}
 	}
+	if err := c.RedisIngest.Validate(); err != nil {
+		return errors.Wrap(err, "redis-ingest")
+	}
 
 	// Validate alert handlers
 	if err := c.Alerta.Validate(); err != nil {
@@ -198,3 +198,15 @@ channel = "#kapacitor_public"
 		t.Fatalf("Expected config to be invalid, %s", cStr)
 	}
 }
+
+func TestConfig_LocalAuthRequiresAuthentication(t *testing.T) {
+	c := NewConfig()
+	c.LocalAuth.Enabled = true
+	if err := c.Validate(); err == nil {
+		t.Fatal("expected local-auth without auth-enabled to be invalid")
+	}
+	c.HTTP.AuthEnabled = true
+	if err := c.Validate(); err != nil {
+		t.Fatalf("expected local-auth with auth-enabled to be valid, %v", err)
+	}
+}
@@ -24,7 +24,9 @@ import (
 	"github.com/thingnario/kapacitor/server/vars"
 	"github.com/thingnario/kapacitor/services/alert"
 	"github.com/thingnario/kapacitor/services/alerta"
+	"github.com/thingnario/kapacitor/services/audit"
 	"github.com/thingnario/kapacitor/services/azure"
+	"github.com/thingnario/kapacitor/services/blob"
 	"github.com/thingnario/kapacitor/services/config"
 	"github.com/thingnario/kapacitor/services/consul"
 	"github.com/thingnario/kapacitor/services/deadman"
@@ -40,15 +42,19 @@ import (
 	"github.com/thingnario/kapacitor/services/k8s"
 	"github.com/thingnario/kapacitor/services/kafka"
 	"github.com/thingnario/kapacitor/services/load"
+	"github.com/thingnario/kapacitor/services/localauth"
 	"github.com/thingnario/kapacitor/services/marathon"
 	"github.com/thingnario/kapacitor/services/mqtt"
 	"github.com/thingnario/kapacitor/services/nerve"
+	"github.com/thingnario/kapacitor/services/nodestate"
 	"github.com/thingnario/kapacitor/services/noauth"
 	"github.com/thingnario/kapacitor/services/opsgenie"
 	"github.com/thingnario/kapacitor/services/opsgenie2"
 	"github.com/thingnario/kapacitor/services/pagerduty"
 	"github.com/thingnario/kapacitor/services/pagerduty2"
 	"github.com/thingnario/kapacitor/services/pushover"
+	"github.com/thingnario/kapacitor/services/redis"
+	"github.com/thingnario/kapacitor/services/redis_ingest"
 	"github.com/thingnario/kapacitor/services/replay"
 	"github.com/thingnario/kapacitor/services/reporting"
 	"github.com/thingnario/kapacitor/services/scraper"
@@ -117,6 +123,9 @@ type Server struct {
 	AuthService           auth.Interface
 	HTTPDService          *httpd.Service
 	StorageService        *storage.Service
+	NodeStateService      *nodestate.Service
+	BlobService           *blob.Service
+	RedisService          *redis.Service
 	AlertService          *alert.Service
 	TaskStore             *task_store.Service
 	ReplayService         *replay.Service
@@ -221,7 +230,12 @@ func New(c *Config, buildInfo BuildInfo, diagService *diagnostic.Service) (*Serv
 	s.appendAuthService()
 	s.appendConfigOverrideService()
 	s.appendTesterService()
+
+	// Append the redis service, it is dynamic and depends on the config override and tester services.
+	s.appendRedisService()
 	s.appendSideloadService()
+	s.appendNodeStateService()
+	s.appendBlobService()
 
 	// Init alert service
 	s.initAlertService()
@@ -268,6 +282,7 @@ func New(c *Config, buildInfo BuildInfo, diagService *diagnostic.Service) (*Serv
 
 	// Append these after InfluxDB because they depend on it
 	s.appendTaskStoreService()
+	s.appendAuditService()
 	s.appendReplayService()
 	s.appendSessionService()
 
@@ -277,6 +292,7 @@ func New(c *Config, buildInfo BuildInfo, diagService *diagnostic.Service) (*Serv
 		return nil, errors.Wrap(err, "collectd service")
 	}
 	s.appendUDPServices()
+	s.appendRedisIngestServices()
 	if err := s.appendOpenTSDBService(); err != nil {
 		return nil, errors.Wrap(err, "opentsdb service")
 	}
@@ -350,6 +366,39 @@ func (s *Server) appendStorageService() {
 	s.AppendService("storage", srv)
 }
 
+func (s *Server) appendRedisService() {
+	c := s.config.Redis
+	d := s.DiagService.NewRedisHandler()
+	srv := redis.NewService(c, d)
+
+	s.RedisService = srv
+	s.SetDynamicService("redis", srv)
+	s.AppendService("redis", srv)
+}
+
+func (s *Server) appendNodeStateService() {
+	c := s.config.NodeState
+	d := s.DiagService.NewNodeStateHandler()
+	srv := nodestate.NewService(c, d)
+	srv.StorageService = s.StorageService
+	srv.RedisService = s.RedisService
+	srv.HTTPDService = s.HTTPDService
+
+	s.NodeStateService = srv
+	s.TaskMaster.StateStore = srv
+	s.AppendService("node-state", srv)
+}
+
+func (s *Server) appendBlobService() {
+	d := s.DiagService.NewBlobHandler()
+	srv := blob.NewService(d)
+	srv.StorageService = s.StorageService
+	srv.HTTPDService = s.HTTPDService
+
+	s.BlobService = srv
+	s.AppendService("blob", srv)
+}
+
 func (s *Server) appendConfigOverrideService() {
 	d := s.DiagService.NewConfigOverrideHandler()
 	srv := config.NewService(s.config.ConfigOverride, s.config, d, s.configUpdates)
@@ -367,6 +416,7 @@ func (s *Server) initAlertService() {
 	srv.Commander = s.Commander
 	srv.HTTPDService = s.HTTPDService
 	srv.StorageService = s.StorageService
+	srv.StateStore = s.NodeStateService
 	srv.PersistTopics = s.config.Alert.PersistTopics
 	s.AlertService = srv
 	s.TaskMaster.AlertService = srv
@@ -388,6 +438,7 @@ func (s *Server) appendSideloadService() {
 	d := s.DiagService.NewSideloadHandler()
 	srv := sideload.NewService(d)
 	srv.HTTPDService = s.HTTPDService
+	srv.RedisService = s.RedisService
 
 	s.SideloadService = srv
 	s.TaskMaster.SideloadService = srv
@@ -421,6 +472,8 @@ func (s *Server) appendLoadService() error {
 	}
 
 	srv.StorageService = s.StorageService
+	srv.HTTPDService = s.HTTPDService
+	srv.APIHandler = s.HTTPDService.Handler
 
 	s.LoadService = srv
 	s.AppendService("load", srv)
@@ -484,12 +537,28 @@ func (s *Server) appendTaskStoreService() {
 	srv.StorageService = s.StorageService
 	srv.HTTPDService = s.HTTPDService
 	srv.TaskMasterLookup = s.TaskMasterLookup
+	srv.AlertService = s.AlertService
 
 	s.TaskStore = srv
 	s.TaskMaster.TaskStore = srv
 	s.AppendService("task_store", srv)
 }
 
+func (s *Server) appendAuditService() {
+	c := s.config.Audit
+	if !c.Enabled {
+		return
+	}
+	d := s.DiagService.NewAuditHandler()
+	srv := audit.NewService(c, d)
+	srv.StorageService = s.StorageService
+	srv.HTTPDService = s.HTTPDService
+	srv.TaskStore = s.TaskStore
+
+	s.HTTPDService.Handler.AuditService = srv
+	s.AppendService("audit", srv)
+}
+
 func (s *Server) appendSessionService() {
 	srv := s.DiagService.SessionService
 	srv.HTTPDService = s.HTTPDService
@@ -561,12 +630,25 @@ func (s *Server) appendDeadmanService() {
 func (s *Server) appendUDFService() {
 	d := s.DiagService.NewUDFServiceHandler()
 	srv := udf.NewService(s.config.UDF, d)
+	srv.BlobService = s.BlobService
 
 	s.TaskMaster.UDFService = srv
 	s.AppendService("udf", srv)
 }
 
 func (s *Server) appendAuthService() {
+	if c := s.config.LocalAuth; c.Enabled {
+		d := s.DiagService.NewLocalAuthHandler()
+		srv := localauth.NewService(c, d)
+		srv.StorageService = s.StorageService
+		srv.HTTPDService = s.HTTPDService
+
+		s.AuthService = srv
+		s.HTTPDService.Handler.AuthService = srv
+		s.HTTPDService.Handler.APITokenService = srv
+		s.AppendService("auth", srv)
+		return
+	}
 	d := s.DiagService.NewNoAuthHandler()
 	srv := noauth.NewService(d)
 
@@ -582,6 +664,7 @@ func (s *Server) appendMQTTService() error {
 	if err != nil {
 		return err
 	}
+	srv.PointsWriter = s.TaskMaster
 
 	s.TaskMaster.MQTTService = srv
 	s.AlertService.MQTTService = srv
@@ -854,6 +937,19 @@ func (s *Server) appendUDPServices() {
 	}
 }
 
+func (s *Server) appendRedisIngestServices() {
+	for _, c := range s.config.RedisIngest {
+		if !c.Enabled {
+			continue
+		}
+		d := s.DiagService.NewRedisIngestHandler(c.Name)
+		srv := redis_ingest.NewService(c, d)
+		srv.RedisService = s.RedisService
+		srv.PointsWriter = s.TaskMaster
+		s.AppendService(fmt.Sprintf("redis-ingest:%s", c.Name), srv)
+	}
+}
+
 func (s *Server) appendStatsService() {
 	c := s.config.Stats
 	if c.Enabled {
@@ -45,6 +45,7 @@ import (
 	"github.com/thingnario/kapacitor/services/kafka/kafkatest"
 	"github.com/thingnario/kapacitor/services/mqtt"
 	"github.com/thingnario/kapacitor/services/mqtt/mqtttest"
+	"github.com/thingnario/kapacitor/services/nodestate"
 	"github.com/thingnario/kapacitor/services/opsgenie"
 	"github.com/thingnario/kapacitor/services/opsgenie/opsgenietest"
 	"github.com/thingnario/kapacitor/services/opsgenie2/opsgenie2test"
@@ -934,6 +935,89 @@ func TestServer_DeleteTask(t *testing.T) {
 	}
 }
 
+func TestServer_ValidateTask_ErrorPosition(t *testing.T) {
+	s, cli := OpenDefaultServer()
+	defer s.Close()
+
+	// The positions are part of the messages of the errors of the tick parser and evaluator,
+	// they must still be reported as structured fields.
+	testCases := []struct {
+		name       string
+		tickScript string
+		line       int
+	}{
+		{
+			name: "parser error",
+			tickScript: `var period = 5m
+var every = )
+stream|from()`,
+			line: 2,
+		},
+		{
+			name: "eval error",
+			tickScript: `stream
+    |from()
+        .foo('bar')`,
+			line: 3,
+		},
+	}
+	for _, tc := range testCases {
+		result, err := cli.ValidateTask(client.ValidateTaskOptions{
+			TICKscript: tc.tickScript,
+			DBRPs:      []client.DBRP{{Database: "mydb", RetentionPolicy: "myrp"}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Valid || len(result.Errors) != 1 {
+			t.Fatalf("%s: expected a single validation error, got %+v", tc.name, result)
+		}
+		if e := result.Errors[0]; e.Line != tc.line || e.Char <= 0 {
+			t.Errorf("%s: unexpected position of %q got line %d char %d exp line %d", tc.name, e.Message, e.Line, e.Char, tc.line)
+		}
+	}
+}
+
+func TestServer_DeleteTask_NodeState(t *testing.T) {
+	c := NewConfig()
+	c.NodeState.Backend = nodestate.MemoryBackend
+	s := OpenServer(c)
+	cli := Client(s)
+	defer s.Close()
+
+	// State persisted by an earlier run of the task and of a task with a similar ID.
+	keys := []string{
+		nodestate.ChangeDetectTaskPrefix("testTaskID") + "host=serverA",
+		nodestate.ChangeDetectTaskPrefix("testTaskID2") + "host=serverA",
+	}
+	for _, key := range keys {
+		if err := s.NodeStateService.Put(key, []byte(`{}`)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	task, err := cli.CreateTask(client.CreateTaskOptions{
+		ID:         "testTaskID",
+		Type:       client.StreamTask,
+		DBRPs:      []client.DBRP{{Database: "mydb", RetentionPolicy: "myrp"}},
+		TICKscript: "stream|from().measurement('test')|changeDetect('value')",
+		Status:     client.Disabled,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cli.DeleteTask(task.Link); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.NodeStateService.Get(keys[0]); err != nodestate.ErrNoKeyExists {
+		t.Errorf("expected state of deleted task to be deleted, got %v", err)
+	}
+	if _, err := s.NodeStateService.Get(keys[1]); err != nil {
+		t.Errorf("unexpected error getting state of other task: %v", err)
+	}
+}
+
 func TestServer_TaskNums(t *testing.T) {
 	s, cli := OpenDefaultServer()
 	defer s.Close()
@@ -6931,6 +7015,137 @@ func TestServer_UpdateConfig(t *testing.T) {
 				},
 			},
 		},
+		{
+			section: "redis",
+			expDefaultSection: client.ConfigSection{
+				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/redis"},
+				Elements: []client.ConfigElement{{
+					Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/redis/"},
+					Options: map[string]interface{}{
+						"enabled":              true,
+						"mode":                 "standalone",
+						"addrs":                []interface{}{"localhost:6379"},
+						"master-name":          "",
+						"username":             "",
+						"password":             false,
+						"db":                   float64(0),
+						"use-ssl":              false,
+						"ssl-ca":               "",
+						"ssl-cert":             "",
+						"ssl-key":              "",
+						"insecure-skip-verify": false,
+						"pool-size":            float64(0),
+						"min-idle-conns":       float64(0),
+						"max-retries":          float64(0),
+						"dial-timeout":         "0s",
+						"read-timeout":         "0s",
+						"write-timeout":        "0s",
+						"pool-timeout":         "0s",
+						"idle-timeout":         "0s",
+					},
+					Redacted: []string{
+						"password",
+					},
+				}},
+			},
+			expDefaultElement: client.ConfigElement{
+				Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/redis/"},
+				Options: map[string]interface{}{
+					"enabled":              true,
+					"mode":                 "standalone",
+					"addrs":                []interface{}{"localhost:6379"},
+					"master-name":          "",
+					"username":             "",
+					"password":             false,
+					"db":                   float64(0),
+					"use-ssl":              false,
+					"ssl-ca":               "",
+					"ssl-cert":             "",
+					"ssl-key":              "",
+					"insecure-skip-verify": false,
+					"pool-size":            float64(0),
+					"min-idle-conns":       float64(0),
+					"max-retries":          float64(0),
+					"dial-timeout":         "0s",
+					"read-timeout":         "0s",
+					"write-timeout":        "0s",
+					"pool-timeout":         "0s",
+					"idle-timeout":         "0s",
+				},
+				Redacted: []string{
+					"password",
+				},
+			},
+			updates: []updateAction{
+				{
+					updateAction: client.ConfigUpdateAction{
+						Set: map[string]interface{}{
+							"password": "secret",
+							"db":       2,
+						},
+					},
+					expSection: client.ConfigSection{
+						Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/redis"},
+						Elements: []client.ConfigElement{{
+							Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/redis/"},
+							Options: map[string]interface{}{
+								"enabled":              true,
+								"mode":                 "standalone",
+								"addrs":                []interface{}{"localhost:6379"},
+								"master-name":          "",
+								"username":             "",
+								"password":             true,
+								"db":                   float64(2),
+								"use-ssl":              false,
+								"ssl-ca":               "",
+								"ssl-cert":             "",
+								"ssl-key":              "",
+								"insecure-skip-verify": false,
+								"pool-size":            float64(0),
+								"min-idle-conns":       float64(0),
+								"max-retries":          float64(0),
+								"dial-timeout":         "0s",
+								"read-timeout":         "0s",
+								"write-timeout":        "0s",
+								"pool-timeout":         "0s",
+								"idle-timeout":         "0s",
+							},
+							Redacted: []string{
+								"password",
+							},
+						}},
+					},
+					expElement: client.ConfigElement{
+						Link: client.Link{Relation: client.Self, Href: "/kapacitor/v1/config/redis/"},
+						Options: map[string]interface{}{
+							"enabled":              true,
+							"mode":                 "standalone",
+							"addrs":                []interface{}{"localhost:6379"},
+							"master-name":          "",
+							"username":             "",
+							"password":             true,
+							"db":                   float64(2),
+							"use-ssl":              false,
+							"ssl-ca":               "",
+							"ssl-cert":             "",
+							"ssl-key":              "",
+							"insecure-skip-verify": false,
+							"pool-size":            float64(0),
+							"min-idle-conns":       float64(0),
+							"max-retries":          float64(0),
+							"dial-timeout":         "0s",
+							"read-timeout":         "0s",
+							"write-timeout":        "0s",
+							"pool-timeout":         "0s",
+							"idle-timeout":         "0s",
+						},
+						Redacted: []string{
+							"password",
+						},
+					},
+				},
+			},
+		},
 		{
 			section: "kubernetes",
 			setDefaults: func(c *server.Config) {
@@ -7124,6 +7339,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 						"client-id":            "",
 						"username":             "",
 						"password":             false,
+						"subscriptions":        nil,
 					},
 					Redacted: []string{
 						"password",
@@ -7144,6 +7360,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 					"client-id":            "",
 					"username":             "",
 					"password":             false,
+					"subscriptions":        nil,
 				},
 				Redacted: []string{
 					"password",
@@ -7174,6 +7391,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 								"client-id":            "kapacitor-default",
 								"username":             "",
 								"password":             true,
+								"subscriptions":        nil,
 							},
 							Redacted: []string{
 								"password",
@@ -7194,6 +7412,7 @@ func TestServer_UpdateConfig(t *testing.T) {
 							"client-id":            "kapacitor-default",
 							"username":             "",
 							"password":             true,
+							"subscriptions":        nil,
 						},
 						Redacted: []string{
 							"password",
@@ -8821,6 +9040,11 @@ func TestServer_ListServiceTests(t *testing.T) {
 					"level":     "CRITICAL",
 				},
 			},
+			{
+				Link:    client.Link{Relation: client.Self, Href: "/kapacitor/v1/service-tests/redis"},
+				Name:    "redis",
+				Options: client.ServiceTestOptions{},
+			},
 			{
 				Link: client.Link{Relation: "self", Href: "/kapacitor/v1/service-tests/scraper"},
 				Name: "scraper",
@@ -9,11 +9,14 @@ import (
 	"path"
 	"sort"
 	"strings"
+	"time"
 
 	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/thingnario/kapacitor/alert"
+	"github.com/thingnario/kapacitor/auth"
 	client "github.com/thingnario/kapacitor/client/v1"
 	"github.com/thingnario/kapacitor/services/httpd"
+	"github.com/thingnario/kapacitor/uuid"
 )
 
 const (
@@ -36,12 +39,18 @@ const (
 
 	eventsRelation   = "events"
 	handlersRelation = "handlers"
+
+	silencesPath             = alertsPath + "/silences"
+	silencesPathAnchored     = alertsPath + "/silences/"
+	silencesBasePath         = httpd.BasePath + silencesPath
+	silencesBasePathAnchored = httpd.BasePath + silencesPathAnchored
 )
 
 type apiServer struct {
 	Registrar    HandlerSpecRegistrar
 	Topics       Topics
 	Persister    TopicPersister
+	Silencer     SilenceRegistrar
 	routes       []httpd.Route
 	HTTPDService interface {
 		AddRoutes([]httpd.Route) error
@@ -89,6 +98,32 @@ func (s *apiServer) Open() error {
 			Pattern:     topicsPathAnchored,
 			HandlerFunc: httpd.ServeOptions,
 		},
+		{
+			Method:      "GET",
+			Pattern:     silencesPath,
+			HandlerFunc: s.handleListSilences,
+		},
+		{
+			Method:      "POST",
+			Pattern:     silencesPath,
+			HandlerFunc: s.handleCreateSilence,
+		},
+		{
+			Method:      "GET",
+			Pattern:     silencesPathAnchored,
+			HandlerFunc: s.handleGetSilence,
+		},
+		{
+			Method:      "DELETE",
+			Pattern:     silencesPathAnchored,
+			HandlerFunc: s.handleDeleteSilence,
+		},
+		{
+			// Satisfy CORS checks.
+			Method:      "OPTIONS",
+			Pattern:     silencesPathAnchored,
+			HandlerFunc: httpd.ServeOptions,
+		},
 	}
 
 	return s.HTTPDService.AddRoutes(s.routes)
@@ -508,3 +543,118 @@ func (s *apiServer) handleGetHandler(topic, handler string, w http.ResponseWrite
 	w.WriteHeader(http.StatusOK)
 	w.Write(httpd.MarshalJSON(h, true))
 }
+
+func (s *apiServer) silenceLink(id string) client.Link {
+	return client.Link{Relation: client.Self, Href: path.Join(silencesBasePath, id)}
+}
+
+func (s *apiServer) convertSilence(silence Silence, now time.Time) client.Silence {
+	return client.Silence{
+		Link:    s.silenceLink(silence.ID),
+		ID:      silence.ID,
+		Topic:   silence.Topic,
+		EventID: silence.EventID,
+		Tags:    silence.Tags,
+		Start:   silence.Start,
+		End:     silence.End,
+		Creator: silence.Creator,
+		Comment: silence.Comment,
+		Active:  !now.Before(silence.Start) && now.Before(silence.End),
+	}
+}
+
+func (s *apiServer) handleListSilences(w http.ResponseWriter, r *http.Request) {
+	silences, err := s.Silencer.Silences()
+	if err != nil {
+		httpd.HttpError(w, fmt.Sprint("failed to list silences: ", err.Error()), true, http.StatusInternalServerError)
+		return
+	}
+	now := time.Now()
+	list := client.Silences{
+		Link:     client.Link{Relation: client.Self, Href: r.URL.String()},
+		Silences: make([]client.Silence, len(silences)),
+	}
+	for i, silence := range silences {
+		list.Silences[i] = s.convertSilence(silence, now)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(httpd.MarshalJSON(list, true))
+}
+
+func (s *apiServer) handleCreateSilence(w http.ResponseWriter, r *http.Request, user auth.User) {
+	var opt client.SilenceOptions
+	if err := json.NewDecoder(r.Body).Decode(&opt); err != nil {
+		httpd.HttpError(w, fmt.Sprint("invalid silence json: ", err.Error()), true, http.StatusBadRequest)
+		return
+	}
+	silence := Silence{
+		ID:      opt.ID,
+		Topic:   opt.Topic,
+		EventID: opt.EventID,
+		Tags:    opt.Tags,
+		Start:   opt.Start,
+		End:     opt.End,
+		Creator: opt.Creator,
+		Comment: opt.Comment,
+	}
+	now := time.Now()
+	if silence.ID == "" {
+		silence.ID = uuid.New().String()
+	}
+	if silence.Start.IsZero() {
+		silence.Start = now
+	}
+	if silence.Creator == "" {
+		silence.Creator = user.Name()
+	}
+	if err := silence.Validate(); err != nil {
+		httpd.HttpError(w, fmt.Sprint("invalid silence: ", err.Error()), true, http.StatusBadRequest)
+		return
+	}
+	if silence.End.Before(now) {
+		httpd.HttpError(w, "invalid silence: silence end must be in the future", true, http.StatusBadRequest)
+		return
+	}
+
+	if err := s.Silencer.CreateSilence(silence); err != nil {
+		code := http.StatusInternalServerError
+		if err == ErrSilenceExists {
+			code = http.StatusBadRequest
+		}
+		httpd.HttpError(w, fmt.Sprint("failed to create silence: ", err.Error()), true, code)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(httpd.MarshalJSON(s.convertSilence(silence, now), true))
+}
+
+func (s *apiServer) handleGetSilence(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, silencesBasePathAnchored)
+	silence, ok, err := s.Silencer.Silence(id)
+	if err != nil {
+		httpd.HttpError(w, fmt.Sprintf("failed to get silence %q: %v", id, err), true, http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		httpd.HttpError(w, fmt.Sprintf("unknown silence: %q", id), true, http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write(httpd.MarshalJSON(s.convertSilence(silence, time.Now()), true))
+}
+
+func (s *apiServer) handleDeleteSilence(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, silencesBasePathAnchored)
+	if err := s.Silencer.DeleteSilence(id); err != nil {
+		code := http.StatusInternalServerError
+		if err == ErrNoSilenceExists {
+			code = http.StatusNotFound
+		}
+		httpd.HttpError(w, fmt.Sprint("failed to delete silence: ", err.Error()), true, code)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
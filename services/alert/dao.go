@@ -344,3 +344,158 @@ func (kv *topicStateKV) List(pattern string, offset, limit int) ([]TopicState, e
 func (kv *topicStateKV) Rebuild() error {
 	return kv.store.Rebuild()
 }
+
+var (
+	ErrSilenceExists   = errors.New("silence already exists")
+	ErrNoSilenceExists = errors.New("no silence exists")
+)
+
+// Data access object for Silence data.
+type SilenceDAO interface {
+	// Retrieve a silence
+	Get(id string) (Silence, error)
+
+	// Create a silence.
+	// ErrSilenceExists is returned if a silence already exists with the same ID.
+	Create(s Silence) error
+
+	// Delete a silence.
+	// It is not an error to delete an non-existent silence.
+	Delete(id string) error
+
+	// List silences matching a pattern.
+	// The pattern is shell/glob matching see https://golang.org/pkg/path/#Match
+	// Offset and limit are pagination bounds. Offset is inclusive starting at index 0.
+	// More results may exist while the number of returned items is equal to limit.
+	List(pattern string, offset, limit int) ([]Silence, error)
+
+	Rebuild() error
+}
+
+const silenceVersion = 1
+
+// Silence suppresses the handlers of matching events between Start and End.
+type Silence struct {
+	ID      string            `json:"id"`
+	Topic   string            `json:"topic"`
+	EventID string            `json:"event-id"`
+	Tags    map[string]string `json:"tags"`
+	Start   time.Time         `json:"start"`
+	End     time.Time         `json:"end"`
+	Creator string            `json:"creator"`
+	Comment string            `json:"comment"`
+}
+
+func (s Silence) Validate() error {
+	if !validHandlerID.MatchString(s.ID) {
+		return fmt.Errorf("silence ID must contain only letters, numbers, '-', '.' and '_'. %q", s.ID)
+	}
+	for _, pattern := range append([]string{s.Topic, s.EventID}, tagPatterns(s.Tags)...) {
+		if err := validatePattern(pattern); err != nil {
+			return fmt.Errorf("invalid pattern %q: %v", pattern, err)
+		}
+	}
+	if s.End.IsZero() {
+		return errors.New("silence end must be set")
+	}
+	if !s.End.After(s.Start) {
+		return errors.New("silence end must be after its start")
+	}
+	return nil
+}
+
+func tagPatterns(tags map[string]string) []string {
+	patterns := make([]string, 0, len(tags))
+	for _, p := range tags {
+		patterns = append(patterns, p)
+	}
+	return patterns
+}
+
+func (s Silence) ObjectID() string {
+	return s.ID
+}
+
+func (s Silence) MarshalBinary() ([]byte, error) {
+	return storage.VersionJSONEncode(silenceVersion, s)
+}
+
+func (s *Silence) UnmarshalBinary(data []byte) error {
+	return storage.VersionJSONDecode(data, func(version int, dec *json.Decoder) error {
+		if version != silenceVersion {
+			return fmt.Errorf("unknown silence version %d: cannot decode", version)
+		}
+		return dec.Decode(s)
+	})
+}
+
+// Key/Value store based implementation of the SilenceDAO
+type silenceKV struct {
+	store *storage.IndexedStore
+}
+
+const (
+	silencePrefix = "silences"
+)
+
+func newSilenceKV(store storage.Interface) (*silenceKV, error) {
+	c := storage.DefaultIndexedStoreConfig(silencePrefix, func() storage.BinaryObject {
+		return new(Silence)
+	})
+	istore, err := storage.NewIndexedStore(store, c)
+	if err != nil {
+		return nil, err
+	}
+	return &silenceKV{
+		store: istore,
+	}, nil
+}
+
+func (kv *silenceKV) error(err error) error {
+	if err == storage.ErrObjectExists {
+		return ErrSilenceExists
+	} else if err == storage.ErrNoObjectExists {
+		return ErrNoSilenceExists
+	}
+	return err
+}
+
+func (kv *silenceKV) Get(id string) (Silence, error) {
+	o, err := kv.store.Get(id)
+	if err != nil {
+		return Silence{}, kv.error(err)
+	}
+	s, ok := o.(*Silence)
+	if !ok {
+		return Silence{}, storage.ImpossibleTypeErr(s, o)
+	}
+	return *s, nil
+}
+
+func (kv *silenceKV) Create(s Silence) error {
+	return kv.error(kv.store.Create(&s))
+}
+
+func (kv *silenceKV) Delete(id string) error {
+	return kv.store.Delete(id)
+}
+
+func (kv *silenceKV) List(pattern string, offset, limit int) ([]Silence, error) {
+	objects, err := kv.store.List(storage.DefaultIDIndex, pattern, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	silences := make([]Silence, len(objects))
+	for i, o := range objects {
+		s, ok := o.(*Silence)
+		if !ok {
+			return nil, storage.ImpossibleTypeErr(s, o)
+		}
+		silences[i] = *s
+	}
+	return silences, nil
+}
+
+func (kv *silenceKV) Rebuild() error {
+	return kv.store.Rebuild()
+}
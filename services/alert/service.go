@@ -21,12 +21,12 @@ import (
 	"github.com/thingnario/kapacitor/services/httppost"
 	"github.com/thingnario/kapacitor/services/kafka"
 	"github.com/thingnario/kapacitor/services/mqtt"
+	"github.com/thingnario/kapacitor/services/nodestate"
 	"github.com/thingnario/kapacitor/services/opsgenie"
 	"github.com/thingnario/kapacitor/services/opsgenie2"
 	"github.com/thingnario/kapacitor/services/pagerduty"
 	"github.com/thingnario/kapacitor/services/pagerduty2"
 	"github.com/thingnario/kapacitor/services/pushover"
-	"github.com/thingnario/kapacitor/services/redis"
 	"github.com/thingnario/kapacitor/services/sensu"
 	"github.com/thingnario/kapacitor/services/slack"
 	"github.com/thingnario/kapacitor/services/smtp"
@@ -55,8 +55,12 @@ type Service struct {
 
 	specsDAO      HandlerSpecDAO
 	topicsDAO     TopicStateDAO
+	silencesDAO   SilenceDAO
 	PersistTopics bool
 
+	closing chan struct{}
+	wg      sync.WaitGroup
+
 	APIServer *apiServer
 
 	handlers map[string]map[string]handler
@@ -79,6 +83,14 @@ type Service struct {
 		Versions() storage.Versions
 	}
 
+	// StateStore persists the state of each event so that alert nodes
+	// can restore it after a restart.
+	StateStore interface {
+		Put(key string, value []byte) error
+		List(prefix string) ([]nodestate.KeyValue, error)
+		DeletePrefix(prefix string) error
+	}
+
 	Commander command.Commander
 
 	diag Diagnostic
@@ -144,11 +156,13 @@ func NewService(d Diagnostic) *Service {
 		topics:          alert.NewTopics(),
 		diag:            d,
 		inhibitorLookup: alert.NewInhibitorLookup(),
+		StateStore:      nodestate.NewMemStore(),
 	}
 	s.APIServer = &apiServer{
 		Registrar: s,
 		Topics:    s,
 		Persister: s,
+		Silencer:  s,
 		diag:      d,
 	}
 	s.EventCollector = s
@@ -160,6 +174,8 @@ const (
 	handlerSpecsAPIName = "handler-specs"
 	// Public name of the handler specs store.
 	topicStatesAPIName = "topic-states"
+	// Public name of the silences store.
+	silencesAPIName = "silences"
 	// The storage namespace for all task data.
 	alertNamespace = "alert_store"
 )
@@ -182,6 +198,12 @@ func (s *Service) Open() error {
 	}
 	s.topicsDAO = topicsDAO
 	s.StorageService.Register(topicStatesAPIName, s.topicsDAO)
+	silencesDAO, err := newSilenceKV(store)
+	if err != nil {
+		return err
+	}
+	s.silencesDAO = silencesDAO
+	s.StorageService.Register(silencesAPIName, s.silencesDAO)
 
 	// Migrate v1.2 handlers
 	if err := s.migrateHandlerSpecs(store); err != nil {
@@ -198,6 +220,17 @@ func (s *Service) Open() error {
 		return err
 	}
 
+	// Load saved silences
+	if err := s.loadSavedSilences(); err != nil {
+		return err
+	}
+	s.closing = make(chan struct{})
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.expireSilences()
+	}()
+
 	s.APIServer.HTTPDService = s.HTTPDService
 	if err := s.APIServer.Open(); err != nil {
 		return err
@@ -207,6 +240,11 @@ func (s *Service) Open() error {
 }
 
 func (s *Service) Close() error {
+	if s.closing != nil {
+		close(s.closing)
+		s.wg.Wait()
+		s.closing = nil
+	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.topics.Close()
@@ -361,6 +399,120 @@ func (s *Service) loadSavedHandlerSpecs() error {
 	return nil
 }
 
+func (s *Service) loadSavedSilences() error {
+	offset := 0
+	limit := 100
+	for {
+		silences, err := s.silencesDAO.List("*", offset, limit)
+		if err != nil {
+			return err
+		}
+
+		for _, silence := range silences {
+			s.topics.Silences().Set(convertSilenceToAlert(silence))
+		}
+
+		offset += limit
+		if len(silences) != limit {
+			break
+		}
+	}
+	// Remove the silences that expired while we were down
+	s.deleteExpiredSilences()
+	return nil
+}
+
+// silenceExpireInterval is how often expired silences are deleted.
+// Silences stop applying at their end regardless of the interval.
+const silenceExpireInterval = time.Minute
+
+func (s *Service) expireSilences() {
+	ticker := time.NewTicker(silenceExpireInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.closing:
+			return
+		case <-ticker.C:
+			s.deleteExpiredSilences()
+		}
+	}
+}
+
+func (s *Service) deleteExpiredSilences() {
+	for _, id := range s.topics.Silences().Expire() {
+		if err := s.silencesDAO.Delete(id); err != nil {
+			s.diag.Error("failed to delete expired silence", err, keyvalue.KV("silence", id))
+		}
+	}
+}
+
+func convertSilenceToAlert(silence Silence) alert.Silence {
+	return alert.Silence{
+		ID:      silence.ID,
+		Topic:   silence.Topic,
+		EventID: silence.EventID,
+		Tags:    silence.Tags,
+		Start:   silence.Start,
+		End:     silence.End,
+		Creator: silence.Creator,
+		Comment: silence.Comment,
+	}
+}
+
+func convertSilenceFromAlert(silence alert.Silence) Silence {
+	return Silence{
+		ID:      silence.ID,
+		Topic:   silence.Topic,
+		EventID: silence.EventID,
+		Tags:    silence.Tags,
+		Start:   silence.Start,
+		End:     silence.End,
+		Creator: silence.Creator,
+		Comment: silence.Comment,
+	}
+}
+
+// CreateSilence saves the silence and applies it to all topics.
+func (s *Service) CreateSilence(silence Silence) error {
+	if err := silence.Validate(); err != nil {
+		return err
+	}
+	if err := s.silencesDAO.Create(silence); err != nil {
+		return err
+	}
+	s.topics.Silences().Set(convertSilenceToAlert(silence))
+	return nil
+}
+
+// DeleteSilence deletes the silence, matching events are handled again.
+func (s *Service) DeleteSilence(id string) error {
+	if _, ok := s.topics.Silences().Silence(id); !ok {
+		return ErrNoSilenceExists
+	}
+	s.topics.Silences().Delete(id)
+	return s.silencesDAO.Delete(id)
+}
+
+// Silence returns the silence with the ID.
+func (s *Service) Silence(id string) (Silence, bool, error) {
+	silence, ok := s.topics.Silences().Silence(id)
+	if !ok {
+		return Silence{}, false, nil
+	}
+	return convertSilenceFromAlert(silence), true, nil
+}
+
+// Silences returns all silences that have not expired yet.
+func (s *Service) Silences() ([]Silence, error) {
+	list := s.topics.Silences().List()
+	silences := make([]Silence, len(list))
+	for i, silence := range list {
+		silences[i] = convertSilenceFromAlert(silence)
+	}
+	return silences, nil
+}
+
 func (s *Service) convertEventStatesToAlert(states map[string]EventState) map[string]alert.EventState {
 	newStates := make(map[string]alert.EventState, len(states))
 	for id, state := range states {
@@ -410,42 +562,57 @@ func (s *Service) loadSavedTopicStates() error {
 			s.topics.RestoreTopic(ts.Topic, s.convertEventStatesToAlert(ts.EventStates))
 		}
 
-		stored, err := redis.ReadAllFromRedis(fmt.Sprint("topics|*"))
-		if stored != nil {
-			topics := make(map[string]map[string]alert.EventState)
-			for k, v := range stored {
-				pos := strings.Index(k, "|")
-				topic := k[0:pos]
-				id := k[pos+1:]
-				if topics[topic] == nil {
-					topics[topic] = make(map[string]alert.EventState)
-				}
-				message := v["Message"].String()
-				details := v["Details"].String()
-				timeStr := v["Time"].String()
-				var t time.Time
-				t.UnmarshalText([]byte(timeStr))
-				duration := time.Duration(v["Duration"].Float())
-				level := alert.Level(v["Level"].Float())
-				state := EventState{
-					message,
-					details,
-					t,
-					duration,
-					level,
-				}
-				topics[topic][id] = s.convertEventStateToAlert(id, state)
-			}
-			for topic, eventStates := range topics {
-				s.topics.RestoreTopic(topic, eventStates)
-			}
-		}
-
 		offset += limit
 		if len(topicStates) != limit {
 			break
 		}
 	}
+	return s.loadStoredEventStates()
+}
+
+// eventStateKey returns the StateStore key of an event on a topic.
+func eventStateKey(topic, id string) string {
+	return nodestate.EventStatePrefix + topic + "|" + id
+}
+
+// loadStoredEventStates restores the event states saved in the StateStore.
+func (s *Service) loadStoredEventStates() error {
+	stored, err := s.StateStore.List(nodestate.EventStatePrefix)
+	if err != nil {
+		// Do not fail to start if the state store is unavailable.
+		s.diag.Error("failed to list stored event states", err)
+		return nil
+	}
+	topics := make(map[string]map[string]alert.EventState)
+	for _, kv := range stored {
+		k := strings.TrimPrefix(kv.Key, nodestate.EventStatePrefix)
+		pos := strings.Index(k, "|")
+		if pos < 0 {
+			s.diag.Error("invalid stored event state key", errors.New("missing topic separator"), keyvalue.KV("key", kv.Key))
+			continue
+		}
+		topic := k[0:pos]
+		id := k[pos+1:]
+		saved, err := nodestate.DecodeEventState(kv.Value)
+		if err != nil {
+			s.diag.Error("failed to decode stored event state", err, keyvalue.KV("key", kv.Key))
+			continue
+		}
+		if topics[topic] == nil {
+			topics[topic] = make(map[string]alert.EventState)
+		}
+		state := EventState{
+			Message:  saved.Message,
+			Details:  saved.Details,
+			Time:     saved.Time,
+			Duration: saved.Duration,
+			Level:    alert.Level(saved.Level),
+		}
+		topics[topic][id] = s.convertEventStateToAlert(id, state)
+	}
+	for topic, eventStates := range topics {
+		s.topics.RestoreTopic(topic, eventStates)
+	}
 	return nil
 }
 
@@ -473,16 +640,9 @@ func (s *Service) Collect(event alert.Event) error {
 		}
 	}
 
-	key := fmt.Sprintf("topics|%s|%s", event.Topic, event.State.ID)
-	value := make(map[string]interface{})
-	value["ID"] = event.State.ID
-	value["Message"] = event.State.Message
-	value["Details"] = event.State.Details
-	timebytes, _ := event.State.Time.MarshalText()
-	value["Time"] = string(timebytes)
-	value["Duration"] = int64(event.State.Duration)
-	value["Level"] = int64(event.State.Level)
-	redis.WriteToRedis(key, value)
+	if err := s.saveEventState(event); err != nil {
+		s.diag.Error("failed to save event state", err, keyvalue.KV("topic", event.Topic), keyvalue.KV("event", event.State.ID))
+	}
 
 	err := s.topics.Collect(event)
 	if err != nil {
@@ -491,6 +651,22 @@ func (s *Service) Collect(event alert.Event) error {
 	return s.persistTopicState(event.Topic)
 }
 
+// saveEventState saves the state of the event in the StateStore.
+func (s *Service) saveEventState(event alert.Event) error {
+	data, err := nodestate.EncodeEventState(nodestate.EventState{
+		ID:       event.State.ID,
+		Message:  event.State.Message,
+		Details:  event.State.Details,
+		Time:     event.State.Time,
+		Duration: event.State.Duration,
+		Level:    int64(event.State.Level),
+	})
+	if err != nil {
+		return err
+	}
+	return s.StateStore.Put(eventStateKey(event.Topic, event.State.ID), data)
+}
+
 func (s *Service) persistTopicState(topic string) error {
 	if !s.PersistTopics {
 		return nil
@@ -565,6 +741,9 @@ func (s *Service) DeleteTopic(topic string) error {
 	defer s.mu.Unlock()
 	delete(s.closedTopics, topic)
 	s.topics.DeleteTopic(topic)
+	if err := s.StateStore.DeletePrefix(nodestate.EventStatePrefix + topic + "|"); err != nil {
+		s.diag.Error("failed to delete stored event states", err, keyvalue.KV("topic", topic))
+	}
 	return s.topicsDAO.Delete(topic)
 }
 
@@ -747,6 +926,20 @@ func (s *Service) HandlerSpecs(topic, pattern string) ([]HandlerSpec, error) {
 	return handlers, nil
 }
 
+// AllHandlerSpecs returns the handler specs of all topics.
+func (s *Service) AllHandlerSpecs() []HandlerSpec {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var specs []HandlerSpec
+	for _, handlers := range s.handlers {
+		for _, h := range handlers {
+			specs = append(specs, h.Spec)
+		}
+	}
+	return specs
+}
+
 func decodeOptions(options map[string]interface{}, c interface{}) error {
 	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
 		ErrorUnused: true,
@@ -19,6 +19,18 @@ type HandlerSpecRegistrar interface {
 	HandlerSpecs(topic, pattern string) ([]HandlerSpec, error)
 }
 
+// SilenceRegistrar is responsible for creating and persisting silences.
+type SilenceRegistrar interface {
+	// CreateSilence saves the silence and applies it to the events of all topics.
+	CreateSilence(silence Silence) error
+	// DeleteSilence deletes the silence.
+	DeleteSilence(id string) error
+	// Silence returns a silence.
+	Silence(id string) (Silence, bool, error)
+	// Silences returns all silences that have not expired.
+	Silences() ([]Silence, error)
+}
+
 // Topics is responsible for querying the state of topics and their events.
 type Topics interface {
 	// TopicState returns the state of the specified topic,
@@ -0,0 +1,33 @@
+package audit
+
+import (
+	"time"
+
+	"github.com/influxdata/influxdb/toml"
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultRetention = toml.Duration(30 * 24 * time.Hour)
+)
+
+type Config struct {
+	// Enabled records an audit entry for every mutating request of the HTTP API.
+	Enabled bool `toml:"enabled"`
+	// Retention is how long audit entries are kept, entries are kept forever if 0.
+	Retention toml.Duration `toml:"retention"`
+}
+
+func NewConfig() Config {
+	return Config{
+		Enabled:   true,
+		Retention: DefaultRetention,
+	}
+}
+
+func (c Config) Validate() error {
+	if c.Retention < 0 {
+		return errors.New("retention must not be negative")
+	}
+	return nil
+}
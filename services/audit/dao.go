@@ -0,0 +1,133 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/thingnario/kapacitor/services/storage"
+)
+
+// Data access object for audit entries.
+type EntryDAO interface {
+	// Create an entry.
+	Create(e Entry) error
+
+	// Delete an entry.
+	// It is not an error to delete an non-existent entry.
+	Delete(id string) error
+
+	// List entries, oldest first.
+	// Offset and limit are pagination bounds. Offset is inclusive starting at index 0.
+	// More results may exist while the number of returned items is equal to limit.
+	List(offset, limit int) ([]Entry, error)
+
+	// ReverseList lists entries, newest first.
+	ReverseList(offset, limit int) ([]Entry, error)
+
+	// Rebuild fixes all indexes of the data.
+	Rebuild() error
+}
+
+//--------------------------------------------------------------------
+// The following structures are stored in a database via JSON encoding.
+// Changes to the structures could break existing data.
+
+const (
+	entryVersion1 = 1
+)
+
+// Entry records a mutating request of the HTTP API.
+type Entry struct {
+	// ID sorts entries by the time they were recorded.
+	ID      string    `json:"id"`
+	Time    time.Time `json:"time"`
+	User    string    `json:"user"`
+	TokenID string    `json:"token-id"`
+	Method  string    `json:"method"`
+	Path    string    `json:"path"`
+	Status  int       `json:"status"`
+	// ResourceType is the first element of the API path, i.e. tasks.
+	ResourceType string `json:"resource-type"`
+	// ResourceID is the remainder of the API path, or the ID of a created task or template.
+	ResourceID string `json:"resource-id"`
+	// Diff is a unified diff of the TICKscript and vars of a changed task or template.
+	Diff string `json:"diff"`
+}
+
+func (e Entry) ObjectID() string {
+	return e.ID
+}
+
+func (e Entry) MarshalBinary() ([]byte, error) {
+	return storage.VersionJSONEncode(entryVersion1, e)
+}
+
+func (e *Entry) UnmarshalBinary(data []byte) error {
+	return storage.VersionJSONDecode(data, func(version int, dec *json.Decoder) error {
+		switch version {
+		case entryVersion1:
+			return dec.Decode(e)
+		default:
+			return fmt.Errorf("unsupported audit entry version %d", version)
+		}
+	})
+}
+
+// Key/Value based implementation of the EntryDAO.
+type entryKV struct {
+	store *storage.IndexedStore
+}
+
+func newEntryKV(store storage.Interface) (*entryKV, error) {
+	c := storage.DefaultIndexedStoreConfig("audit_entries", func() storage.BinaryObject {
+		return new(Entry)
+	})
+	istore, err := storage.NewIndexedStore(store, c)
+	if err != nil {
+		return nil, err
+	}
+	return &entryKV{
+		store: istore,
+	}, nil
+}
+
+func (kv *entryKV) Rebuild() error {
+	return kv.store.Rebuild()
+}
+
+func (kv *entryKV) Create(e Entry) error {
+	return kv.store.Create(&e)
+}
+
+func (kv *entryKV) Delete(id string) error {
+	return kv.store.Delete(id)
+}
+
+func (kv *entryKV) List(offset, limit int) ([]Entry, error) {
+	objects, err := kv.store.List(storage.DefaultIDIndex, "", offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return kv.convert(objects)
+}
+
+func (kv *entryKV) ReverseList(offset, limit int) ([]Entry, error) {
+	objects, err := kv.store.ReverseList(storage.DefaultIDIndex, "", offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return kv.convert(objects)
+}
+
+func (kv *entryKV) convert(objects []storage.BinaryObject) ([]Entry, error) {
+	entries := make([]Entry, len(objects))
+	for i, o := range objects {
+		e, ok := o.(*Entry)
+		if !ok {
+			return nil, storage.ImpossibleTypeErr(e, o)
+		}
+		entries[i] = *e
+	}
+	return entries, nil
+}
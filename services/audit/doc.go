@@ -0,0 +1,13 @@
+/*
+The audit package records every mutating request of the HTTP API.
+
+The HTTPD service calls Audit after a POST, PATCH, PUT or DELETE request is authenticated,
+except for data ingest via /write. Each entry holds the user, the API token if any,
+the time, the path, the response status and the type and ID of the resource, i.e. tasks and cpu.
+For tasks and templates the entry also holds a unified diff of the TICKscript and vars.
+
+Entries are kept for the configured retention and are served, newest first, via the HTTP API:
+
+	GET /kapacitor/v1/audit[?start=<time>&stop=<time>&type=<type>&resource=<pattern>&user=<name>]
+*/
+package audit
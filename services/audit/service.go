@@ -0,0 +1,519 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/thingnario/kapacitor/auth"
+	"github.com/thingnario/kapacitor/client/v1"
+	"github.com/thingnario/kapacitor/services/httpd"
+	"github.com/thingnario/kapacitor/services/storage"
+)
+
+const (
+	auditPath = "/audit"
+
+	// Public name of the audit entries store
+	entriesAPIName = "audit"
+	// The storage namespace for audit entries.
+	entriesNamespace = "audit_store"
+
+	// Format of the time part of entry IDs, it is fixed width so that IDs sort by time.
+	idTimeFormat = "20060102T150405.000000000Z"
+
+	// Number of entries read at once when filtering or purging entries.
+	entryBatchSize = 100
+
+	// Interval at which entries older than the retention are deleted.
+	purgeInterval = time.Hour
+
+	// Maximum size of the request bodies of tasks and templates read to find their ID.
+	maxRequestSize = 10 * 1024 * 1024
+)
+
+type Diagnostic interface {
+	Error(msg string, err error)
+}
+
+// Service records mutating requests of the HTTP API and serves the recorded entries.
+type Service struct {
+	config Config
+
+	entries EntryDAO
+
+	// mu protects lastID and seq.
+	mu     sync.Mutex
+	lastID string
+	seq    int
+
+	routes []httpd.Route
+
+	closing chan struct{}
+	wg      sync.WaitGroup
+
+	StorageService interface {
+		Store(namespace string) storage.Interface
+		Register(name string, store storage.StoreActioner)
+	}
+	HTTPDService interface {
+		AddRoutes([]httpd.Route) error
+		DelRoutes([]httpd.Route)
+	}
+	TaskStore interface {
+		TaskDefinition(id string) (string, client.Vars, error)
+		TemplateDefinition(id string) (string, error)
+	}
+
+	diag Diagnostic
+}
+
+func NewService(c Config, d Diagnostic) *Service {
+	return &Service{
+		config: c,
+		diag:   d,
+	}
+}
+
+func (s *Service) Open() error {
+	entries, err := newEntryKV(s.StorageService.Store(entriesNamespace))
+	if err != nil {
+		return err
+	}
+	s.entries = entries
+	s.StorageService.Register(entriesAPIName, s.entries)
+
+	s.routes = []httpd.Route{
+		{
+			Method:      "GET",
+			Pattern:     auditPath,
+			HandlerFunc: s.handleListEntries,
+		},
+	}
+	if err := s.HTTPDService.AddRoutes(s.routes); err != nil {
+		return err
+	}
+
+	s.closing = make(chan struct{})
+	if s.config.Retention > 0 {
+		s.wg.Add(1)
+		go s.runPurge()
+	}
+	return nil
+}
+
+func (s *Service) Close() error {
+	if s.HTTPDService != nil {
+		s.HTTPDService.DelRoutes(s.routes)
+	}
+	if s.closing != nil {
+		close(s.closing)
+		s.wg.Wait()
+		s.closing = nil
+	}
+	return nil
+}
+
+func (s *Service) runPurge() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+	for {
+		if err := s.purge(time.Now().Add(-time.Duration(s.config.Retention))); err != nil {
+			s.diag.Error("failed to delete expired audit entries", err)
+		}
+		select {
+		case <-ticker.C:
+		case <-s.closing:
+			return
+		}
+	}
+}
+
+// purge deletes the entries recorded before the cutoff.
+func (s *Service) purge(cutoff time.Time) error {
+	for {
+		entries, err := s.entries.List(0, entryBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if !e.Time.Before(cutoff) {
+				return nil
+			}
+			if err := s.entries.Delete(e.ID); err != nil {
+				return err
+			}
+		}
+		if len(entries) < entryBatchSize {
+			return nil
+		}
+	}
+}
+
+// nextID returns a unique ID that sorts after all previous IDs recorded at or before the time.
+func (s *Service) nextID(t time.Time) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := t.Format(idTimeFormat)
+	if id == s.lastID {
+		s.seq++
+	} else {
+		s.lastID = id
+		s.seq = 0
+	}
+	return fmt.Sprintf("%s-%06d", id, s.seq)
+}
+
+// definition is the part of a task or template that is diffed.
+type definition struct {
+	TICKscript string
+	Vars       client.Vars
+}
+
+// Audit is called by the HTTPD service before a mutating request is handled.
+// The returned function records the request once it is handled,
+// it is nil if the request is not audited.
+func (s *Service) Audit(r *http.Request, user auth.User) func(status int, response []byte) {
+	if !strings.HasPrefix(r.URL.Path, httpd.BasePath+"/") {
+		return nil
+	}
+	p := strings.TrimPrefix(r.URL.Path, httpd.BasePath)
+	resourceType, resourceID := splitPath(p)
+	if resourceType == "" || resourceType == "write" {
+		// Data ingest is not audited
+		return nil
+	}
+	if readOnlyPaths[p] {
+		return nil
+	}
+	e := Entry{
+		Time:         time.Now().UTC(),
+		User:         user.Name(),
+		TokenID:      r.Header.Get(httpd.TokenIDHeader),
+		Method:       r.Method,
+		Path:         p,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+	}
+
+	// Tasks and templates are diffed, the definition before the request is read now.
+	var getDefinition func(id string) (definition, error)
+	switch resourceType {
+	case "tasks":
+		getDefinition = s.taskDefinition
+	case "templates":
+		getDefinition = s.templateDefinition
+	}
+	if getDefinition == nil || s.TaskStore == nil {
+		return func(status int, response []byte) {
+			e.Status = status
+			s.record(e)
+		}
+	}
+	// Sub resources such as /tasks/cpu/rollback change the definition of their task or template.
+	if i := strings.IndexByte(resourceID, '/'); i >= 0 {
+		resourceID = resourceID[:i]
+	}
+	var before definition
+	if resourceID != "" {
+		before, _ = getDefinition(resourceID)
+	}
+	// Tasks and templates are created or renamed with the ID in the request body.
+	requestID := ""
+	if r.Method == "POST" || r.Method == "PATCH" {
+		requestID = s.peekID(r)
+	}
+	return func(status int, response []byte) {
+		e.Status = status
+		if status >= 200 && status < 300 {
+			id := resourceID
+			if requestID != "" {
+				id = requestID
+			} else if r.Method == "POST" {
+				// Find the generated ID in the response
+				id = readID(response)
+			}
+			e.ResourceID = id
+			var after definition
+			if r.Method != "DELETE" && id != "" {
+				after, _ = getDefinition(id)
+			}
+			diff, err := diffDefinitions(before, after)
+			if err != nil {
+				s.diag.Error("failed to diff definitions", err)
+			}
+			e.Diff = diff
+		}
+		s.record(e)
+	}
+}
+
+func (s *Service) record(e Entry) {
+	e.ID = s.nextID(e.Time)
+	if err := s.entries.Create(e); err != nil {
+		s.diag.Error("failed to store audit entry", err)
+	}
+}
+
+// readOnlyPaths are POST endpoints that do not change any resources.
+var readOnlyPaths = map[string]bool{
+	"/tasks/validate": true,
+}
+
+// splitPath returns the resource type and ID of an API path, i.e. /tasks/cpu is the task cpu.
+func splitPath(p string) (string, string) {
+	p = strings.Trim(p, "/")
+	parts := strings.SplitN(p, "/", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+// peekID reads the ID from the JSON body of a request without consuming the body.
+// Only the first maxRequestSize bytes of the body are read, the handler of the request
+// still reads the whole body.
+func (s *Service) peekID(r *http.Request) string {
+	if r.Body == nil {
+		return ""
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxRequestSize))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{
+		Reader: io.MultiReader(bytes.NewReader(body), r.Body),
+		Closer: r.Body,
+	}
+	if err != nil || len(body) == maxRequestSize {
+		return ""
+	}
+	return readID(body)
+}
+
+func readID(data []byte) string {
+	o := struct {
+		ID string `json:"id"`
+	}{}
+	if err := json.Unmarshal(data, &o); err != nil {
+		return ""
+	}
+	return o.ID
+}
+
+func (s *Service) taskDefinition(id string) (definition, error) {
+	script, vars, err := s.TaskStore.TaskDefinition(id)
+	if err != nil {
+		return definition{}, err
+	}
+	return definition{TICKscript: script, Vars: vars}, nil
+}
+
+func (s *Service) templateDefinition(id string) (definition, error) {
+	script, err := s.TaskStore.TemplateDefinition(id)
+	if err != nil {
+		return definition{}, err
+	}
+	return definition{TICKscript: script}, nil
+}
+
+// diffDefinitions returns a unified diff of the TICKscripts and the vars of the definitions.
+func diffDefinitions(before, after definition) (string, error) {
+	scriptDiff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(before.TICKscript),
+		B:        difflib.SplitLines(after.TICKscript),
+		FromFile: "tickscript",
+		ToFile:   "tickscript",
+		Context:  3,
+	})
+	if err != nil {
+		return "", err
+	}
+	beforeVars, err := formatVars(before.Vars)
+	if err != nil {
+		return "", err
+	}
+	afterVars, err := formatVars(after.Vars)
+	if err != nil {
+		return "", err
+	}
+	varsDiff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(beforeVars),
+		B:        difflib.SplitLines(afterVars),
+		FromFile: "vars",
+		ToFile:   "vars",
+		Context:  3,
+	})
+	if err != nil {
+		return "", err
+	}
+	return scriptDiff + varsDiff, nil
+}
+
+// formatVars formats vars as indented JSON, so that each var is diffed on its own lines.
+func formatVars(vars client.Vars) (string, error) {
+	if len(vars) == 0 {
+		return "", nil
+	}
+	data, err := json.MarshalIndent(vars, "", "    ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Filter selects audit entries.
+type Filter struct {
+	// Start and Stop bound the time of entries, zero values are unbounded.
+	Start time.Time
+	Stop  time.Time
+	// ResourceType matches the resource type exactly.
+	ResourceType string
+	// ResourceID is a glob pattern matched against the resource ID, see https://golang.org/pkg/path/#Match
+	ResourceID string
+	User       string
+}
+
+func (f Filter) match(e Entry) bool {
+	if !f.Start.IsZero() && e.Time.Before(f.Start) {
+		return false
+	}
+	if !f.Stop.IsZero() && e.Time.After(f.Stop) {
+		return false
+	}
+	if f.ResourceType != "" && e.ResourceType != f.ResourceType {
+		return false
+	}
+	if f.ResourceID != "" {
+		if matched, _ := path.Match(f.ResourceID, e.ResourceID); !matched {
+			return false
+		}
+	}
+	if f.User != "" && e.User != f.User {
+		return false
+	}
+	return true
+}
+
+// ListEntries returns the entries matching the filter, newest first.
+func (s *Service) ListEntries(f Filter, offset, limit int) ([]Entry, error) {
+	var matches []Entry
+	skipped := 0
+	for i := 0; ; i += entryBatchSize {
+		entries, err := s.entries.ReverseList(i, entryBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if !f.Start.IsZero() && e.Time.Before(f.Start) {
+				// All remaining entries are older
+				return matches, nil
+			}
+			if !f.match(e) {
+				continue
+			}
+			if skipped < offset {
+				skipped++
+				continue
+			}
+			matches = append(matches, e)
+			if len(matches) == limit {
+				return matches, nil
+			}
+		}
+		if len(entries) < entryBatchSize {
+			return matches, nil
+		}
+	}
+}
+
+func convertEntry(e Entry) client.AuditEntry {
+	return client.AuditEntry{
+		ID:           e.ID,
+		Time:         e.Time,
+		User:         e.User,
+		TokenID:      e.TokenID,
+		Method:       e.Method,
+		Path:         e.Path,
+		Status:       e.Status,
+		ResourceType: e.ResourceType,
+		ResourceID:   e.ResourceID,
+		Diff:         e.Diff,
+	}
+}
+
+func parseTime(q, name string) (time.Time, error) {
+	if q == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, q)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "invalid %s parameter %q must be an RFC3339 time", name, q)
+	}
+	return t, nil
+}
+
+func (s *Service) handleListEntries(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	start, err := parseTime(q.Get("start"), "start")
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
+		return
+	}
+	stop, err := parseTime(q.Get("stop"), "stop")
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
+		return
+	}
+	offset := 0
+	if offsetStr := q.Get("offset"); offsetStr != "" {
+		offset, err = strconv.Atoi(offsetStr)
+		if err != nil {
+			httpd.HttpError(w, fmt.Sprintf("invalid offset parameter %q must be an integer: %s", offsetStr, err), true, http.StatusBadRequest)
+			return
+		}
+	}
+	limit := 100
+	if limitStr := q.Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil {
+			httpd.HttpError(w, fmt.Sprintf("invalid limit parameter %q must be an integer: %s", limitStr, err), true, http.StatusBadRequest)
+			return
+		}
+	}
+	f := Filter{
+		Start:        start,
+		Stop:         stop,
+		ResourceType: q.Get("type"),
+		ResourceID:   q.Get("resource"),
+		User:         q.Get("user"),
+	}
+	if _, err := path.Match(f.ResourceID, ""); err != nil {
+		httpd.HttpError(w, fmt.Sprintf("invalid resource pattern %q: %s", f.ResourceID, err), true, http.StatusBadRequest)
+		return
+	}
+	entries, err := s.ListEntries(f, offset, limit)
+	if err != nil {
+		httpd.HttpError(w, fmt.Sprintf("failed to list audit entries: %s", err), true, http.StatusInternalServerError)
+		return
+	}
+	type response struct {
+		Entries []client.AuditEntry `json:"entries"`
+	}
+	resp := response{Entries: make([]client.AuditEntry, len(entries))}
+	for i, e := range entries {
+		resp.Entries[i] = convertEntry(e)
+	}
+	w.Write(httpd.MarshalJSON(resp, true))
+}
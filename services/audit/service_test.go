@@ -0,0 +1,183 @@
+package audit_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thingnario/kapacitor/auth"
+	"github.com/thingnario/kapacitor/client/v1"
+	"github.com/thingnario/kapacitor/services/audit"
+	"github.com/thingnario/kapacitor/services/httpd"
+	"github.com/thingnario/kapacitor/services/storage/storagetest"
+)
+
+type diag struct{}
+
+func (diag) Error(msg string, err error) {}
+
+type routes struct{}
+
+func (routes) AddRoutes([]httpd.Route) error { return nil }
+func (routes) DelRoutes([]httpd.Route)       {}
+
+// taskStore is an in memory store of task TICKscripts.
+type taskStore struct {
+	tasks map[string]string
+}
+
+func (ts *taskStore) TaskDefinition(id string) (string, client.Vars, error) {
+	script, ok := ts.tasks[id]
+	if !ok {
+		return "", nil, fmt.Errorf("no task %s", id)
+	}
+	return script, client.Vars{"period": {Type: client.VarDuration, Value: time.Minute}}, nil
+}
+
+func (ts *taskStore) TemplateDefinition(id string) (string, error) {
+	return "", fmt.Errorf("no template %s", id)
+}
+
+func newService(t *testing.T) (*audit.Service, *taskStore) {
+	t.Helper()
+	ts := &taskStore{tasks: make(map[string]string)}
+	c := audit.NewConfig()
+	c.Retention = 0
+	s := audit.NewService(c, diag{})
+	s.StorageService = storagetest.New()
+	s.HTTPDService = routes{}
+	s.TaskStore = ts
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	return s, ts
+}
+
+func TestService_Audit(t *testing.T) {
+	s, ts := newService(t)
+	user := auth.NewUser("bob", nil, true, nil)
+
+	// Create a task with the ID in the request body
+	r := httptest.NewRequest("POST", httpd.BasePath+"/tasks", strings.NewReader(`{"id":"cpu"}`))
+	done := s.Audit(r, user)
+	if done == nil {
+		t.Fatal("expected task creation to be audited")
+	}
+	ts.tasks["cpu"] = "stream\n    |from()\n"
+	done(http.StatusOK, nil)
+
+	// Update the task
+	r = httptest.NewRequest("PATCH", httpd.BasePath+"/tasks/cpu", strings.NewReader(`{"status":"enabled"}`))
+	done = s.Audit(r, user)
+	ts.tasks["cpu"] = "stream\n    |from()\n        .measurement('cpu')\n"
+	done(http.StatusOK, nil)
+
+	// A failed request is recorded without a diff
+	r = httptest.NewRequest("DELETE", httpd.BasePath+"/config/smtp/", nil)
+	s.Audit(r, user)(http.StatusForbidden, nil)
+
+	// Data ingest is not audited
+	r = httptest.NewRequest("POST", httpd.BasePath+"/write?db=db", strings.NewReader("m v=1"))
+	if done := s.Audit(r, user); done != nil {
+		t.Error("expected writes not to be audited")
+	}
+	r = httptest.NewRequest("POST", httpd.BasePath+"/tasks/validate", strings.NewReader(`{"script":"stream|from()"}`))
+	if done := s.Audit(r, user); done != nil {
+		t.Error("expected task validation not to be audited")
+	}
+
+	entries, err := s.ListEntries(audit.Filter{}, 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := len(entries), 3; got != exp {
+		t.Fatalf("unexpected number of entries: got %d exp %d", got, exp)
+	}
+	// Entries are listed newest first
+	if e := entries[0]; e.ResourceType != "config" || e.ResourceID != "smtp" || e.Status != http.StatusForbidden || e.Diff != "" {
+		t.Errorf("unexpected config entry %+v", e)
+	}
+	if e := entries[1]; e.Method != "PATCH" || e.ResourceID != "cpu" || !strings.Contains(e.Diff, "+        .measurement('cpu')") {
+		t.Errorf("unexpected update entry %+v", e)
+	}
+	if e := entries[2]; e.User != "bob" || e.ResourceID != "cpu" || !strings.Contains(e.Diff, "+stream") || !strings.Contains(e.Diff, `+    "period": {`) {
+		t.Errorf("unexpected create entry %+v", e)
+	}
+
+	// The request body of a task is still readable by the handler
+	r = httptest.NewRequest("POST", httpd.BasePath+"/tasks", strings.NewReader(`{"id":"mem"}`))
+	s.Audit(r, user)
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := string(body), `{"id":"mem"}`; got != exp {
+		t.Errorf("unexpected request body: got %q exp %q", got, exp)
+	}
+
+	// Large request bodies are not truncated
+	large := `{"id":"large","script":"` + strings.Repeat("x", 11*1024*1024) + `"}`
+	r = httptest.NewRequest("POST", httpd.BasePath+"/tasks", strings.NewReader(large))
+	s.Audit(r, user)
+	body, err = ioutil.ReadAll(r.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := len(body), len(large); got != exp {
+		t.Errorf("unexpected large request body length: got %d exp %d", got, exp)
+	}
+}
+
+func TestService_ListEntries_Filter(t *testing.T) {
+	s, _ := newService(t)
+	for _, p := range []string{"/tasks/cpu", "/tasks/mem", "/templates/cpu", "/tasks/cpu_high"} {
+		r := httptest.NewRequest("DELETE", httpd.BasePath+p, nil)
+		s.Audit(r, auth.NewUser("bob", nil, true, nil))(http.StatusNoContent, nil)
+	}
+	testCases := []struct {
+		f   audit.Filter
+		exp []string
+	}{
+		{
+			f:   audit.Filter{ResourceType: "tasks"},
+			exp: []string{"/tasks/cpu_high", "/tasks/mem", "/tasks/cpu"},
+		},
+		{
+			f:   audit.Filter{ResourceType: "tasks", ResourceID: "cpu*"},
+			exp: []string{"/tasks/cpu_high", "/tasks/cpu"},
+		},
+		{
+			f:   audit.Filter{Start: time.Now().Add(time.Hour)},
+			exp: nil,
+		},
+		{
+			f:   audit.Filter{User: "alice"},
+			exp: nil,
+		},
+	}
+	for _, tc := range testCases {
+		entries, err := s.ListEntries(tc.f, 0, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, e := range entries {
+			got = append(got, e.Path)
+		}
+		if strings.Join(got, ",") != strings.Join(tc.exp, ",") {
+			t.Errorf("unexpected entries for filter %+v: got %v exp %v", tc.f, got, tc.exp)
+		}
+	}
+
+	entries, err := s.ListEntries(audit.Filter{}, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Path != "/templates/cpu" || entries[1].Path != "/tasks/mem" {
+		t.Errorf("unexpected page of entries: %+v", entries)
+	}
+}
@@ -0,0 +1,311 @@
+package blob
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/thingnario/kapacitor/services/storage"
+)
+
+var (
+	ErrNoBlobExists = errors.New("no blob exists")
+	ErrNoTagExists  = errors.New("no tag exists")
+	ErrInvalidID    = errors.New("invalid blob ID, must be the hex encoded SHA256 sum of the content")
+)
+
+// Data access object for blob metadata.
+type BlobDAO interface {
+	// Retrieve a blob
+	Get(id string) (Blob, error)
+
+	// Put a blob, replacing any existing blob with the same ID.
+	Put(blob Blob) error
+
+	// Delete a blob.
+	// It is not an error to delete an non-existent blob.
+	Delete(id string) error
+
+	// List blobs matching a pattern.
+	// The pattern is shell/glob matching see https://golang.org/pkg/path/#Match
+	// Offset and limit are pagination bounds. Offset is inclusive starting at index 0.
+	// More results may exist while the number of returned items is equal to limit.
+	List(pattern string, offset, limit int) ([]Blob, error)
+
+	// Rebuild fixes all indexes of the data.
+	Rebuild() error
+}
+
+// Data access object for blob tags.
+type TagDAO interface {
+	// Retrieve a tag
+	Get(name string) (Tag, error)
+
+	// Put a tag, replacing any existing tag with the same name.
+	Put(tag Tag) error
+
+	// Delete a tag.
+	// It is not an error to delete an non-existent tag.
+	Delete(name string) error
+
+	// List tags matching a pattern.
+	// The pattern is shell/glob matching see https://golang.org/pkg/path/#Match
+	// Offset and limit are pagination bounds. Offset is inclusive starting at index 0.
+	// More results may exist while the number of returned items is equal to limit.
+	List(pattern string, offset, limit int) ([]Tag, error)
+
+	// Rebuild fixes all indexes of the data.
+	Rebuild() error
+}
+
+//--------------------------------------------------------------------
+// The following structures are stored in a database via JSON encoding.
+// Changes to the structures could break existing data.
+
+const (
+	blobVersion1 = 1
+	tagVersion1  = 1
+)
+
+// Blob is the metadata of a blob.
+// The content of the blob is stored separately in chunks.
+type Blob struct {
+	// ID is the hex encoded SHA256 sum of the content of the blob.
+	ID   string `json:"id"`
+	Size int64  `json:"size"`
+	// DataKey is the key prefix under which the chunks of the blob are stored.
+	DataKey string    `json:"data-key"`
+	Chunks  int       `json:"chunks"`
+	Created time.Time `json:"created"`
+}
+
+func (b Blob) ObjectID() string {
+	return b.ID
+}
+
+func (b Blob) MarshalBinary() ([]byte, error) {
+	return storage.VersionJSONEncode(blobVersion1, b)
+}
+
+func (b *Blob) UnmarshalBinary(data []byte) error {
+	return storage.VersionJSONDecode(data, func(version int, dec *json.Decoder) error {
+		switch version {
+		case blobVersion1:
+			return dec.Decode(b)
+		default:
+			return fmt.Errorf("unsupported blob version %d", version)
+		}
+	})
+}
+
+// Tag associates a name with a blob.
+// The history of the tag is preserved, the last entry is the current association.
+type Tag struct {
+	Name    string     `json:"name"`
+	History []TagEntry `json:"history"`
+}
+
+// TagEntry records that a tag was associated with a blob at a point in time.
+type TagEntry struct {
+	Blob string    `json:"blob"`
+	Time time.Time `json:"time"`
+}
+
+// Current returns the ID of the blob the tag is currently associated with.
+func (t Tag) Current() string {
+	if len(t.History) == 0 {
+		return ""
+	}
+	return t.History[len(t.History)-1].Blob
+}
+
+func (t Tag) ObjectID() string {
+	return t.Name
+}
+
+func (t Tag) MarshalBinary() ([]byte, error) {
+	return storage.VersionJSONEncode(tagVersion1, t)
+}
+
+func (t *Tag) UnmarshalBinary(data []byte) error {
+	return storage.VersionJSONDecode(data, func(version int, dec *json.Decoder) error {
+		switch version {
+		case tagVersion1:
+			return dec.Decode(t)
+		default:
+			return fmt.Errorf("unsupported tag version %d", version)
+		}
+	})
+}
+
+// Key/Value based implementation of the BlobDAO.
+type blobKV struct {
+	store *storage.IndexedStore
+}
+
+func newBlobKV(store storage.Interface) (*blobKV, error) {
+	c := storage.DefaultIndexedStoreConfig("blobs", func() storage.BinaryObject {
+		return new(Blob)
+	})
+	istore, err := storage.NewIndexedStore(store, c)
+	if err != nil {
+		return nil, err
+	}
+	return &blobKV{
+		store: istore,
+	}, nil
+}
+
+func (kv *blobKV) Rebuild() error {
+	return kv.store.Rebuild()
+}
+
+func (kv *blobKV) error(err error) error {
+	if err == storage.ErrNoObjectExists {
+		return ErrNoBlobExists
+	}
+	return err
+}
+
+func (kv *blobKV) Get(id string) (Blob, error) {
+	o, err := kv.store.Get(id)
+	if err != nil {
+		return Blob{}, kv.error(err)
+	}
+	b, ok := o.(*Blob)
+	if !ok {
+		return Blob{}, storage.ImpossibleTypeErr(b, o)
+	}
+	return *b, nil
+}
+
+func (kv *blobKV) Put(b Blob) error {
+	return kv.error(kv.store.Put(&b))
+}
+
+func (kv *blobKV) Delete(id string) error {
+	return kv.store.Delete(id)
+}
+
+func (kv *blobKV) List(pattern string, offset, limit int) ([]Blob, error) {
+	objects, err := kv.store.List(storage.DefaultIDIndex, pattern, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	blobs := make([]Blob, len(objects))
+	for i, o := range objects {
+		b, ok := o.(*Blob)
+		if !ok {
+			return nil, storage.ImpossibleTypeErr(b, o)
+		}
+		blobs[i] = *b
+	}
+	return blobs, nil
+}
+
+// Key/Value based implementation of the TagDAO.
+type tagKV struct {
+	store *storage.IndexedStore
+}
+
+func newTagKV(store storage.Interface) (*tagKV, error) {
+	c := storage.DefaultIndexedStoreConfig("tags", func() storage.BinaryObject {
+		return new(Tag)
+	})
+	istore, err := storage.NewIndexedStore(store, c)
+	if err != nil {
+		return nil, err
+	}
+	return &tagKV{
+		store: istore,
+	}, nil
+}
+
+func (kv *tagKV) Rebuild() error {
+	return kv.store.Rebuild()
+}
+
+func (kv *tagKV) error(err error) error {
+	if err == storage.ErrNoObjectExists {
+		return ErrNoTagExists
+	}
+	return err
+}
+
+func (kv *tagKV) Get(name string) (Tag, error) {
+	o, err := kv.store.Get(name)
+	if err != nil {
+		return Tag{}, kv.error(err)
+	}
+	t, ok := o.(*Tag)
+	if !ok {
+		return Tag{}, storage.ImpossibleTypeErr(t, o)
+	}
+	return *t, nil
+}
+
+func (kv *tagKV) Put(t Tag) error {
+	return kv.error(kv.store.Put(&t))
+}
+
+func (kv *tagKV) Delete(name string) error {
+	return kv.store.Delete(name)
+}
+
+func (kv *tagKV) List(pattern string, offset, limit int) ([]Tag, error) {
+	objects, err := kv.store.List(storage.DefaultIDIndex, pattern, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	tags := make([]Tag, len(objects))
+	for i, o := range objects {
+		t, ok := o.(*Tag)
+		if !ok {
+			return nil, storage.ImpossibleTypeErr(t, o)
+		}
+		tags[i] = *t
+	}
+	return tags, nil
+}
+
+// chunkKV stores the content of blobs in fixed size chunks,
+// so that blobs never need to be held in memory as a whole.
+type chunkKV struct {
+	store storage.Interface
+}
+
+func chunkKey(dataKey string, i int) string {
+	return fmt.Sprintf("/chunks/%s/%08d", dataKey, i)
+}
+
+func (kv *chunkKV) Put(dataKey string, i int, data []byte) error {
+	return kv.store.Update(func(tx storage.Tx) error {
+		return tx.Put(chunkKey(dataKey, i), data)
+	})
+}
+
+func (kv *chunkKV) Get(dataKey string, i int) ([]byte, error) {
+	var data []byte
+	err := kv.store.View(func(tx storage.ReadOnlyTx) error {
+		kv, err := tx.Get(chunkKey(dataKey, i))
+		if err != nil {
+			return err
+		}
+		data = kv.Value
+		return nil
+	})
+	return data, err
+}
+
+// Delete the first n chunks stored under the data key.
+func (kv *chunkKV) Delete(dataKey string, n int) error {
+	return kv.store.Update(func(tx storage.Tx) error {
+		for i := 0; i < n; i++ {
+			if err := tx.Delete(chunkKey(dataKey, i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,25 @@
+/*
+The blob package provides a store for arbitrary immutable data, see BLOB_STORE_DESIGN.md.
+
+Blobs are identified by the hex encoded SHA256 sum of their content,
+creating a blob with content that is already stored returns the existing blob.
+The content is streamed in and out of the store in fixed size chunks,
+so that a blob is never held in memory as a whole.
+
+Tags are names associated with a blob. A tag may be moved to a different blob,
+the history of the associations is kept and the most recent association is the current one.
+
+The store is exposed via the HTTP API:
+
+	POST   /kapacitor/v1/blobs[?tag=<name>]        create a blob from the request body
+	GET    /kapacitor/v1/blobs                      list blobs
+	GET    /kapacitor/v1/blobs/<id>                 blob metadata
+	GET    /kapacitor/v1/blobs/<id>/data            blob content
+	DELETE /kapacitor/v1/blobs/<id>                 delete a blob
+	GET    /kapacitor/v1/blobs/tags                 list tags
+	GET    /kapacitor/v1/blobs/tags/<name>          tag and its history
+	GET    /kapacitor/v1/blobs/tags/<name>/data     content of the current blob of the tag
+	PUT    /kapacitor/v1/blobs/tags/<name>          associate the tag with the blob {"blob": "<id>"}
+	DELETE /kapacitor/v1/blobs/tags/<name>          delete a tag
+*/
+package blob
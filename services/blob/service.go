@@ -0,0 +1,636 @@
+package blob
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thingnario/kapacitor/client/v1"
+	"github.com/thingnario/kapacitor/services/httpd"
+	"github.com/thingnario/kapacitor/services/storage"
+	"github.com/thingnario/kapacitor/uuid"
+)
+
+const (
+	blobsPath         = "/blobs"
+	blobsPathAnchored = "/blobs/"
+	blobsBasePath     = httpd.BasePath + blobsPathAnchored
+	tagsPath          = "tags"
+	dataPath          = "data"
+
+	// Public name of the blobs store
+	blobsAPIName = "blobs"
+	// Public name of the blob tags store
+	tagsAPIName = "blob-tags"
+	// The storage namespace for blob metadata, tags and content.
+	blobsNamespace  = "blob_store"
+	tagsNamespace   = "blob_tag_store"
+	chunksNamespace = "blob_chunk_store"
+
+	// Size of the chunks the content of blobs is stored in.
+	chunkSize = 512 * 1024
+)
+
+var validID = regexp.MustCompile(`^[0-9a-f]{64}$`)
+var validTagName = regexp.MustCompile(`^[-\._\p{L}0-9]+$`)
+
+type Diagnostic interface {
+	Error(msg string, err error)
+}
+
+// Service is a content addressed store of immutable blobs.
+// Blobs may be associated with named tags, the history of each tag is preserved.
+type Service struct {
+	// mu serializes changes to the metadata of blobs and tags.
+	mu sync.Mutex
+
+	blobs  BlobDAO
+	tags   TagDAO
+	chunks *chunkKV
+
+	routes []httpd.Route
+
+	StorageService interface {
+		Store(namespace string) storage.Interface
+		Register(name string, store storage.StoreActioner)
+	}
+	HTTPDService interface {
+		AddRoutes([]httpd.Route) error
+		DelRoutes([]httpd.Route)
+	}
+
+	diag Diagnostic
+}
+
+func NewService(d Diagnostic) *Service {
+	return &Service{
+		diag: d,
+	}
+}
+
+func (s *Service) Open() error {
+	// Create DAOs
+	blobs, err := newBlobKV(s.StorageService.Store(blobsNamespace))
+	if err != nil {
+		return err
+	}
+	s.blobs = blobs
+	s.StorageService.Register(blobsAPIName, s.blobs)
+
+	tags, err := newTagKV(s.StorageService.Store(tagsNamespace))
+	if err != nil {
+		return err
+	}
+	s.tags = tags
+	s.StorageService.Register(tagsAPIName, s.tags)
+
+	s.chunks = &chunkKV{store: s.StorageService.Store(chunksNamespace)}
+
+	// Setup routes
+	s.routes = []httpd.Route{
+		{
+			Method:      "GET",
+			Pattern:     blobsPathAnchored,
+			HandlerFunc: s.handleGet,
+		},
+		{
+			Method:      "PUT",
+			Pattern:     blobsPathAnchored,
+			HandlerFunc: s.handlePut,
+		},
+		{
+			Method:      "DELETE",
+			Pattern:     blobsPathAnchored,
+			HandlerFunc: s.handleDelete,
+		},
+		{
+			Method:      "OPTIONS",
+			Pattern:     blobsPathAnchored,
+			HandlerFunc: httpd.ServeOptions,
+		},
+		{
+			Method:      "GET",
+			Pattern:     blobsPath,
+			HandlerFunc: s.handleListBlobs,
+		},
+		{
+			Method:      "POST",
+			Pattern:     blobsPath,
+			HandlerFunc: s.handleCreateBlob,
+		},
+	}
+	return s.HTTPDService.AddRoutes(s.routes)
+}
+
+func (s *Service) Close() error {
+	if s.HTTPDService != nil {
+		s.HTTPDService.DelRoutes(s.routes)
+	}
+	return nil
+}
+
+// Create stores the content read from r as a blob and returns its metadata.
+// The content is streamed into the store in chunks.
+// If a blob with the same content already exists the existing blob is returned.
+func (s *Service) Create(r io.Reader) (Blob, error) {
+	dataKey := uuid.New().String()
+	h := sha256.New()
+	var size int64
+	chunks := 0
+	for {
+		buf := make([]byte, chunkSize)
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			h.Write(buf[:n])
+			size += int64(n)
+			if err := s.chunks.Put(dataKey, chunks, buf[:n]); err != nil {
+				s.deleteChunks(dataKey, chunks+1)
+				return Blob{}, err
+			}
+			chunks++
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			s.deleteChunks(dataKey, chunks)
+			return Blob{}, err
+		}
+	}
+	id := hex.EncodeToString(h.Sum(nil))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, err := s.blobs.Get(id)
+	if err == nil {
+		// The content is already stored.
+		s.deleteChunks(dataKey, chunks)
+		return existing, nil
+	} else if err != ErrNoBlobExists {
+		s.deleteChunks(dataKey, chunks)
+		return Blob{}, err
+	}
+	b := Blob{
+		ID:      id,
+		Size:    size,
+		DataKey: dataKey,
+		Chunks:  chunks,
+		Created: time.Now().UTC(),
+	}
+	if err := s.blobs.Put(b); err != nil {
+		s.deleteChunks(dataKey, chunks)
+		return Blob{}, err
+	}
+	return b, nil
+}
+
+func (s *Service) deleteChunks(dataKey string, n int) {
+	if err := s.chunks.Delete(dataKey, n); err != nil {
+		s.diag.Error("failed to delete blob content", err)
+	}
+}
+
+// Get returns the metadata of a blob.
+func (s *Service) Get(id string) (Blob, error) {
+	if !validID.MatchString(id) {
+		return Blob{}, ErrInvalidID
+	}
+	return s.blobs.Get(id)
+}
+
+// OpenBlob returns a reader of the content of a blob.
+// The content is read from the store one chunk at a time.
+func (s *Service) OpenBlob(id string) (Blob, io.ReadCloser, error) {
+	b, err := s.Get(id)
+	if err != nil {
+		return Blob{}, nil, err
+	}
+	return b, &blobReader{blob: b, chunks: s.chunks}, nil
+}
+
+// Read returns the complete content of a blob.
+func (s *Service) Read(id string) ([]byte, error) {
+	b, r, err := s.OpenBlob(id)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	data := make([]byte, 0, b.Size)
+	buf := make([]byte, chunkSize)
+	for {
+		n, err := r.Read(buf)
+		data = append(data, buf[:n]...)
+		if err == io.EOF {
+			return data, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Delete removes a blob from the store and from the history of all tags.
+// Tags that were only ever associated with the blob are deleted.
+func (s *Service) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	tags, err := s.tags.List("", 0, -1)
+	if err != nil {
+		return err
+	}
+	for _, t := range tags {
+		history := t.History[:0]
+		for _, e := range t.History {
+			if e.Blob != id {
+				history = append(history, e)
+			}
+		}
+		if len(history) == len(t.History) {
+			continue
+		}
+		if len(history) == 0 {
+			err = s.tags.Delete(t.Name)
+		} else {
+			t.History = history
+			err = s.tags.Put(t)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := s.blobs.Delete(id); err != nil {
+		return err
+	}
+	s.deleteChunks(b.DataKey, b.Chunks)
+	return nil
+}
+
+// List returns the metadata of the blobs whose ID matches the pattern.
+func (s *Service) List(pattern string, offset, limit int) ([]Blob, error) {
+	return s.blobs.List(pattern, offset, limit)
+}
+
+// Tag associates the tag with a blob, creating the tag if it does not exist.
+func (s *Service) Tag(name, id string) (Tag, error) {
+	if !validTagName.MatchString(name) {
+		return Tag{}, fmt.Errorf("tag name must contain only letters, numbers, '-', '.' and '_'. %q", name)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.Get(id); err != nil {
+		return Tag{}, err
+	}
+	t, err := s.tags.Get(name)
+	if err == ErrNoTagExists {
+		t = Tag{Name: name}
+	} else if err != nil {
+		return Tag{}, err
+	}
+	if t.Current() == id {
+		return t, nil
+	}
+	t.History = append(t.History, TagEntry{
+		Blob: id,
+		Time: time.Now().UTC(),
+	})
+	if err := s.tags.Put(t); err != nil {
+		return Tag{}, err
+	}
+	return t, nil
+}
+
+// GetTag returns a tag and its history.
+func (s *Service) GetTag(name string) (Tag, error) {
+	return s.tags.Get(name)
+}
+
+// Resolve returns the ID of the blob currently associated with the tag.
+func (s *Service) Resolve(name string) (string, error) {
+	t, err := s.tags.Get(name)
+	if err != nil {
+		return "", err
+	}
+	return t.Current(), nil
+}
+
+// DeleteTag deletes a tag, the blobs associated with the tag are not deleted.
+func (s *Service) DeleteTag(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.tags.Get(name); err != nil {
+		return err
+	}
+	return s.tags.Delete(name)
+}
+
+// ListTags returns the tags whose name matches the pattern.
+func (s *Service) ListTags(pattern string, offset, limit int) ([]Tag, error) {
+	return s.tags.List(pattern, offset, limit)
+}
+
+// blobReader reads the content of a blob one chunk at a time.
+type blobReader struct {
+	blob   Blob
+	chunks *chunkKV
+	next   int
+	buf    []byte
+}
+
+func (r *blobReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.next >= r.blob.Chunks {
+			return 0, io.EOF
+		}
+		data, err := r.chunks.Get(r.blob.DataKey, r.next)
+		if err == storage.ErrNoKeyExists {
+			return 0, ErrNoBlobExists
+		}
+		if err != nil {
+			return 0, err
+		}
+		r.buf = data
+		r.next++
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func (r *blobReader) Close() error {
+	r.buf = nil
+	r.next = r.blob.Chunks
+	return nil
+}
+
+func blobLink(id string) client.Link {
+	return client.Link{Relation: client.Self, Href: path.Join(httpd.BasePath, blobsPath, id)}
+}
+
+func tagLink(name string) client.Link {
+	return client.Link{Relation: client.Self, Href: path.Join(httpd.BasePath, blobsPath, tagsPath, name)}
+}
+
+func convertBlob(b Blob) client.Blob {
+	return client.Blob{
+		Link:    blobLink(b.ID),
+		ID:      b.ID,
+		Size:    b.Size,
+		Created: b.Created,
+	}
+}
+
+func convertTag(t Tag) client.BlobTag {
+	history := make([]client.BlobTagEntry, len(t.History))
+	for i, e := range t.History {
+		history[i] = client.BlobTagEntry{
+			Blob: e.Blob,
+			Time: e.Time,
+		}
+	}
+	return client.BlobTag{
+		Link:    tagLink(t.Name),
+		Name:    t.Name,
+		Blob:    t.Current(),
+		History: history,
+	}
+}
+
+// blobPath is the parsed path of a request below the blobs path.
+type blobPath struct {
+	// Either ID or Tag is set, unless the path refers to the list of tags.
+	ID   string
+	Tag  string
+	Tags bool
+	// Data is true if the path refers to the content of the blob.
+	Data bool
+}
+
+func parseBlobPath(p string) (blobPath, error) {
+	if !strings.HasPrefix(p, blobsBasePath) {
+		return blobPath{}, fmt.Errorf("invalid blob path %q", p)
+	}
+	parts := strings.Split(strings.TrimSuffix(p[len(blobsBasePath):], "/"), "/")
+	var bp blobPath
+	if parts[0] == tagsPath {
+		parts = parts[1:]
+		if len(parts) == 0 {
+			bp.Tags = true
+			return bp, nil
+		}
+		bp.Tag = parts[0]
+	} else {
+		bp.ID = parts[0]
+	}
+	switch {
+	case len(parts) == 1:
+	case len(parts) == 2 && parts[1] == dataPath:
+		bp.Data = true
+	default:
+		return blobPath{}, fmt.Errorf("invalid blob path %q", p)
+	}
+	if bp.ID == "" && bp.Tag == "" {
+		return blobPath{}, fmt.Errorf("invalid blob path %q", p)
+	}
+	return bp, nil
+}
+
+func errorCode(err error) int {
+	switch err {
+	case ErrNoBlobExists, ErrNoTagExists:
+		return http.StatusNotFound
+	case ErrInvalidID:
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+func (s *Service) handleGet(w http.ResponseWriter, r *http.Request) {
+	bp, err := parseBlobPath(r.URL.Path)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
+		return
+	}
+	switch {
+	case bp.Tags:
+		s.handleListTags(w, r)
+	case bp.Data:
+		id := bp.ID
+		if bp.Tag != "" {
+			id, err = s.Resolve(bp.Tag)
+			if err != nil {
+				httpd.HttpError(w, err.Error(), true, errorCode(err))
+				return
+			}
+		}
+		b, rc, err := s.OpenBlob(id)
+		if err != nil {
+			httpd.HttpError(w, err.Error(), true, errorCode(err))
+			return
+		}
+		defer rc.Close()
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Length", strconv.FormatInt(b.Size, 10))
+		w.Header().Set("X-Kapacitor-Blob-ID", b.ID)
+		w.WriteHeader(http.StatusOK)
+		if _, err := io.Copy(w, rc); err != nil {
+			s.diag.Error("failed to write blob content", err)
+		}
+	case bp.Tag != "":
+		t, err := s.GetTag(bp.Tag)
+		if err != nil {
+			httpd.HttpError(w, err.Error(), true, errorCode(err))
+			return
+		}
+		w.Write(httpd.MarshalJSON(convertTag(t), true))
+	default:
+		b, err := s.Get(bp.ID)
+		if err != nil {
+			httpd.HttpError(w, err.Error(), true, errorCode(err))
+			return
+		}
+		w.Write(httpd.MarshalJSON(convertBlob(b), true))
+	}
+}
+
+func (s *Service) handlePut(w http.ResponseWriter, r *http.Request) {
+	bp, err := parseBlobPath(r.URL.Path)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
+		return
+	}
+	if bp.Tag == "" || bp.Data {
+		httpd.HttpError(w, "only tags can be updated, blobs are immutable", true, http.StatusMethodNotAllowed)
+		return
+	}
+	opt := client.TagBlobOptions{}
+	if err := json.NewDecoder(r.Body).Decode(&opt); err != nil {
+		httpd.HttpError(w, "invalid JSON: "+err.Error(), true, http.StatusBadRequest)
+		return
+	}
+	t, err := s.Tag(bp.Tag, opt.Blob)
+	if err != nil {
+		code := errorCode(err)
+		if code == http.StatusInternalServerError {
+			code = http.StatusBadRequest
+		}
+		httpd.HttpError(w, err.Error(), true, code)
+		return
+	}
+	w.Write(httpd.MarshalJSON(convertTag(t), true))
+}
+
+func (s *Service) handleDelete(w http.ResponseWriter, r *http.Request) {
+	bp, err := parseBlobPath(r.URL.Path)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
+		return
+	}
+	switch {
+	case bp.Tags || bp.Data:
+		httpd.HttpError(w, fmt.Sprintf("cannot delete %q", r.URL.Path), true, http.StatusMethodNotAllowed)
+		return
+	case bp.Tag != "":
+		err = s.DeleteTag(bp.Tag)
+	default:
+		err = s.Delete(bp.ID)
+	}
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, errorCode(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Service) handleCreateBlob(w http.ResponseWriter, r *http.Request) {
+	tag := r.URL.Query().Get("tag")
+	if tag != "" && !validTagName.MatchString(tag) {
+		httpd.HttpError(w, fmt.Sprintf("tag name must contain only letters, numbers, '-', '.' and '_'. %q", tag), true, http.StatusBadRequest)
+		return
+	}
+	b, err := s.Create(r.Body)
+	if err != nil {
+		httpd.HttpError(w, "failed to create blob: "+err.Error(), true, http.StatusInternalServerError)
+		return
+	}
+	if tag != "" {
+		if _, err := s.Tag(tag, b.ID); err != nil {
+			httpd.HttpError(w, "failed to tag blob: "+err.Error(), true, http.StatusInternalServerError)
+			return
+		}
+	}
+	w.Write(httpd.MarshalJSON(convertBlob(b), true))
+}
+
+func listOptions(r *http.Request) (pattern string, offset, limit int, err error) {
+	pattern = r.URL.Query().Get("pattern")
+	offset = 0
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		offset, err = strconv.Atoi(offsetStr)
+		if err != nil {
+			return "", 0, 0, fmt.Errorf("invalid offset parameter %q must be an integer: %s", offsetStr, err)
+		}
+	}
+	limit = 100
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil {
+			return "", 0, 0, fmt.Errorf("invalid limit parameter %q must be an integer: %s", limitStr, err)
+		}
+	}
+	return
+}
+
+func (s *Service) handleListBlobs(w http.ResponseWriter, r *http.Request) {
+	pattern, offset, limit, err := listOptions(r)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
+		return
+	}
+	blobs, err := s.List(pattern, offset, limit)
+	if err != nil {
+		httpd.HttpError(w, fmt.Sprintf("failed to list blobs with pattern %q: %s", pattern, err), true, http.StatusBadRequest)
+		return
+	}
+	type response struct {
+		Blobs []client.Blob `json:"blobs"`
+	}
+	resp := response{Blobs: make([]client.Blob, len(blobs))}
+	for i, b := range blobs {
+		resp.Blobs[i] = convertBlob(b)
+	}
+	w.Write(httpd.MarshalJSON(resp, true))
+}
+
+func (s *Service) handleListTags(w http.ResponseWriter, r *http.Request) {
+	pattern, offset, limit, err := listOptions(r)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
+		return
+	}
+	tags, err := s.ListTags(pattern, offset, limit)
+	if err != nil {
+		httpd.HttpError(w, fmt.Sprintf("failed to list tags with pattern %q: %s", pattern, err), true, http.StatusBadRequest)
+		return
+	}
+	type response struct {
+		Tags []client.BlobTag `json:"tags"`
+	}
+	resp := response{Tags: make([]client.BlobTag, len(tags))}
+	for i, t := range tags {
+		resp.Tags[i] = convertTag(t)
+	}
+	w.Write(httpd.MarshalJSON(resp, true))
+}
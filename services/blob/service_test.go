@@ -0,0 +1,243 @@
+package blob_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/thingnario/kapacitor/services/blob"
+	"github.com/thingnario/kapacitor/services/httpd"
+	"github.com/thingnario/kapacitor/services/storage/storagetest"
+)
+
+type diag struct{}
+
+func (diag) Error(msg string, err error) {}
+
+// routes records the routes of the service so requests can be served without the httpd service.
+type routes struct {
+	routes []httpd.Route
+}
+
+func (r *routes) AddRoutes(routes []httpd.Route) error {
+	r.routes = append(r.routes, routes...)
+	return nil
+}
+
+func (r *routes) DelRoutes([]httpd.Route) {}
+
+func (r *routes) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	p := strings.TrimPrefix(req.URL.Path, httpd.BasePath)
+	for _, route := range r.routes {
+		if route.Method != req.Method {
+			continue
+		}
+		if p == route.Pattern || (strings.HasSuffix(route.Pattern, "/") && strings.HasPrefix(p, route.Pattern)) {
+			route.HandlerFunc.(func(http.ResponseWriter, *http.Request))(w, req)
+			return
+		}
+	}
+	http.NotFound(w, req)
+}
+
+func newService(t *testing.T) (*blob.Service, *routes) {
+	t.Helper()
+	r := new(routes)
+	s := blob.NewService(diag{})
+	s.StorageService = storagetest.New()
+	s.HTTPDService = r
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	return s, r
+}
+
+func sum(data []byte) string {
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:])
+}
+
+func TestService_CreateRead(t *testing.T) {
+	s, _ := newService(t)
+	// Larger than a single chunk and not a multiple of the chunk size
+	data := bytes.Repeat([]byte("0123456789abcdef"), 100*1024+3)
+	b, err := s.Create(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := b.ID, sum(data); got != exp {
+		t.Errorf("unexpected blob ID got %s exp %s", got, exp)
+	}
+	if got, exp := b.Size, int64(len(data)); got != exp {
+		t.Errorf("unexpected blob size got %d exp %d", got, exp)
+	}
+	got, err := s.Read(b.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Error("read content does not match created content")
+	}
+
+	// Creating the same content again returns the existing blob.
+	again, err := s.Create(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != b {
+		t.Errorf("unexpected blob for duplicate content got %v exp %v", again, b)
+	}
+	blobs, err := s.List("", 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blobs) != 1 {
+		t.Errorf("unexpected number of blobs got %d exp 1", len(blobs))
+	}
+}
+
+func TestService_CreateEmpty(t *testing.T) {
+	s, _ := newService(t)
+	b, err := s.Create(bytes.NewReader(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.Read(b.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("unexpected content got %q exp empty", got)
+	}
+}
+
+func TestService_TagHistory(t *testing.T) {
+	s, _ := newService(t)
+	b1, err := s.Create(strings.NewReader("v1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b2, err := s.Create(strings.NewReader("v2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Tag("model", b1.ID); err != nil {
+		t.Fatal(err)
+	}
+	tag, err := s.Tag("model", b2.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := tag.Current(), b2.ID; got != exp {
+		t.Errorf("unexpected current blob got %s exp %s", got, exp)
+	}
+	if got, exp := len(tag.History), 2; got != exp {
+		t.Fatalf("unexpected history length got %d exp %d", got, exp)
+	}
+	if got, exp := tag.History[0].Blob, b1.ID; got != exp {
+		t.Errorf("unexpected first history entry got %s exp %s", got, exp)
+	}
+
+	if _, err := s.Tag("model", sum([]byte("missing"))); err != blob.ErrNoBlobExists {
+		t.Errorf("unexpected error tagging missing blob got %v exp %v", err, blob.ErrNoBlobExists)
+	}
+	if _, err := s.Tag("bad name", b1.ID); err == nil {
+		t.Error("expected error tagging with an invalid name")
+	}
+
+	// Deleting the current blob restores the previous association.
+	if err := s.Delete(b2.ID); err != nil {
+		t.Fatal(err)
+	}
+	id, err := s.Resolve("model")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != b1.ID {
+		t.Errorf("unexpected tag blob after delete got %s exp %s", id, b1.ID)
+	}
+	if _, err := s.Read(b2.ID); err != blob.ErrNoBlobExists {
+		t.Errorf("unexpected error reading deleted blob got %v exp %v", err, blob.ErrNoBlobExists)
+	}
+
+	// Deleting the last blob of a tag deletes the tag.
+	if err := s.Delete(b1.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.GetTag("model"); err != blob.ErrNoTagExists {
+		t.Errorf("unexpected error getting deleted tag got %v exp %v", err, blob.ErrNoTagExists)
+	}
+}
+
+func TestService_HTTP(t *testing.T) {
+	_, r := newService(t)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+	base := ts.URL + httpd.BasePath + "/blobs"
+
+	resp, err := http.Post(base+"?tag=model", "application/octet-stream", strings.NewReader("content"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected create status got %d exp %d", resp.StatusCode, http.StatusOK)
+	}
+
+	for _, p := range []string{"/" + sum([]byte("content")) + "/data", "/tags/model/data"} {
+		resp, err := http.Get(base + p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s: unexpected status got %d exp %d", p, resp.StatusCode, http.StatusOK)
+		}
+		if string(data) != "content" {
+			t.Errorf("%s: unexpected content got %q exp %q", p, data, "content")
+		}
+	}
+
+	resp, err = http.Get(base + "/tags/missing/data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("unexpected status for missing tag got %d exp %d", resp.StatusCode, http.StatusNotFound)
+	}
+
+	deletes := []struct {
+		path string
+		exp  int
+	}{
+		{path: "/not-an-id", exp: http.StatusBadRequest},
+		{path: "/" + sum([]byte("missing")), exp: http.StatusNotFound},
+		{path: "/tags/missing", exp: http.StatusNotFound},
+		{path: "/tags/model", exp: http.StatusNoContent},
+		{path: "/" + sum([]byte("content")), exp: http.StatusNoContent},
+	}
+	for _, d := range deletes {
+		req, err := http.NewRequest("DELETE", base+d.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != d.exp {
+			t.Errorf("%s: unexpected delete status got %d exp %d", d.path, resp.StatusCode, d.exp)
+		}
+	}
+}
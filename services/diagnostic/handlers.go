@@ -472,6 +472,7 @@ func (h *HTTPDHandler) WriteBodyReceived(body string) {
 func (h *HTTPDHandler) HTTP(
 	host string,
 	username string,
+	tokenID string,
 	start time.Time,
 	method string,
 	uri string,
@@ -485,6 +486,7 @@ func (h *HTTPDHandler) HTTP(
 	h.l.Info("http request",
 		String("host", host),
 		String("username", username),
+		String("token-id", tokenID),
 		Time("start", start),
 		String("method", method),
 		String("uri", uri),
@@ -642,6 +644,14 @@ func (h *TaskStoreHandler) Migrated(entity, id string) {
 	h.l.Debug("entity was migrated to new storage service", String(entity, id))
 }
 
+func (h *TaskStoreHandler) LoopbackCycle(taskID string, cycle []string) {
+	h.l.Info("task is part of a kapacitorLoopback cycle", String("task", taskID), Strings("cycle", cycle))
+}
+
+func (h *TaskStoreHandler) DiscardedPausedPoints(taskID string, count int64) {
+	h.l.Info("discarded points buffered while the task was paused", String("task", taskID), Int64("count", count))
+}
+
 // VictorOps Handler
 
 type VictorOpsHandler struct {
@@ -963,6 +973,82 @@ func (h *DeadmanHandler) ConfiguredGlobally() {
 	h.l.Info("Deadman's switch is configured globally")
 }
 
+// NodeState handler
+
+type NodeStateHandler struct {
+	l Logger
+}
+
+func (h *NodeStateHandler) Error(msg string, err error) {
+	h.l.Error(msg, Error(err))
+}
+
+func (h *NodeStateHandler) DeletedExpiredKeys(count int) {
+	h.l.Debug("deleted expired node state keys", Int("count", count))
+}
+
+// Blob handler
+
+type BlobHandler struct {
+	l Logger
+}
+
+func (h *BlobHandler) Error(msg string, err error) {
+	h.l.Error(msg, Error(err))
+}
+
+// Redis handler
+
+type RedisHandler struct {
+	l Logger
+}
+
+func (h *RedisHandler) Error(msg string, err error) {
+	h.l.Error(msg, Error(err))
+}
+
+// Redis ingest handler
+
+type RedisIngestHandler struct {
+	l Logger
+}
+
+func (h *RedisIngestHandler) Error(msg string, err error, ctx ...keyvalue.T) {
+	Err(h.l, msg, err, ctx)
+}
+
+func (h *RedisIngestHandler) StartedConsuming(streams, channels []string) {
+	h.l.Info("started consuming from redis", Strings("streams", streams), Strings("channels", channels))
+}
+
+func (h *RedisIngestHandler) ClosedService() {
+	h.l.Info("closed service")
+}
+
+// LocalAuth handler
+
+type LocalAuthHandler struct {
+	l Logger
+}
+
+func (h *LocalAuthHandler) Error(msg string, err error) {
+	h.l.Error(msg, Error(err))
+}
+
+func (h *LocalAuthHandler) CreatedAdminUser(username string) {
+	h.l.Info("created admin user", String("user", username))
+}
+
+// Audit handler
+
+type AuditHandler struct {
+	l Logger
+}
+
+func (h *AuditHandler) Error(msg string, err error) {
+	h.l.Error(msg, Error(err))
+}
+
 // NoAuth handler
 
 type NoAuthHandler struct {
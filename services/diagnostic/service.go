@@ -324,12 +324,48 @@ func (s *Service) NewDeadmanHandler() *DeadmanHandler {
 	}
 }
 
+func (s *Service) NewLocalAuthHandler() *LocalAuthHandler {
+	return &LocalAuthHandler{
+		l: s.Logger.With(String("service", "localauth")),
+	}
+}
+
+func (s *Service) NewAuditHandler() *AuditHandler {
+	return &AuditHandler{
+		l: s.Logger.With(String("service", "audit")),
+	}
+}
+
 func (s *Service) NewNoAuthHandler() *NoAuthHandler {
 	return &NoAuthHandler{
 		l: s.Logger.With(String("service", "noauth")),
 	}
 }
 
+func (s *Service) NewNodeStateHandler() *NodeStateHandler {
+	return &NodeStateHandler{
+		l: s.Logger.With(String("service", "node-state")),
+	}
+}
+
+func (s *Service) NewBlobHandler() *BlobHandler {
+	return &BlobHandler{
+		l: s.Logger.With(String("service", "blob")),
+	}
+}
+
+func (s *Service) NewRedisHandler() *RedisHandler {
+	return &RedisHandler{
+		l: s.Logger.With(String("service", "redis")),
+	}
+}
+
+func (s *Service) NewRedisIngestHandler(name string) *RedisIngestHandler {
+	return &RedisIngestHandler{
+		l: s.Logger.With(String("service", "redis-ingest"), String("name", name)),
+	}
+}
+
 func (s *Service) NewStatsHandler() *StatsHandler {
 	return &StatsHandler{
 		l: s.Logger.With(String("service", "stats")),
@@ -1,8 +1,14 @@
 package httpd
 
 import (
+	"errors"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/dgrijalva/jwt-go"
 	"github.com/thingnario/kapacitor/auth"
 )
 
@@ -103,3 +109,155 @@ func Test_RequiredPrilegeForHTTPMethod(t *testing.T) {
 		}
 	}
 }
+
+func Test_ParseCredentials(t *testing.T) {
+	testCases := []struct {
+		header string
+		exp    credentials
+	}{
+		{
+			header: "Bearer eyJhbGciOiJIUzI1NiJ9.eyJ1c2VybmFtZSI6ImJvYiJ9.c2ln",
+			exp: credentials{
+				Method: BearerAuthentication,
+				Token:  "eyJhbGciOiJIUzI1NiJ9.eyJ1c2VybmFtZSI6ImJvYiJ9.c2ln",
+			},
+		},
+		{
+			header: "Bearer kapacitor_0123456789abcdef_secret",
+			exp: credentials{
+				Method: APITokenAuthentication,
+				Token:  "kapacitor_0123456789abcdef_secret",
+			},
+		},
+		{
+			header: "Basic Ym9iOnNlY3JldA==",
+			exp: credentials{
+				Method:   UserAuthentication,
+				Username: "bob",
+				Password: "secret",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		r := httptest.NewRequest("GET", "/kapacitor/v1/tasks", nil)
+		r.Header.Set("Authorization", tc.header)
+		got, err := parseCredentials(r)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.header, err)
+			continue
+		}
+		if got != tc.exp {
+			t.Errorf("%s: unexpected credentials: got %+v exp %+v", tc.header, got, tc.exp)
+		}
+	}
+}
+
+type auditService struct {
+	status   int
+	response string
+}
+
+func (a *auditService) Audit(r *http.Request, user auth.User) func(int, []byte) {
+	if r.URL.Path == "/write" {
+		return nil
+	}
+	return func(status int, response []byte) {
+		a.status = status
+		a.response = string(response)
+	}
+}
+
+func Test_ServeAudited(t *testing.T) {
+	a := new(auditService)
+	h := &Handler{AuditService: a}
+	inner := func(w http.ResponseWriter, r *http.Request, user auth.User) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	}
+
+	w := httptest.NewRecorder()
+	h.serveAudited(inner, w, httptest.NewRequest("POST", "/kapacitor/v1/tasks", nil), auth.AdminUser)
+	if a.status != http.StatusCreated || a.response != "created" {
+		t.Errorf("unexpected audited response: got %d %q", a.status, a.response)
+	}
+	if w.Code != http.StatusCreated || w.Body.String() != "created" {
+		t.Errorf("unexpected response: got %d %q", w.Code, w.Body.String())
+	}
+
+	// Reads and requests the audit service ignores are not audited
+	a.status = 0
+	h.serveAudited(inner, httptest.NewRecorder(), httptest.NewRequest("GET", "/kapacitor/v1/tasks", nil), auth.AdminUser)
+	h.serveAudited(inner, httptest.NewRecorder(), httptest.NewRequest("POST", "/write", nil), auth.AdminUser)
+	if a.status != 0 {
+		t.Errorf("unexpected audited request with status %d", a.status)
+	}
+}
+
+// userService knows a single admin user.
+type userService struct {
+	auth.Interface
+}
+
+func (userService) User(username string) (auth.User, error) {
+	if username != "admin" {
+		return auth.User{}, errors.New("unknown user")
+	}
+	return auth.AdminUser, nil
+}
+
+func Test_Authenticate_Bearer(t *testing.T) {
+	sign := func(secret string) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"username": "admin",
+			"exp":      time.Now().Add(time.Hour).Unix(),
+		})
+		s, err := token.SignedString([]byte(secret))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	testCases := []struct {
+		name         string
+		sharedSecret string
+		token        string
+		exp          int
+	}{
+		{
+			name:  "no shared secret",
+			token: sign(""),
+			exp:   http.StatusUnauthorized,
+		},
+		{
+			name:         "wrong secret",
+			sharedSecret: "secret",
+			token:        sign(""),
+			exp:          http.StatusUnauthorized,
+		},
+		{
+			name:         "valid",
+			sharedSecret: "secret",
+			token:        sign("secret"),
+			exp:          http.StatusOK,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := &Handler{
+				AuthService:  userService{},
+				sharedSecret: tc.sharedSecret,
+				statMap:      new(expvar.Map).Init(),
+			}
+			inner := func(w http.ResponseWriter, r *http.Request, user auth.User) {
+				w.WriteHeader(http.StatusOK)
+			}
+			r := httptest.NewRequest("GET", "/kapacitor/v1/tasks", nil)
+			r.Header.Set("Authorization", "Bearer "+tc.token)
+			w := httptest.NewRecorder()
+			authenticate(inner, h, true).ServeHTTP(w, r)
+			if w.Code != tc.exp {
+				t.Errorf("unexpected status got %d exp %d: %s", w.Code, tc.exp, w.Body.String())
+			}
+		})
+	}
+}
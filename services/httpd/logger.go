@@ -93,6 +93,7 @@ func buildLogLine(d Diagnostic, l *responseLogger, r *http.Request, start time.T
 	d.HTTP(
 		host,
 		detect(username, "-"),
+		detect(r.Header.Get(TokenIDHeader), "-"),
 		start,
 		r.Method,
 		uri,
@@ -28,6 +28,7 @@ type Diagnostic interface {
 	HTTP(
 		host string,
 		username string,
+		tokenID string,
 		start time.Time,
 		method string,
 		uri string,
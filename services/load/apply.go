@@ -0,0 +1,611 @@
+package load
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"reflect"
+
+	"github.com/pkg/errors"
+	"github.com/thingnario/kapacitor/auth"
+	"github.com/thingnario/kapacitor/client/v1"
+	"github.com/thingnario/kapacitor/services/httpd"
+	"github.com/thingnario/kapacitor/tick/ast"
+)
+
+const (
+	applyPath = "/apply"
+
+	// API paths of the resources of a manifest set, used to authorize changes.
+	tasksPath     = "/tasks"
+	templatesPath = "/templates"
+	topicsPath    = "/alerts/topics"
+	handlersPath  = "handlers"
+
+	taskKind         = "task"
+	templateKind     = "template"
+	topicHandlerKind = "topic-handler"
+
+	createAction = "create"
+	updateAction = "update"
+	deleteAction = "delete"
+)
+
+// change is a single step of an apply plan.
+// Undo reverts the step once it has been applied.
+type change struct {
+	action client.ApplyAction
+	apply  func() error
+	undo   func() error
+	// Actions the user must be authorized for to apply and undo the change.
+	requires []auth.Action
+}
+
+// applier plans and applies a manifest set with a client whose requests
+// are made as the user that requested the apply.
+type applier struct {
+	cli  *client.Client
+	diag Diagnostic
+}
+
+// newApplier returns an applier making its requests as the user,
+// so that they are authorized, audited and recorded in revisions as requests of the user.
+func (s *Service) newApplier(user auth.User) (*applier, error) {
+	if s.APIHandler == nil {
+		return &applier{cli: s.cli, diag: s.diag}, nil
+	}
+	cli, err := client.New(client.Config{
+		URL:       defaultURL,
+		UserAgent: "internal-load-service",
+		Transport: client.NewLocalTransport(s.APIHandler.ServeAs(user)),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create client")
+	}
+	return &applier{cli: cli, diag: s.diag}, nil
+}
+
+func (s *Service) handleApply(w http.ResponseWriter, r *http.Request, user auth.User) {
+	opt := client.ApplyOptions{}
+	if err := json.NewDecoder(r.Body).Decode(&opt); err != nil {
+		httpd.HttpError(w, "invalid JSON", true, http.StatusBadRequest)
+		return
+	}
+	a, err := s.newApplier(user)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusInternalServerError)
+		return
+	}
+
+	s.applyMu.Lock()
+	defer s.applyMu.Unlock()
+
+	changes, err := a.plan(opt)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
+		return
+	}
+	if err := authorizeChanges(user, changes); err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusForbidden)
+		return
+	}
+	result := client.ApplyResult{
+		Actions: make([]client.ApplyAction, len(changes)),
+	}
+	for i, c := range changes {
+		result.Actions[i] = c.action
+	}
+	if !opt.DryRun {
+		if err := a.applyChanges(changes); err != nil {
+			httpd.HttpError(w, err.Error(), true, http.StatusInternalServerError)
+			return
+		}
+		result.Applied = true
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(httpd.MarshalJSON(result, true))
+}
+
+// authorizeChanges checks that the user is authorized to apply all changes,
+// and to undo them so that a failed apply can be reverted.
+func authorizeChanges(user auth.User, changes []change) error {
+	for _, c := range changes {
+		for _, action := range c.requires {
+			if err := user.AuthorizeAction(action); err != nil {
+				return fmt.Errorf("not authorized to %s %s %s: %v", c.action.Action, c.action.Kind, c.action.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+func requireWrite(p ...string) auth.Action {
+	return auth.Action{Resource: auth.APIResource(path.Join(p...)), Privilege: auth.WritePrivilege}
+}
+
+func requireDelete(p ...string) auth.Action {
+	return auth.Action{Resource: auth.APIResource(path.Join(p...)), Privilege: auth.DeletePrivilege}
+}
+
+// applyChanges applies all changes in order.
+// If a change fails all previously applied changes are undone in reverse order.
+func (s *applier) applyChanges(changes []change) error {
+	for i, c := range changes {
+		if err := c.apply(); err != nil {
+			for j := i - 1; j >= 0; j-- {
+				if uerr := changes[j].undo(); uerr != nil {
+					s.diag.Error(fmt.Sprintf("failed to undo %s of %s %s", changes[j].action.Action, changes[j].action.Kind, changes[j].action.ID), uerr)
+				}
+			}
+			return fmt.Errorf("failed to %s %s %s, all changes have been reverted: %v", c.action.Action, c.action.Kind, c.action.ID, err)
+		}
+	}
+	return nil
+}
+
+// plan computes the changes needed to make the server match the manifest set.
+// Templates are created and updated before the tasks that use them,
+// deletes are done last with tasks deleted before templates.
+func (s *applier) plan(opt client.ApplyOptions) ([]change, error) {
+	var changes []change
+
+	templates := make(map[string]bool, len(opt.Templates))
+	for _, t := range opt.Templates {
+		if t.ID == "" {
+			return nil, errors.New("all templates must have an ID")
+		}
+		if templates[t.ID] {
+			return nil, fmt.Errorf("duplicate template %s", t.ID)
+		}
+		templates[t.ID] = true
+		c, err := s.planTemplate(t)
+		if err != nil {
+			return nil, err
+		}
+		if c != nil {
+			changes = append(changes, *c)
+		}
+	}
+
+	tasks := make(map[string]bool, len(opt.Tasks))
+	for _, t := range opt.Tasks {
+		if t.ID == "" {
+			return nil, errors.New("all tasks must have an ID")
+		}
+		if tasks[t.ID] {
+			return nil, fmt.Errorf("duplicate task %s", t.ID)
+		}
+		tasks[t.ID] = true
+		c, err := s.planTask(t)
+		if err != nil {
+			return nil, err
+		}
+		if c != nil {
+			changes = append(changes, *c)
+		}
+	}
+
+	handlers := make(map[string]bool, len(opt.TopicHandlers))
+	for _, h := range opt.TopicHandlers {
+		if h.Topic == "" || h.ID == "" {
+			return nil, errors.New("all topic handlers must have a topic and an ID")
+		}
+		id := path.Join(h.Topic, h.ID)
+		if handlers[id] {
+			return nil, fmt.Errorf("duplicate topic handler %s", id)
+		}
+		handlers[id] = true
+		c, err := s.planTopicHandler(h)
+		if err != nil {
+			return nil, err
+		}
+		if c != nil {
+			changes = append(changes, *c)
+		}
+	}
+
+	if !opt.Prune {
+		return changes, nil
+	}
+
+	deletes, err := s.planPruneTopicHandlers(handlers)
+	if err != nil {
+		return nil, err
+	}
+	changes = append(changes, deletes...)
+	deletes, err = s.planPruneTasks(tasks)
+	if err != nil {
+		return nil, err
+	}
+	changes = append(changes, deletes...)
+	deletes, err = s.planPruneTemplates(templates)
+	if err != nil {
+		return nil, err
+	}
+	changes = append(changes, deletes...)
+	return changes, nil
+}
+
+func (s *applier) planTemplate(t client.CreateTemplateOptions) (*change, error) {
+	l := s.cli.TemplateLink(t.ID)
+	current := client.Template{}
+	exists, err := s.get(l, rawScript, &current)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get template %s", t.ID)
+	}
+	if !exists {
+		return &change{
+			action: client.ApplyAction{Kind: templateKind, ID: t.ID, Action: createAction},
+			apply: func() error {
+				_, err := s.cli.CreateTemplate(t)
+				return err
+			},
+			undo: func() error {
+				return s.cli.DeleteTemplate(l)
+			},
+			requires: []auth.Action{requireWrite(templatesPath), requireDelete(templatesPath, t.ID)},
+		}, nil
+	}
+	if current.TICKscript == t.TICKscript && (t.Type == client.InvalidTask || t.Type == current.Type) {
+		return nil, nil
+	}
+	revision, err := s.latestRevision(l)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list revisions of template %s", t.ID)
+	}
+	return &change{
+		action: client.ApplyAction{Kind: templateKind, ID: t.ID, Action: updateAction},
+		apply: func() error {
+			_, err := s.cli.UpdateTemplate(l, client.UpdateTemplateOptions{
+				Type:       t.Type,
+				TICKscript: t.TICKscript,
+			})
+			return err
+		},
+		undo: func() error {
+			_, err := s.cli.RollbackTemplate(l, revision)
+			return err
+		},
+		requires: []auth.Action{requireWrite(templatesPath, t.ID)},
+	}, nil
+}
+
+func (s *applier) planTask(t client.CreateTaskOptions) (*change, error) {
+	if t.TemplateID == "" && t.TICKscript == "" {
+		return nil, fmt.Errorf("task %s must have a TICKscript or a template ID", t.ID)
+	}
+	l := s.cli.TaskLink(t.ID)
+	current := client.Task{}
+	exists, err := s.get(l, rawScript, &current)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get task %s", t.ID)
+	}
+	if !exists {
+		return &change{
+			action: client.ApplyAction{Kind: taskKind, ID: t.ID, Action: createAction},
+			apply: func() error {
+				_, err := s.cli.CreateTask(t)
+				return err
+			},
+			undo: func() error {
+				return s.cli.DeleteTask(l)
+			},
+			requires: []auth.Action{requireWrite(tasksPath), requireDelete(tasksPath, t.ID)},
+		}, nil
+	}
+	if taskUpToDate(current, t) {
+		return nil, nil
+	}
+	revision, err := s.latestRevision(l)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list revisions of task %s", t.ID)
+	}
+	return &change{
+		action: client.ApplyAction{Kind: taskKind, ID: t.ID, Action: updateAction},
+		apply: func() error {
+			return s.updateTask(l, current.Status, client.UpdateTaskOptions{
+				TemplateID: t.TemplateID,
+				Type:       t.Type,
+				DBRPs:      t.DBRPs,
+				TICKscript: t.TICKscript,
+				Status:     t.Status,
+				Vars:       t.Vars,
+			})
+		},
+		undo: func() error {
+			if _, err := s.cli.RollbackTask(l, revision); err != nil {
+				return err
+			}
+			_, err := s.cli.UpdateTask(l, client.UpdateTaskOptions{Status: current.Status})
+			return err
+		},
+		requires: []auth.Action{requireWrite(tasksPath, t.ID)},
+	}, nil
+}
+
+// rawScript requests TICKscripts unformatted so that they can be compared with manifests.
+var rawScript = url.Values{"script-format": []string{"raw"}}
+
+// get decodes the resource of the link into result and reports whether it exists.
+// Only a missing resource is reported as not existing, any other failure is an error
+// so that it is not planned as a create.
+func (s *applier) get(l client.Link, query url.Values, result interface{}) (bool, error) {
+	u := s.cli.BaseURL()
+	u.Path = l.Href
+	u.RawQuery = query.Encode()
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return false, err
+	}
+	var data json.RawMessage
+	resp, err := s.cli.Do(req, &data, http.StatusOK, http.StatusNotFound)
+	if err != nil {
+		return false, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	return true, errors.Wrap(json.Unmarshal(data, result), "failed to decode JSON")
+}
+
+// updateTask updates a task and reloads it if it was already running.
+func (s *applier) updateTask(l client.Link, status client.TaskStatus, o client.UpdateTaskOptions) error {
+	task, err := s.cli.UpdateTask(l, o)
+	if err != nil {
+		return err
+	}
+	if status != client.Enabled || task.Status != client.Enabled {
+		return nil
+	}
+	// do reload
+	if _, err := s.cli.UpdateTask(l, client.UpdateTaskOptions{Status: client.Disabled}); err != nil {
+		return err
+	}
+	_, err = s.cli.UpdateTask(l, client.UpdateTaskOptions{Status: client.Enabled})
+	return err
+}
+
+// latestRevision returns the revision an update of a task or template can be rolled back to.
+// Definitions without revisions get their current definition recorded as the first revision when updated.
+func (s *applier) latestRevision(l client.Link) (int, error) {
+	revisions, err := s.cli.ListRevisions(l, &client.ListRevisionsOptions{Limit: -1})
+	if err != nil {
+		return 0, err
+	}
+	if len(revisions) == 0 {
+		return 1, nil
+	}
+	return revisions[len(revisions)-1].Number, nil
+}
+
+// taskUpToDate reports whether the task already matches its manifest.
+// Optional fields of the manifest that are not set are not compared.
+func taskUpToDate(current client.Task, t client.CreateTaskOptions) bool {
+	if current.TemplateID != t.TemplateID {
+		return false
+	}
+	if t.TemplateID == "" && current.TICKscript != t.TICKscript {
+		return false
+	}
+	if t.Type != client.InvalidTask && t.Type != current.Type {
+		return false
+	}
+	if len(t.DBRPs) > 0 && !reflect.DeepEqual(current.DBRPs, t.DBRPs) {
+		return false
+	}
+	if len(t.Vars) > 0 && !reflect.DeepEqual(current.Vars, t.Vars) {
+		return false
+	}
+	if t.Status != 0 && t.Status != current.Status {
+		return false
+	}
+	return true
+}
+
+func (s *applier) planTopicHandler(h client.TopicHandlerOptions) (*change, error) {
+	l := s.cli.TopicHandlerLink(h.Topic, h.ID)
+	id := path.Join(h.Topic, h.ID)
+	current := client.TopicHandler{}
+	exists, err := s.get(l, nil, &current)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get topic handler %s", id)
+	}
+	if !exists {
+		return &change{
+			action: client.ApplyAction{Kind: topicHandlerKind, ID: id, Action: createAction},
+			apply: func() error {
+				_, err := s.cli.CreateTopicHandler(s.cli.TopicHandlersLink(h.Topic), h)
+				return err
+			},
+			undo: func() error {
+				return s.cli.DeleteTopicHandler(l)
+			},
+			requires: []auth.Action{
+				requireWrite(topicsPath, h.Topic, handlersPath),
+				requireDelete(topicsPath, h.Topic, handlersPath, h.ID),
+			},
+		}, nil
+	}
+	previous := topicHandlerOptions(h.Topic, current)
+	if previous.Kind == h.Kind && previous.Match == h.Match && reflect.DeepEqual(previous.Options, h.Options) {
+		return nil, nil
+	}
+	return &change{
+		action: client.ApplyAction{Kind: topicHandlerKind, ID: id, Action: updateAction},
+		apply: func() error {
+			_, err := s.cli.ReplaceTopicHandler(l, h)
+			return err
+		},
+		undo: func() error {
+			_, err := s.cli.ReplaceTopicHandler(l, previous)
+			return err
+		},
+		requires: []auth.Action{requireWrite(topicsPath, h.Topic, handlersPath, h.ID)},
+	}, nil
+}
+
+func topicHandlerOptions(topic string, h client.TopicHandler) client.TopicHandlerOptions {
+	options := h.Options
+	if len(options) == 0 {
+		options = nil
+	}
+	return client.TopicHandlerOptions{
+		Topic:   topic,
+		ID:      h.ID,
+		Kind:    h.Kind,
+		Options: options,
+		Match:   h.Match,
+	}
+}
+
+func (s *applier) planPruneTopicHandlers(keep map[string]bool) ([]change, error) {
+	topics, err := s.cli.ListTopics(nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list topics")
+	}
+	var changes []change
+	for _, topic := range topics.Topics {
+		handlers, err := s.cli.ListTopicHandlers(topic.HandlersLink, nil)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to list handlers of topic %s", topic.ID)
+		}
+		for _, h := range handlers.Handlers {
+			id := path.Join(topic.ID, h.ID)
+			if keep[id] {
+				continue
+			}
+			l := s.cli.TopicHandlerLink(topic.ID, h.ID)
+			previous := topicHandlerOptions(topic.ID, h)
+			changes = append(changes, change{
+				action: client.ApplyAction{Kind: topicHandlerKind, ID: id, Action: deleteAction},
+				apply: func() error {
+					return s.cli.DeleteTopicHandler(l)
+				},
+				undo: func() error {
+					_, err := s.cli.CreateTopicHandler(s.cli.TopicHandlersLink(previous.Topic), previous)
+					return err
+				},
+				requires: []auth.Action{
+					requireDelete(topicsPath, topic.ID, handlersPath, h.ID),
+					requireWrite(topicsPath, topic.ID, handlersPath),
+				},
+			})
+		}
+	}
+	return changes, nil
+}
+
+func (s *applier) planPruneTasks(keep map[string]bool) ([]change, error) {
+	var changes []change
+	opt := &client.ListTasksOptions{
+		Fields: []string{"id"},
+	}
+	for {
+		tasks, err := s.cli.ListTasks(opt)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list tasks")
+		}
+		for _, item := range tasks {
+			if keep[item.ID] {
+				continue
+			}
+			l := s.cli.TaskLink(item.ID)
+			// The full definition is needed to recreate the task.
+			t, err := s.cli.Task(l, &client.TaskOptions{ScriptFormat: "raw"})
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to get task %s", item.ID)
+			}
+			previous := client.CreateTaskOptions{
+				ID:         t.ID,
+				TemplateID: t.TemplateID,
+				Type:       t.Type,
+				Status:     t.Status,
+				Vars:       t.Vars,
+			}
+			if t.TemplateID == "" {
+				previous.TICKscript = t.TICKscript
+			}
+			// DBRPs must only be set explicitly if the TICKscript does not declare them.
+			if !declaresDBRPs(t.TICKscript) {
+				previous.DBRPs = t.DBRPs
+			}
+			changes = append(changes, change{
+				action: client.ApplyAction{Kind: taskKind, ID: t.ID, Action: deleteAction},
+				apply: func() error {
+					return s.cli.DeleteTask(l)
+				},
+				undo: func() error {
+					_, err := s.cli.CreateTask(previous)
+					return err
+				},
+				requires: []auth.Action{requireDelete(tasksPath, t.ID), requireWrite(tasksPath)},
+			})
+		}
+		if len(tasks) != opt.Limit {
+			break
+		}
+		opt.Offset += opt.Limit
+	}
+	return changes, nil
+}
+
+func (s *applier) planPruneTemplates(keep map[string]bool) ([]change, error) {
+	var changes []change
+	opt := &client.ListTemplatesOptions{
+		TemplateOptions: client.TemplateOptions{ScriptFormat: "raw"},
+		Fields:          []string{"type", "script"},
+	}
+	for {
+		templates, err := s.cli.ListTemplates(opt)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to list templates")
+		}
+		for _, t := range templates {
+			if keep[t.ID] {
+				continue
+			}
+			l := s.cli.TemplateLink(t.ID)
+			previous := client.CreateTemplateOptions{
+				ID:         t.ID,
+				Type:       t.Type,
+				TICKscript: t.TICKscript,
+			}
+			changes = append(changes, change{
+				action: client.ApplyAction{Kind: templateKind, ID: t.ID, Action: deleteAction},
+				apply: func() error {
+					return s.cli.DeleteTemplate(l)
+				},
+				undo: func() error {
+					_, err := s.cli.CreateTemplate(previous)
+					return err
+				},
+				requires: []auth.Action{requireDelete(templatesPath, t.ID), requireWrite(templatesPath)},
+			})
+		}
+		if len(templates) != opt.Limit {
+			break
+		}
+		opt.Offset += opt.Limit
+	}
+	return changes, nil
+}
+
+// declaresDBRPs reports whether a TICKscript contains dbrp statements.
+func declaresDBRPs(script string) bool {
+	p, err := ast.Parse(script)
+	if err != nil {
+		return false
+	}
+	pn, ok := p.(*ast.ProgramNode)
+	if !ok {
+		return false
+	}
+	for _, n := range pn.Nodes {
+		if _, ok := n.(*ast.DBRPNode); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package load
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/thingnario/kapacitor/auth"
+	"github.com/thingnario/kapacitor/client/v1"
+)
+
+func TestTaskUpToDate(t *testing.T) {
+	current := client.Task{
+		ID:         "cpu",
+		Type:       client.StreamTask,
+		DBRPs:      []client.DBRP{{Database: "telegraf", RetentionPolicy: "autogen"}},
+		TICKscript: "dbrp \"telegraf\".\"autogen\"\n\nstream|from()",
+		Status:     client.Enabled,
+	}
+	testCases := []struct {
+		name     string
+		manifest client.CreateTaskOptions
+		exp      bool
+	}{
+		{
+			name:     "same script",
+			manifest: client.CreateTaskOptions{ID: "cpu", TICKscript: current.TICKscript, Status: client.Enabled},
+			exp:      true,
+		},
+		{
+			name:     "changed script",
+			manifest: client.CreateTaskOptions{ID: "cpu", TICKscript: "stream|from()", Status: client.Enabled},
+			exp:      false,
+		},
+		{
+			name:     "changed status",
+			manifest: client.CreateTaskOptions{ID: "cpu", TICKscript: current.TICKscript, Status: client.Disabled},
+			exp:      false,
+		},
+		{
+			name:     "changed template",
+			manifest: client.CreateTaskOptions{ID: "cpu", TemplateID: "cpu_template"},
+			exp:      false,
+		},
+		{
+			name: "changed vars",
+			manifest: client.CreateTaskOptions{
+				ID:         "cpu",
+				TICKscript: current.TICKscript,
+				Vars:       client.Vars{"crit": {Type: client.VarFloat, Value: 90.0}},
+			},
+			exp: false,
+		},
+	}
+	for _, tc := range testCases {
+		if got := taskUpToDate(current, tc.manifest); got != tc.exp {
+			t.Errorf("%s: unexpected result got %v exp %v", tc.name, got, tc.exp)
+		}
+	}
+}
+
+func TestDeclaresDBRPs(t *testing.T) {
+	if !declaresDBRPs("dbrp \"telegraf\".\"autogen\"\n\nstream|from()") {
+		t.Error("expected dbrp statement to be found")
+	}
+	if declaresDBRPs("stream|from()") {
+		t.Error("unexpected dbrp statement found")
+	}
+}
+
+func TestAuthorizeChanges(t *testing.T) {
+	user := auth.NewUser("bob", nil, false, map[string][]auth.Privilege{
+		auth.APIResource(tasksPath): {auth.WritePrivilege, auth.DeletePrivilege},
+	})
+	taskChange := change{
+		action:   client.ApplyAction{Action: "create", Kind: "task", ID: "cpu"},
+		requires: []auth.Action{requireWrite(tasksPath), requireDelete(tasksPath, "cpu")},
+	}
+	templateChange := change{
+		action:   client.ApplyAction{Action: "update", Kind: "template", ID: "tmpl"},
+		requires: []auth.Action{requireWrite(templatesPath, "tmpl")},
+	}
+	if err := authorizeChanges(user, []change{taskChange}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := authorizeChanges(user, []change{taskChange, templateChange}); err == nil {
+		t.Error("expected template change to be denied")
+	}
+	admin := auth.NewUser("admin", nil, true, nil)
+	if err := authorizeChanges(admin, []change{taskChange, templateChange}); err != nil {
+		t.Errorf("unexpected error for admin: %v", err)
+	}
+}
+
+func TestPlan_FailedLookup(t *testing.T) {
+	// Only the cpu task exists, looking up the mem task and the revisions of the cpu task fails.
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/kapacitor/v1/tasks/cpu":
+			w.Write([]byte(`{"id":"cpu","type":"stream","script":"stream|from()","status":"enabled"}`))
+		case "/kapacitor/v1/tasks/cpu/revisions":
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error":"failed to list revisions"}`))
+		case "/kapacitor/v1/tasks/mem":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error":"forbidden"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"not found"}`))
+		}
+	}))
+	defer ts.Close()
+	cli, err := client.New(client.Config{URL: ts.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &applier{cli: cli}
+
+	changes, err := a.plan(client.ApplyOptions{
+		Tasks: []client.CreateTaskOptions{
+			{ID: "cpu", TICKscript: "stream|from()", Status: client.Enabled},
+			{ID: "disk", TICKscript: "stream|from()"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].action.ID != "disk" || changes[0].action.Action != createAction {
+		t.Errorf("unexpected plan %v", changes)
+	}
+
+	if _, err := a.plan(client.ApplyOptions{
+		Tasks: []client.CreateTaskOptions{{ID: "mem", TICKscript: "stream|from()"}},
+	}); err == nil {
+		t.Error("expected failed lookup to fail the plan")
+	}
+
+	// The undo of an update needs the revision to roll back to.
+	if _, err := a.plan(client.ApplyOptions{
+		Tasks: []client.CreateTaskOptions{{ID: "cpu", TICKscript: "stream|from().measurement('cpu')"}},
+	}); err == nil {
+		t.Error("expected failed revision lookup to fail the plan")
+	}
+}
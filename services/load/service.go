@@ -13,9 +13,11 @@ import (
 
 	"github.com/ghodss/yaml"
 
+	"github.com/thingnario/kapacitor/auth"
 	"github.com/thingnario/kapacitor/client/v1"
 	kexpvar "github.com/thingnario/kapacitor/expvar"
 	"github.com/thingnario/kapacitor/server/vars"
+	"github.com/thingnario/kapacitor/services/httpd"
 	"github.com/thingnario/kapacitor/services/storage"
 	"github.com/pkg/errors"
 )
@@ -43,6 +45,10 @@ type Service struct {
 	mu     sync.Mutex
 	config Config
 
+	// applyMu serializes applies of manifest sets.
+	applyMu sync.Mutex
+	routes  []httpd.Route
+
 	cli        *client.Client
 	statsKey   string
 	statMap    *kexpvar.Map
@@ -58,6 +64,14 @@ type Service struct {
 		Store(namespace string) storage.Interface
 		Register(name string, store storage.StoreActioner)
 	}
+	HTTPDService interface {
+		AddRoutes([]httpd.Route) error
+		DelRoutes([]httpd.Route)
+	}
+	// APIHandler serves the requests of an apply as the user that requested it.
+	APIHandler interface {
+		ServeAs(user auth.User) http.Handler
+	}
 
 	diag Diagnostic
 }
@@ -103,10 +117,27 @@ func (s *Service) Open() error {
 	}
 	s.items = items
 	s.StorageService.Register(loadAPIName, s.items)
+
+	// Define API routes
+	s.routes = []httpd.Route{
+		{
+			Method:      "POST",
+			Pattern:     applyPath,
+			HandlerFunc: s.handleApply,
+		},
+	}
+	if s.HTTPDService != nil {
+		if err := s.HTTPDService.AddRoutes(s.routes); err != nil {
+			return errors.Wrap(err, "failed to add API routes")
+		}
+	}
 	return nil
 }
 
 func (s *Service) Close() error {
+	if s.HTTPDService != nil {
+		s.HTTPDService.DelRoutes(s.routes)
+	}
 	return nil
 }
 
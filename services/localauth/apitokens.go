@@ -0,0 +1,268 @@
+package localauth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/thingnario/kapacitor/auth"
+	"github.com/thingnario/kapacitor/client/v1"
+	"github.com/thingnario/kapacitor/services/httpd"
+)
+
+const (
+	apiTokensPath         = "/tokens"
+	apiTokensPathAnchored = "/tokens/"
+	apiTokensBasePath     = httpd.BasePath + apiTokensPathAnchored
+
+	// Number of random bytes of the ID and the secret of API tokens.
+	apiTokenIDSize     = 8
+	apiTokenSecretSize = 32
+)
+
+// APITokenUser returns a user with the privileges of the scopes of an API token
+// and the ID of the token.
+func (s *Service) APITokenUser(token string) (auth.User, string, error) {
+	id, secret, err := splitAPIToken(token)
+	if err != nil {
+		return auth.User{}, "", ErrInvalidCredentials
+	}
+	t, err := s.apiTokens.Get(id)
+	if err != nil {
+		if err == ErrNoAPITokenExists {
+			return auth.User{}, "", ErrInvalidCredentials
+		}
+		return auth.User{}, "", err
+	}
+	sum := sha256.Sum256([]byte(secret))
+	if subtle.ConstantTimeCompare(t.Hash, sum[:]) != 1 {
+		return auth.User{}, "", ErrInvalidCredentials
+	}
+	if !t.Expires.IsZero() && time.Now().After(t.Expires) {
+		return auth.User{}, "", ErrInvalidCredentials
+	}
+	privileges := make(map[string][]auth.Privilege, len(t.Scopes))
+	for resource, names := range t.Scopes {
+		ps := make([]auth.Privilege, len(names))
+		for i, name := range names {
+			ps[i], err = auth.ParsePrivilege(name)
+			if err != nil {
+				return auth.User{}, "", errors.Wrapf(err, "invalid scopes of API token %q", t.ID)
+			}
+		}
+		privileges[resource] = ps
+	}
+	return auth.NewUser(apiTokenUsername(t.ID), nil, false, privileges), t.ID, nil
+}
+
+// apiTokenUsername is the name of the user of an API token.
+// It cannot collide with the name of a stored user since ':' is not valid in user names.
+func apiTokenUsername(id string) string {
+	return "token:" + id
+}
+
+// splitAPIToken returns the ID and the secret of a token of the form kapacitor_<id>_<secret>.
+func splitAPIToken(token string) (string, string, error) {
+	if !strings.HasPrefix(token, httpd.APITokenPrefix) {
+		return "", "", errors.New("invalid API token prefix")
+	}
+	parts := strings.SplitN(token[len(httpd.APITokenPrefix):], "_", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", errors.New("invalid API token")
+	}
+	return parts[0], parts[1], nil
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// CreateAPIToken creates a new API token and returns it along with the bearer token.
+// The bearer token cannot be retrieved later since only the hash of its secret is stored.
+func (s *Service) CreateAPIToken(createdBy, description string, scopes map[string][]string, expires time.Time) (APIToken, string, error) {
+	if len(scopes) == 0 {
+		return APIToken{}, "", errors.New("API token must have at least one scope")
+	}
+	if err := validatePrivileges(scopes); err != nil {
+		return APIToken{}, "", err
+	}
+	if !expires.IsZero() && !expires.After(time.Now()) {
+		return APIToken{}, "", errors.New("API token expiration must be in the future")
+	}
+	id, err := randomHex(apiTokenIDSize)
+	if err != nil {
+		return APIToken{}, "", err
+	}
+	secret, err := randomHex(apiTokenSecretSize)
+	if err != nil {
+		return APIToken{}, "", err
+	}
+	sum := sha256.Sum256([]byte(secret))
+	t := APIToken{
+		ID:          id,
+		Description: description,
+		Hash:        sum[:],
+		Scopes:      scopes,
+		CreatedBy:   createdBy,
+		Created:     time.Now().UTC(),
+		Expires:     expires.UTC(),
+	}
+	if err := s.apiTokens.Create(t); err != nil {
+		return APIToken{}, "", err
+	}
+	return t, httpd.APITokenPrefix + id + "_" + secret, nil
+}
+
+// GetAPIToken returns a stored API token.
+func (s *Service) GetAPIToken(id string) (APIToken, error) {
+	return s.apiTokens.Get(id)
+}
+
+// RevokeAPIToken deletes an API token.
+func (s *Service) RevokeAPIToken(id string) error {
+	return s.apiTokens.Delete(id)
+}
+
+// ListAPITokens returns the stored API tokens.
+func (s *Service) ListAPITokens(offset, limit int) ([]APIToken, error) {
+	return s.apiTokens.List(offset, limit)
+}
+
+func apiTokenLink(id string) client.Link {
+	return client.Link{Relation: client.Self, Href: path.Join(httpd.BasePath, apiTokensPath, id)}
+}
+
+func convertAPIToken(t APIToken) client.APIToken {
+	scopes := make(map[string][]string, len(t.Scopes))
+	for p, names := range t.Scopes {
+		scopes[p] = append([]string(nil), names...)
+		sort.Strings(scopes[p])
+	}
+	return client.APIToken{
+		Link:        apiTokenLink(t.ID),
+		ID:          t.ID,
+		Description: t.Description,
+		Scopes:      scopes,
+		CreatedBy:   t.CreatedBy,
+		Created:     t.Created,
+		Expires:     t.Expires,
+	}
+}
+
+func apiTokenIDFromPath(p string) (string, error) {
+	if !strings.HasPrefix(p, apiTokensBasePath) {
+		return "", fmt.Errorf("invalid API token path %q", p)
+	}
+	id := strings.TrimSuffix(p[len(apiTokensBasePath):], "/")
+	if id == "" || strings.Contains(id, "/") {
+		return "", fmt.Errorf("invalid API token path %q", p)
+	}
+	return id, nil
+}
+
+func (s *Service) handleGetAPIToken(w http.ResponseWriter, r *http.Request, user auth.User) {
+	if !requireAdmin(w, user) {
+		return
+	}
+	id, err := apiTokenIDFromPath(r.URL.Path)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
+		return
+	}
+	t, err := s.GetAPIToken(id)
+	if err != nil {
+		code := http.StatusInternalServerError
+		if err == ErrNoAPITokenExists {
+			code = http.StatusNotFound
+		}
+		httpd.HttpError(w, err.Error(), true, code)
+		return
+	}
+	w.Write(httpd.MarshalJSON(convertAPIToken(t), true))
+}
+
+func (s *Service) handleRevokeAPIToken(w http.ResponseWriter, r *http.Request, user auth.User) {
+	if !requireAdmin(w, user) {
+		return
+	}
+	id, err := apiTokenIDFromPath(r.URL.Path)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
+		return
+	}
+	if err := s.RevokeAPIToken(id); err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Service) handleCreateAPIToken(w http.ResponseWriter, r *http.Request, user auth.User) {
+	if !requireAdmin(w, user) {
+		return
+	}
+	opt := client.CreateAPITokenOptions{}
+	if err := json.NewDecoder(r.Body).Decode(&opt); err != nil {
+		httpd.HttpError(w, "invalid JSON: "+err.Error(), true, http.StatusBadRequest)
+		return
+	}
+	t, token, err := s.CreateAPIToken(user.Name(), opt.Description, opt.Scopes, opt.Expires)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
+		return
+	}
+	ct := convertAPIToken(t)
+	ct.Token = token
+	w.Write(httpd.MarshalJSON(ct, true))
+}
+
+func (s *Service) handleListAPITokens(w http.ResponseWriter, r *http.Request, user auth.User) {
+	if !requireAdmin(w, user) {
+		return
+	}
+	offset := 0
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		var err error
+		offset, err = strconv.Atoi(offsetStr)
+		if err != nil {
+			httpd.HttpError(w, fmt.Sprintf("invalid offset parameter %q must be an integer: %s", offsetStr, err), true, http.StatusBadRequest)
+			return
+		}
+	}
+	limit := 100
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil {
+			httpd.HttpError(w, fmt.Sprintf("invalid limit parameter %q must be an integer: %s", limitStr, err), true, http.StatusBadRequest)
+			return
+		}
+	}
+	tokens, err := s.ListAPITokens(offset, limit)
+	if err != nil {
+		httpd.HttpError(w, fmt.Sprintf("failed to list API tokens: %s", err), true, http.StatusInternalServerError)
+		return
+	}
+	type response struct {
+		Tokens []client.APIToken `json:"tokens"`
+	}
+	resp := response{Tokens: make([]client.APIToken, len(tokens))}
+	for i, t := range tokens {
+		resp.Tokens[i] = convertAPIToken(t)
+	}
+	w.Write(httpd.MarshalJSON(resp, true))
+}
@@ -0,0 +1,55 @@
+package localauth
+
+import (
+	"time"
+
+	"github.com/influxdata/influxdb/toml"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	DefaultBcryptCost      = bcrypt.DefaultCost
+	DefaultCacheExpiration = toml.Duration(10 * time.Minute)
+)
+
+type Config struct {
+	// Enabled replaces the noauth service, which grants every request admin privileges,
+	// with users stored in Kapacitor.
+	Enabled bool `toml:"enabled"`
+	// BcryptCost is the cost of the bcrypt hashes of new passwords.
+	BcryptCost int `toml:"bcrypt-cost"`
+	// CacheExpiration is how long successfully authenticated credentials are cached,
+	// so that the expensive bcrypt comparison is not performed on every request.
+	// A value of 0 disables the cache.
+	CacheExpiration toml.Duration `toml:"cache-expiration"`
+
+	// AdminUsername and AdminPassword are the credentials of an admin user
+	// that is created when the service is opened if no user with the name exists.
+	// It is used to bootstrap the management of users.
+	AdminUsername string `toml:"admin-username"`
+	AdminPassword string `toml:"admin-password"`
+}
+
+func NewConfig() Config {
+	return Config{
+		BcryptCost:      DefaultBcryptCost,
+		CacheExpiration: DefaultCacheExpiration,
+	}
+}
+
+func (c Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.BcryptCost < bcrypt.MinCost || c.BcryptCost > bcrypt.MaxCost {
+		return errors.Errorf("bcrypt-cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	if c.CacheExpiration < 0 {
+		return errors.New("cache-expiration must not be negative")
+	}
+	if (c.AdminUsername == "") != (c.AdminPassword == "") {
+		return errors.New("must specify both admin-username and admin-password or neither")
+	}
+	return nil
+}
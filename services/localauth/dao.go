@@ -0,0 +1,388 @@
+package localauth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/thingnario/kapacitor/services/storage"
+)
+
+var (
+	ErrUserExists         = errors.New("user already exists")
+	ErrNoUserExists       = errors.New("no user exists")
+	ErrNoTokenExists      = errors.New("no subscription token exists")
+	ErrNoAPITokenExists   = errors.New("no API token exists")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Data access object for users.
+type UserDAO interface {
+	// Retrieve a user
+	Get(name string) (User, error)
+
+	// Create a user.
+	// ErrUserExists is returned if a user already exists with the same name.
+	Create(u User) error
+
+	// Replace an existing user.
+	// ErrNoUserExists is returned if the user does not exist.
+	Replace(u User) error
+
+	// Delete a user.
+	// It is not an error to delete an non-existent user.
+	Delete(name string) error
+
+	// List users matching a pattern.
+	// The pattern is shell/glob matching see https://golang.org/pkg/path/#Match
+	// Offset and limit are pagination bounds. Offset is inclusive starting at index 0.
+	// More results may exist while the number of returned items is equal to limit.
+	List(pattern string, offset, limit int) ([]User, error)
+
+	// Rebuild fixes all indexes of the data.
+	Rebuild() error
+}
+
+// Data access object for subscription tokens.
+type TokenDAO interface {
+	// Retrieve a token
+	Get(token string) (SubscriptionToken, error)
+
+	// Put a token, replacing any existing token.
+	Put(t SubscriptionToken) error
+
+	// Delete a token.
+	// It is not an error to delete an non-existent token.
+	Delete(token string) error
+
+	// List tokens.
+	// Offset and limit are pagination bounds. Offset is inclusive starting at index 0.
+	// More results may exist while the number of returned items is equal to limit.
+	List(offset, limit int) ([]SubscriptionToken, error)
+
+	// Rebuild fixes all indexes of the data.
+	Rebuild() error
+}
+
+// Data access object for API tokens.
+type APITokenDAO interface {
+	// Retrieve a token
+	Get(id string) (APIToken, error)
+
+	// Create a token.
+	Create(t APIToken) error
+
+	// Delete a token.
+	// It is not an error to delete an non-existent token.
+	Delete(id string) error
+
+	// List tokens.
+	// Offset and limit are pagination bounds. Offset is inclusive starting at index 0.
+	// More results may exist while the number of returned items is equal to limit.
+	List(offset, limit int) ([]APIToken, error)
+
+	// Rebuild fixes all indexes of the data.
+	Rebuild() error
+}
+
+//--------------------------------------------------------------------
+// The following structures are stored in a database via JSON encoding.
+// Changes to the structures could break existing data.
+
+const (
+	userVersion1     = 1
+	tokenVersion1    = 1
+	apiTokenVersion1 = 1
+)
+
+// User is a stored user.
+type User struct {
+	Name string `json:"name"`
+	// Hash is the bcrypt hash of the password of the user.
+	Hash  []byte `json:"hash"`
+	Admin bool   `json:"admin"`
+	// Privileges maps resources to the names of the privileges the user has on them.
+	Privileges map[string][]string `json:"privileges"`
+}
+
+func (u User) ObjectID() string {
+	return u.Name
+}
+
+func (u User) MarshalBinary() ([]byte, error) {
+	return storage.VersionJSONEncode(userVersion1, u)
+}
+
+func (u *User) UnmarshalBinary(data []byte) error {
+	return storage.VersionJSONDecode(data, func(version int, dec *json.Decoder) error {
+		switch version {
+		case userVersion1:
+			return dec.Decode(u)
+		default:
+			return fmt.Errorf("unsupported user version %d", version)
+		}
+	})
+}
+
+// SubscriptionToken grants an InfluxDB subscription write access to a database.
+type SubscriptionToken struct {
+	Token           string `json:"token"`
+	Database        string `json:"db"`
+	RetentionPolicy string `json:"rp"`
+}
+
+func (t SubscriptionToken) ObjectID() string {
+	return t.Token
+}
+
+func (t SubscriptionToken) MarshalBinary() ([]byte, error) {
+	return storage.VersionJSONEncode(tokenVersion1, t)
+}
+
+func (t *SubscriptionToken) UnmarshalBinary(data []byte) error {
+	return storage.VersionJSONDecode(data, func(version int, dec *json.Decoder) error {
+		switch version {
+		case tokenVersion1:
+			return dec.Decode(t)
+		default:
+			return fmt.Errorf("unsupported subscription token version %d", version)
+		}
+	})
+}
+
+// APIToken is a stored API token.
+// Only the hash of the secret part of the token is stored.
+type APIToken struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	// Hash is the SHA-256 hash of the secret of the token.
+	Hash []byte `json:"hash"`
+	// Scopes maps resources to the names of the privileges the token grants on them,
+	// like the privileges of a user.
+	Scopes    map[string][]string `json:"scopes"`
+	CreatedBy string              `json:"created-by"`
+	Created   time.Time           `json:"created"`
+	// Expires is when the token expires, the token never expires if it is zero.
+	Expires time.Time `json:"expires"`
+}
+
+func (t APIToken) ObjectID() string {
+	return t.ID
+}
+
+func (t APIToken) MarshalBinary() ([]byte, error) {
+	return storage.VersionJSONEncode(apiTokenVersion1, t)
+}
+
+func (t *APIToken) UnmarshalBinary(data []byte) error {
+	return storage.VersionJSONDecode(data, func(version int, dec *json.Decoder) error {
+		switch version {
+		case apiTokenVersion1:
+			return dec.Decode(t)
+		default:
+			return fmt.Errorf("unsupported API token version %d", version)
+		}
+	})
+}
+
+// Key/Value based implementation of the UserDAO.
+type userKV struct {
+	store *storage.IndexedStore
+}
+
+func newUserKV(store storage.Interface) (*userKV, error) {
+	c := storage.DefaultIndexedStoreConfig("users", func() storage.BinaryObject {
+		return new(User)
+	})
+	istore, err := storage.NewIndexedStore(store, c)
+	if err != nil {
+		return nil, err
+	}
+	return &userKV{
+		store: istore,
+	}, nil
+}
+
+func (kv *userKV) Rebuild() error {
+	return kv.store.Rebuild()
+}
+
+func (kv *userKV) error(err error) error {
+	if err == storage.ErrObjectExists {
+		return ErrUserExists
+	} else if err == storage.ErrNoObjectExists {
+		return ErrNoUserExists
+	}
+	return err
+}
+
+func (kv *userKV) Get(name string) (User, error) {
+	o, err := kv.store.Get(name)
+	if err != nil {
+		return User{}, kv.error(err)
+	}
+	u, ok := o.(*User)
+	if !ok {
+		return User{}, storage.ImpossibleTypeErr(u, o)
+	}
+	return *u, nil
+}
+
+func (kv *userKV) Create(u User) error {
+	return kv.error(kv.store.Create(&u))
+}
+
+func (kv *userKV) Replace(u User) error {
+	return kv.error(kv.store.Replace(&u))
+}
+
+func (kv *userKV) Delete(name string) error {
+	return kv.store.Delete(name)
+}
+
+func (kv *userKV) List(pattern string, offset, limit int) ([]User, error) {
+	objects, err := kv.store.List(storage.DefaultIDIndex, pattern, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	users := make([]User, len(objects))
+	for i, o := range objects {
+		u, ok := o.(*User)
+		if !ok {
+			return nil, storage.ImpossibleTypeErr(u, o)
+		}
+		users[i] = *u
+	}
+	return users, nil
+}
+
+// Key/Value based implementation of the TokenDAO.
+type tokenKV struct {
+	store *storage.IndexedStore
+}
+
+func newTokenKV(store storage.Interface) (*tokenKV, error) {
+	c := storage.DefaultIndexedStoreConfig("subscription_tokens", func() storage.BinaryObject {
+		return new(SubscriptionToken)
+	})
+	istore, err := storage.NewIndexedStore(store, c)
+	if err != nil {
+		return nil, err
+	}
+	return &tokenKV{
+		store: istore,
+	}, nil
+}
+
+func (kv *tokenKV) Rebuild() error {
+	return kv.store.Rebuild()
+}
+
+func (kv *tokenKV) error(err error) error {
+	if err == storage.ErrNoObjectExists {
+		return ErrNoTokenExists
+	}
+	return err
+}
+
+func (kv *tokenKV) Get(token string) (SubscriptionToken, error) {
+	o, err := kv.store.Get(token)
+	if err != nil {
+		return SubscriptionToken{}, kv.error(err)
+	}
+	t, ok := o.(*SubscriptionToken)
+	if !ok {
+		return SubscriptionToken{}, storage.ImpossibleTypeErr(t, o)
+	}
+	return *t, nil
+}
+
+func (kv *tokenKV) Put(t SubscriptionToken) error {
+	return kv.error(kv.store.Put(&t))
+}
+
+func (kv *tokenKV) Delete(token string) error {
+	return kv.store.Delete(token)
+}
+
+func (kv *tokenKV) List(offset, limit int) ([]SubscriptionToken, error) {
+	objects, err := kv.store.List(storage.DefaultIDIndex, "", offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	tokens := make([]SubscriptionToken, len(objects))
+	for i, o := range objects {
+		t, ok := o.(*SubscriptionToken)
+		if !ok {
+			return nil, storage.ImpossibleTypeErr(t, o)
+		}
+		tokens[i] = *t
+	}
+	return tokens, nil
+}
+
+// Key/Value based implementation of the APITokenDAO.
+type apiTokenKV struct {
+	store *storage.IndexedStore
+}
+
+func newAPITokenKV(store storage.Interface) (*apiTokenKV, error) {
+	c := storage.DefaultIndexedStoreConfig("api_tokens", func() storage.BinaryObject {
+		return new(APIToken)
+	})
+	istore, err := storage.NewIndexedStore(store, c)
+	if err != nil {
+		return nil, err
+	}
+	return &apiTokenKV{
+		store: istore,
+	}, nil
+}
+
+func (kv *apiTokenKV) Rebuild() error {
+	return kv.store.Rebuild()
+}
+
+func (kv *apiTokenKV) error(err error) error {
+	if err == storage.ErrNoObjectExists {
+		return ErrNoAPITokenExists
+	}
+	return err
+}
+
+func (kv *apiTokenKV) Get(id string) (APIToken, error) {
+	o, err := kv.store.Get(id)
+	if err != nil {
+		return APIToken{}, kv.error(err)
+	}
+	t, ok := o.(*APIToken)
+	if !ok {
+		return APIToken{}, storage.ImpossibleTypeErr(t, o)
+	}
+	return *t, nil
+}
+
+func (kv *apiTokenKV) Create(t APIToken) error {
+	return kv.error(kv.store.Create(&t))
+}
+
+func (kv *apiTokenKV) Delete(id string) error {
+	return kv.store.Delete(id)
+}
+
+func (kv *apiTokenKV) List(offset, limit int) ([]APIToken, error) {
+	objects, err := kv.store.List(storage.DefaultIDIndex, "", offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	tokens := make([]APIToken, len(objects))
+	for i, o := range objects {
+		t, ok := o.(*APIToken)
+		if !ok {
+			return nil, storage.ImpossibleTypeErr(t, o)
+		}
+		tokens[i] = *t
+	}
+	return tokens, nil
+}
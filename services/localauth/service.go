@@ -0,0 +1,608 @@
+package localauth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/thingnario/kapacitor/auth"
+	"github.com/thingnario/kapacitor/client/v1"
+	"github.com/thingnario/kapacitor/services/httpd"
+	"github.com/thingnario/kapacitor/services/storage"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	usersPath         = "/users"
+	usersPathAnchored = "/users/"
+	usersBasePath     = httpd.BasePath + usersPathAnchored
+
+	// Public name of the users store
+	usersAPIName = "users"
+	// Public name of the subscription tokens store
+	tokensAPIName = "subscription-tokens"
+	// Public name of the API tokens store
+	apiTokensAPIName = "api-tokens"
+	// The storage namespaces for users, subscription tokens and API tokens.
+	usersNamespace     = "user_store"
+	tokensNamespace    = "subscription_token_store"
+	apiTokensNamespace = "api_token_store"
+
+	// Number of tokens listed at once.
+	tokenListLimit = 100
+)
+
+var validUsername = regexp.MustCompile(`^[-\._@\p{L}0-9]+$`)
+
+type Diagnostic interface {
+	Error(msg string, err error)
+	CreatedAdminUser(username string)
+}
+
+// Service authenticates users stored in Kapacitor
+// and authorizes their requests based on their privileges.
+type Service struct {
+	config Config
+
+	users     UserDAO
+	tokens    TokenDAO
+	apiTokens APITokenDAO
+
+	// mu protects cache.
+	mu sync.Mutex
+	// cache maps usernames to the last successfully authenticated credentials.
+	cache map[string]cachedCredentials
+
+	routes []httpd.Route
+
+	StorageService interface {
+		Store(namespace string) storage.Interface
+		Register(name string, store storage.StoreActioner)
+	}
+	HTTPDService interface {
+		AddRoutes([]httpd.Route) error
+		DelRoutes([]httpd.Route)
+	}
+
+	diag Diagnostic
+}
+
+type cachedCredentials struct {
+	sum     [sha256.Size]byte
+	expires time.Time
+}
+
+func NewService(c Config, d Diagnostic) *Service {
+	return &Service{
+		config: c,
+		cache:  make(map[string]cachedCredentials),
+		diag:   d,
+	}
+}
+
+func (s *Service) Open() error {
+	// Create DAOs
+	users, err := newUserKV(s.StorageService.Store(usersNamespace))
+	if err != nil {
+		return err
+	}
+	s.users = users
+	s.StorageService.Register(usersAPIName, s.users)
+
+	tokens, err := newTokenKV(s.StorageService.Store(tokensNamespace))
+	if err != nil {
+		return err
+	}
+	s.tokens = tokens
+	s.StorageService.Register(tokensAPIName, s.tokens)
+
+	apiTokens, err := newAPITokenKV(s.StorageService.Store(apiTokensNamespace))
+	if err != nil {
+		return err
+	}
+	s.apiTokens = apiTokens
+	s.StorageService.Register(apiTokensAPIName, s.apiTokens)
+
+	if err := s.bootstrapAdmin(); err != nil {
+		return err
+	}
+
+	// Setup routes
+	s.routes = []httpd.Route{
+		{
+			Method:      "GET",
+			Pattern:     usersPathAnchored,
+			HandlerFunc: s.handleGetUser,
+		},
+		{
+			Method:      "PATCH",
+			Pattern:     usersPathAnchored,
+			HandlerFunc: s.handleUpdateUser,
+		},
+		{
+			Method:      "DELETE",
+			Pattern:     usersPathAnchored,
+			HandlerFunc: s.handleDeleteUser,
+		},
+		{
+			Method:      "OPTIONS",
+			Pattern:     usersPathAnchored,
+			HandlerFunc: httpd.ServeOptions,
+		},
+		{
+			Method:      "GET",
+			Pattern:     usersPath,
+			HandlerFunc: s.handleListUsers,
+		},
+		{
+			Method:      "POST",
+			Pattern:     usersPath,
+			HandlerFunc: s.handleCreateUser,
+		},
+		{
+			Method:      "GET",
+			Pattern:     apiTokensPathAnchored,
+			HandlerFunc: s.handleGetAPIToken,
+		},
+		{
+			Method:      "DELETE",
+			Pattern:     apiTokensPathAnchored,
+			HandlerFunc: s.handleRevokeAPIToken,
+		},
+		{
+			Method:      "OPTIONS",
+			Pattern:     apiTokensPathAnchored,
+			HandlerFunc: httpd.ServeOptions,
+		},
+		{
+			Method:      "GET",
+			Pattern:     apiTokensPath,
+			HandlerFunc: s.handleListAPITokens,
+		},
+		{
+			Method:      "POST",
+			Pattern:     apiTokensPath,
+			HandlerFunc: s.handleCreateAPIToken,
+		},
+	}
+	return s.HTTPDService.AddRoutes(s.routes)
+}
+
+func (s *Service) Close() error {
+	if s.HTTPDService != nil {
+		s.HTTPDService.DelRoutes(s.routes)
+	}
+	return nil
+}
+
+// bootstrapAdmin creates the configured admin user if it does not exist.
+func (s *Service) bootstrapAdmin() error {
+	if s.config.AdminUsername == "" {
+		return nil
+	}
+	_, err := s.users.Get(s.config.AdminUsername)
+	if err != ErrNoUserExists {
+		return err
+	}
+	if _, err := s.CreateUser(s.config.AdminUsername, s.config.AdminPassword, true, nil); err != nil {
+		return errors.Wrap(err, "failed to create admin user")
+	}
+	s.diag.CreatedAdminUser(s.config.AdminUsername)
+	return nil
+}
+
+// Authenticate returns the user if the password is the password of the user.
+func (s *Service) Authenticate(username, password string) (auth.User, error) {
+	u, err := s.users.Get(username)
+	if err != nil {
+		if err == ErrNoUserExists {
+			return auth.User{}, ErrInvalidCredentials
+		}
+		return auth.User{}, err
+	}
+	sum := sha256.Sum256([]byte(password))
+	if !s.cached(username, sum) {
+		if err := bcrypt.CompareHashAndPassword(u.Hash, []byte(password)); err != nil {
+			return auth.User{}, ErrInvalidCredentials
+		}
+		s.remember(username, sum)
+	}
+	return convertAuthUser(u)
+}
+
+// cached reports whether the credentials were successfully authenticated recently.
+func (s *Service) cached(username string, sum [sha256.Size]byte) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.cache[username]
+	if !ok {
+		return false
+	}
+	if time.Now().After(c.expires) {
+		delete(s.cache, username)
+		return false
+	}
+	return subtle.ConstantTimeCompare(c.sum[:], sum[:]) == 1
+}
+
+func (s *Service) remember(username string, sum [sha256.Size]byte) {
+	if s.config.CacheExpiration <= 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache[username] = cachedCredentials{
+		sum:     sum,
+		expires: time.Now().Add(time.Duration(s.config.CacheExpiration)),
+	}
+}
+
+// invalidate removes the cached credentials of a user.
+func (s *Service) invalidate(username string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.cache, username)
+}
+
+// User returns a user without authenticating it.
+func (s *Service) User(username string) (auth.User, error) {
+	u, err := s.users.Get(username)
+	if err != nil {
+		return auth.User{}, err
+	}
+	return convertAuthUser(u)
+}
+
+// SubscriptionUser returns a user that may write to the database the token grants access to.
+func (s *Service) SubscriptionUser(token string) (auth.User, error) {
+	t, err := s.tokens.Get(token)
+	if err != nil {
+		if err == ErrNoTokenExists {
+			return auth.User{}, ErrInvalidCredentials
+		}
+		return auth.User{}, err
+	}
+	return auth.NewUser(httpd.SubscriptionUser, nil, false, map[string][]auth.Privilege{
+		auth.APIResource("/write"):        {auth.WritePrivilege},
+		auth.DatabaseResource(t.Database): {auth.WritePrivilege},
+	}), nil
+}
+
+func (s *Service) GrantSubscriptionAccess(token, db, rp string) error {
+	return s.tokens.Put(SubscriptionToken{
+		Token:           token,
+		Database:        db,
+		RetentionPolicy: rp,
+	})
+}
+
+func (s *Service) ListSubscriptionTokens() ([]string, error) {
+	var tokens []string
+	for offset := 0; ; offset += tokenListLimit {
+		ts, err := s.tokens.List(offset, tokenListLimit)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range ts {
+			tokens = append(tokens, t.Token)
+		}
+		if len(ts) < tokenListLimit {
+			return tokens, nil
+		}
+	}
+}
+
+func (s *Service) RevokeSubscriptionAccess(token string) error {
+	return s.tokens.Delete(token)
+}
+
+// CreateUser creates a new user with the password.
+func (s *Service) CreateUser(name, password string, admin bool, privileges map[string][]string) (User, error) {
+	if !validUsername.MatchString(name) {
+		return User{}, fmt.Errorf("user name must contain only letters, numbers, '-', '.', '@' and '_'. %q", name)
+	}
+	if err := validatePrivileges(privileges); err != nil {
+		return User{}, err
+	}
+	hash, err := s.hash(password)
+	if err != nil {
+		return User{}, err
+	}
+	u := User{
+		Name:       name,
+		Hash:       hash,
+		Admin:      admin,
+		Privileges: privileges,
+	}
+	if err := s.users.Create(u); err != nil {
+		return User{}, err
+	}
+	return u, nil
+}
+
+// UpdateUser updates the password, admin status or privileges of a user.
+// The password is only updated if it is not empty and the privileges only if they are not nil.
+func (s *Service) UpdateUser(name, password string, admin *bool, privileges map[string][]string) (User, error) {
+	u, err := s.users.Get(name)
+	if err != nil {
+		return User{}, err
+	}
+	if password != "" {
+		u.Hash, err = s.hash(password)
+		if err != nil {
+			return User{}, err
+		}
+	}
+	if admin != nil {
+		u.Admin = *admin
+	}
+	if privileges != nil {
+		if err := validatePrivileges(privileges); err != nil {
+			return User{}, err
+		}
+		u.Privileges = privileges
+	}
+	if err := s.users.Replace(u); err != nil {
+		return User{}, err
+	}
+	s.invalidate(name)
+	return u, nil
+}
+
+// DeleteUser deletes a user.
+func (s *Service) DeleteUser(name string) error {
+	if err := s.users.Delete(name); err != nil {
+		return err
+	}
+	s.invalidate(name)
+	return nil
+}
+
+// GetUser returns a stored user.
+func (s *Service) GetUser(name string) (User, error) {
+	return s.users.Get(name)
+}
+
+// ListUsers returns the stored users whose name matches the pattern.
+func (s *Service) ListUsers(pattern string, offset, limit int) ([]User, error) {
+	return s.users.List(pattern, offset, limit)
+}
+
+func (s *Service) hash(password string) ([]byte, error) {
+	if password == "" {
+		return nil, errors.New("password must not be empty")
+	}
+	cost := s.config.BcryptCost
+	if cost == 0 {
+		cost = DefaultBcryptCost
+	}
+	return bcrypt.GenerateFromPassword([]byte(password), cost)
+}
+
+// Roots of the resources privileges can be granted on.
+var resourceRoots = []string{auth.APIResource("/"), auth.DatabaseResource("")}
+
+// validatePrivileges validates the privileges of a user or the scopes of an API token,
+// both map resources, i.e. /api/tasks, to the names of privileges.
+func validatePrivileges(privileges map[string][]string) error {
+	for resource, names := range privileges {
+		if !validResource(resource) {
+			return fmt.Errorf("invalid resource %q, must be a clean path below %s", resource, strings.Join(resourceRoots, " or "))
+		}
+		for _, name := range names {
+			if _, err := auth.ParsePrivilege(name); err != nil {
+				return errors.Wrapf(err, "invalid privileges of resource %q", resource)
+			}
+		}
+	}
+	return nil
+}
+
+func validResource(resource string) bool {
+	if path.Clean(resource) != resource {
+		return false
+	}
+	for _, root := range resourceRoots {
+		if resource == root || strings.HasPrefix(resource, root+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func convertAuthUser(u User) (auth.User, error) {
+	privileges := make(map[string][]auth.Privilege, len(u.Privileges))
+	for resource, names := range u.Privileges {
+		ps := make([]auth.Privilege, len(names))
+		for i, name := range names {
+			p, err := auth.ParsePrivilege(name)
+			if err != nil {
+				return auth.User{}, errors.Wrapf(err, "invalid privileges of user %q", u.Name)
+			}
+			ps[i] = p
+		}
+		privileges[resource] = ps
+	}
+	return auth.NewUser(u.Name, u.Hash, u.Admin, privileges), nil
+}
+
+func userLink(name string) client.Link {
+	return client.Link{Relation: client.Self, Href: path.Join(httpd.BasePath, usersPath, name)}
+}
+
+func convertUser(u User) client.User {
+	privileges := make(map[string][]string, len(u.Privileges))
+	for resource, names := range u.Privileges {
+		privileges[resource] = append([]string(nil), names...)
+		sort.Strings(privileges[resource])
+	}
+	return client.User{
+		Link:       userLink(u.Name),
+		Name:       u.Name,
+		Admin:      u.Admin,
+		Privileges: privileges,
+	}
+}
+
+func errorCode(err error) int {
+	switch err {
+	case ErrNoUserExists:
+		return http.StatusNotFound
+	case ErrUserExists:
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+func usernameFromPath(p string) (string, error) {
+	if !strings.HasPrefix(p, usersBasePath) {
+		return "", fmt.Errorf("invalid user path %q", p)
+	}
+	name := strings.TrimSuffix(p[len(usersBasePath):], "/")
+	if name == "" || strings.Contains(name, "/") {
+		return "", fmt.Errorf("invalid user path %q", p)
+	}
+	return name, nil
+}
+
+// requireAdmin writes an error and returns false if the user is not an admin.
+// Managing users is restricted to admins regardless of the API privileges of a user,
+// since a user that can create users could grant itself any privilege.
+func requireAdmin(w http.ResponseWriter, user auth.User) bool {
+	if !user.IsAdmin() {
+		httpd.HttpError(w, fmt.Sprintf("user %s must be an admin to manage users", user.Name()), true, http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+func (s *Service) handleGetUser(w http.ResponseWriter, r *http.Request, user auth.User) {
+	name, err := usernameFromPath(r.URL.Path)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
+		return
+	}
+	// Users may see themselves
+	if name != user.Name() && !requireAdmin(w, user) {
+		return
+	}
+	u, err := s.GetUser(name)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, errorCode(err))
+		return
+	}
+	w.Write(httpd.MarshalJSON(convertUser(u), true))
+}
+
+func (s *Service) handleUpdateUser(w http.ResponseWriter, r *http.Request, user auth.User) {
+	name, err := usernameFromPath(r.URL.Path)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
+		return
+	}
+	opt := client.UpdateUserOptions{}
+	if err := json.NewDecoder(r.Body).Decode(&opt); err != nil {
+		httpd.HttpError(w, "invalid JSON: "+err.Error(), true, http.StatusBadRequest)
+		return
+	}
+	// Users may change their own password
+	self := name == user.Name() && opt.Admin == nil && opt.Privileges == nil
+	if !self && !requireAdmin(w, user) {
+		return
+	}
+	u, err := s.UpdateUser(name, opt.Password, opt.Admin, opt.Privileges)
+	if err != nil {
+		code := errorCode(err)
+		if code == http.StatusInternalServerError {
+			code = http.StatusBadRequest
+		}
+		httpd.HttpError(w, err.Error(), true, code)
+		return
+	}
+	w.Write(httpd.MarshalJSON(convertUser(u), true))
+}
+
+func (s *Service) handleDeleteUser(w http.ResponseWriter, r *http.Request, user auth.User) {
+	if !requireAdmin(w, user) {
+		return
+	}
+	name, err := usernameFromPath(r.URL.Path)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
+		return
+	}
+	if err := s.DeleteUser(name); err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Service) handleCreateUser(w http.ResponseWriter, r *http.Request, user auth.User) {
+	if !requireAdmin(w, user) {
+		return
+	}
+	opt := client.CreateUserOptions{}
+	if err := json.NewDecoder(r.Body).Decode(&opt); err != nil {
+		httpd.HttpError(w, "invalid JSON: "+err.Error(), true, http.StatusBadRequest)
+		return
+	}
+	u, err := s.CreateUser(opt.Name, opt.Password, opt.Admin, opt.Privileges)
+	if err != nil {
+		code := errorCode(err)
+		if code == http.StatusInternalServerError {
+			code = http.StatusBadRequest
+		}
+		httpd.HttpError(w, err.Error(), true, code)
+		return
+	}
+	w.Write(httpd.MarshalJSON(convertUser(u), true))
+}
+
+func (s *Service) handleListUsers(w http.ResponseWriter, r *http.Request, user auth.User) {
+	if !requireAdmin(w, user) {
+		return
+	}
+	pattern := r.URL.Query().Get("pattern")
+	offset := 0
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		var err error
+		offset, err = strconv.Atoi(offsetStr)
+		if err != nil {
+			httpd.HttpError(w, fmt.Sprintf("invalid offset parameter %q must be an integer: %s", offsetStr, err), true, http.StatusBadRequest)
+			return
+		}
+	}
+	limit := 100
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil {
+			httpd.HttpError(w, fmt.Sprintf("invalid limit parameter %q must be an integer: %s", limitStr, err), true, http.StatusBadRequest)
+			return
+		}
+	}
+	users, err := s.ListUsers(pattern, offset, limit)
+	if err != nil {
+		httpd.HttpError(w, fmt.Sprintf("failed to list users with pattern %q: %s", pattern, err), true, http.StatusBadRequest)
+		return
+	}
+	type response struct {
+		Users []client.User `json:"users"`
+	}
+	resp := response{Users: make([]client.User, len(users))}
+	for i, u := range users {
+		resp.Users[i] = convertUser(u)
+	}
+	w.Write(httpd.MarshalJSON(resp, true))
+}
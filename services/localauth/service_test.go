@@ -0,0 +1,360 @@
+package localauth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/thingnario/kapacitor/auth"
+	"github.com/thingnario/kapacitor/services/httpd"
+	"github.com/thingnario/kapacitor/services/localauth"
+	"github.com/thingnario/kapacitor/services/storage/storagetest"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type diag struct {
+	admins []string
+}
+
+func (d *diag) Error(msg string, err error) {}
+
+func (d *diag) CreatedAdminUser(username string) {
+	d.admins = append(d.admins, username)
+}
+
+// routes records the routes of the service so requests can be served without the httpd service.
+type routes struct {
+	routes []httpd.Route
+}
+
+func (r *routes) AddRoutes(routes []httpd.Route) error {
+	r.routes = append(r.routes, routes...)
+	return nil
+}
+
+func (r *routes) DelRoutes([]httpd.Route) {}
+
+func (r *routes) serve(user auth.User, method, p, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(method, httpd.BasePath+p, strings.NewReader(body))
+	for _, route := range r.routes {
+		if route.Method != method {
+			continue
+		}
+		if p == route.Pattern || (strings.HasSuffix(route.Pattern, "/") && strings.HasPrefix(p, route.Pattern)) {
+			route.HandlerFunc.(func(http.ResponseWriter, *http.Request, auth.User))(w, req, user)
+			return w
+		}
+	}
+	http.NotFound(w, req)
+	return w
+}
+
+func newService(t *testing.T, c localauth.Config) (*localauth.Service, *routes, *diag) {
+	t.Helper()
+	c.Enabled = true
+	c.BcryptCost = bcrypt.MinCost
+	r := new(routes)
+	d := new(diag)
+	s := localauth.NewService(c, d)
+	s.StorageService = storagetest.New()
+	s.HTTPDService = r
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	return s, r, d
+}
+
+func TestService_Authenticate(t *testing.T) {
+	s, _, _ := newService(t, localauth.NewConfig())
+	if _, err := s.CreateUser("bob", "secret", false, map[string][]string{
+		"/api/tasks": {"read"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CreateUser("bob", "other", false, nil); err != localauth.ErrUserExists {
+		t.Fatalf("unexpected error creating duplicate user: got %v exp %v", err, localauth.ErrUserExists)
+	}
+
+	u, err := s.Authenticate("bob", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := u.AuthorizeAction(auth.Action{Resource: auth.APIResource("/tasks"), Privilege: auth.ReadPrivilege}); err != nil {
+		t.Error(err)
+	}
+	if err := u.AuthorizeAction(auth.Action{Resource: auth.APIResource("/tasks"), Privilege: auth.WritePrivilege}); err == nil {
+		t.Error("expected write to tasks to be unauthorized")
+	}
+	if _, err := s.Authenticate("bob", "wrong"); err != localauth.ErrInvalidCredentials {
+		t.Errorf("unexpected error for wrong password: got %v exp %v", err, localauth.ErrInvalidCredentials)
+	}
+	if _, err := s.Authenticate("alice", "secret"); err != localauth.ErrInvalidCredentials {
+		t.Errorf("unexpected error for unknown user: got %v exp %v", err, localauth.ErrInvalidCredentials)
+	}
+
+	// Changing the password invalidates the cached credentials.
+	if _, err := s.UpdateUser("bob", "new", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Authenticate("bob", "secret"); err != localauth.ErrInvalidCredentials {
+		t.Errorf("unexpected error for old password: got %v exp %v", err, localauth.ErrInvalidCredentials)
+	}
+	if _, err := s.Authenticate("bob", "new"); err != nil {
+		t.Error(err)
+	}
+
+	if err := s.DeleteUser("bob"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Authenticate("bob", "new"); err != localauth.ErrInvalidCredentials {
+		t.Errorf("unexpected error for deleted user: got %v exp %v", err, localauth.ErrInvalidCredentials)
+	}
+}
+
+func TestService_CreateUser_Invalid(t *testing.T) {
+	s, _, _ := newService(t, localauth.NewConfig())
+	if _, err := s.CreateUser("bob smith", "secret", false, nil); err == nil {
+		t.Error("expected error for invalid user name")
+	}
+	if _, err := s.CreateUser("bob", "secret", false, map[string][]string{"/api/tasks": {"execute"}}); err == nil {
+		t.Error("expected error for invalid privilege")
+	}
+	for _, resource := range []string{"/tasks", "api/tasks", "/api/tasks/", "/api/../users", "/"} {
+		if _, err := s.CreateUser("bob", "secret", false, map[string][]string{resource: {"read"}}); err == nil {
+			t.Errorf("expected error for invalid resource %q", resource)
+		}
+		if _, _, err := s.CreateAPIToken("admin", "", map[string][]string{resource: {"read"}}, time.Time{}); err == nil {
+			t.Errorf("expected error for invalid scope %q", resource)
+		}
+	}
+}
+
+func TestService_SubscriptionTokens(t *testing.T) {
+	s, _, _ := newService(t, localauth.NewConfig())
+	if err := s.GrantSubscriptionAccess("token", "db", "rp"); err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := s.ListSubscriptionTokens()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 1 || tokens[0] != "token" {
+		t.Fatalf("unexpected tokens: %v", tokens)
+	}
+	u, err := s.SubscriptionUser("token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := u.AuthorizeAction(auth.Action{Resource: auth.DatabaseResource("db"), Privilege: auth.WritePrivilege}); err != nil {
+		t.Error(err)
+	}
+	if err := u.AuthorizeAction(auth.Action{Resource: auth.DatabaseResource("other"), Privilege: auth.WritePrivilege}); err == nil {
+		t.Error("expected write to other database to be unauthorized")
+	}
+
+	if err := s.RevokeSubscriptionAccess("token"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SubscriptionUser("token"); err != localauth.ErrInvalidCredentials {
+		t.Errorf("unexpected error for revoked token: got %v exp %v", err, localauth.ErrInvalidCredentials)
+	}
+}
+
+func TestService_BootstrapAdmin(t *testing.T) {
+	c := localauth.NewConfig()
+	c.AdminUsername = "admin"
+	c.AdminPassword = "secret"
+	s, _, d := newService(t, c)
+	if len(d.admins) != 1 || d.admins[0] != "admin" {
+		t.Fatalf("unexpected created admins: %v", d.admins)
+	}
+	u, err := s.Authenticate("admin", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !u.IsAdmin() {
+		t.Error("expected bootstrapped user to be an admin")
+	}
+}
+
+func TestService_HTTP(t *testing.T) {
+	s, r, _ := newService(t, localauth.NewConfig())
+	if _, err := s.CreateUser("bob", "secret", false, nil); err != nil {
+		t.Fatal(err)
+	}
+	bob, err := s.User("bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name   string
+		user   auth.User
+		method string
+		path   string
+		body   string
+		code   int
+	}{
+		{
+			name:   "admin creates user",
+			user:   auth.AdminUser,
+			method: "POST",
+			path:   "/users",
+			body:   `{"name":"alice","password":"secret","privileges":{"/api/tasks":["read"]}}`,
+			code:   http.StatusOK,
+		},
+		{
+			name:   "admin creates duplicate user",
+			user:   auth.AdminUser,
+			method: "POST",
+			path:   "/users",
+			body:   `{"name":"alice","password":"secret"}`,
+			code:   http.StatusConflict,
+		},
+		{
+			name:   "user creates user",
+			user:   bob,
+			method: "POST",
+			path:   "/users",
+			body:   `{"name":"eve","password":"secret","admin":true}`,
+			code:   http.StatusForbidden,
+		},
+		{
+			name:   "user gets self",
+			user:   bob,
+			method: "GET",
+			path:   "/users/bob",
+			code:   http.StatusOK,
+		},
+		{
+			name:   "user gets other user",
+			user:   bob,
+			method: "GET",
+			path:   "/users/alice",
+			code:   http.StatusForbidden,
+		},
+		{
+			name:   "user changes own password",
+			user:   bob,
+			method: "PATCH",
+			path:   "/users/bob",
+			body:   `{"password":"new"}`,
+			code:   http.StatusOK,
+		},
+		{
+			name:   "user makes self admin",
+			user:   bob,
+			method: "PATCH",
+			path:   "/users/bob",
+			body:   `{"admin":true}`,
+			code:   http.StatusForbidden,
+		},
+		{
+			name:   "user lists users",
+			user:   bob,
+			method: "GET",
+			path:   "/users",
+			code:   http.StatusForbidden,
+		},
+		{
+			name:   "admin gets missing user",
+			user:   auth.AdminUser,
+			method: "GET",
+			path:   "/users/eve",
+			code:   http.StatusNotFound,
+		},
+		{
+			name:   "admin deletes user",
+			user:   auth.AdminUser,
+			method: "DELETE",
+			path:   "/users/alice",
+			code:   http.StatusNoContent,
+		},
+	}
+	for _, tc := range testCases {
+		w := r.serve(tc.user, tc.method, tc.path, tc.body)
+		if w.Code != tc.code {
+			t.Errorf("%s: unexpected status code: got %d exp %d: %s", tc.name, w.Code, tc.code, w.Body.String())
+		}
+	}
+
+	if _, err := s.Authenticate("bob", "new"); err != nil {
+		t.Error(err)
+	}
+	if _, err := s.GetUser("alice"); err != localauth.ErrNoUserExists {
+		t.Errorf("unexpected error getting deleted user: got %v exp %v", err, localauth.ErrNoUserExists)
+	}
+}
+
+func TestService_APITokens(t *testing.T) {
+	s, r, _ := newService(t, localauth.NewConfig())
+	tok, token, err := s.CreateAPIToken("admin", "deploy", map[string][]string{
+		"/api/tasks": {"read", "write"},
+	}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(token, httpd.APITokenPrefix) {
+		t.Fatalf("unexpected token %q, must start with %q", token, httpd.APITokenPrefix)
+	}
+
+	u, id, err := s.APITokenUser(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != tok.ID {
+		t.Errorf("unexpected token ID: got %q exp %q", id, tok.ID)
+	}
+	if err := u.AuthorizeAction(auth.Action{Resource: auth.APIResource("/tasks/cpu"), Privilege: auth.WritePrivilege}); err != nil {
+		t.Error(err)
+	}
+	if err := u.AuthorizeAction(auth.Action{Resource: auth.APIResource("/tasks/cpu"), Privilege: auth.DeletePrivilege}); err == nil {
+		t.Error("expected delete of task to be unauthorized")
+	}
+	if err := u.AuthorizeAction(auth.Action{Resource: auth.APIResource("/users"), Privilege: auth.ReadPrivilege}); err == nil {
+		t.Error("expected read of users to be unauthorized")
+	}
+
+	// Tokens cannot manage tokens, since they are not admins.
+	if w := r.serve(u, "GET", "/tokens", ""); w.Code != http.StatusForbidden {
+		t.Errorf("unexpected status code listing tokens with token: got %d exp %d", w.Code, http.StatusForbidden)
+	}
+	w := r.serve(auth.AdminUser, "GET", "/tokens", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status code listing tokens: got %d exp %d", w.Code, http.StatusOK)
+	}
+	if strings.Contains(w.Body.String(), token) {
+		t.Error("listed tokens must not contain the secret token")
+	}
+
+	if _, _, err := s.APITokenUser(token + "x"); err != localauth.ErrInvalidCredentials {
+		t.Errorf("unexpected error for wrong secret: got %v exp %v", err, localauth.ErrInvalidCredentials)
+	}
+	if err := s.RevokeAPIToken(tok.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.APITokenUser(token); err != localauth.ErrInvalidCredentials {
+		t.Errorf("unexpected error for revoked token: got %v exp %v", err, localauth.ErrInvalidCredentials)
+	}
+}
+
+func TestService_APITokens_Expired(t *testing.T) {
+	s, _, _ := newService(t, localauth.NewConfig())
+	if _, _, err := s.CreateAPIToken("admin", "", map[string][]string{"/api/tasks": {"read"}}, time.Now().Add(-time.Minute)); err == nil {
+		t.Error("expected error creating expired token")
+	}
+	_, token, err := s.CreateAPIToken("admin", "", map[string][]string{"/api/tasks": {"read"}}, time.Now().Add(50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.APITokenUser(token); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if _, _, err := s.APITokenUser(token); err != localauth.ErrInvalidCredentials {
+		t.Errorf("unexpected error for expired token: got %v exp %v", err, localauth.ErrInvalidCredentials)
+	}
+}
@@ -1,6 +1,7 @@
 package mqtt
 
 import (
+	"sync"
 	"time"
 
 	pahomqtt "github.com/eclipse/paho.mqtt.golang"
@@ -13,8 +14,13 @@ type Client interface {
 	Connect() error
 	Disconnect()
 	Publish(topic string, qos QoSLevel, retained bool, message []byte) error
+	// Subscribe calls handler for each message received on a topic matching the topic filter.
+	Subscribe(topic string, qos QoSLevel, handler MessageHandler) error
 }
 
+// MessageHandler handles a message received on a topic.
+type MessageHandler func(topic string, payload []byte)
+
 // newClient produces a disconnected MQTT client
 var newClient = func(c Config) (Client, error) {
 	opts := pahomqtt.NewClientOptions()
@@ -41,6 +47,15 @@ var newClient = func(c Config) (Client, error) {
 type PahoClient struct {
 	opts   *pahomqtt.ClientOptions
 	client pahomqtt.Client
+
+	mu            sync.Mutex
+	subscriptions []subscription
+}
+
+type subscription struct {
+	topic   string
+	qos     QoSLevel
+	handler MessageHandler
 }
 
 // DefaultQuiesceTimeout is the duration the client will wait for outstanding
@@ -53,7 +68,10 @@ func (p *PahoClient) Connect() error {
 	// constrained clients.  Since Kapacitor is only publishing, it has no
 	// storage requirements and can reduce load on the broker by using a clean
 	// session.
+	// Subscriptions are therefore lost when the connection is lost,
+	// they are subscribed to again once the client has reconnected.
 	p.opts.SetCleanSession(true)
+	p.opts.SetOnConnectHandler(p.resubscribe)
 
 	p.client = pahomqtt.NewClient(p.opts)
 	token := p.client.Connect()
@@ -72,3 +90,35 @@ func (p *PahoClient) Publish(topic string, qos QoSLevel, retained bool, message
 	token.Wait()
 	return token.Error()
 }
+
+func (p *PahoClient) Subscribe(topic string, qos QoSLevel, handler MessageHandler) error {
+	p.mu.Lock()
+	p.subscriptions = append(p.subscriptions, subscription{
+		topic:   topic,
+		qos:     qos,
+		handler: handler,
+	})
+	p.mu.Unlock()
+	return p.subscribe(p.client, topic, qos, handler)
+}
+
+func (p *PahoClient) subscribe(client pahomqtt.Client, topic string, qos QoSLevel, handler MessageHandler) error {
+	token := client.Subscribe(topic, byte(qos), func(_ pahomqtt.Client, m pahomqtt.Message) {
+		handler(m.Topic(), m.Payload())
+	})
+	token.Wait()
+	return token.Error()
+}
+
+// resubscribe subscribes to the topics again after reconnecting.
+// The subscriptions of the first connect are made by Subscribe.
+func (p *PahoClient) resubscribe(client pahomqtt.Client) {
+	p.mu.Lock()
+	subscriptions := p.subscriptions
+	p.mu.Unlock()
+	for _, s := range subscriptions {
+		// The error is returned to the subscriber on the first connect,
+		// afterwards there is no one to report it to.
+		p.subscribe(client, s.topic, s.qos, s.handler)
+	}
+}
@@ -2,6 +2,17 @@ package mqtt
 
 import (
 	"errors"
+	"fmt"
+	"reflect"
+)
+
+const (
+	// Payloads are InfluxDB line protocol.
+	LineFormat = "line"
+	// Payloads are JSON objects, or arrays of JSON objects, mapped to points.
+	JSONFormat = "json"
+
+	DefaultTimeField = "time"
 )
 
 type Config struct {
@@ -29,6 +40,10 @@ type Config struct {
 	Username string `toml:"username" override:"username"`
 	Password string `toml:"password" override:"password,redact"`
 
+	// Subscriptions are the topic filters to subscribe to,
+	// the points of the received messages are written to Kapacitor.
+	Subscriptions []SubscriptionConfig `toml:"subscription" override:"subscriptions"`
+
 	// newClientF is a function that returns a client for a given config.
 	// It is used exclusively for testing.
 	newClientF func(c Config) (Client, error) `override:"-"`
@@ -56,6 +71,11 @@ func (c Config) Validate() error {
 			return errors.New("must specify a url for mqtt service")
 		}
 	}
+	for _, sub := range c.Subscriptions {
+		if err := sub.Validate(); err != nil {
+			return fmt.Errorf("invalid subscription %q of mqtt broker %q: %v", sub.Topic, c.Name, err)
+		}
+	}
 	return nil
 }
 
@@ -104,9 +124,80 @@ func (c Config) Equal(o Config) bool {
 	if c.Password != o.Password {
 		return false
 	}
+	if !reflect.DeepEqual(c.Subscriptions, o.Subscriptions) {
+		return false
+	}
 	return true
 }
 
+// SubscriptionConfig describes how the messages of a topic filter are written as points.
+type SubscriptionConfig struct {
+	// Topic is the topic filter, it may contain the + and # wildcards.
+	Topic string   `toml:"topic" override:"topic" mapstructure:"topic"`
+	QoS   QoSLevel `toml:"qos" override:"qos" mapstructure:"qos"`
+
+	// Database and retention policy the points are written to.
+	Database        string `toml:"database" override:"database" mapstructure:"database"`
+	RetentionPolicy string `toml:"retention-policy" override:"retention-policy" mapstructure:"retention-policy"`
+
+	// Format of the payloads, one of "line" or "json".
+	Format string `toml:"format" override:"format" mapstructure:"format"`
+	// Precision of numeric timestamps, i.e. "s" or "ms".
+	// Defaults to nanoseconds.
+	Precision string `toml:"precision" override:"precision" mapstructure:"precision"`
+
+	// TopicTags are the tag keys of the levels of the topic of a message.
+	// Levels with an empty tag key are skipped, i.e. ["", "site", "device"]
+	// adds the tags site=a,device=b to the points of messages on the topic plant/a/b.
+	TopicTags []string `toml:"topic-tags" override:"topic-tags" mapstructure:"topic-tags"`
+
+	// The options below only apply to JSON payloads.
+
+	// Measurement of the points.
+	Measurement string `toml:"measurement" override:"measurement" mapstructure:"measurement"`
+	// MeasurementKey is the key of the measurement in the JSON objects.
+	// If the key is missing Measurement is used.
+	MeasurementKey string `toml:"measurement-key" override:"measurement-key" mapstructure:"measurement-key"`
+	// TimeKey is the key of the time in the JSON objects, defaults to "time".
+	// Objects without time get the time the message is received.
+	TimeKey string `toml:"time-key" override:"time-key" mapstructure:"time-key"`
+	// TimeFormat is the Go time layout of string times, defaults to RFC3339.
+	// Numeric times are interpreted according to Precision.
+	TimeFormat string `toml:"time-format" override:"time-format" mapstructure:"time-format"`
+	// TagKeys are the keys of the JSON objects that become tags.
+	TagKeys []string `toml:"tag-keys" override:"tag-keys" mapstructure:"tag-keys"`
+	// FieldKeys are the keys of the JSON objects that become fields.
+	// If empty all other keys with a number, string or boolean value become fields.
+	FieldKeys []string `toml:"field-keys" override:"field-keys" mapstructure:"field-keys"`
+}
+
+func (c SubscriptionConfig) Validate() error {
+	if c.Topic == "" {
+		return errors.New("must specify a topic")
+	}
+	if c.QoS > ExactlyOnce {
+		return ErrInvalidQoS
+	}
+	if c.Database == "" {
+		return errors.New("must specify a database")
+	}
+	switch c.Format {
+	case "", LineFormat:
+	case JSONFormat:
+		if c.Measurement == "" && c.MeasurementKey == "" {
+			return errors.New("must specify a measurement or measurement-key for json payloads")
+		}
+	default:
+		return fmt.Errorf("invalid format %q, must be one of %q or %q", c.Format, LineFormat, JSONFormat)
+	}
+	switch c.Precision {
+	case "", "n", "ns", "u", "ms", "s", "m", "h":
+	default:
+		return fmt.Errorf("invalid precision %q", c.Precision)
+	}
+	return nil
+}
+
 type Configs []Config
 
 // Validate calls config.Validate for each element in Configs
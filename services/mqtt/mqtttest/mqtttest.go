@@ -2,6 +2,7 @@ package mqtttest
 
 import (
 	"errors"
+	"strings"
 
 	"github.com/thingnario/kapacitor/services/mqtt"
 )
@@ -23,7 +24,8 @@ func (s *ClientCreator) NewClient(c mqtt.Config) (mqtt.Client, error) {
 type MockClient struct {
 	connected bool
 
-	PublishData []PublishData
+	PublishData   []PublishData
+	Subscriptions []SubscribeData
 }
 
 func NewClient(mqtt.Config) (mqtt.Client, error) {
@@ -52,6 +54,56 @@ func (m *MockClient) Publish(topic string, qos mqtt.QoSLevel, retained bool, mes
 	return nil
 }
 
+func (m *MockClient) Subscribe(topic string, qos mqtt.QoSLevel, handler mqtt.MessageHandler) error {
+	if !m.connected {
+		return errors.New("Subscribe() called before Connect()")
+	}
+	m.Subscriptions = append(m.Subscriptions, SubscribeData{
+		Topic:   topic,
+		QoS:     qos,
+		Handler: handler,
+	})
+	return nil
+}
+
+// Deliver passes a message to the handlers of the subscriptions whose topic filter matches the topic,
+// the same as a broker would.
+func (m *MockClient) Deliver(topic string, message []byte) error {
+	if !m.connected {
+		return errors.New("Deliver() called before Connect()")
+	}
+	for _, s := range m.Subscriptions {
+		if matchTopic(s.Topic, topic) {
+			s.Handler(topic, message)
+		}
+	}
+	return nil
+}
+
+// matchTopic reports whether the topic matches the topic filter.
+func matchTopic(filter, topic string) bool {
+	filterLevels := strings.Split(filter, "/")
+	topicLevels := strings.Split(topic, "/")
+	for i, f := range filterLevels {
+		if f == "#" {
+			return true
+		}
+		if i >= len(topicLevels) {
+			return false
+		}
+		if f != "+" && f != topicLevels[i] {
+			return false
+		}
+	}
+	return len(filterLevels) == len(topicLevels)
+}
+
+type SubscribeData struct {
+	Topic   string
+	QoS     mqtt.QoSLevel
+	Handler mqtt.MessageHandler
+}
+
 type PublishData struct {
 	Topic    string
 	QoS      mqtt.QoSLevel
@@ -7,6 +7,7 @@ import (
 	"sync"
 	text "text/template"
 
+	imodels "github.com/influxdata/influxdb/models"
 	"github.com/thingnario/kapacitor/alert"
 	"github.com/thingnario/kapacitor/keyvalue"
 	"github.com/pkg/errors"
@@ -72,6 +73,11 @@ type Service struct {
 	configs map[string]Config
 
 	defaultBrokerName string
+
+	// PointsWriter writes the points of the messages received on subscriptions.
+	PointsWriter interface {
+		WritePoints(database, retentionPolicy string, consistencyLevel imodels.ConsistencyLevel, points []imodels.Point) error
+	}
 }
 
 func NewService(cs Configs, d Diagnostic) (*Service, error) {
@@ -118,6 +124,9 @@ func (s *Service) Open() error {
 		if err := client.Connect(); err != nil {
 			return errors.Wrapf(err, "failed to connect to MQTT broker %q", name)
 		}
+		if err := s.subscribe(s.configs[name], client); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -196,6 +205,9 @@ func (s *Service) update(cs Configs) error {
 				return err
 			}
 			s.clients[name] = client
+			if err := s.subscribe(c, client); err != nil {
+				return err
+			}
 		}
 	}
 	if len(cs) == 1 {
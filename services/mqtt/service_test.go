@@ -0,0 +1,140 @@
+package mqtt_test
+
+import (
+	"sort"
+	"testing"
+
+	imodels "github.com/influxdata/influxdb/models"
+	"github.com/thingnario/kapacitor/keyvalue"
+	"github.com/thingnario/kapacitor/services/mqtt"
+	"github.com/thingnario/kapacitor/services/mqtt/mqtttest"
+)
+
+type diag struct {
+	t *testing.T
+}
+
+func (d diag) WithContext(ctx ...keyvalue.T) mqtt.Diagnostic { return d }
+func (d diag) Error(msg string, err error)                   { d.t.Log(msg, err) }
+func (d diag) CreatingAlertHandler(c mqtt.HandlerConfig)     {}
+func (d diag) HandlingEvent()                                {}
+
+type pointsWriter struct {
+	databases []string
+	points    []string
+}
+
+func (w *pointsWriter) WritePoints(database, retentionPolicy string, _ imodels.ConsistencyLevel, points []imodels.Point) error {
+	for _, p := range points {
+		w.databases = append(w.databases, database+"."+retentionPolicy)
+		w.points = append(w.points, p.String())
+	}
+	return nil
+}
+
+func newService(t *testing.T, subs ...mqtt.SubscriptionConfig) (*mqtttest.MockClient, *pointsWriter) {
+	t.Helper()
+	cc := new(mqtttest.ClientCreator)
+	c := mqtt.Config{
+		Enabled:       true,
+		Name:          "plant",
+		URL:           "tcp://mqtt.example.com:1883",
+		Subscriptions: subs,
+	}
+	c.SetNewClientF(cc.NewClient)
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	s, err := mqtt.NewService(mqtt.Configs{c}, diag{t: t})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := new(pointsWriter)
+	s.PointsWriter = w
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return cc.Clients[0], w
+}
+
+func TestService_SubscribeLineProtocol(t *testing.T) {
+	client, w := newService(t, mqtt.SubscriptionConfig{
+		Topic:     "plant/+/+/telemetry",
+		QoS:       mqtt.AtLeastOnce,
+		Database:  "plant",
+		Precision: "s",
+		TopicTags: []string{"", "site", "device"},
+	})
+	if got, exp := len(client.Subscriptions), 1; got != exp {
+		t.Fatalf("unexpected number of subscriptions got %d exp %d", got, exp)
+	}
+	if got, exp := client.Subscriptions[0].QoS, mqtt.AtLeastOnce; got != exp {
+		t.Errorf("unexpected QoS got %v exp %v", got, exp)
+	}
+	if err := client.Deliver("plant/a/pump1/telemetry", []byte("pump speed=10 10")); err != nil {
+		t.Fatal(err)
+	}
+	// Does not match the topic filter
+	if err := client.Deliver("plant/a/pump1/status", []byte("pump running=true 11")); err != nil {
+		t.Fatal(err)
+	}
+	exp := []string{"pump,device=pump1,site=a speed=10 10000000000"}
+	if len(w.points) != len(exp) || w.points[0] != exp[0] {
+		t.Errorf("unexpected points got %v exp %v", w.points, exp)
+	}
+	if got, exp := w.databases[0], "plant."; got != exp {
+		t.Errorf("unexpected database got %q exp %q", got, exp)
+	}
+}
+
+func TestService_SubscribeJSON(t *testing.T) {
+	client, w := newService(t, mqtt.SubscriptionConfig{
+		Topic:           "plant/#",
+		Database:        "plant",
+		RetentionPolicy: "autogen",
+		Format:          mqtt.JSONFormat,
+		Precision:       "ms",
+		TopicTags:       []string{"", "site"},
+		Measurement:     "telemetry",
+		MeasurementKey:  "kind",
+		TimeKey:         "ts",
+		TagKeys:         []string{"line"},
+	})
+	payload := `[
+		{"kind": "pump", "ts": 1000, "line": 2, "speed": 10, "running": true, "nested": {"a": 1}},
+		{"ts": "1970-01-01T00:00:02Z", "temperature": 20.5},
+		{"ts": 3000, "line": "3"}
+	]`
+	if err := client.Deliver("plant/a", []byte(payload)); err != nil {
+		t.Fatal(err)
+	}
+	got := append([]string(nil), w.points...)
+	sort.Strings(got)
+	exp := []string{
+		"pump,line=2,site=a running=true,speed=10 1000000000",
+		"telemetry,site=a temperature=20.5 2000000000",
+	}
+	if len(got) != len(exp) {
+		t.Fatalf("unexpected points got %v exp %v", got, exp)
+	}
+	for i := range exp {
+		if got[i] != exp[i] {
+			t.Errorf("unexpected point %d got %q exp %q", i, got[i], exp[i])
+		}
+	}
+}
+
+func TestSubscriptionConfig_Validate(t *testing.T) {
+	for _, c := range []mqtt.SubscriptionConfig{
+		{Database: "db"},
+		{Topic: "a"},
+		{Topic: "a", Database: "db", Format: "csv"},
+		{Topic: "a", Database: "db", Format: mqtt.JSONFormat},
+		{Topic: "a", Database: "db", Precision: "d"},
+	} {
+		if err := c.Validate(); err == nil {
+			t.Errorf("expected error for %+v", c)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package mqtt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	imodels "github.com/influxdata/influxdb/models"
+	"github.com/pkg/errors"
+	"github.com/thingnario/kapacitor/keyvalue"
+	"github.com/thingnario/kapacitor/models"
+)
+
+// subscribe subscribes the client to the topic filters of the broker config.
+func (s *Service) subscribe(c Config, client Client) error {
+	for _, sub := range c.Subscriptions {
+		sub := sub
+		d := s.diag.WithContext(keyvalue.KV("broker", c.Name), keyvalue.KV("subscription", sub.Topic))
+		handler := func(topic string, payload []byte) {
+			s.handleMessage(sub, d, topic, payload)
+		}
+		if err := client.Subscribe(sub.Topic, sub.QoS, handler); err != nil {
+			return errors.Wrapf(err, "failed to subscribe to topic %q of MQTT broker %q", sub.Topic, c.Name)
+		}
+	}
+	return nil
+}
+
+// handleMessage writes the points of a message received on a subscription.
+func (s *Service) handleMessage(sub SubscriptionConfig, d Diagnostic, topic string, payload []byte) {
+	points, err := parseMessage(sub, topic, payload, time.Now().UTC())
+	if err != nil {
+		d.Error("failed to parse MQTT message", errors.Wrapf(err, "topic %q", topic))
+		return
+	}
+	if len(points) == 0 {
+		return
+	}
+	if err := s.PointsWriter.WritePoints(sub.Database, sub.RetentionPolicy, imodels.ConsistencyLevelAll, points); err != nil {
+		d.Error("failed to write points of MQTT message", err)
+	}
+}
+
+// parseMessage returns the points of a message.
+// Points without a time get the time now.
+func parseMessage(sub SubscriptionConfig, topic string, payload []byte, now time.Time) ([]imodels.Point, error) {
+	var points []imodels.Point
+	var err error
+	switch sub.Format {
+	case JSONFormat:
+		points, err = parseJSONMessage(sub, payload, now)
+	default:
+		points, err = imodels.ParsePointsWithPrecision(payload, now, sub.Precision)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(sub.TopicTags) > 0 {
+		levels := strings.Split(topic, "/")
+		for i, key := range sub.TopicTags {
+			if key == "" || i >= len(levels) || levels[i] == "" {
+				continue
+			}
+			for _, p := range points {
+				p.AddTag(key, levels[i])
+			}
+		}
+	}
+	return points, nil
+}
+
+// parseJSONMessage maps a JSON object, or each object of a JSON array, to a point.
+func parseJSONMessage(sub SubscriptionConfig, payload []byte, now time.Time) ([]imodels.Point, error) {
+	payload = bytes.TrimSpace(payload)
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	var objects []map[string]interface{}
+	if len(payload) > 0 && payload[0] == '[' {
+		if err := dec.Decode(&objects); err != nil {
+			return nil, errors.Wrap(err, "failed to decode json array")
+		}
+	} else {
+		objects = make([]map[string]interface{}, 1)
+		if err := dec.Decode(&objects[0]); err != nil {
+			return nil, errors.Wrap(err, "failed to decode json object")
+		}
+	}
+	points := make([]imodels.Point, 0, len(objects))
+	for _, o := range objects {
+		p, err := jsonPoint(sub, o, now)
+		if err != nil {
+			return nil, err
+		}
+		if p != nil {
+			points = append(points, p)
+		}
+	}
+	return points, nil
+}
+
+// jsonPoint maps a JSON object to a point, it returns nil if the object has no fields.
+func jsonPoint(sub SubscriptionConfig, o map[string]interface{}, now time.Time) (imodels.Point, error) {
+	timeKey := sub.TimeKey
+	if timeKey == "" {
+		timeKey = DefaultTimeField
+	}
+
+	measurement := sub.Measurement
+	if m, ok := o[sub.MeasurementKey].(string); ok && sub.MeasurementKey != "" && m != "" {
+		measurement = m
+	}
+	if measurement == "" {
+		return nil, fmt.Errorf("json object is missing the measurement key %q", sub.MeasurementKey)
+	}
+
+	t := now
+	if v, ok := o[timeKey]; ok {
+		var err error
+		t, err = models.ParseJSONTime(v, sub.TimeFormat, sub.Precision)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tags := make(map[string]string, len(sub.TagKeys))
+	for _, k := range sub.TagKeys {
+		switch v := o[k].(type) {
+		case nil:
+		case string:
+			tags[k] = v
+		default:
+			tags[k] = fmt.Sprint(v)
+		}
+	}
+
+	fields := make(imodels.Fields)
+	addField := func(k string, v interface{}) {
+		switch v := v.(type) {
+		case json.Number:
+			// Numbers are always floats so that the type of a field
+			// does not depend on whether a value happens to be whole.
+			if f, err := v.Float64(); err == nil {
+				fields[k] = f
+			}
+		case string, bool:
+			fields[k] = v
+		}
+	}
+	if len(sub.FieldKeys) > 0 {
+		for _, k := range sub.FieldKeys {
+			addField(k, o[k])
+		}
+	} else {
+		for k, v := range o {
+			if k == timeKey || k == sub.MeasurementKey || contains(sub.TagKeys, k) {
+				continue
+			}
+			addField(k, v)
+		}
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return imodels.NewPoint(measurement, imodels.NewTags(tags), fields, t)
+}
+
+func contains(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package nodestate
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	client "github.com/thingnario/kapacitor/client/v1"
+	"github.com/thingnario/kapacitor/services/httpd"
+)
+
+const (
+	nodeStatePath    = "/node-state"
+	validatePath     = nodeStatePath + "/validate"
+	validateBasePath = httpd.BasePath + validatePath
+)
+
+type apiServer struct {
+	store  Store
+	routes []httpd.Route
+
+	HTTPDService interface {
+		AddRoutes([]httpd.Route) error
+		DelRoutes([]httpd.Route)
+	}
+}
+
+func (s *apiServer) Open() error {
+	s.routes = []httpd.Route{
+		{
+			Method:      "POST",
+			Pattern:     validatePath,
+			HandlerFunc: s.handleValidate,
+		},
+	}
+	return s.HTTPDService.AddRoutes(s.routes)
+}
+
+func (s *apiServer) Close() error {
+	s.HTTPDService.DelRoutes(s.routes)
+	return nil
+}
+
+func (s *apiServer) handleValidate(w http.ResponseWriter, r *http.Request) {
+	opts := client.NodeStateValidateOptions{}
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+		httpd.HttpError(w, fmt.Sprintf("failed to unmarshal validate options: %v", err), true, http.StatusBadRequest)
+		return
+	}
+	report, err := Validate(s.store, opts.Repair)
+	if err != nil {
+		httpd.HttpError(w, fmt.Sprintf("failed to validate node state: %v", err), true, http.StatusInternalServerError)
+		return
+	}
+	result := client.NodeStateValidateResult{
+		Link:     client.Link{Relation: client.Self, Href: validateBasePath},
+		Checked:  report.Checked,
+		Outdated: report.Outdated,
+		Invalid:  make([]client.NodeStateProblem, len(report.Invalid)),
+		Migrated: report.Migrated,
+		Deleted:  report.Deleted,
+	}
+	for i, p := range report.Invalid {
+		result.Invalid[i] = client.NodeStateProblem{Key: p.Key, Error: p.Err.Error()}
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(httpd.MarshalJSON(result, true))
+}
@@ -0,0 +1,82 @@
+package nodestate
+
+import (
+	"github.com/thingnario/kapacitor/services/storage"
+)
+
+// StorageStore is an implementation of Store on top of a storage.Interface,
+// typically the BoltDB backed store of the storage service.
+type StorageStore struct {
+	store storage.Interface
+}
+
+func NewStorageStore(store storage.Interface) *StorageStore {
+	return &StorageStore{
+		store: store,
+	}
+}
+
+func (s *StorageStore) Get(key string) (value []byte, err error) {
+	err = s.store.View(func(tx storage.ReadOnlyTx) error {
+		kv, err := tx.Get(key)
+		if err != nil {
+			return err
+		}
+		value = kv.Value
+		return nil
+	})
+	if err == storage.ErrNoKeyExists {
+		err = ErrNoKeyExists
+	}
+	return
+}
+
+func (s *StorageStore) Put(key string, value []byte) error {
+	return s.store.Update(func(tx storage.Tx) error {
+		return tx.Put(key, value)
+	})
+}
+
+func (s *StorageStore) Delete(key string) error {
+	return s.store.Update(func(tx storage.Tx) error {
+		return tx.Delete(key)
+	})
+}
+
+func (s *StorageStore) Exists(key string) (exists bool, err error) {
+	err = s.store.View(func(tx storage.ReadOnlyTx) error {
+		exists, err = tx.Exists(key)
+		return err
+	})
+	return
+}
+
+func (s *StorageStore) List(prefix string) (kvs []KeyValue, err error) {
+	err = s.store.View(func(tx storage.ReadOnlyTx) error {
+		list, err := tx.List(prefix)
+		if err != nil {
+			return err
+		}
+		kvs = make([]KeyValue, len(list))
+		for i, kv := range list {
+			kvs[i] = KeyValue{Key: kv.Key, Value: kv.Value}
+		}
+		return nil
+	})
+	return
+}
+
+func (s *StorageStore) DeletePrefix(prefix string) error {
+	return s.store.Update(func(tx storage.Tx) error {
+		list, err := tx.List(prefix)
+		if err != nil {
+			return err
+		}
+		for _, kv := range list {
+			if err := tx.Delete(kv.Key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,59 @@
+package nodestate
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/toml"
+	"github.com/pkg/errors"
+)
+
+const (
+	// Store node state in Redis.
+	RedisBackend = "redis"
+	// Store node state in the BoltDB database of the storage service.
+	BoltBackend = "bolt"
+	// Keep node state in memory only.
+	MemoryBackend = "memory"
+
+	// Default interval at which expired keys are deleted
+	// when the backend does not expire keys natively.
+	DefaultGCInterval = toml.Duration(10 * time.Minute)
+)
+
+type Config struct {
+	// Backend used to persist node state.
+	// One of "redis", "bolt" or "memory".
+	Backend string `toml:"backend"`
+	// Prefix prepended to every key, allows several
+	// Kapacitor instances to share a single Redis server.
+	KeyPrefix string `toml:"key-prefix"`
+	// Time after its last update that state expires.
+	// A zero value means state never expires.
+	TTL toml.Duration `toml:"ttl"`
+	// Interval at which expired keys are deleted from the bolt and memory backends.
+	// Redis expires keys itself.
+	GCInterval toml.Duration `toml:"gc-interval"`
+}
+
+func NewConfig() Config {
+	return Config{
+		Backend:    RedisBackend,
+		GCInterval: DefaultGCInterval,
+	}
+}
+
+func (c Config) Validate() error {
+	switch c.Backend {
+	case RedisBackend, BoltBackend, MemoryBackend:
+	default:
+		return fmt.Errorf("invalid node state backend %q, must be one of %q, %q or %q", c.Backend, RedisBackend, BoltBackend, MemoryBackend)
+	}
+	if c.TTL < 0 {
+		return errors.New("ttl cannot be negative")
+	}
+	if c.TTL > 0 && c.Backend != RedisBackend && c.GCInterval <= 0 {
+		return errors.New("gc-interval must be positive when a ttl is set")
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+/*
+The nodestate package provides a key/value store for state that task nodes
+persist across restarts, for example the last seen values of a changeDetect
+node or the current level of an alert.
+
+Three implementations of Store are provided:
+
+	redis  - stores state in Redis using the client of the redis service.
+	bolt   - stores state in the Kapacitor BoltDB database via the storage service.
+	memory - keeps state in memory only; it does not survive a restart.
+
+The Service selects one of the implementations based on its Config.
+It prepends the configured key prefix to every key, so that several Kapacitor
+instances can share a Redis server, and expires state after the configured TTL.
+Redis expires keys natively, the bolt and memory stores are wrapped in an
+ExpiringStore and expired keys are periodically deleted by the Service.
+*/
+package nodestate
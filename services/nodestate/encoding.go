@@ -0,0 +1,227 @@
+package nodestate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/thingnario/kapacitor/models"
+	"github.com/thingnario/kapacitor/services/storage"
+)
+
+// Versions of the encodings of persisted state.
+// Version 0 is the unversioned encoding used before state was versioned.
+const (
+	fieldsVersion     = 1
+	eventStateVersion = 1
+)
+
+// Types of field values in the typed fields encoding.
+const (
+	floatFieldType  = "float"
+	intFieldType    = "int"
+	uintFieldType   = "uint"
+	stringFieldType = "string"
+	boolFieldType   = "bool"
+)
+
+type typedFieldValue struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+// EncodeFields encodes fields, preserving the type of each field value.
+func EncodeFields(fields models.Fields) ([]byte, error) {
+	typed := make(map[string]typedFieldValue, len(fields))
+	for name, value := range fields {
+		var t string
+		switch value.(type) {
+		case float64:
+			t = floatFieldType
+		case int64:
+			t = intFieldType
+		case uint64:
+			t = uintFieldType
+		case string:
+			t = stringFieldType
+		case bool:
+			t = boolFieldType
+		default:
+			return nil, fmt.Errorf("unsupported type %T for field %q", value, name)
+		}
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to encode field %q", name)
+		}
+		typed[name] = typedFieldValue{Type: t, Value: raw}
+	}
+	return storage.VersionJSONEncode(fieldsVersion, typed)
+}
+
+// DecodeFields decodes fields encoded by EncodeFields or by any previous version of the encoding.
+func DecodeFields(data []byte) (models.Fields, error) {
+	fields, _, err := decodeFields(data)
+	return fields, err
+}
+
+func decodeFields(data []byte) (fields models.Fields, version int, err error) {
+	version, err = decodeVersioned(data, func(version int, dec *json.Decoder) error {
+		switch version {
+		case 0:
+			// Unversioned fields were stored as a plain JSON object,
+			// numbers can only be restored as floats.
+			if err := dec.Decode(&fields); err != nil {
+				return err
+			}
+			for name, value := range fields {
+				switch value.(type) {
+				case float64, string, bool:
+				default:
+					return fmt.Errorf("unsupported type %T for field %q", value, name)
+				}
+			}
+			return nil
+		case 1:
+			typed := make(map[string]typedFieldValue)
+			if err := dec.Decode(&typed); err != nil {
+				return err
+			}
+			fields = make(models.Fields, len(typed))
+			for name, tv := range typed {
+				value, err := decodeFieldValue(tv)
+				if err != nil {
+					return errors.Wrapf(err, "invalid field %q", name)
+				}
+				fields[name] = value
+			}
+			return nil
+		default:
+			return fmt.Errorf("unsupported fields version %d", version)
+		}
+	})
+	if err == nil && fields == nil {
+		err = errors.New("missing fields")
+	}
+	return
+}
+
+func decodeFieldValue(tv typedFieldValue) (interface{}, error) {
+	switch tv.Type {
+	case floatFieldType:
+		var f float64
+		err := json.Unmarshal(tv.Value, &f)
+		return f, err
+	case intFieldType:
+		// Parse the literal directly so that large values are not rounded through a float.
+		return strconv.ParseInt(string(tv.Value), 10, 64)
+	case uintFieldType:
+		return strconv.ParseUint(string(tv.Value), 10, 64)
+	case stringFieldType:
+		var s string
+		err := json.Unmarshal(tv.Value, &s)
+		return s, err
+	case boolFieldType:
+		var b bool
+		err := json.Unmarshal(tv.Value, &b)
+		return b, err
+	default:
+		return nil, fmt.Errorf("unknown field type %q", tv.Type)
+	}
+}
+
+// EventState is the persisted state of an alert event.
+type EventState struct {
+	ID       string        `json:"id"`
+	Message  string        `json:"message"`
+	Details  string        `json:"details"`
+	Time     time.Time     `json:"time"`
+	Duration time.Duration `json:"duration"`
+	Level    int64         `json:"level"`
+}
+
+// EncodeEventState encodes the state of an alert event.
+func EncodeEventState(state EventState) ([]byte, error) {
+	return storage.VersionJSONEncode(eventStateVersion, state)
+}
+
+// DecodeEventState decodes an event state encoded by EncodeEventState or by any previous version of the encoding.
+func DecodeEventState(data []byte) (EventState, error) {
+	state, _, err := decodeEventState(data)
+	return state, err
+}
+
+func decodeEventState(data []byte) (state EventState, version int, err error) {
+	version, err = decodeVersioned(data, func(version int, dec *json.Decoder) error {
+		switch version {
+		case 0:
+			// Unversioned event states were stored as a JSON object
+			// with capitalized keys and the time as RFC3339 text.
+			var legacy struct {
+				ID       string
+				Message  string
+				Details  string
+				Time     time.Time
+				Duration *int64
+				Level    *int64
+			}
+			if err := dec.Decode(&legacy); err != nil {
+				return err
+			}
+			if legacy.Duration == nil || legacy.Level == nil {
+				return errors.New("missing duration or level")
+			}
+			state = EventState{
+				ID:       legacy.ID,
+				Message:  legacy.Message,
+				Details:  legacy.Details,
+				Time:     legacy.Time,
+				Duration: time.Duration(*legacy.Duration),
+				Level:    *legacy.Level,
+			}
+			return nil
+		case 1:
+			return dec.Decode(&state)
+		default:
+			return fmt.Errorf("unsupported event state version %d", version)
+		}
+	})
+	return
+}
+
+// decodeVersioned decodes data written with storage.VersionJSONEncode,
+// data that is not wrapped with a version is decoded as version 0.
+// It returns the version of the data.
+func decodeVersioned(data []byte, decF func(version int, dec *json.Decoder) error) (int, error) {
+	if isVersioned(data) {
+		version := 0
+		err := storage.VersionJSONDecode(data, func(v int, dec *json.Decoder) error {
+			version = v
+			return decF(v, dec)
+		})
+		return version, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if err := decF(0, dec); err != nil {
+		return 0, err
+	}
+	return 0, nil
+}
+
+// isVersioned reports whether data is a version wrapper, an object with exactly
+// an integer "version" key and an object "value" key.
+// Unversioned data may be an object with its own version or value keys, such as the fields of a point.
+func isVersioned(data []byte) bool {
+	var wrapper map[string]json.RawMessage
+	if err := json.Unmarshal(data, &wrapper); err != nil || len(wrapper) != 2 {
+		return false
+	}
+	var version int
+	if err := json.Unmarshal(wrapper["version"], &version); err != nil || version < 1 {
+		return false
+	}
+	value := bytes.TrimSpace(wrapper["value"])
+	return len(value) > 0 && value[0] == '{'
+}
@@ -0,0 +1,149 @@
+package nodestate_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/thingnario/kapacitor/models"
+	"github.com/thingnario/kapacitor/services/nodestate"
+)
+
+func TestFields_RoundTrip(t *testing.T) {
+	fields := models.Fields{
+		"float":  1.0,
+		"int":    int64(math.MaxInt64),
+		"uint":   uint64(math.MaxUint64),
+		"string": "value",
+		"bool":   true,
+	}
+	data, err := nodestate.EncodeFields(fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := nodestate.DecodeFields(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, fields) {
+		t.Errorf("unexpected fields got %#v exp %#v", got, fields)
+	}
+}
+
+func TestFields_Decode(t *testing.T) {
+	testCases := []struct {
+		name   string
+		data   string
+		exp    models.Fields
+		hasErr bool
+	}{
+		{
+			name: "unversioned",
+			data: `{"value":1,"state":"on","ok":true}`,
+			exp:  models.Fields{"value": 1.0, "state": "on", "ok": true},
+		},
+		{
+			name: "unversioned with version and value fields",
+			data: `{"version":3,"value":2.5}`,
+			exp:  models.Fields{"version": 3.0, "value": 2.5},
+		},
+		{
+			name: "unversioned with capitalized version and value fields",
+			data: `{"Version":1,"Value":2}`,
+			exp:  models.Fields{"Version": 1.0, "Value": 2.0},
+		},
+		{
+			name: "unversioned with more fields",
+			data: `{"version":1,"value":1,"state":"on"}`,
+			exp:  models.Fields{"version": 1.0, "value": 1.0, "state": "on"},
+		},
+		{
+			name:   "unversioned nested object",
+			data:   `{"value":{"a":1}}`,
+			hasErr: true,
+		},
+		{
+			name:   "unknown type",
+			data:   `{"version":1,"value":{"value":{"type":"complex","value":1}}}`,
+			hasErr: true,
+		},
+		{
+			name:   "int value with a fraction",
+			data:   `{"version":1,"value":{"value":{"type":"int","value":1.5}}}`,
+			hasErr: true,
+		},
+		{
+			name:   "future version",
+			data:   `{"version":2,"value":{}}`,
+			hasErr: true,
+		},
+		{
+			name:   "not json",
+			data:   `value`,
+			hasErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := nodestate.DecodeFields([]byte(tc.data))
+			if tc.hasErr {
+				if err == nil {
+					t.Fatalf("expected error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.exp) {
+				t.Errorf("unexpected fields got %#v exp %#v", got, tc.exp)
+			}
+		})
+	}
+}
+
+func TestEventState_RoundTrip(t *testing.T) {
+	state := nodestate.EventState{
+		ID:       "cpu:host=a",
+		Message:  "message",
+		Details:  "details",
+		Time:     time.Date(2019, 1, 2, 3, 4, 5, 6, time.UTC),
+		Duration: 1500 * time.Millisecond,
+		Level:    3,
+	}
+	data, err := nodestate.EncodeEventState(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := nodestate.DecodeEventState(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, state) {
+		t.Errorf("unexpected event state got %#v exp %#v", got, state)
+	}
+}
+
+func TestEventState_DecodeUnversioned(t *testing.T) {
+	data := `{"Details":"details","Duration":1500000000,"ID":"cpu:host=a","Level":2,"Message":"message","Time":"2019-01-02T03:04:05.000000006Z"}`
+	got, err := nodestate.DecodeEventState([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := nodestate.EventState{
+		ID:       "cpu:host=a",
+		Message:  "message",
+		Details:  "details",
+		Time:     time.Date(2019, 1, 2, 3, 4, 5, 6, time.UTC),
+		Duration: 1500 * time.Millisecond,
+		Level:    2,
+	}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected event state got %#v exp %#v", got, exp)
+	}
+
+	if _, err := nodestate.DecodeEventState([]byte(`{"ID":"cpu:host=a"}`)); err == nil {
+		t.Error("expected error for event state without level")
+	}
+}
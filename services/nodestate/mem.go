@@ -0,0 +1,77 @@
+package nodestate
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MemStore is an in memory only implementation of Store.
+// State stored in a MemStore does not survive a restart.
+type MemStore struct {
+	mu    sync.RWMutex
+	store map[string][]byte
+}
+
+func NewMemStore() *MemStore {
+	return &MemStore{
+		store: make(map[string][]byte),
+	}
+}
+
+func (s *MemStore) Get(key string) ([]byte, error) {
+	s.mu.RLock()
+	value, ok := s.store[key]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, ErrNoKeyExists
+	}
+	return value, nil
+}
+
+func (s *MemStore) Put(key string, value []byte) error {
+	v := make([]byte, len(value))
+	copy(v, value)
+	s.mu.Lock()
+	s.store[key] = v
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *MemStore) Delete(key string) error {
+	s.mu.Lock()
+	delete(s.store, key)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *MemStore) Exists(key string) (bool, error) {
+	s.mu.RLock()
+	_, ok := s.store[key]
+	s.mu.RUnlock()
+	return ok, nil
+}
+
+func (s *MemStore) List(prefix string) ([]KeyValue, error) {
+	s.mu.RLock()
+	kvs := make([]KeyValue, 0, len(s.store))
+	for k, v := range s.store {
+		if strings.HasPrefix(k, prefix) {
+			kvs = append(kvs, KeyValue{Key: k, Value: v})
+		}
+	}
+	s.mu.RUnlock()
+	sort.Sort(keySortedKVs(kvs))
+	return kvs, nil
+}
+
+func (s *MemStore) DeletePrefix(prefix string) error {
+	s.mu.Lock()
+	for k := range s.store {
+		if strings.HasPrefix(k, prefix) {
+			delete(s.store, k)
+		}
+	}
+	s.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,37 @@
+package nodestate
+
+import "errors"
+
+// Common errors that can be returned
+var (
+	ErrNoKeyExists = errors.New("no key exists")
+)
+
+// Store is a simple key/value store used by nodes to persist their state.
+type Store interface {
+	// Retrieve a value.
+	// Returns ErrNoKeyExists if the key does not exist.
+	Get(key string) ([]byte, error)
+	// Store a value.
+	Put(key string, value []byte) error
+	// Delete a key.
+	// Deleting a non-existent key is not an error.
+	Delete(key string) error
+	// Check if a key exists.
+	Exists(key string) (bool, error)
+	// List all values with given prefix, sorted by key.
+	List(prefix string) ([]KeyValue, error)
+	// Delete all keys with given prefix.
+	DeletePrefix(prefix string) error
+}
+
+type KeyValue struct {
+	Key   string
+	Value []byte
+}
+
+type keySortedKVs []KeyValue
+
+func (s keySortedKVs) Len() int               { return len(s) }
+func (s keySortedKVs) Less(i int, j int) bool { return s[i].Key < s[j].Key }
+func (s keySortedKVs) Swap(i int, j int)      { s[i], s[j] = s[j], s[i] }
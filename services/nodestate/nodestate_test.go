@@ -0,0 +1,398 @@
+package nodestate_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/go-redis/redis"
+	"github.com/thingnario/kapacitor/services/httpd"
+	"github.com/thingnario/kapacitor/services/nodestate"
+	"github.com/thingnario/kapacitor/services/storage"
+)
+
+// Address of a Redis server to run the tests against.
+// The Redis tests are skipped if it is not set.
+// WARNING: the database used by the tests is flushed.
+const redisAddrEnv = "KAPACITOR_TEST_REDIS_ADDR"
+
+// Redis database flushed and used by the tests.
+const redisTestDB = 15
+
+type createStoreCloser func() (storeCloser, error)
+
+// stores is a map of all Store implementations,
+// each test will be run against the stores found in this map.
+var stores = map[string]createStoreCloser{
+	"bolt":     newBolt,
+	"mem":      newMem,
+	"expiring": newExpiring,
+	"redis":    newRedis,
+}
+
+type storeCloser interface {
+	nodestate.Store
+	Close()
+}
+
+type boltStore struct {
+	*nodestate.StorageStore
+	db  *bolt.DB
+	dir string
+}
+
+func newBolt() (storeCloser, error) {
+	tmpDir, err := ioutil.TempDir("", "nodestate-bolt")
+	if err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(filepath.Join(tmpDir, "bolt.db"), 0600, nil)
+	if err != nil {
+		return nil, err
+	}
+	return boltStore{
+		StorageStore: nodestate.NewStorageStore(storage.NewBolt(db, "node_state")),
+		db:           db,
+		dir:          tmpDir,
+	}, nil
+}
+
+func (b boltStore) Close() {
+	b.db.Close()
+	os.RemoveAll(b.dir)
+}
+
+type memStore struct {
+	*nodestate.MemStore
+}
+
+func newMem() (storeCloser, error) {
+	return memStore{MemStore: nodestate.NewMemStore()}, nil
+}
+
+func (memStore) Close() {}
+
+type expiringStore struct {
+	*nodestate.ExpiringStore
+}
+
+func newExpiring() (storeCloser, error) {
+	return expiringStore{ExpiringStore: nodestate.NewExpiringStore(nodestate.NewMemStore(), time.Hour)}, nil
+}
+
+func (expiringStore) Close() {}
+
+type redisStore struct {
+	*nodestate.RedisStore
+	client *redis.Client
+}
+
+type redisService struct {
+	client redis.UniversalClient
+}
+
+func (r redisService) Client() (redis.UniversalClient, error) {
+	return r.client, nil
+}
+
+var errSkipRedis = fmt.Errorf("%s not set", redisAddrEnv)
+
+func newRedis() (storeCloser, error) {
+	return newRedisWithTTL(0)
+}
+
+func newRedisWithTTL(ttl time.Duration) (storeCloser, error) {
+	addr := os.Getenv(redisAddrEnv)
+	if addr == "" {
+		return nil, errSkipRedis
+	}
+	client := redis.NewClient(&redis.Options{
+		Addr: addr,
+		DB:   redisTestDB,
+	})
+	if err := client.FlushDB().Err(); err != nil {
+		return nil, err
+	}
+	return redisStore{
+		RedisStore: nodestate.NewRedisStore(redisService{client: client}, ttl),
+		client:     client,
+	}, nil
+}
+
+func (r redisStore) Close() {
+	r.client.FlushDB()
+	r.client.Close()
+}
+
+func forEachStore(t *testing.T, f func(t *testing.T, s nodestate.Store)) {
+	for name, sc := range stores {
+		t.Run(name, func(t *testing.T) {
+			s, err := sc()
+			if err == errSkipRedis {
+				t.Skip(err)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+			f(t, s)
+		})
+	}
+}
+
+func TestStore_CRUD(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s nodestate.Store) {
+		key := "key0"
+		value := []byte("test value")
+		if exists, err := s.Exists(key); err != nil {
+			t.Fatal(err)
+		} else if exists {
+			t.Fatal("expected key to not exist")
+		}
+		if _, err := s.Get(key); err != nodestate.ErrNoKeyExists {
+			t.Fatalf("unexpected error got %v exp %v", err, nodestate.ErrNoKeyExists)
+		}
+
+		if err := s.Put(key, value); err != nil {
+			t.Fatal(err)
+		}
+		if exists, err := s.Exists(key); err != nil {
+			t.Fatal(err)
+		} else if !exists {
+			t.Fatal("expected key to exist")
+		}
+
+		got, err := s.Get(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, value) {
+			t.Fatalf("unexpected value got %q exp %q", string(got), string(value))
+		}
+
+		if err := s.Delete(key); err != nil {
+			t.Fatal(err)
+		}
+		if exists, err := s.Exists(key); err != nil {
+			t.Fatal(err)
+		} else if exists {
+			t.Fatal("expected key to not exist after delete")
+		}
+		// Deleting a non-existent key is not an error
+		if err := s.Delete(key); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestStore_Overwrite(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s nodestate.Store) {
+		if err := s.Put("key0", []byte("first")); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.Put("key0", []byte("second")); err != nil {
+			t.Fatal(err)
+		}
+		got, err := s.Get("key0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exp := "second"; string(got) != exp {
+			t.Errorf("unexpected value got %q exp %q", string(got), exp)
+		}
+	})
+}
+
+func TestStore_List(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s nodestate.Store) {
+		keys := []string{
+			"topics|cpu|host=b",
+			"topics|cpu|host=a",
+			"topics*|cpu|host=c",
+			"changeDetectNode:task:host=a",
+			"topics",
+		}
+		for _, k := range keys {
+			if err := s.Put(k, []byte(k)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		kvs, err := s.List("topics|")
+		if err != nil {
+			t.Fatal(err)
+		}
+		exp := []string{
+			"topics|cpu|host=a",
+			"topics|cpu|host=b",
+		}
+		if len(kvs) != len(exp) {
+			t.Fatalf("unexpected number of keys got %d exp %d: %v", len(kvs), len(exp), kvs)
+		}
+		for i, kv := range kvs {
+			if kv.Key != exp[i] {
+				t.Errorf("unexpected key at %d got %q exp %q", i, kv.Key, exp[i])
+			}
+			if string(kv.Value) != exp[i] {
+				t.Errorf("unexpected value at %d got %q exp %q", i, string(kv.Value), exp[i])
+			}
+		}
+
+		kvs, err = s.List("missing")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(kvs) != 0 {
+			t.Errorf("expected no keys, got %v", kvs)
+		}
+	})
+}
+
+func TestStore_DeletePrefix(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s nodestate.Store) {
+		keys := []string{
+			"changeDetectNode:task:host=a",
+			"changeDetectNode:task:host=b",
+			"changeDetectNode:task2:host=a",
+			"topics|task:alert|host=a",
+		}
+		for _, k := range keys {
+			if err := s.Put(k, []byte(k)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := s.DeletePrefix("changeDetectNode:task:"); err != nil {
+			t.Fatal(err)
+		}
+		kvs, err := s.List("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		exp := []string{
+			"changeDetectNode:task2:host=a",
+			"topics|task:alert|host=a",
+		}
+		if len(kvs) != len(exp) {
+			t.Fatalf("unexpected number of keys got %d exp %d: %v", len(kvs), len(exp), kvs)
+		}
+		for i, kv := range kvs {
+			if kv.Key != exp[i] {
+				t.Errorf("unexpected key at %d got %q exp %q", i, kv.Key, exp[i])
+			}
+		}
+	})
+}
+
+func TestStore_TTL(t *testing.T) {
+	ttl := 50 * time.Millisecond
+	ttlStores := map[string]createStoreCloser{
+		"expiring": func() (storeCloser, error) {
+			return expiringStore{ExpiringStore: nodestate.NewExpiringStore(nodestate.NewMemStore(), ttl)}, nil
+		},
+		"redis": func() (storeCloser, error) {
+			return newRedisWithTTL(ttl)
+		},
+	}
+	for name, sc := range ttlStores {
+		t.Run(name, func(t *testing.T) {
+			s, err := sc()
+			if err == errSkipRedis {
+				t.Skip(err)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+			if err := s.Put("key0", []byte("value")); err != nil {
+				t.Fatal(err)
+			}
+			if exists, err := s.Exists("key0"); err != nil {
+				t.Fatal(err)
+			} else if !exists {
+				t.Fatal("expected key to exist before ttl")
+			}
+			time.Sleep(2 * ttl)
+			if _, err := s.Get("key0"); err != nodestate.ErrNoKeyExists {
+				t.Fatalf("unexpected error got %v exp %v", err, nodestate.ErrNoKeyExists)
+			}
+			kvs, err := s.List("")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(kvs) != 0 {
+				t.Errorf("expected no keys after ttl, got %v", kvs)
+			}
+		})
+	}
+}
+
+func TestExpiringStore_DeleteExpired(t *testing.T) {
+	mem := nodestate.NewMemStore()
+	short := nodestate.NewExpiringStore(mem, time.Millisecond)
+	long := nodestate.NewExpiringStore(mem, 0)
+	if err := short.Put("expired", []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	if err := long.Put("kept", []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	n, err := short.DeleteExpired()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("unexpected number of deleted keys got %d exp 1", n)
+	}
+	if exists, _ := mem.Exists("expired"); exists {
+		t.Error("expected expired key to be deleted from the underlying store")
+	}
+	if exists, _ := mem.Exists("kept"); !exists {
+		t.Error("expected key without expiry to be kept")
+	}
+}
+
+type httpdService struct{}
+
+func (httpdService) AddRoutes([]httpd.Route) error { return nil }
+func (httpdService) DelRoutes([]httpd.Route)       {}
+
+type diag struct{}
+
+func (diag) Error(msg string, err error)  {}
+func (diag) DeletedExpiredKeys(count int) {}
+
+func TestService_KeyPrefix(t *testing.T) {
+	c := nodestate.NewConfig()
+	c.Backend = nodestate.MemoryBackend
+	c.KeyPrefix = "instance-a:"
+	s := nodestate.NewService(c, diag{})
+	s.HTTPDService = httpdService{}
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if err := s.Put("topics|cpu|host=a", []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	kvs, err := s.List("topics|")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kvs) != 1 || kvs[0].Key != "topics|cpu|host=a" {
+		t.Fatalf("unexpected keys %v", kvs)
+	}
+	if err := s.DeletePrefix("topics|cpu|"); err != nil {
+		t.Fatal(err)
+	}
+	if exists, err := s.Exists("topics|cpu|host=a"); err != nil {
+		t.Fatal(err)
+	} else if exists {
+		t.Error("expected key to be deleted")
+	}
+}
@@ -0,0 +1,188 @@
+package nodestate
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// Number of keys requested per SCAN iteration when listing keys.
+const redisScanCount = 100
+
+// RedisService provides the client used by a RedisStore.
+type RedisService interface {
+	Client() (redis.UniversalClient, error)
+}
+
+// RedisStore is an implementation of Store backed by a Redis server.
+// Keys expire natively in Redis after the TTL, a zero TTL means keys never expire.
+type RedisStore struct {
+	redis RedisService
+	ttl   time.Duration
+}
+
+func NewRedisStore(r RedisService, ttl time.Duration) *RedisStore {
+	return &RedisStore{
+		redis: r,
+		ttl:   ttl,
+	}
+}
+
+func (s *RedisStore) Get(key string) ([]byte, error) {
+	client, err := s.redis.Client()
+	if err != nil {
+		return nil, err
+	}
+	value, err := client.Get(key).Bytes()
+	if err == redis.Nil {
+		return nil, ErrNoKeyExists
+	}
+	return value, err
+}
+
+func (s *RedisStore) Put(key string, value []byte) error {
+	client, err := s.redis.Client()
+	if err != nil {
+		return err
+	}
+	return client.Set(key, value, s.ttl).Err()
+}
+
+func (s *RedisStore) Delete(key string) error {
+	client, err := s.redis.Client()
+	if err != nil {
+		return err
+	}
+	return client.Del(key).Err()
+}
+
+func (s *RedisStore) Exists(key string) (bool, error) {
+	client, err := s.redis.Client()
+	if err != nil {
+		return false, err
+	}
+	n, err := client.Exists(key).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (s *RedisStore) List(prefix string) ([]KeyValue, error) {
+	keys, err := s.keys(prefix)
+	if err != nil {
+		return nil, err
+	}
+	kvs := make([]KeyValue, 0, len(keys))
+	for _, key := range keys {
+		value, err := s.Get(key)
+		if err == ErrNoKeyExists {
+			// Key was deleted since it was scanned
+			continue
+		}
+		if isWrongType(err) {
+			// Key holds a stream or hash of another service, not a value of the store
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		kvs = append(kvs, KeyValue{Key: key, Value: value})
+	}
+	return kvs, nil
+}
+
+func (s *RedisStore) DeletePrefix(prefix string) error {
+	keys, err := s.keys(prefix)
+	if err != nil {
+		return err
+	}
+	client, err := s.redis.Client()
+	if err != nil {
+		return err
+	}
+	// Delete keys one at a time, in cluster mode the keys of a single
+	// DEL command must all belong to the same hash slot.
+	for _, key := range keys {
+		if err := client.Del(key).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keys returns the sorted and deduplicated list of keys with the given prefix.
+func (s *RedisStore) keys(prefix string) ([]string, error) {
+	client, err := s.redis.Client()
+	if err != nil {
+		return nil, err
+	}
+	match := escapeRedisPattern(prefix) + "*"
+	var keys []string
+	if cluster, ok := client.(*redis.ClusterClient); ok {
+		// Keys are distributed across the masters, scan each of them.
+		var mu sync.Mutex
+		err = cluster.ForEachMaster(func(c *redis.Client) error {
+			ks, err := scanKeys(c, match)
+			mu.Lock()
+			keys = append(keys, ks...)
+			mu.Unlock()
+			return err
+		})
+	} else {
+		keys, err = scanKeys(client, match)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// SCAN may return a key more than once and does not order its results.
+	sort.Strings(keys)
+	unique := keys[:0]
+	for i, key := range keys {
+		if i > 0 && keys[i-1] == key {
+			continue
+		}
+		unique = append(unique, key)
+	}
+	return unique, nil
+}
+
+// scanKeys returns all keys matching the pattern.
+func scanKeys(client redis.Cmdable, match string) ([]string, error) {
+	var keys []string
+	var cursor uint64
+	for {
+		ks, next, err := client.Scan(cursor, match, redisScanCount).Result()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, ks...)
+		cursor = next
+		if cursor == 0 {
+			return keys, nil
+		}
+	}
+}
+
+// isWrongType reports whether err is the error returned for an operation on a key holding the wrong kind of value.
+func isWrongType(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "WRONGTYPE")
+}
+
+var redisPatternEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`*`, `\*`,
+	`?`, `\?`,
+	`[`, `\[`,
+	`]`, `\]`,
+)
+
+// escapeRedisPattern escapes all glob-style special characters so that s
+// is matched literally by a Redis MATCH pattern.
+func escapeRedisPattern(s string) string {
+	return redisPatternEscaper.Replace(s)
+}
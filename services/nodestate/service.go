@@ -0,0 +1,163 @@
+package nodestate
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thingnario/kapacitor/services/httpd"
+	"github.com/thingnario/kapacitor/services/storage"
+)
+
+const (
+	// The storage namespace for node state when using the bolt backend.
+	nodeStateNamespace = "node_state"
+)
+
+type Diagnostic interface {
+	Error(msg string, err error)
+	DeletedExpiredKeys(count int)
+}
+
+// Service is a Store that delegates to the backend selected in its Config.
+// All keys are stored under the configured key prefix.
+type Service struct {
+	mu      sync.RWMutex
+	c       Config
+	store   Store
+	closing chan struct{}
+	wg      sync.WaitGroup
+	diag    Diagnostic
+
+	apiServer *apiServer
+
+	StorageService interface {
+		Store(namespace string) storage.Interface
+	}
+	RedisService RedisService
+	HTTPDService interface {
+		AddRoutes([]httpd.Route) error
+		DelRoutes([]httpd.Route)
+	}
+}
+
+func NewService(c Config, d Diagnostic) *Service {
+	return &Service{
+		c:    c,
+		diag: d,
+	}
+}
+
+func (s *Service) Open() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ttl := time.Duration(s.c.TTL)
+	var expiring *ExpiringStore
+	switch s.c.Backend {
+	case RedisBackend:
+		s.store = NewRedisStore(s.RedisService, ttl)
+	case BoltBackend:
+		expiring = NewExpiringStore(NewStorageStore(s.StorageService.Store(nodeStateNamespace)), ttl)
+		s.store = expiring
+	case MemoryBackend:
+		expiring = NewExpiringStore(NewMemStore(), ttl)
+		s.store = expiring
+	default:
+		return fmt.Errorf("unknown node state backend %q", s.c.Backend)
+	}
+	s.apiServer = &apiServer{
+		store:        s,
+		HTTPDService: s.HTTPDService,
+	}
+	if err := s.apiServer.Open(); err != nil {
+		return err
+	}
+
+	s.closing = make(chan struct{})
+	if expiring != nil && ttl > 0 {
+		s.wg.Add(1)
+		go s.runGC(expiring, time.Duration(s.c.GCInterval))
+	}
+	return nil
+}
+
+func (s *Service) Close() error {
+	s.mu.Lock()
+	if s.apiServer != nil {
+		s.apiServer.Close()
+		s.apiServer = nil
+	}
+	if s.closing != nil {
+		close(s.closing)
+		s.closing = nil
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+	return nil
+}
+
+// runGC periodically deletes expired keys from a store that does not expire them natively.
+func (s *Service) runGC(store *ExpiringStore, interval time.Duration) {
+	defer s.wg.Done()
+	s.mu.RLock()
+	closing := s.closing
+	s.mu.RUnlock()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-closing:
+			return
+		case <-ticker.C:
+			n, err := store.DeleteExpired()
+			if err != nil {
+				s.diag.Error("failed to delete expired node state", err)
+			}
+			if n > 0 {
+				s.diag.DeletedExpiredKeys(n)
+			}
+		}
+	}
+}
+
+func (s *Service) backend() Store {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.store
+}
+
+func (s *Service) key(key string) string {
+	return s.c.KeyPrefix + key
+}
+
+func (s *Service) Get(key string) ([]byte, error) {
+	return s.backend().Get(s.key(key))
+}
+
+func (s *Service) Put(key string, value []byte) error {
+	return s.backend().Put(s.key(key), value)
+}
+
+func (s *Service) Delete(key string) error {
+	return s.backend().Delete(s.key(key))
+}
+
+func (s *Service) Exists(key string) (bool, error) {
+	return s.backend().Exists(s.key(key))
+}
+
+func (s *Service) List(prefix string) ([]KeyValue, error) {
+	kvs, err := s.backend().List(s.key(prefix))
+	if err != nil {
+		return nil, err
+	}
+	for i := range kvs {
+		kvs[i].Key = strings.TrimPrefix(kvs[i].Key, s.c.KeyPrefix)
+	}
+	return kvs, nil
+}
+
+func (s *Service) DeletePrefix(prefix string) error {
+	return s.backend().DeletePrefix(s.key(prefix))
+}
@@ -0,0 +1,127 @@
+package nodestate
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+// Size of the expiration header prepended to each value by an ExpiringStore.
+const expiresHeaderSize = 8
+
+var errInvalidExpiringValue = errors.New("invalid expiring value, value too short")
+
+// ExpiringStore adds key expiration to a Store that does not support it natively.
+//
+// Each value is stored with the time it expires, expired keys are not returned
+// and are removed from the underlying store by DeleteExpired.
+// A zero TTL means keys never expire.
+type ExpiringStore struct {
+	store Store
+	ttl   time.Duration
+}
+
+func NewExpiringStore(store Store, ttl time.Duration) *ExpiringStore {
+	return &ExpiringStore{
+		store: store,
+		ttl:   ttl,
+	}
+}
+
+func (s *ExpiringStore) Get(key string) ([]byte, error) {
+	data, err := s.store.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	value, expired, err := decodeExpiring(data, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if expired {
+		return nil, ErrNoKeyExists
+	}
+	return value, nil
+}
+
+func (s *ExpiringStore) Put(key string, value []byte) error {
+	var expires int64
+	if s.ttl > 0 {
+		expires = time.Now().Add(s.ttl).UnixNano()
+	}
+	data := make([]byte, expiresHeaderSize+len(value))
+	binary.BigEndian.PutUint64(data, uint64(expires))
+	copy(data[expiresHeaderSize:], value)
+	return s.store.Put(key, data)
+}
+
+func (s *ExpiringStore) Delete(key string) error {
+	return s.store.Delete(key)
+}
+
+func (s *ExpiringStore) Exists(key string) (bool, error) {
+	_, err := s.Get(key)
+	if err == ErrNoKeyExists {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// List returns the keys that have not expired.
+// Values too short to hold an expiration are returned without a value,
+// so that they are reported as invalid instead of failing the whole list.
+func (s *ExpiringStore) List(prefix string) ([]KeyValue, error) {
+	list, err := s.store.List(prefix)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	kvs := make([]KeyValue, 0, len(list))
+	for _, kv := range list {
+		value, expired, err := decodeExpiring(kv.Value, now)
+		if err != nil {
+			kvs = append(kvs, KeyValue{Key: kv.Key})
+			continue
+		}
+		if expired {
+			continue
+		}
+		kvs = append(kvs, KeyValue{Key: kv.Key, Value: value})
+	}
+	return kvs, nil
+}
+
+func (s *ExpiringStore) DeletePrefix(prefix string) error {
+	return s.store.DeletePrefix(prefix)
+}
+
+// DeleteExpired removes all expired keys from the underlying store.
+// It returns the number of keys deleted.
+func (s *ExpiringStore) DeleteExpired() (int, error) {
+	list, err := s.store.List("")
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	n := 0
+	for _, kv := range list {
+		_, expired, err := decodeExpiring(kv.Value, now)
+		if err != nil || !expired {
+			continue
+		}
+		if err := s.store.Delete(kv.Key); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// decodeExpiring returns the value stored in data and whether it has expired at now.
+func decodeExpiring(data []byte, now time.Time) ([]byte, bool, error) {
+	if len(data) < expiresHeaderSize {
+		return nil, false, errInvalidExpiringValue
+	}
+	expires := int64(binary.BigEndian.Uint64(data))
+	value := data[expiresHeaderSize:]
+	return value, expires != 0 && now.UnixNano() >= expires, nil
+}
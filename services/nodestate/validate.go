@@ -0,0 +1,116 @@
+package nodestate
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/thingnario/kapacitor/models"
+)
+
+// Prefixes of the keys of each kind of persisted node state.
+const (
+	// Keys are of the form changeDetectNode:<task>:<group>.
+	ChangeDetectPrefix = "changeDetectNode:"
+	// Keys are of the form topics|<topic>|<event ID>.
+	EventStatePrefix = "topics|"
+)
+
+// ChangeDetectTaskPrefix returns the prefix of the keys of all change detect state of a task.
+func ChangeDetectTaskPrefix(taskID string) string {
+	return ChangeDetectPrefix + taskID + ":"
+}
+
+// Problem is a key whose state could not be decoded.
+type Problem struct {
+	Key string
+	Err error
+}
+
+// ValidateReport is the result of validating all keys of a Store.
+type ValidateReport struct {
+	// Number of keys checked.
+	Checked int
+	// Keys whose state uses an older version of its encoding.
+	Outdated []string
+	// Keys whose state is invalid.
+	Invalid []Problem
+	// Number of outdated keys rewritten with the current encoding.
+	Migrated int
+	// Number of invalid keys deleted.
+	Deleted int
+}
+
+var (
+	errInvalidKey = errors.New("invalid key")
+	errEmptyValue = errors.New("empty value")
+)
+
+// Validate decodes the state of all keys in the store with the prefix of a kind of node state,
+// other keys are never read, they may belong to other services sharing the store.
+// If repair is true outdated state is migrated to the current encoding and invalid state is deleted.
+func Validate(store Store, repair bool) (ValidateReport, error) {
+	var report ValidateReport
+	var kvs []KeyValue
+	for _, prefix := range []string{ChangeDetectPrefix, EventStatePrefix} {
+		l, err := store.List(prefix)
+		if err != nil {
+			return report, err
+		}
+		kvs = append(kvs, l...)
+	}
+	for _, kv := range kvs {
+		var (
+			version, current int
+			encode           func() ([]byte, error)
+			err              error
+		)
+		switch {
+		case len(kv.Value) == 0:
+			err = errEmptyValue
+		case strings.HasPrefix(kv.Key, ChangeDetectPrefix):
+			current = fieldsVersion
+			if !strings.Contains(strings.TrimPrefix(kv.Key, ChangeDetectPrefix), ":") {
+				err = errInvalidKey
+				break
+			}
+			var fields models.Fields
+			fields, version, err = decodeFields(kv.Value)
+			encode = func() ([]byte, error) { return EncodeFields(fields) }
+		case strings.HasPrefix(kv.Key, EventStatePrefix):
+			current = eventStateVersion
+			if !strings.Contains(strings.TrimPrefix(kv.Key, EventStatePrefix), "|") {
+				err = errInvalidKey
+				break
+			}
+			var state EventState
+			state, version, err = decodeEventState(kv.Value)
+			encode = func() ([]byte, error) { return EncodeEventState(state) }
+		}
+		report.Checked++
+
+		if err != nil {
+			report.Invalid = append(report.Invalid, Problem{Key: kv.Key, Err: err})
+			if repair {
+				if err := store.Delete(kv.Key); err != nil {
+					return report, err
+				}
+				report.Deleted++
+			}
+			continue
+		}
+		if version < current {
+			report.Outdated = append(report.Outdated, kv.Key)
+			if repair {
+				data, err := encode()
+				if err != nil {
+					return report, err
+				}
+				if err := store.Put(kv.Key, data); err != nil {
+					return report, err
+				}
+				report.Migrated++
+			}
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,113 @@
+package nodestate_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/thingnario/kapacitor/models"
+	"github.com/thingnario/kapacitor/services/nodestate"
+)
+
+func TestValidate(t *testing.T) {
+	s := nodestate.NewMemStore()
+
+	current, err := nodestate.EncodeFields(models.Fields{"value": int64(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kvs := map[string]string{
+		"changeDetectNode:task:host=a": string(current),
+		"changeDetectNode:task:host=b": `{"value":1}`,
+		"changeDetectNode:task:host=c": `not json`,
+		"changeDetectNode:task":        string(current),
+		"topics|task:alert|host=a":     `{"ID":"host=a","Duration":0,"Level":1}`,
+		"topics|task:alert|host=b":     `{"ID":"host=b"}`,
+		"other:key":                    `value`,
+	}
+	for k, v := range kvs {
+		if err := s.Put(k, []byte(v)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, err := nodestate.Validate(s, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Checked != 6 {
+		t.Errorf("unexpected checked count got %d exp 6", r.Checked)
+	}
+	expOutdated := []string{
+		"changeDetectNode:task:host=b",
+		"topics|task:alert|host=a",
+	}
+	if !reflect.DeepEqual(r.Outdated, expOutdated) {
+		t.Errorf("unexpected outdated keys got %v exp %v", r.Outdated, expOutdated)
+	}
+	var invalid []string
+	for _, p := range r.Invalid {
+		invalid = append(invalid, p.Key)
+	}
+	expInvalid := []string{
+		"changeDetectNode:task",
+		"changeDetectNode:task:host=c",
+		"topics|task:alert|host=b",
+	}
+	if !reflect.DeepEqual(invalid, expInvalid) {
+		t.Errorf("unexpected invalid keys got %v exp %v", invalid, expInvalid)
+	}
+	if r.Migrated != 0 || r.Deleted != 0 {
+		t.Errorf("expected no changes without repair, got %d migrated %d deleted", r.Migrated, r.Deleted)
+	}
+
+	r, err = nodestate.Validate(s, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Migrated != 2 || r.Deleted != 3 {
+		t.Errorf("unexpected repair got %d migrated %d deleted exp 2 and 3", r.Migrated, r.Deleted)
+	}
+
+	r, err = nodestate.Validate(s, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Checked != 3 || len(r.Outdated) != 0 || len(r.Invalid) != 0 {
+		t.Errorf("unexpected result after repair %+v", r)
+	}
+	if exists, err := s.Exists("other:key"); err != nil {
+		t.Fatal(err)
+	} else if !exists {
+		t.Error("expected unknown key to be kept")
+	}
+}
+
+func TestValidate_ExpiringStore(t *testing.T) {
+	mem := nodestate.NewMemStore()
+	s := nodestate.NewExpiringStore(mem, 0)
+
+	current, err := nodestate.EncodeFields(models.Fields{"value": int64(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put("changeDetectNode:task:host=a", current); err != nil {
+		t.Fatal(err)
+	}
+	// A value written without the expiration header of the expiring store.
+	if err := mem.Put("changeDetectNode:task:host=b", []byte(`{}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := nodestate.Validate(s, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Checked != 2 || len(r.Invalid) != 1 || r.Invalid[0].Key != "changeDetectNode:task:host=b" || r.Deleted != 1 {
+		t.Errorf("unexpected result %+v", r)
+	}
+	if exists, err := mem.Exists("changeDetectNode:task:host=b"); err != nil {
+		t.Fatal(err)
+	} else if exists {
+		t.Error("expected invalid key to be deleted")
+	}
+}
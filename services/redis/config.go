@@ -0,0 +1,216 @@
+package redis
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/influxdata/influxdb/toml"
+	"github.com/thingnario/kapacitor/tlsconfig"
+	"github.com/pkg/errors"
+)
+
+const (
+	// Connect to a single Redis server.
+	StandaloneMode = "standalone"
+	// Connect to the master of a set of servers monitored by Redis Sentinel.
+	SentinelMode = "sentinel"
+	// Connect to a Redis Cluster.
+	ClusterMode = "cluster"
+
+	DefaultAddr = "localhost:6379"
+
+	// AddrEnv is the environment variable that held the address of the Redis server
+	// before the redis section existed, it is still used as the default address.
+	AddrEnv = "REDIS_ADDR"
+)
+
+type Config struct {
+	// Enabled indicates whether the service should be enabled
+	Enabled bool `toml:"enabled" override:"enabled"`
+	// Mode is one of "standalone", "sentinel" or "cluster".
+	Mode string `toml:"mode" override:"mode"`
+	// Addrs is a list of host:port addresses.
+	// In standalone mode it must contain exactly the address of the Redis server,
+	// in sentinel mode the addresses of the sentinels
+	// and in cluster mode the addresses of one or more seed nodes.
+	Addrs []string `toml:"addrs" override:"addrs"`
+	// MasterName is the name of the master monitored by the sentinels.
+	// Only used in sentinel mode.
+	MasterName string `toml:"master-name" override:"master-name"`
+
+	// Username of the Redis 6 ACL user.
+	// If empty the legacy AUTH using only the password is performed.
+	Username string `toml:"username" override:"username"`
+	Password string `toml:"password" override:"password,redact"`
+	// DB is the index of the database to select.
+	// Must be 0 in cluster mode.
+	DB int `toml:"db" override:"db"`
+
+	// UseSSL enable ssl communication
+	// Must be true for the other ssl options to take effect.
+	UseSSL bool `toml:"use-ssl" override:"use-ssl"`
+	// Path to CA file
+	SSLCA string `toml:"ssl-ca" override:"ssl-ca"`
+	// Path to host cert file
+	SSLCert string `toml:"ssl-cert" override:"ssl-cert"`
+	// Path to cert key file
+	SSLKey string `toml:"ssl-key" override:"ssl-key"`
+	// Use SSL but skip chain & host verification
+	InsecureSkipVerify bool `toml:"insecure-skip-verify" override:"insecure-skip-verify"`
+
+	// For the pool and timeout options below a value of 0 uses the client default.
+
+	// Maximum number of connections per server.
+	PoolSize int `toml:"pool-size" override:"pool-size"`
+	// Minimum number of idle connections per server.
+	MinIdleConns int `toml:"min-idle-conns" override:"min-idle-conns"`
+	// Maximum number of retries of a failed command.
+	MaxRetries int `toml:"max-retries" override:"max-retries"`
+	// Timeout for establishing new connections.
+	DialTimeout toml.Duration `toml:"dial-timeout" override:"dial-timeout"`
+	// Timeout for socket reads.
+	ReadTimeout toml.Duration `toml:"read-timeout" override:"read-timeout"`
+	// Timeout for socket writes.
+	WriteTimeout toml.Duration `toml:"write-timeout" override:"write-timeout"`
+	// Time to wait for a connection if all connections of the pool are busy.
+	PoolTimeout toml.Duration `toml:"pool-timeout" override:"pool-timeout"`
+	// Time after which idle connections are closed.
+	IdleTimeout toml.Duration `toml:"idle-timeout" override:"idle-timeout"`
+}
+
+func NewConfig() Config {
+	addr := DefaultAddr
+	if a, ok := os.LookupEnv(AddrEnv); ok && a != "" {
+		addr = a
+	}
+	return Config{
+		Enabled: true,
+		Mode:    StandaloneMode,
+		Addrs:   []string{addr},
+	}
+}
+
+func (c Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if len(c.Addrs) == 0 {
+		return errors.New("must specify at least one address")
+	}
+	switch c.Mode {
+	case StandaloneMode:
+		if len(c.Addrs) != 1 {
+			return fmt.Errorf("must specify exactly one address in %s mode", c.Mode)
+		}
+	case SentinelMode:
+		if c.MasterName == "" {
+			return fmt.Errorf("must specify master-name in %s mode", c.Mode)
+		}
+	case ClusterMode:
+		if c.DB != 0 {
+			return fmt.Errorf("db must be 0 in %s mode", c.Mode)
+		}
+	default:
+		return fmt.Errorf("invalid mode %q, must be one of %q, %q or %q", c.Mode, StandaloneMode, SentinelMode, ClusterMode)
+	}
+	if c.DB < 0 {
+		return errors.New("db must not be negative")
+	}
+	if c.Username != "" && c.Password == "" {
+		return errors.New("must specify a password with a username")
+	}
+	if c.PoolSize < 0 {
+		return errors.New("pool-size must not be negative")
+	}
+	if c.MinIdleConns < 0 {
+		return errors.New("min-idle-conns must not be negative")
+	}
+	if c.MaxRetries < 0 {
+		return errors.New("max-retries must not be negative")
+	}
+	return nil
+}
+
+// NewClient creates a new client based off this configuration.
+func (c Config) NewClient() (redis.UniversalClient, error) {
+	var tlsConfig *tls.Config
+	if c.UseSSL {
+		t, err := tlsconfig.Create(c.SSLCA, c.SSLCert, c.SSLKey, c.InsecureSkipVerify)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig = t
+	}
+
+	// The client only supports the legacy AUTH command,
+	// so when a username is configured we authenticate when connecting.
+	// The SELECT must then follow the AUTH.
+	password, db := c.Password, c.DB
+	var onConnect func(*redis.Conn) error
+	if c.Username != "" {
+		password, db = "", 0
+		onConnect = func(conn *redis.Conn) error {
+			if err := conn.Do("auth", c.Username, c.Password).Err(); err != nil {
+				return err
+			}
+			if c.DB > 0 {
+				return conn.Do("select", c.DB).Err()
+			}
+			return nil
+		}
+	}
+
+	switch c.Mode {
+	case SentinelMode:
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:    c.MasterName,
+			SentinelAddrs: c.Addrs,
+			OnConnect:     onConnect,
+			Password:      password,
+			DB:            db,
+			MaxRetries:    c.MaxRetries,
+			DialTimeout:   time.Duration(c.DialTimeout),
+			ReadTimeout:   time.Duration(c.ReadTimeout),
+			WriteTimeout:  time.Duration(c.WriteTimeout),
+			PoolSize:      c.PoolSize,
+			MinIdleConns:  c.MinIdleConns,
+			PoolTimeout:   time.Duration(c.PoolTimeout),
+			IdleTimeout:   time.Duration(c.IdleTimeout),
+			TLSConfig:     tlsConfig,
+		}), nil
+	case ClusterMode:
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:        c.Addrs,
+			OnConnect:    onConnect,
+			Password:     password,
+			MaxRetries:   c.MaxRetries,
+			DialTimeout:  time.Duration(c.DialTimeout),
+			ReadTimeout:  time.Duration(c.ReadTimeout),
+			WriteTimeout: time.Duration(c.WriteTimeout),
+			PoolSize:     c.PoolSize,
+			MinIdleConns: c.MinIdleConns,
+			PoolTimeout:  time.Duration(c.PoolTimeout),
+			IdleTimeout:  time.Duration(c.IdleTimeout),
+			TLSConfig:    tlsConfig,
+		}), nil
+	default:
+		return redis.NewClient(&redis.Options{
+			Addr:         c.Addrs[0],
+			OnConnect:    onConnect,
+			Password:     password,
+			DB:           db,
+			MaxRetries:   c.MaxRetries,
+			DialTimeout:  time.Duration(c.DialTimeout),
+			ReadTimeout:  time.Duration(c.ReadTimeout),
+			WriteTimeout: time.Duration(c.WriteTimeout),
+			PoolSize:     c.PoolSize,
+			MinIdleConns: c.MinIdleConns,
+			PoolTimeout:  time.Duration(c.PoolTimeout),
+			IdleTimeout:  time.Duration(c.IdleTimeout),
+			TLSConfig:    tlsConfig,
+		}), nil
+	}
+}
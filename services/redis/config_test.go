@@ -0,0 +1,123 @@
+package redis
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	testCases := []struct {
+		name  string
+		c     func(c *Config)
+		valid bool
+	}{
+		{
+			name:  "default",
+			c:     func(c *Config) {},
+			valid: true,
+		},
+		{
+			name: "disabled without addrs",
+			c: func(c *Config) {
+				c.Enabled = false
+				c.Addrs = nil
+			},
+			valid: true,
+		},
+		{
+			name: "no addrs",
+			c: func(c *Config) {
+				c.Addrs = nil
+			},
+		},
+		{
+			name: "standalone with several addrs",
+			c: func(c *Config) {
+				c.Addrs = []string{"a:6379", "b:6379"}
+			},
+		},
+		{
+			name: "invalid mode",
+			c: func(c *Config) {
+				c.Mode = "replicated"
+			},
+		},
+		{
+			name: "sentinel without master name",
+			c: func(c *Config) {
+				c.Mode = SentinelMode
+				c.Addrs = []string{"a:26379", "b:26379"}
+			},
+		},
+		{
+			name: "sentinel",
+			c: func(c *Config) {
+				c.Mode = SentinelMode
+				c.Addrs = []string{"a:26379", "b:26379"}
+				c.MasterName = "mymaster"
+				c.DB = 3
+			},
+			valid: true,
+		},
+		{
+			name: "cluster with db",
+			c: func(c *Config) {
+				c.Mode = ClusterMode
+				c.DB = 1
+			},
+		},
+		{
+			name: "cluster",
+			c: func(c *Config) {
+				c.Mode = ClusterMode
+				c.Addrs = []string{"a:7000", "b:7000"}
+			},
+			valid: true,
+		},
+		{
+			name: "username without password",
+			c: func(c *Config) {
+				c.Username = "kapacitor"
+			},
+		},
+		{
+			name: "acl user",
+			c: func(c *Config) {
+				c.Username = "kapacitor"
+				c.Password = "secret"
+			},
+			valid: true,
+		},
+		{
+			name: "negative pool size",
+			c: func(c *Config) {
+				c.PoolSize = -1
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewConfig()
+			tc.c(&c)
+			err := c.Validate()
+			if tc.valid && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			} else if !tc.valid && err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestNewConfig_AddrEnv(t *testing.T) {
+	os.Unsetenv(AddrEnv)
+	if got := NewConfig().Addrs; !reflect.DeepEqual(got, []string{DefaultAddr}) {
+		t.Errorf("unexpected default addrs got %v exp %v", got, []string{DefaultAddr})
+	}
+	os.Setenv(AddrEnv, "redis.example.com:6380")
+	defer os.Unsetenv(AddrEnv)
+	if got, exp := NewConfig().Addrs, []string{"redis.example.com:6380"}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected addrs from %s got %v exp %v", AddrEnv, got, exp)
+	}
+}
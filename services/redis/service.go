@@ -0,0 +1,128 @@
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/go-redis/redis"
+)
+
+var ErrNotEnabled = errors.New("redis service is not enabled")
+
+type Diagnostic interface {
+	Error(msg string, err error)
+}
+
+// Service manages a Redis client shared by all of Kapacitor.
+type Service struct {
+	mu     sync.RWMutex
+	c      Config
+	client redis.UniversalClient
+
+	diag Diagnostic
+}
+
+func NewService(c Config, d Diagnostic) *Service {
+	return &Service{
+		c:    c,
+		diag: d,
+	}
+}
+
+func (s *Service) Open() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.c.Enabled {
+		return nil
+	}
+	client, err := s.c.NewClient()
+	if err != nil {
+		return err
+	}
+	s.client = client
+	return nil
+}
+
+func (s *Service) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.client != nil {
+		err := s.client.Close()
+		s.client = nil
+		return err
+	}
+	return nil
+}
+
+// Client returns the current Redis client.
+// The client must not be closed by the caller
+// and should not be retained since it is replaced when the configuration is updated.
+func (s *Service) Client() (redis.UniversalClient, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.client == nil {
+		return nil, ErrNotEnabled
+	}
+	return s.client, nil
+}
+
+// DB returns the index of the database selected by the current Redis client.
+func (s *Service) DB() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.c.DB
+}
+
+func (s *Service) Update(newConfig []interface{}) error {
+	if l := len(newConfig); l != 1 {
+		return fmt.Errorf("expected only one new config object, got %d", l)
+	}
+	c, ok := newConfig[0].(Config)
+	if !ok {
+		return fmt.Errorf("expected config object to be of type %T, got %T", c, newConfig[0])
+	}
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if reflect.DeepEqual(s.c, c) {
+		return nil
+	}
+	var client redis.UniversalClient
+	if c.Enabled {
+		var err error
+		client, err = c.NewClient()
+		if err != nil {
+			return err
+		}
+	}
+	if s.client != nil {
+		if err := s.client.Close(); err != nil {
+			s.diag.Error("failed to close previous redis client", err)
+		}
+	}
+	s.c = c
+	s.client = client
+	return nil
+}
+
+type testOptions struct{}
+
+func (s *Service) TestOptions() interface{} {
+	return &testOptions{}
+}
+
+func (s *Service) Test(options interface{}) error {
+	if _, ok := options.(*testOptions); !ok {
+		return fmt.Errorf("unexpected options type %T", options)
+	}
+	client, err := s.Client()
+	if err != nil {
+		return err
+	}
+	return client.Ping().Err()
+}
@@ -0,0 +1,140 @@
+package redis_ingest
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/toml"
+	"github.com/pkg/errors"
+)
+
+const (
+	// Payloads are InfluxDB line protocol.
+	LineFormat = "line"
+	// Payloads are JSON encoded points.
+	JSONFormat = "json"
+
+	DefaultField         = "data"
+	DefaultConsumer      = "kapacitor"
+	DefaultBatchSize     = 100
+	DefaultBlock         = toml.Duration(time.Second)
+	DefaultRetryInterval = toml.Duration(5 * time.Second)
+)
+
+type Config struct {
+	Enabled bool `toml:"enabled"`
+	// Name identifies the ingest source in logs and statistics.
+	Name string `toml:"name"`
+
+	// Streams are the names of the Redis streams to consume.
+	Streams []string `toml:"streams"`
+	// Group is the consumer group used to consume the streams.
+	// The group is created if it does not exist.
+	// Entries are acknowledged once their points are written.
+	// If empty the streams are read without a consumer group,
+	// starting with the entries added after the service is opened.
+	Group string `toml:"group"`
+	// Consumer is the name of the consumer within the group.
+	Consumer string `toml:"consumer"`
+	// Field is the field of stream entries containing the payload.
+	Field string `toml:"field"`
+	// BatchSize is the maximum number of entries read from a stream at once.
+	BatchSize int64 `toml:"batch-size"`
+	// Block is the maximum duration to wait for new entries.
+	Block toml.Duration `toml:"block"`
+
+	// Channels are the Redis pub/sub channels to subscribe to.
+	// Patterns are supported, i.e. "sensors.*".
+	Channels []string `toml:"channels"`
+
+	// Format is the format of the payloads, one of "line" or "json".
+	Format string `toml:"format"`
+	// Precision of the timestamps of line protocol payloads, i.e. "s" or "ms".
+	// Defaults to nanoseconds.
+	Precision string `toml:"precision"`
+
+	// RetryInterval is the duration to wait before retrying after Redis failed.
+	RetryInterval toml.Duration `toml:"retry-interval"`
+
+	Database        string `toml:"database"`
+	RetentionPolicy string `toml:"retention-policy"`
+}
+
+// WithDefaults takes the given config and returns a new config with any required
+// default values set.
+func (c *Config) WithDefaults() *Config {
+	d := *c
+	if d.Consumer == "" {
+		d.Consumer = DefaultConsumer
+	}
+	if d.Field == "" {
+		d.Field = DefaultField
+	}
+	if d.Format == "" {
+		d.Format = LineFormat
+	}
+	if d.BatchSize == 0 {
+		d.BatchSize = DefaultBatchSize
+	}
+	if d.Block == 0 {
+		d.Block = DefaultBlock
+	}
+	if d.RetryInterval == 0 {
+		d.RetryInterval = DefaultRetryInterval
+	}
+	return &d
+}
+
+func (c Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Name == "" {
+		return errors.New("must specify a name")
+	}
+	if len(c.Streams) == 0 && len(c.Channels) == 0 {
+		return errors.New("must specify at least one stream or channel")
+	}
+	if c.Database == "" {
+		return errors.New("must specify a database")
+	}
+	switch c.Format {
+	case "", LineFormat, JSONFormat:
+	default:
+		return fmt.Errorf("invalid format %q, must be one of %q or %q", c.Format, LineFormat, JSONFormat)
+	}
+	switch c.Precision {
+	case "", "n", "ns", "u", "ms", "s", "m", "h":
+	default:
+		return fmt.Errorf("invalid precision %q", c.Precision)
+	}
+	if c.BatchSize < 0 {
+		return errors.New("batch-size must not be negative")
+	}
+	if c.Block < 0 {
+		return errors.New("block must not be negative")
+	}
+	if c.RetryInterval < 0 {
+		return errors.New("retry-interval must not be negative")
+	}
+	return nil
+}
+
+// Configs is the configuration of multiple ingest sources.
+type Configs []Config
+
+// Validate calls config.Validate for each element in Configs
+// and checks that the names are unique.
+func (cs Configs) Validate() error {
+	names := make(map[string]bool, len(cs))
+	for _, c := range cs {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+		if names[c.Name] {
+			return fmt.Errorf("duplicate name %q", c.Name)
+		}
+		names[c.Name] = true
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package redis_ingest_test
+
+import (
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/thingnario/kapacitor/services/redis_ingest"
+)
+
+func TestConfig_Parse(t *testing.T) {
+	var c struct {
+		RedisIngest redis_ingest.Configs `toml:"redis-ingest"`
+	}
+	if _, err := toml.Decode(`
+[[redis-ingest]]
+enabled = true
+name = "gateways"
+streams = ["sensors"]
+group = "kapacitor"
+format = "json"
+database = "iot"
+`, &c); err != nil {
+		t.Fatal(err)
+	}
+	cs := c.RedisIngest
+	if len(cs) != 1 {
+		t.Fatalf("unexpected number of configs got %d exp 1", len(cs))
+	}
+	if err := cs.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	d := cs[0].WithDefaults()
+	if d.Field != redis_ingest.DefaultField {
+		t.Errorf("unexpected field got %q exp %q", d.Field, redis_ingest.DefaultField)
+	}
+	if d.Consumer != redis_ingest.DefaultConsumer {
+		t.Errorf("unexpected consumer got %q exp %q", d.Consumer, redis_ingest.DefaultConsumer)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	valid := redis_ingest.Config{Enabled: true, Name: "a", Channels: []string{"c"}, Database: "db"}
+	testCases := map[string]func(c *redis_ingest.Config){
+		"no name":           func(c *redis_ingest.Config) { c.Name = "" },
+		"no source":         func(c *redis_ingest.Config) { c.Channels = nil },
+		"no database":       func(c *redis_ingest.Config) { c.Database = "" },
+		"invalid format":    func(c *redis_ingest.Config) { c.Format = "csv" },
+		"invalid precision": func(c *redis_ingest.Config) { c.Precision = "d" },
+	}
+	for name, f := range testCases {
+		c := valid
+		f(&c)
+		if err := c.Validate(); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+	if err := (redis_ingest.Configs{valid, valid}).Validate(); err == nil {
+		t.Error("expected error for duplicate names")
+	}
+}
@@ -0,0 +1,87 @@
+package redis_ingest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/models"
+	"github.com/pkg/errors"
+	kmodels "github.com/thingnario/kapacitor/models"
+)
+
+// jsonPoint is the JSON encoding of a point.
+// Time is either an RFC3339 string or a number of units of the configured precision since the epoch.
+type jsonPoint struct {
+	Measurement string                 `json:"measurement"`
+	Tags        map[string]string      `json:"tags"`
+	Fields      map[string]interface{} `json:"fields"`
+	Time        interface{}            `json:"time"`
+}
+
+// parsePoints parses the points of a payload.
+// Points without a time get the time now.
+func parsePoints(format, precision string, data []byte, now time.Time) ([]models.Point, error) {
+	switch format {
+	case JSONFormat:
+		return parseJSONPoints(precision, data, now)
+	default:
+		return models.ParsePointsWithPrecision(data, now, precision)
+	}
+}
+
+// parseJSONPoints parses a single JSON point or an array of JSON points.
+func parseJSONPoints(precision string, data []byte, now time.Time) ([]models.Point, error) {
+	data = bytes.TrimSpace(data)
+	var jps []jsonPoint
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if len(data) > 0 && data[0] == '[' {
+		if err := dec.Decode(&jps); err != nil {
+			return nil, errors.Wrap(err, "failed to decode json points")
+		}
+	} else {
+		jps = make([]jsonPoint, 1)
+		if err := dec.Decode(&jps[0]); err != nil {
+			return nil, errors.Wrap(err, "failed to decode json point")
+		}
+	}
+	points := make([]models.Point, len(jps))
+	for i, jp := range jps {
+		p, err := jp.point(precision, now)
+		if err != nil {
+			return nil, err
+		}
+		points[i] = p
+	}
+	return points, nil
+}
+
+func (jp jsonPoint) point(precision string, now time.Time) (models.Point, error) {
+	if jp.Measurement == "" {
+		return nil, errors.New("json point is missing the measurement")
+	}
+	fields := make(models.Fields, len(jp.Fields))
+	for k, v := range jp.Fields {
+		if n, ok := v.(json.Number); ok {
+			// Numbers are always floats so that the type of a field
+			// does not depend on whether a value happens to be whole.
+			f, err := n.Float64()
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q of field %q", n, k)
+			}
+			v = f
+		}
+		fields[k] = v
+	}
+	t := now
+	if jp.Time != nil {
+		var err error
+		t, err = kmodels.ParseJSONTime(jp.Time, "", precision)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return models.NewPoint(jp.Measurement, models.NewTags(jp.Tags), fields, t)
+}
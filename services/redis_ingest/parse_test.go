@@ -0,0 +1,68 @@
+package redis_ingest
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePoints(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name      string
+		format    string
+		precision string
+		data      string
+		exp       string
+	}{
+		{
+			name:      "line",
+			format:    LineFormat,
+			precision: "s",
+			data:      "cpu,host=a value=1 10\ncpu,host=b value=2i",
+			exp:       "cpu,host=a value=1 10000000000\ncpu,host=b value=2i 1577836800000000000",
+		},
+		{
+			name:      "json object",
+			format:    JSONFormat,
+			precision: "ms",
+			data:      `{"measurement":"cpu","tags":{"host":"a"},"fields":{"value":1.5,"count":2,"ok":true},"time":10}`,
+			exp:       "cpu,host=a count=2,ok=true,value=1.5 10000000",
+		},
+		{
+			name:   "json array",
+			format: JSONFormat,
+			data:   `[{"measurement":"cpu","fields":{"value":"x"},"time":"2020-01-01T00:00:01Z"},{"measurement":"mem","fields":{"value":1}}]`,
+			exp:    "cpu value=\"x\" 1577836801000000000\nmem value=1 1577836800000000000",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			points, err := parsePoints(tc.format, tc.precision, []byte(tc.data), now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := ""
+			for i, p := range points {
+				if i > 0 {
+					got += "\n"
+				}
+				got += p.String()
+			}
+			if got != tc.exp {
+				t.Errorf("unexpected points:\ngot\n%s\nexp\n%s", got, tc.exp)
+			}
+		})
+	}
+}
+
+func TestParsePoints_Invalid(t *testing.T) {
+	for _, data := range []string{
+		`{"fields":{"value":1}}`,
+		`{"measurement":"cpu","fields":{"value":1},"time":true}`,
+		`{"measurement":"cpu"`,
+	} {
+		if _, err := parsePoints(JSONFormat, "", []byte(data), time.Now()); err == nil {
+			t.Errorf("expected error parsing %s", data)
+		}
+	}
+}
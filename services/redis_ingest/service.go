@@ -0,0 +1,346 @@
+package redis_ingest
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/influxdata/influxdb/models"
+	"github.com/thingnario/kapacitor/expvar"
+	"github.com/thingnario/kapacitor/keyvalue"
+	"github.com/thingnario/kapacitor/server/vars"
+)
+
+// statistics gathered by the Redis ingest package.
+const (
+	statMessagesReceived  = "messages_rx"
+	statPointsReceived    = "points_rx"
+	statPointsParseFail   = "points_parse_fail"
+	statReadFail          = "read_fail"
+	statPointsTransmitted = "points_tx"
+	statTransmitFail      = "tx_fail"
+	statAckFail           = "ack_fail"
+)
+
+type Diagnostic interface {
+	Error(msg string, err error, ctx ...keyvalue.T)
+	StartedConsuming(streams, channels []string)
+	ClosedService()
+}
+
+// Service consumes points from Redis streams and pub/sub channels
+// and writes them to the task master.
+type Service struct {
+	config Config
+	diag   Diagnostic
+
+	RedisService interface {
+		Client() (redis.UniversalClient, error)
+	}
+	PointsWriter interface {
+		WritePoints(database, retentionPolicy string, consistencyLevel models.ConsistencyLevel, points []models.Point) error
+	}
+
+	statMap *expvar.Map
+	statKey string
+
+	closing chan struct{}
+	wg      sync.WaitGroup
+}
+
+func NewService(c Config, d Diagnostic) *Service {
+	return &Service{
+		config: *c.WithDefaults(),
+		diag:   d,
+	}
+}
+
+func (s *Service) Open() error {
+	if s.closing != nil {
+		return errors.New("service already open")
+	}
+	if s.config.Database == "" {
+		return errors.New("database has to be specified in config")
+	}
+	tags := map[string]string{"name": s.config.Name}
+	s.statKey, s.statMap = vars.NewStatistic("redis_ingest", tags)
+
+	s.closing = make(chan struct{})
+	if len(s.config.Streams) > 0 {
+		s.wg.Add(1)
+		go s.consumeStreams()
+	}
+	if len(s.config.Channels) > 0 {
+		s.wg.Add(1)
+		go s.consumeChannels()
+	}
+	s.diag.StartedConsuming(s.config.Streams, s.config.Channels)
+	return nil
+}
+
+func (s *Service) Close() error {
+	if s.closing == nil {
+		return errors.New("service already closed")
+	}
+	close(s.closing)
+	s.wg.Wait()
+	s.closing = nil
+	vars.DeleteStatistic(s.statKey)
+	s.diag.ClosedService()
+	return nil
+}
+
+// wait waits for the retry interval and reports whether the service is still open.
+func (s *Service) wait() bool {
+	timer := time.NewTimer(time.Duration(s.config.RetryInterval))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-s.closing:
+		return false
+	}
+}
+
+func (s *Service) isClosing() bool {
+	select {
+	case <-s.closing:
+		return true
+	default:
+		return false
+	}
+}
+
+// consumeStreams reads the entries of the streams until the service is closed.
+//
+// With a consumer group the entries that were delivered to the consumer but not acknowledged,
+// i.e. because Kapacitor stopped before writing them, are read first.
+// Without a consumer group reading starts after the last entry at the time the service is opened.
+// Entries whose points could not be written are read again after the retry interval.
+func (s *Service) consumeStreams() {
+	defer s.wg.Done()
+	// ids are the IDs after which the next entries of each stream are read.
+	var ids map[string]string
+	for !s.isClosing() {
+		client, err := s.RedisService.Client()
+		if err == nil && ids == nil {
+			ids, err = s.initStreams(client)
+		}
+		if err != nil {
+			s.statMap.Add(statReadFail, 1)
+			s.diag.Error("failed to read redis streams", err)
+			if !s.wait() {
+				return
+			}
+			continue
+		}
+		streams, err := s.readStreams(client, ids)
+		if err == redis.Nil {
+			// No new entries within the block duration
+			continue
+		}
+		if err != nil {
+			s.statMap.Add(statReadFail, 1)
+			s.diag.Error("failed to read redis streams", err)
+			if !s.wait() {
+				return
+			}
+			continue
+		}
+		if !s.handleStreams(client, streams, ids) {
+			if !s.wait() {
+				return
+			}
+		}
+	}
+}
+
+// streamAcker acknowledges the entries of a stream read by a consumer group.
+type streamAcker interface {
+	XAck(stream, group string, ids ...string) *redis.IntCmd
+}
+
+// handleStreams handles the entries read from the streams and updates the IDs after which the streams are read next.
+// The entries of a stream following an entry whose points could not be written are not handled,
+// the entry is read again with them.
+// It returns false if the points of an entry could not be written.
+func (s *Service) handleStreams(acker streamAcker, streams []redis.XStream, ids map[string]string) bool {
+	written := true
+	for _, stream := range streams {
+		if len(stream.Messages) == 0 && ids[stream.Stream] != ">" && s.config.Group != "" {
+			// All pending entries were read, continue with new entries.
+			ids[stream.Stream] = ">"
+			continue
+		}
+		for _, m := range stream.Messages {
+			if !s.handleEntry(stream.Stream, m) {
+				written = false
+				if s.config.Group != "" {
+					// The entry is pending, read the pending entries again from the start.
+					ids[stream.Stream] = "0"
+				}
+				break
+			}
+			if ids[stream.Stream] != ">" {
+				ids[stream.Stream] = m.ID
+			}
+			if s.config.Group != "" {
+				if err := acker.XAck(stream.Stream, s.config.Group, m.ID).Err(); err != nil {
+					s.statMap.Add(statAckFail, 1)
+					s.diag.Error("failed to acknowledge redis stream entry", err,
+						keyvalue.KV("stream", stream.Stream), keyvalue.KV("id", m.ID))
+				}
+			}
+		}
+	}
+	return written
+}
+
+// initStreams creates the consumer group of the streams if needed
+// and returns the IDs from which reading the streams starts.
+func (s *Service) initStreams(client redis.UniversalClient) (map[string]string, error) {
+	ids := make(map[string]string, len(s.config.Streams))
+	for _, stream := range s.config.Streams {
+		if s.config.Group != "" {
+			err := client.XGroupCreateMkStream(stream, s.config.Group, "$").Err()
+			if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+				return nil, err
+			}
+			ids[stream] = "0"
+			continue
+		}
+		last, err := client.XRevRangeN(stream, "+", "-", 1).Result()
+		if err != nil {
+			return nil, err
+		}
+		ids[stream] = "0"
+		if len(last) > 0 {
+			ids[stream] = last[0].ID
+		}
+	}
+	return ids, nil
+}
+
+func (s *Service) readStreams(client redis.UniversalClient, ids map[string]string) ([]redis.XStream, error) {
+	streams := make([]string, 0, 2*len(s.config.Streams))
+	streams = append(streams, s.config.Streams...)
+	for _, stream := range s.config.Streams {
+		streams = append(streams, ids[stream])
+	}
+	if s.config.Group == "" {
+		return client.XRead(&redis.XReadArgs{
+			Streams: streams,
+			Count:   s.config.BatchSize,
+			Block:   time.Duration(s.config.Block),
+		}).Result()
+	}
+	return client.XReadGroup(&redis.XReadGroupArgs{
+		Group:    s.config.Group,
+		Consumer: s.config.Consumer,
+		Streams:  streams,
+		Count:    s.config.BatchSize,
+		Block:    time.Duration(s.config.Block),
+	}).Result()
+}
+
+// handleEntry writes the points of a stream entry.
+// It returns whether the entry is done with and can be acknowledged.
+// Entries that cannot be parsed are acknowledged as they will never succeed.
+func (s *Service) handleEntry(stream string, m redis.XMessage) bool {
+	s.statMap.Add(statMessagesReceived, 1)
+	var data []byte
+	switch v := m.Values[s.config.Field].(type) {
+	case string:
+		data = []byte(v)
+	case nil:
+		s.statMap.Add(statPointsParseFail, 1)
+		s.diag.Error("failed to parse points", errors.New("entry is missing the payload field"),
+			keyvalue.KV("stream", stream), keyvalue.KV("id", m.ID), keyvalue.KV("field", s.config.Field))
+		return true
+	default:
+		s.statMap.Add(statPointsParseFail, 1)
+		s.diag.Error("failed to parse points", errors.New("payload field is not a string"),
+			keyvalue.KV("stream", stream), keyvalue.KV("id", m.ID), keyvalue.KV("field", s.config.Field))
+		return true
+	}
+	return s.writePayload(data, keyvalue.KV("stream", stream), keyvalue.KV("id", m.ID))
+}
+
+// consumeChannels receives the messages of the channels until the service is closed.
+// The channels are subscribed to again when the Redis client changes.
+func (s *Service) consumeChannels() {
+	defer s.wg.Done()
+	for !s.isClosing() {
+		client, err := s.RedisService.Client()
+		if err != nil {
+			s.statMap.Add(statReadFail, 1)
+			s.diag.Error("failed to subscribe to redis channels", err)
+			if !s.wait() {
+				return
+			}
+			continue
+		}
+		pubsub := client.PSubscribe(s.config.Channels...)
+		if _, err := pubsub.Receive(); err != nil {
+			pubsub.Close()
+			s.statMap.Add(statReadFail, 1)
+			s.diag.Error("failed to subscribe to redis channels", err)
+			if !s.wait() {
+				return
+			}
+			continue
+		}
+		s.receiveMessages(client, pubsub)
+		pubsub.Close()
+	}
+}
+
+// receiveMessages handles the messages of pubsub until the service is closed
+// or the Redis client is no longer the current client.
+func (s *Service) receiveMessages(client redis.UniversalClient, pubsub *redis.PubSub) {
+	ticker := time.NewTicker(time.Duration(s.config.RetryInterval))
+	defer ticker.Stop()
+	messages := pubsub.Channel()
+	for {
+		select {
+		case m, ok := <-messages:
+			if !ok {
+				return
+			}
+			s.statMap.Add(statMessagesReceived, 1)
+			s.writePayload([]byte(m.Payload), keyvalue.KV("channel", m.Channel))
+		case <-ticker.C:
+			if current, err := s.RedisService.Client(); err != nil || current != client {
+				return
+			}
+		case <-s.closing:
+			return
+		}
+	}
+}
+
+// writePayload parses the payload and writes its points.
+// It returns false if the points could not be written.
+func (s *Service) writePayload(data []byte, ctx ...keyvalue.T) bool {
+	points, err := parsePoints(s.config.Format, s.config.Precision, data, time.Now().UTC())
+	if err != nil {
+		s.statMap.Add(statPointsParseFail, 1)
+		s.diag.Error("failed to parse points", err, ctx...)
+		return true
+	}
+	s.statMap.Add(statPointsReceived, int64(len(points)))
+	if err := s.PointsWriter.WritePoints(
+		s.config.Database,
+		s.config.RetentionPolicy,
+		models.ConsistencyLevelAll,
+		points,
+	); err != nil {
+		s.statMap.Add(statTransmitFail, 1)
+		s.diag.Error("failed to write points", err, append(ctx, keyvalue.KV("database", s.config.Database))...)
+		return false
+	}
+	s.statMap.Add(statPointsTransmitted, int64(len(points)))
+	return true
+}
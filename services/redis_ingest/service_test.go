@@ -0,0 +1,128 @@
+package redis_ingest
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/go-redis/redis"
+	"github.com/influxdata/influxdb/models"
+	"github.com/thingnario/kapacitor/keyvalue"
+	"github.com/thingnario/kapacitor/server/vars"
+)
+
+type diag struct{}
+
+func (diag) Error(msg string, err error, ctx ...keyvalue.T) {}
+func (diag) StartedConsuming(streams, channels []string)    {}
+func (diag) ClosedService()                                 {}
+
+// pointsWriter fails to write the points of the fail measurement until fail is false.
+type pointsWriter struct {
+	fail    bool
+	written []string
+}
+
+func (w *pointsWriter) WritePoints(database, retentionPolicy string, consistencyLevel models.ConsistencyLevel, points []models.Point) error {
+	for _, p := range points {
+		if w.fail && p.Name() == "fail" {
+			return errors.New("write failed")
+		}
+	}
+	for _, p := range points {
+		w.written = append(w.written, p.Name())
+	}
+	return nil
+}
+
+type acker struct {
+	acked []string
+}
+
+func (a *acker) XAck(stream, group string, ids ...string) *redis.IntCmd {
+	a.acked = append(a.acked, ids...)
+	return redis.NewIntResult(int64(len(ids)), nil)
+}
+
+func newTestService(group string, w *pointsWriter) *Service {
+	s := NewService(Config{Name: "test", Database: "db", Group: group}, diag{})
+	s.PointsWriter = w
+	s.statKey, s.statMap = vars.NewStatistic("redis_ingest", map[string]string{"name": "test"})
+	return s
+}
+
+func entry(id, measurement string) redis.XMessage {
+	return redis.XMessage{
+		ID:     id,
+		Values: map[string]interface{}{DefaultField: measurement + " value=1"},
+	}
+}
+
+func TestService_HandleStreams_Group(t *testing.T) {
+	w := &pointsWriter{fail: true}
+	s := newTestService("kapacitor", w)
+	defer vars.DeleteStatistic(s.statKey)
+	a := &acker{}
+	ids := map[string]string{"points": ">"}
+
+	// Entries after an entry that failed are not handled and not acknowledged.
+	streams := []redis.XStream{{
+		Stream:   "points",
+		Messages: []redis.XMessage{entry("1-0", "ok"), entry("2-0", "fail"), entry("3-0", "ok")},
+	}}
+	if s.handleStreams(a, streams, ids) {
+		t.Error("expected the entries to fail")
+	}
+	if exp := []string{"1-0"}; !reflect.DeepEqual(a.acked, exp) {
+		t.Errorf("unexpected acknowledged entries got %v exp %v", a.acked, exp)
+	}
+	if got, exp := ids["points"], "0"; got != exp {
+		t.Errorf("unexpected stream ID after failure got %q exp %q", got, exp)
+	}
+
+	// The pending entries are read again from the start.
+	w.fail = false
+	streams[0].Messages = streams[0].Messages[1:]
+	if !s.handleStreams(a, streams, ids) {
+		t.Error("expected the pending entries to be written")
+	}
+	if exp := []string{"1-0", "2-0", "3-0"}; !reflect.DeepEqual(a.acked, exp) {
+		t.Errorf("unexpected acknowledged entries got %v exp %v", a.acked, exp)
+	}
+	if exp := []string{"ok", "fail", "ok"}; !reflect.DeepEqual(w.written, exp) {
+		t.Errorf("unexpected written points got %v exp %v", w.written, exp)
+	}
+	if got, exp := ids["points"], "3-0"; got != exp {
+		t.Errorf("unexpected stream ID got %q exp %q", got, exp)
+	}
+
+	// Once no pending entries are left new entries are read.
+	streams[0].Messages = nil
+	s.handleStreams(a, streams, ids)
+	if got, exp := ids["points"], ">"; got != exp {
+		t.Errorf("unexpected stream ID after pending entries got %q exp %q", got, exp)
+	}
+}
+
+func TestService_HandleStreams_NoGroup(t *testing.T) {
+	w := &pointsWriter{fail: true}
+	s := newTestService("", w)
+	defer vars.DeleteStatistic(s.statKey)
+	a := &acker{}
+	ids := map[string]string{"points": "0-0"}
+
+	streams := []redis.XStream{{
+		Stream:   "points",
+		Messages: []redis.XMessage{entry("1-0", "ok"), entry("2-0", "fail"), entry("3-0", "ok")},
+	}}
+	if s.handleStreams(a, streams, ids) {
+		t.Error("expected the entries to fail")
+	}
+	if len(a.acked) != 0 {
+		t.Errorf("unexpected acknowledged entries without a group %v", a.acked)
+	}
+	// The failed entry is read again.
+	if got, exp := ids["points"], "1-0"; got != exp {
+		t.Errorf("unexpected stream ID after failure got %q exp %q", got, exp)
+	}
+}
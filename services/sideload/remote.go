@@ -0,0 +1,448 @@
+package sideload
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/pkg/errors"
+)
+
+const (
+	// Default duration values of remote sources are cached.
+	defaultTTL = time.Minute
+	// Timeout of requests to HTTP sources.
+	httpTimeout = 10 * time.Second
+	// Maximum duration before a failed fetch is retried.
+	retryInterval = 10 * time.Second
+)
+
+// fetcher loads the values stored under a path of a remote source.
+type fetcher interface {
+	// fetch returns the values stored under the path, or nil if nothing is stored under the path.
+	fetch(path string) (map[string]interface{}, error)
+}
+
+type cachedValues struct {
+	values map[string]interface{}
+	// Zero if the values never expire.
+	expires time.Time
+	// Whether a fetch of the values is in progress.
+	fetching bool
+}
+
+func (c cachedValues) fresh(now time.Time) bool {
+	return c.expires.IsZero() || now.Before(c.expires)
+}
+
+// remoteSource lazily fetches the values of the paths of the order list
+// and caches them until they expire or are invalidated.
+// Only the first lookup of a path waits for its values, expired values are
+// refreshed in the background and keep being used until the refresh completes.
+// Values are kept when a fetch fails, the fetch is retried after the retry interval.
+// Paths that are not looked up for a ttl after their values expired are evicted.
+type remoteSource struct {
+	referenceCount
+	s   *Service
+	key string
+	ttl time.Duration
+	f   fetcher
+
+	mu    sync.RWMutex
+	cache map[string]cachedValues
+	// Earliest time expired values are evicted again.
+	nextEviction time.Time
+
+	// closing is closed once the source is no longer referenced.
+	closing chan struct{}
+	wg      sync.WaitGroup
+}
+
+func newRemoteSource(s *Service, key string, ttl time.Duration, f fetcher) *remoteSource {
+	return &remoteSource{
+		s:       s,
+		key:     key,
+		ttl:     ttl,
+		f:       f,
+		cache:   make(map[string]cachedValues),
+		closing: make(chan struct{}),
+	}
+}
+
+func (s *remoteSource) Close() {
+	s.s.removeSource(s.key, s)
+}
+
+func (s *remoteSource) close() {
+	close(s.closing)
+	s.wg.Wait()
+}
+
+func (s *remoteSource) updateCache() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cache = make(map[string]cachedValues)
+	return nil
+}
+
+// invalidate removes the cached values of a path.
+func (s *remoteSource) invalidate(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.cache, path)
+}
+
+func (s *remoteSource) Lookup(order []string, key string) interface{} {
+	for _, o := range order {
+		if v, ok := s.values(o)[key]; ok {
+			return v
+		}
+	}
+	return nil
+}
+
+func (s *remoteSource) values(path string) map[string]interface{} {
+	now := time.Now()
+	s.mu.RLock()
+	cached, ok := s.cache[path]
+	s.mu.RUnlock()
+	if ok && (cached.fresh(now) || cached.fetching) {
+		return cached.values
+	}
+
+	s.mu.Lock()
+	cached, ok = s.cache[path]
+	if ok && (cached.fresh(now) || cached.fetching) {
+		s.mu.Unlock()
+		return cached.values
+	}
+	s.evictExpired(now)
+	cached.fetching = true
+	s.cache[path] = cached
+	s.mu.Unlock()
+
+	if !ok {
+		// Nothing to use until the values are fetched.
+		return s.fetch(path)
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.fetch(path)
+	}()
+	return cached.values
+}
+
+// evictExpired removes the values of paths that were not looked up for a ttl after they expired,
+// at most once per ttl. s.mu must be held.
+func (s *remoteSource) evictExpired(now time.Time) {
+	if s.ttl <= 0 || now.Before(s.nextEviction) {
+		return
+	}
+	s.nextEviction = now.Add(s.ttl)
+	for path, cached := range s.cache {
+		if !cached.fetching && !cached.expires.IsZero() && now.Sub(cached.expires) >= s.ttl {
+			delete(s.cache, path)
+		}
+	}
+}
+
+// fetch fetches and caches the values of a path, it returns the cached values.
+// The result is dropped if the path was invalidated during the fetch.
+func (s *remoteSource) fetch(path string) map[string]interface{} {
+	values, err := s.f.fetch(path)
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cached, ok := s.cache[path]
+	if !ok || !cached.fetching {
+		return values
+	}
+	cached.fetching = false
+	if err != nil {
+		if s.s.diag != nil {
+			s.s.diag.Error("failed to fetch sideload values", err)
+		}
+		// Keep using the previous values until the source is available again.
+		retry := retryInterval
+		if s.ttl > 0 && s.ttl < retry {
+			retry = s.ttl
+		}
+		cached.expires = now.Add(retry)
+	} else {
+		cached.values = values
+		cached.expires = time.Time{}
+		if s.ttl > 0 {
+			cached.expires = now.Add(s.ttl)
+		}
+	}
+	s.cache[path] = cached
+	return cached.values
+}
+
+// parseTTL removes the ttl query parameter from u and returns its value.
+func parseTTL(u *url.URL) (time.Duration, error) {
+	q := u.Query()
+	ttl := defaultTTL
+	if str := q.Get("ttl"); str != "" {
+		var err error
+		ttl, err = time.ParseDuration(str)
+		if err != nil {
+			return 0, errors.Wrapf(err, "invalid ttl %q", str)
+		}
+	}
+	q.Del("ttl")
+	u.RawQuery = q.Encode()
+	return ttl, nil
+}
+
+// Redis source
+
+type redisFetcher struct {
+	s      *Service
+	prefix string
+}
+
+func (s *Service) newRedisSource(key string, u *url.URL) (*remoteSource, error) {
+	if s.RedisService == nil {
+		return nil, errors.New("redis sideload sources require the redis service")
+	}
+	u = copyURL(u)
+	ttl, err := parseTTL(u)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	notify := false
+	if str := q.Get("notify"); str != "" {
+		notify, err = strconv.ParseBool(str)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid notify %q", str)
+		}
+	}
+	f := &redisFetcher{
+		s:      s,
+		prefix: q.Get("prefix"),
+	}
+	src := newRemoteSource(s, key, ttl, f)
+	if notify {
+		client, pubsub, err := s.subscribeKeyspace(f.prefix)
+		if err != nil {
+			return nil, err
+		}
+		src.wg.Add(1)
+		go src.watchKeyspace(client, pubsub, f.prefix)
+	}
+	return src, nil
+}
+
+// subscribeKeyspace subscribes to the keyspace notifications of the keys with the prefix
+// in the database of the current Redis client.
+func (s *Service) subscribeKeyspace(prefix string) (redis.UniversalClient, *redis.PubSub, error) {
+	client, err := s.RedisService.Client()
+	if err != nil {
+		return nil, nil, err
+	}
+	pubsub := client.PSubscribe(keyspacePattern(s.RedisService.DB(), prefix))
+	// Wait for the subscription to be confirmed so that no changes are missed.
+	if _, err := pubsub.Receive(); err != nil {
+		pubsub.Close()
+		return nil, nil, errors.Wrap(err, "failed to subscribe to redis keyspace notifications")
+	}
+	return client, pubsub, nil
+}
+
+// keyspacePattern returns the pattern of the keyspace notification channels of the keys with the prefix.
+func keyspacePattern(db int, prefix string) string {
+	return fmt.Sprintf("__keyspace@%d__:%s*", db, globEscaper.Replace(prefix))
+}
+
+// globEscaper escapes the metacharacters of Redis glob patterns.
+var globEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`*`, `\*`,
+	`?`, `\?`,
+	`[`, `\[`,
+	`]`, `\]`,
+)
+
+// watchKeyspace invalidates the cached values of Redis keys that are modified until the source is closed.
+// The keyspace is subscribed to again when the Redis client changes,
+// all cached values are invalidated then since changes may have been missed.
+func (s *remoteSource) watchKeyspace(client redis.UniversalClient, pubsub *redis.PubSub, prefix string) {
+	defer s.wg.Done()
+	for {
+		s.receiveKeyspace(client, pubsub, prefix)
+		pubsub.Close()
+		for {
+			if s.isClosing() {
+				return
+			}
+			var err error
+			client, pubsub, err = s.s.subscribeKeyspace(prefix)
+			if err == nil {
+				break
+			}
+			if s.s.diag != nil {
+				s.s.diag.Error("failed to subscribe to redis keyspace notifications", err)
+			}
+			if !s.wait(retryInterval) {
+				return
+			}
+		}
+		s.updateCache()
+	}
+}
+
+// receiveKeyspace handles the keyspace notifications of pubsub until the source is closed
+// or the Redis client is no longer the current client.
+func (s *remoteSource) receiveKeyspace(client redis.UniversalClient, pubsub *redis.PubSub, prefix string) {
+	ticker := time.NewTicker(retryInterval)
+	defer ticker.Stop()
+	messages := pubsub.Channel()
+	for {
+		select {
+		case m, ok := <-messages:
+			if !ok {
+				return
+			}
+			// The channel is __keyspace@<db>__:<key>
+			i := strings.Index(m.Channel, "__:")
+			if i < 0 {
+				continue
+			}
+			key := m.Channel[i+len("__:"):]
+			s.invalidate(strings.TrimPrefix(key, prefix))
+		case <-ticker.C:
+			if current, err := s.s.RedisService.Client(); err != nil || current != client {
+				return
+			}
+		case <-s.closing:
+			return
+		}
+	}
+}
+
+// wait waits for d and reports whether the source is still open.
+func (s *remoteSource) wait(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-s.closing:
+		return false
+	}
+}
+
+func (s *remoteSource) isClosing() bool {
+	select {
+	case <-s.closing:
+		return true
+	default:
+		return false
+	}
+}
+
+// fetch reads the values of a Redis hash, or of a string containing a JSON object.
+// The values of hashes are strings and are converted to the type of the default value.
+func (f *redisFetcher) fetch(path string) (map[string]interface{}, error) {
+	client, err := f.s.RedisService.Client()
+	if err != nil {
+		return nil, err
+	}
+	key := f.prefix + path
+	typ, err := client.Type(key).Result()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get type of redis key %q", key)
+	}
+	switch typ {
+	case "none":
+		return nil, nil
+	case "hash":
+		hash, err := client.HGetAll(key).Result()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get redis hash %q", key)
+		}
+		values := make(map[string]interface{}, len(hash))
+		for k, v := range hash {
+			values[k] = v
+		}
+		return values, nil
+	case "string":
+		data, err := client.Get(key).Bytes()
+		if err == redis.Nil {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get redis key %q", key)
+		}
+		return decodeJSONValues(data)
+	default:
+		return nil, fmt.Errorf("unsupported type %q of redis key %q, must be a hash or a string", typ, key)
+	}
+}
+
+// HTTP source
+
+type httpFetcher struct {
+	base   *url.URL
+	client *http.Client
+}
+
+func (s *Service) newHTTPSource(key string, u *url.URL) (*remoteSource, error) {
+	u = copyURL(u)
+	ttl, err := parseTTL(u)
+	if err != nil {
+		return nil, err
+	}
+	f := &httpFetcher{
+		base:   u,
+		client: &http.Client{Timeout: httpTimeout},
+	}
+	return newRemoteSource(s, key, ttl, f), nil
+}
+
+// fetch requests the path below the base URL, the response must be a JSON object.
+func (f *httpFetcher) fetch(path string) (map[string]interface{}, error) {
+	u := copyURL(f.base)
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + strings.TrimPrefix(path, "/")
+	resp, err := f.client.Get(u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response code %d from %q", resp.StatusCode, u)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read response from %q", u)
+	}
+	values, err := decodeJSONValues(data)
+	return values, errors.Wrapf(err, "invalid response from %q", u)
+}
+
+func decodeJSONValues(data []byte) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal json values")
+	}
+	return values, nil
+}
+
+func copyURL(u *url.URL) *url.URL {
+	c := *u
+	return &c
+}
@@ -0,0 +1,46 @@
+package sideload
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKeyspacePattern(t *testing.T) {
+	testCases := []struct {
+		db     int
+		prefix string
+		exp    string
+	}{
+		{db: 0, prefix: "", exp: "__keyspace@0__:*"},
+		{db: 3, prefix: "thresholds:", exp: "__keyspace@3__:thresholds:*"},
+		{db: 1, prefix: `a*b?c[d]e\f`, exp: `__keyspace@1__:a\*b\?c\[d\]e\\f*`},
+	}
+	for _, tc := range testCases {
+		if got := keyspacePattern(tc.db, tc.prefix); got != tc.exp {
+			t.Errorf("unexpected pattern for db %d prefix %q: got %q exp %q", tc.db, tc.prefix, got, tc.exp)
+		}
+	}
+}
+
+type staticFetcher map[string]interface{}
+
+func (f staticFetcher) fetch(string) (map[string]interface{}, error) {
+	return f, nil
+}
+
+func TestRemoteSource_EvictExpired(t *testing.T) {
+	s := newRemoteSource(&Service{}, "test", time.Minute, staticFetcher{"value": 1.0})
+	now := time.Now()
+	s.cache["unused"] = cachedValues{expires: now.Add(-2 * time.Minute)}
+	s.cache["expired"] = cachedValues{expires: now.Add(-30 * time.Second)}
+	s.cache["fresh"] = cachedValues{expires: now.Add(time.Minute)}
+
+	if v := s.Lookup([]string{"new"}, "value"); v != 1.0 {
+		t.Fatalf("unexpected value got %v exp 1", v)
+	}
+	for path, exp := range map[string]bool{"unused": false, "expired": true, "fresh": true, "new": true} {
+		if _, ok := s.cache[path]; ok != exp {
+			t.Errorf("unexpected cached path %q got %t exp %t", path, ok, exp)
+		}
+	}
+}
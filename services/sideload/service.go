@@ -11,6 +11,7 @@ import (
 	"sync"
 
 	"github.com/ghodss/yaml"
+	"github.com/go-redis/redis"
 	"github.com/thingnario/kapacitor/keyvalue"
 	"github.com/thingnario/kapacitor/services/httpd"
 	"github.com/pkg/errors"
@@ -32,18 +33,22 @@ type Service struct {
 	routes []httpd.Route
 
 	mu      sync.Mutex
-	sources map[string]*source
+	sources map[string]cachedSource
 
 	HTTPDService interface {
 		AddRoutes([]httpd.Route) error
 		DelRoutes([]httpd.Route)
 	}
+	RedisService interface {
+		Client() (redis.UniversalClient, error)
+		DB() int
+	}
 }
 
 func NewService(d Diagnostic) *Service {
 	return &Service{
 		diag:    d,
-		sources: make(map[string]*source),
+		sources: make(map[string]cachedSource),
 	}
 }
 
@@ -77,52 +82,88 @@ func (s *Service) handleReload(w http.ResponseWriter, r *http.Request) {
 func (s *Service) Reload() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	for dir, src := range s.sources {
+	for key, src := range s.sources {
 		if err := src.updateCache(); err != nil {
-			return errors.Wrapf(err, "failed to update source %q", dir)
+			return errors.Wrapf(err, "failed to update source %q", key)
 		}
 	}
 	return nil
 }
 
+// Source returns the source for the URL.
+// Sources are shared, each returned source must be closed once it is no longer used.
+//
+// Supported URLs are:
+//
+//	file:///path/to/dir                    YAML and JSON files in the directory, cached until reloaded.
+//	redis://?prefix=<prefix>&notify=true   Hashes or JSON strings stored in Redis under the key prefix.
+//	http(s)://host/path                    JSON objects served below the URL.
+//
+// The paths of the order list are appended to the prefix or URL of redis and HTTP sources.
+// Their values are cached for the duration of the ttl query parameter, one minute by default,
+// a ttl of 0 caches values until reloaded.
+// With notify=true cached Redis values are invalidated by the keyspace notifications of the configured database,
+// which must be enabled on the Redis server.
 func (s *Service) Source(srcURL string) (Source, error) {
 	u, err := url.Parse(srcURL)
 	if err != nil {
 		return nil, err
 	}
-	if u.Scheme != "file" {
-		return nil, fmt.Errorf("unsupported source scheme %q, must be 'file'", u.Scheme)
-	}
-	if !filepath.IsAbs(u.Path) {
-		return nil, fmt.Errorf("sideload source path must be absolute %q", u.Path)
+	var key string
+	var newSource func() (cachedSource, error)
+	switch u.Scheme {
+	case "file":
+		if !filepath.IsAbs(u.Path) {
+			return nil, fmt.Errorf("sideload source path must be absolute %q", u.Path)
+		}
+		dir := filepath.Clean(u.Path)
+		key = dir
+		newSource = func() (cachedSource, error) {
+			src := &fileSource{
+				s:   s,
+				dir: dir,
+			}
+			return src, src.updateCache()
+		}
+	case "redis":
+		key = u.String()
+		newSource = func() (cachedSource, error) {
+			return s.newRedisSource(key, u)
+		}
+	case "http", "https":
+		key = u.String()
+		newSource = func() (cachedSource, error) {
+			return s.newHTTPSource(key, u)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported source scheme %q, must be one of 'file', 'redis', 'http' or 'https'", u.Scheme)
 	}
-	dir := filepath.Clean(u.Path)
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	src, ok := s.sources[dir]
+	src, ok := s.sources[key]
 	if !ok {
-		src = &source{
-			s:   s,
-			dir: dir,
-		}
-		err := src.updateCache()
+		src, err = newSource()
 		if err != nil {
 			return nil, err
 		}
-		s.sources[dir] = src
+		s.sources[key] = src
 	}
-	src.referenceCount++
+	src.reference(1)
 
 	return src, nil
 }
 
-func (s *Service) removeSource(src *source) {
+func (s *Service) removeSource(key string, src cachedSource) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-	src.referenceCount--
-	if src.referenceCount == 0 {
-		delete(s.sources, src.dir)
+	unused := src.reference(-1) == 0
+	if unused {
+		delete(s.sources, key)
+	}
+	s.mu.Unlock()
+	// Closing waits for the background work of the source, which must not block other sources.
+	if unused {
+		src.close()
 	}
 }
 
@@ -131,19 +172,42 @@ type Source interface {
 	Close()
 }
 
-type source struct {
-	s              *Service
-	dir            string
-	mu             sync.RWMutex
-	cache          map[string]map[string]interface{}
-	referenceCount int
+// cachedSource is a Source shared by all users of the same URL.
+type cachedSource interface {
+	Source
+	// updateCache reloads or invalidates all cached values.
+	updateCache() error
+	// reference adds delta to the reference count and returns the new count.
+	reference(delta int) int
+	// close releases the resources of the source once it is no longer referenced.
+	close()
+}
+
+// referenceCount implements the reference counting of a cachedSource.
+type referenceCount struct {
+	count int
+}
+
+func (r *referenceCount) reference(delta int) int {
+	r.count += delta
+	return r.count
+}
+
+type fileSource struct {
+	referenceCount
+	s     *Service
+	dir   string
+	mu    sync.RWMutex
+	cache map[string]map[string]interface{}
 }
 
-func (s *source) Close() {
-	s.s.removeSource(s)
+func (s *fileSource) Close() {
+	s.s.removeSource(s.dir, s)
 }
 
-func (s *source) updateCache() error {
+func (s *fileSource) close() {}
+
+func (s *fileSource) updateCache() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.cache = make(map[string]map[string]interface{})
@@ -174,7 +238,7 @@ func (s *source) updateCache() error {
 	return errors.Wrapf(err, "failed to update sideload cache for source %q", s.dir)
 }
 
-func (s *source) Lookup(order []string, key string) (value interface{}) {
+func (s *fileSource) Lookup(order []string, key string) (value interface{}) {
 	key = filepath.Clean(key)
 
 	s.mu.RLock()
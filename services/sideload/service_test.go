@@ -2,9 +2,14 @@ package sideload_test
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/thingnario/kapacitor/services/sideload"
@@ -78,3 +83,142 @@ func TestService_Source_Lookup(t *testing.T) {
 		})
 	}
 }
+
+func TestService_HTTPSource_Lookup(t *testing.T) {
+	var requests int32
+	values := map[string]string{
+		"/thresholds/host/hostA": `{"key0": 5, "key1": "one"}`,
+		"/thresholds/default":    `{"key0": 1, "key1": "default", "key2": true}`,
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		v, ok := values[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(v))
+	}))
+	defer ts.Close()
+
+	s := NewService()
+	src, err := s.Source(ts.URL + "/thresholds?ttl=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+
+	testCases := []struct {
+		order []string
+		key   string
+		want  interface{}
+	}{
+		{
+			order: []string{"host/hostA", "default"},
+			key:   "key0",
+			want:  5.0,
+		},
+		{
+			order: []string{"host/hostB", "default"},
+			key:   "key1",
+			want:  "default",
+		},
+		{
+			order: []string{"host/hostA", "default"},
+			key:   "key2",
+			want:  true,
+		},
+		{
+			order: []string{"host/hostB"},
+			key:   "key0",
+			want:  nil,
+		},
+	}
+	for i, tc := range testCases {
+		tc := tc
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			got := src.Lookup(tc.order, tc.key)
+			if !cmp.Equal(got, tc.want) {
+				t.Errorf("unexpected values: -want/+got:\n%s", cmp.Diff(tc.want, got))
+			}
+		})
+	}
+
+	// All paths are cached, including missing paths.
+	if got, exp := atomic.LoadInt32(&requests), int32(3); got != exp {
+		t.Errorf("unexpected number of requests got %d exp %d", got, exp)
+	}
+	// Reloading invalidates the cache.
+	if err := s.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	values["/thresholds/default"] = `{"key1": "changed"}`
+	if got := src.Lookup([]string{"default"}, "key1"); got != "changed" {
+		t.Errorf("unexpected value after reload got %v exp %q", got, "changed")
+	}
+}
+
+func TestService_HTTPSource_Refresh(t *testing.T) {
+	var (
+		requests int32
+		mu       sync.Mutex
+		value    = `{"key": "first"}`
+		status   = http.StatusOK
+	)
+	release := make(chan struct{})
+	close(release)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		mu.Lock()
+		v, code, wait := value, status, release
+		mu.Unlock()
+		<-wait
+		w.WriteHeader(code)
+		w.Write([]byte(v))
+	}))
+	defer ts.Close()
+
+	s := NewService()
+	src, err := s.Source(ts.URL + "?ttl=10ms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+
+	if got := src.Lookup([]string{"default"}, "key"); got != "first" {
+		t.Fatalf("unexpected value got %v exp %q", got, "first")
+	}
+
+	// Expired values are used while they are refreshed in the background.
+	mu.Lock()
+	value = `{"key": "second"}`
+	release = make(chan struct{})
+	mu.Unlock()
+	time.Sleep(20 * time.Millisecond)
+	if got := src.Lookup([]string{"default"}, "key"); got != "first" {
+		t.Errorf("unexpected value during refresh got %v exp %q", got, "first")
+	}
+	close(release)
+	deadline := time.Now().Add(time.Second)
+	for src.Lookup([]string{"default"}, "key") != "second" {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for refreshed value")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// Failed fetches keep the previous values and are not retried on every lookup.
+	mu.Lock()
+	status = http.StatusInternalServerError
+	mu.Unlock()
+	time.Sleep(20 * time.Millisecond)
+	before := atomic.LoadInt32(&requests)
+	for i := 0; i < 10; i++ {
+		if got := src.Lookup([]string{"default"}, "key"); got != "second" {
+			t.Errorf("unexpected value after failure got %v exp %q", got, "second")
+		}
+	}
+	if got := atomic.LoadInt32(&requests) - before; got > 2 {
+		t.Errorf("unexpected number of requests after failure got %d exp at most 2", got)
+	}
+}
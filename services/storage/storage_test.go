@@ -131,6 +131,46 @@ func TestStorage_CRUD(t *testing.T) {
 	}
 }
 
+func TestStorage_List_EmptyPrefix(t *testing.T) {
+	for name, sc := range stores {
+		t.Run(name, func(t *testing.T) {
+			db, err := sc()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			s := db.Store("list")
+			keys := []string{"a", "b", "c"}
+			if err := s.Update(func(tx storage.Tx) error {
+				for _, k := range keys {
+					if err := tx.Put(k, []byte(k)); err != nil {
+						return err
+					}
+				}
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+			var kvs []*storage.KeyValue
+			if err := s.View(func(tx storage.ReadOnlyTx) error {
+				kvs, err = tx.List("")
+				return err
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if len(kvs) != len(keys) {
+				t.Fatalf("unexpected number of keys got %d exp %d", len(kvs), len(keys))
+			}
+			for i, kv := range kvs {
+				if kv.Key != keys[i] {
+					t.Errorf("unexpected key at %d got %q exp %q", i, kv.Key, keys[i])
+				}
+			}
+		})
+	}
+}
+
 func TestStorage_Update(t *testing.T) {
 	for name, sc := range stores {
 		t.Run(name, func(t *testing.T) {
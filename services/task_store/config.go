@@ -1,24 +1,53 @@
 package task_store
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/influxdata/influxdb/toml"
 )
 
+const (
+	// PauseModeSpool writes the points of a paused task to disk.
+	PauseModeSpool = "spool"
+	// PauseModeDrop drops the points of a paused task and only counts them.
+	PauseModeDrop = "drop"
+)
+
 type Config struct {
 	// Deprecated, only needed to find old db and migrate
 	Dir              string        `toml:"dir"`
 	SnapshotInterval toml.Duration `toml:"snapshot-interval"`
+	// What happens to the points of a paused task, either "spool" or "drop".
+	PauseMode string `toml:"pause-mode"`
+	// Where paused tasks spool their points.
+	SpoolDir string `toml:"spool-dir"`
+	// Maximum size in bytes of the spool of a paused task, further points are dropped.
+	MaxSpoolSize toml.Size `toml:"max-spool-size"`
 }
 
 func NewConfig() Config {
 	return Config{
 		Dir:              "./tasks",
 		SnapshotInterval: toml.Duration(time.Minute),
+		PauseMode:        PauseModeSpool,
+		SpoolDir:         "./spool",
+		MaxSpoolSize:     100 * 1024 * 1024,
 	}
 }
 
 func (c Config) Validate() error {
+	switch c.PauseMode {
+	case PauseModeSpool:
+		if c.SpoolDir == "" {
+			return fmt.Errorf("must specify spool-dir when pause-mode is %q", PauseModeSpool)
+		}
+		if c.MaxSpoolSize <= 0 {
+			return fmt.Errorf("max-spool-size must be positive, got %d", c.MaxSpoolSize)
+		}
+	case PauseModeDrop:
+	default:
+		return fmt.Errorf("invalid pause-mode %q, must be one of %q or %q", c.PauseMode, PauseModeSpool, PauseModeDrop)
+	}
 	return nil
 }
@@ -17,6 +17,7 @@ var (
 	ErrTemplateExists   = errors.New("template already exists")
 	ErrNoTemplateExists = errors.New("no template exists")
 	ErrNoSnapshotExists = errors.New("no snapshot exists")
+	ErrNoRevisionExists = errors.New("no revision exists")
 )
 
 // Data access object for Task data.
@@ -90,6 +91,30 @@ type SnapshotDAO interface {
 	Exists(id string) (bool, error)
 }
 
+// Data access object for the revisions of tasks or templates.
+type RevisionDAO interface {
+	// Retrieve a revision of a task or template.
+	Get(id string, number int) (Revision, error)
+
+	// Latest returns the most recent revision of a task or template.
+	// ErrNoRevisionExists is returned if the task or template has no revisions.
+	Latest(id string) (Revision, error)
+
+	// Create a revision.
+	Create(r Revision) error
+
+	// List the revisions of a task or template, oldest first.
+	// Offset and limit are pagination bounds. Offset is inclusive starting at index 0.
+	// More results may exist while the number of returned items is equal to limit.
+	// A negative limit lists all revisions.
+	List(id string, offset, limit int) ([]Revision, error)
+
+	// Delete all revisions of a task or template.
+	DeleteAll(id string) error
+
+	Rebuild() error
+}
+
 //--------------------------------------------------------------------
 // The following structures are stored in a database via gob encoding.
 // Changes to the structures could break existing data.
@@ -137,6 +162,8 @@ type Task struct {
 	Modified time.Time
 	// The time the task was last changed to status Enabled.
 	LastEnabled time.Time
+	// Whether the points of the enabled task are held back.
+	Paused bool
 }
 
 type rawTask Task
@@ -177,8 +204,55 @@ type DBRP struct {
 	RetentionPolicy string
 }
 
+// Revision is an immutable copy of the definition of a task or template.
+type Revision struct {
+	// ID of the task or template
+	ID string
+	// Number of the revision, the first revision of a task or template is 1.
+	Number int
+	// The task type (stream|batch).
+	Type TaskType
+	// The TICKscript of the task or template.
+	TICKscript string
+	// The DBRPs of a task.
+	DBRPs []DBRP
+	// ID of the template of a task.
+	TemplateID string
+	// Set of vars of a templated task
+	Vars map[string]Var
+	// Name of the user that made the revision, empty if unknown.
+	User string
+	// Created Date
+	Created time.Time
+}
+
+type rawRevision Revision
+
+// ObjectID sorts the revisions of a task or template by their number.
+// ':' is not valid in task and template IDs so the revisions of different tasks do not collide.
+func (r Revision) ObjectID() string {
+	return revisionObjectID(r.ID, r.Number)
+}
+
+func revisionObjectID(id string, number int) string {
+	return fmt.Sprintf("%s:%010d", id, number)
+}
+
+func (r Revision) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+	err := enc.Encode(rawRevision(r))
+	return buf.Bytes(), err
+}
+
+func (r *Revision) UnmarshalBinary(data []byte) error {
+	dec := gob.NewDecoder(bytes.NewReader(data))
+	return dec.Decode((*rawRevision)(r))
+}
+
 type Snapshot struct {
 	NodeSnapshots map[string][]byte
+	PipelineHash  string
 }
 
 // Key/Value store based implementation of the TaskDAO
@@ -653,3 +727,113 @@ func newVar(value interface{}, typ VarType, desc string) (Var, error) {
 	}
 	return g, nil
 }
+
+// Key/Value store based implementation of the RevisionDAO
+type revisionKV struct {
+	store *storage.IndexedStore
+}
+
+func newRevisionKV(store storage.Interface, prefix string) (*revisionKV, error) {
+	c := storage.DefaultIndexedStoreConfig(prefix, func() storage.BinaryObject {
+		return new(Revision)
+	})
+	istore, err := storage.NewIndexedStore(store, c)
+	if err != nil {
+		return nil, err
+	}
+	return &revisionKV{
+		store: istore,
+	}, nil
+}
+
+func (kv *revisionKV) error(err error) error {
+	if err == storage.ErrNoObjectExists {
+		return ErrNoRevisionExists
+	}
+	return err
+}
+
+func (kv *revisionKV) Get(id string, number int) (Revision, error) {
+	o, err := kv.store.Get(revisionObjectID(id, number))
+	if err != nil {
+		return Revision{}, kv.error(err)
+	}
+	r, ok := o.(*Revision)
+	if !ok {
+		return Revision{}, fmt.Errorf("impossible error, object not a Revision, got %T", o)
+	}
+	return *r, nil
+}
+
+func (kv *revisionKV) Latest(id string) (Revision, error) {
+	objects, err := kv.store.ReverseList(storage.DefaultIDIndex, id+":*", 0, 1)
+	if err != nil {
+		return Revision{}, err
+	}
+	if len(objects) == 0 {
+		return Revision{}, ErrNoRevisionExists
+	}
+	r, ok := objects[0].(*Revision)
+	if !ok {
+		return Revision{}, fmt.Errorf("impossible error, object not a Revision, got %T", objects[0])
+	}
+	return *r, nil
+}
+
+func (kv *revisionKV) Create(r Revision) error {
+	return kv.store.Create(&r)
+}
+
+func (kv *revisionKV) List(id string, offset, limit int) ([]Revision, error) {
+	if limit < 0 {
+		// The store does not match the pattern without a limit, page through all revisions instead.
+		var revisions []Revision
+		for {
+			page, err := kv.list(id, offset, revisionPageSize)
+			if err != nil {
+				return nil, err
+			}
+			revisions = append(revisions, page...)
+			if len(page) < revisionPageSize {
+				return revisions, nil
+			}
+			offset += revisionPageSize
+		}
+	}
+	return kv.list(id, offset, limit)
+}
+
+const revisionPageSize = 100
+
+func (kv *revisionKV) list(id string, offset, limit int) ([]Revision, error) {
+	objects, err := kv.store.List(storage.DefaultIDIndex, id+":*", offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	revisions := make([]Revision, len(objects))
+	for i, o := range objects {
+		r, ok := o.(*Revision)
+		if !ok {
+			return nil, fmt.Errorf("impossible error, object not a Revision, got %T", o)
+		}
+		revisions[i] = *r
+	}
+	return revisions, nil
+}
+
+func (kv *revisionKV) DeleteAll(id string) error {
+	revisions, err := kv.List(id, 0, -1)
+	if err != nil {
+		return err
+	}
+	for _, r := range revisions {
+		if err := kv.store.Delete(r.ObjectID()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (kv *revisionKV) Rebuild() error {
+	return kv.store.Rebuild()
+}
@@ -0,0 +1,426 @@
+package task_store
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/thingnario/kapacitor/client/v1"
+	"github.com/thingnario/kapacitor/pipeline"
+	"github.com/thingnario/kapacitor/services/httpd"
+)
+
+const graphPath = "/graph"
+
+// Kinds of the nodes of the task graph.
+const (
+	graphTask    = "task"
+	graphStream  = "stream"
+	graphTopic   = "topic"
+	graphHandler = "handler"
+)
+
+// stream identifies the points a task reads or writes, an empty measurement matches any measurement.
+type stream struct {
+	Database        string
+	RetentionPolicy string
+	Measurement     string
+}
+
+func (s stream) String() string {
+	m := s.Measurement
+	if m == "" {
+		m = "*"
+	}
+	return fmt.Sprintf("%q.%q.%s", s.Database, s.RetentionPolicy, m)
+}
+
+// feeds reports whether points written to s can be read from r.
+func (s stream) feeds(r stream) bool {
+	return s.Database == r.Database &&
+		s.RetentionPolicy == r.RetentionPolicy &&
+		(s.Measurement == "" || r.Measurement == "" || s.Measurement == r.Measurement)
+}
+
+type graph struct {
+	nodes map[string]client.GraphNode
+	edges map[client.GraphEdge]bool
+	// Adjacency list of the edges.
+	out map[string][]string
+}
+
+func newGraph() *graph {
+	return &graph{
+		nodes: make(map[string]client.GraphNode),
+		edges: make(map[client.GraphEdge]bool),
+		out:   make(map[string][]string),
+	}
+}
+
+// addNode adds the node if it does not exist yet and returns its ID.
+func (g *graph) addNode(kind, name string) string {
+	id := kind + ":" + name
+	if _, ok := g.nodes[id]; !ok {
+		g.nodes[id] = client.GraphNode{
+			ID:   id,
+			Kind: kind,
+			Name: name,
+		}
+	}
+	return id
+}
+
+func (g *graph) addEdge(from, to, kind string) {
+	e := client.GraphEdge{From: from, To: to, Kind: kind}
+	if g.edges[e] {
+		return
+	}
+	g.edges[e] = true
+	g.out[from] = append(g.out[from], to)
+}
+
+// cycles returns the strongly connected components of the graph with more than one node.
+func (g *graph) cycles() [][]string {
+	ids := make([]string, 0, len(g.nodes))
+	for id := range g.nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	// Tarjan's strongly connected components algorithm
+	index := make(map[string]int, len(ids))
+	lowlink := make(map[string]int, len(ids))
+	onStack := make(map[string]bool)
+	var stack []string
+	var cycles [][]string
+	var connect func(id string)
+	connect = func(id string) {
+		index[id] = len(index)
+		lowlink[id] = index[id]
+		stack = append(stack, id)
+		onStack[id] = true
+		for _, to := range g.out[id] {
+			if _, ok := index[to]; !ok {
+				connect(to)
+				if lowlink[to] < lowlink[id] {
+					lowlink[id] = lowlink[to]
+				}
+			} else if onStack[to] && index[to] < lowlink[id] {
+				lowlink[id] = index[to]
+			}
+		}
+		if lowlink[id] != index[id] {
+			return
+		}
+		var component []string
+		for {
+			n := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[n] = false
+			component = append(component, n)
+			if n == id {
+				break
+			}
+		}
+		if len(component) > 1 {
+			sort.Strings(component)
+			cycles = append(cycles, component)
+		}
+	}
+	for _, id := range ids {
+		if _, ok := index[id]; !ok {
+			connect(id)
+		}
+	}
+	sort.Slice(cycles, func(i, j int) bool {
+		return cycles[i][0] < cycles[j][0]
+	})
+	return cycles
+}
+
+func (g *graph) graph() client.Graph {
+	cg := client.Graph{
+		Nodes:  make([]client.GraphNode, 0, len(g.nodes)),
+		Edges:  make([]client.GraphEdge, 0, len(g.edges)),
+		Cycles: g.cycles(),
+	}
+	for _, n := range g.nodes {
+		cg.Nodes = append(cg.Nodes, n)
+	}
+	sort.Slice(cg.Nodes, func(i, j int) bool {
+		return cg.Nodes[i].ID < cg.Nodes[j].ID
+	})
+	for e := range g.edges {
+		cg.Edges = append(cg.Edges, e)
+	}
+	sort.Slice(cg.Edges, func(i, j int) bool {
+		a, b := cg.Edges[i], cg.Edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		return a.Kind < b.Kind
+	})
+	cg.Dot = graphDot(cg)
+	return cg
+}
+
+func graphDot(g client.Graph) string {
+	inCycle := make(map[string]bool)
+	for _, c := range g.Cycles {
+		for _, id := range c {
+			inCycle[id] = true
+		}
+	}
+	var buf bytes.Buffer
+	buf.WriteString("digraph kapacitor {\n")
+	for _, n := range g.Nodes {
+		shape := "ellipse"
+		switch n.Kind {
+		case graphTask:
+			shape = "box"
+		case graphTopic:
+			shape = "hexagon"
+		case graphHandler:
+			shape = "note"
+		}
+		attrs := fmt.Sprintf("label=%q shape=%s", n.Name, shape)
+		if inCycle[n.ID] || n.Error != "" {
+			attrs += " color=red"
+		}
+		fmt.Fprintf(&buf, "%q [%s];\n", n.ID, attrs)
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&buf, "%q -> %q [label=%q];\n", e.From, e.To, e.Kind)
+	}
+	buf.WriteString("}")
+	return buf.String()
+}
+
+func (ts *Service) handleGraph(w http.ResponseWriter, r *http.Request) {
+	g, err := ts.buildGraph()
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(httpd.MarshalJSON(g.graph(), true))
+}
+
+// buildGraph links all tasks through the streams they read and write,
+// the topics they send alerts to and the topic handlers of those topics.
+func (ts *Service) buildGraph() (*graph, error) {
+	g := newGraph()
+	reads := make(map[string]stream)
+	writes := make(map[string]stream)
+
+	ids := make(map[string]bool)
+	offset := 0
+	limit := 100
+	for {
+		tasks, err := ts.tasks.List("*", offset, limit)
+		if err != nil {
+			return nil, err
+		}
+		for _, task := range tasks {
+			ids[task.ID] = true
+			ts.addTaskToGraph(g, task, reads, writes)
+		}
+		if len(tasks) != limit {
+			break
+		}
+		offset += limit
+	}
+	ts.graphLinks.retain(ids)
+	for wid, w := range writes {
+		for rid, r := range reads {
+			if wid != rid && w.feeds(r) {
+				g.addEdge(wid, rid, "feeds")
+			}
+		}
+	}
+
+	if ts.AlertService != nil {
+		for _, spec := range ts.AlertService.AllHandlerSpecs() {
+			topic := g.addNode(graphTopic, spec.Topic)
+			handler := g.addNode(graphHandler, spec.Topic+"/"+spec.ID)
+			g.addEdge(topic, handler, "handles")
+			if spec.Kind != "publish" {
+				continue
+			}
+			topics, _ := spec.Options["topics"].([]interface{})
+			for _, t := range topics {
+				if name, ok := t.(string); ok {
+					g.addEdge(handler, g.addNode(graphTopic, name), "publishes")
+				}
+			}
+		}
+	}
+	return g, nil
+}
+
+func (ts *Service) addTaskToGraph(g *graph, task Task, reads, writes map[string]stream) {
+	id := g.addNode(graphTask, task.ID)
+	n := g.nodes[id]
+	n.Status, _ = clientStatus(task)
+	l := ts.taskLinks(task)
+	if l.err != "" {
+		n.Error = l.err
+		g.nodes[id] = n
+		return
+	}
+	g.nodes[id] = n
+
+	for _, s := range l.reads {
+		sid := g.addNode(graphStream, s.String())
+		reads[sid] = s
+		g.addEdge(sid, id, "reads")
+	}
+	for _, s := range l.writes {
+		sid := g.addNode(graphStream, s.String())
+		writes[sid] = s
+		g.addEdge(id, sid, "loopback")
+	}
+	for _, topic := range l.topics {
+		g.addEdge(id, g.addNode(graphTopic, topic), "alerts")
+	}
+}
+
+// links are the streams a task reads and writes and the topics it sends alerts to.
+type links struct {
+	// Definition of the task the links were computed from.
+	tickscript string
+	taskType   TaskType
+	dbrps      []DBRP
+	vars       map[string]Var
+
+	// Error compiling the task.
+	err    string
+	reads  []stream
+	writes []stream
+	topics []string
+}
+
+// definedBy reports whether the links were computed from the definition of the task.
+func (l links) definedBy(task Task) bool {
+	return l.tickscript == task.TICKscript &&
+		l.taskType == task.Type &&
+		reflect.DeepEqual(l.dbrps, task.DBRPs) &&
+		reflect.DeepEqual(l.vars, task.Vars)
+}
+
+// linksCache keeps the links of each task,
+// so that only tasks whose definition changed are compiled when the graph is built.
+type linksCache struct {
+	mu    sync.Mutex
+	links map[string]links
+}
+
+func (c *linksCache) get(task Task) (links, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	l, ok := c.links[task.ID]
+	return l, ok && l.definedBy(task)
+}
+
+func (c *linksCache) set(id string, l links) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.links == nil {
+		c.links = make(map[string]links)
+	}
+	c.links[id] = l
+}
+
+// retain removes the links of all tasks not in ids.
+func (c *linksCache) retain(ids map[string]bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id := range c.links {
+		if !ids[id] {
+			delete(c.links, id)
+		}
+	}
+}
+
+// taskLinks returns the links of a task, the task is only compiled if its definition changed.
+func (ts *Service) taskLinks(task Task) links {
+	if l, ok := ts.graphLinks.get(task); ok {
+		return l
+	}
+	l := links{
+		tickscript: task.TICKscript,
+		taskType:   task.Type,
+		dbrps:      task.DBRPs,
+		vars:       task.Vars,
+	}
+	kt, err := ts.newKapacitorTask(task)
+	if err != nil {
+		l.err = err.Error()
+		ts.graphLinks.set(task.ID, l)
+		return l
+	}
+	kt.Pipeline.Walk(func(pn pipeline.Node) error {
+		switch node := pn.(type) {
+		case *pipeline.FromNode:
+			// A task only receives the points of its dbrps.
+			for _, dbrp := range task.DBRPs {
+				if (node.Database != "" && node.Database != dbrp.Database) ||
+					(node.RetentionPolicy != "" && node.RetentionPolicy != dbrp.RetentionPolicy) {
+					continue
+				}
+				l.reads = append(l.reads, stream{
+					Database:        dbrp.Database,
+					RetentionPolicy: dbrp.RetentionPolicy,
+					Measurement:     node.Measurement,
+				})
+			}
+		case *pipeline.KapacitorLoopbackNode:
+			l.writes = append(l.writes, stream{
+				Database:        node.Database,
+				RetentionPolicy: node.RetentionPolicy,
+				Measurement:     node.Measurement,
+			})
+		case *pipeline.AlertNode:
+			if node.Topic != "" {
+				l.topics = append(l.topics, node.Topic)
+			}
+		}
+		return nil
+	})
+	ts.graphLinks.set(task.ID, l)
+	return l
+}
+
+// loopbackCycleWarnings returns a warning for each cycle of the task graph the task is part of.
+// Only kapacitorLoopback nodes can link a task back to itself,
+// the graph is only built if the task has any.
+func (ts *Service) loopbackCycleWarnings(task Task) []string {
+	if len(ts.taskLinks(task).writes) == 0 {
+		return nil
+	}
+	taskID := task.ID
+	g, err := ts.buildGraph()
+	if err != nil {
+		ts.diag.Error("failed to build task graph", err)
+		return nil
+	}
+	id := graphTask + ":" + taskID
+	var warnings []string
+	for _, c := range g.cycles() {
+		for _, n := range c {
+			if n == id {
+				ts.diag.LoopbackCycle(taskID, c)
+				warnings = append(warnings, "task is part of a kapacitorLoopback cycle: "+strings.Join(c, ", "))
+				break
+			}
+		}
+	}
+	return warnings
+}
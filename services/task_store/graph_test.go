@@ -0,0 +1,97 @@
+package task_store
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStream_Feeds(t *testing.T) {
+	cpu := stream{Database: "telegraf", RetentionPolicy: "autogen", Measurement: "cpu"}
+	testCases := []struct {
+		name string
+		r    stream
+		exp  bool
+	}{
+		{name: "same measurement", r: cpu, exp: true},
+		{name: "wildcard measurement", r: stream{Database: "telegraf", RetentionPolicy: "autogen"}, exp: true},
+		{name: "other measurement", r: stream{Database: "telegraf", RetentionPolicy: "autogen", Measurement: "mem"}, exp: false},
+		{name: "other retention policy", r: stream{Database: "telegraf", RetentionPolicy: "weekly", Measurement: "cpu"}, exp: false},
+	}
+	for _, tc := range testCases {
+		if got := cpu.feeds(tc.r); got != tc.exp {
+			t.Errorf("%s: unexpected result got %v exp %v", tc.name, got, tc.exp)
+		}
+	}
+}
+
+func TestGraph_Cycles(t *testing.T) {
+	g := newGraph()
+	raw := g.addNode(graphStream, stream{Database: "telegraf", RetentionPolicy: "autogen", Measurement: "cpu"}.String())
+	agg := g.addNode(graphStream, stream{Database: "telegraf", RetentionPolicy: "autogen", Measurement: "cpu_agg"}.String())
+	wildcard := g.addNode(graphStream, stream{Database: "telegraf", RetentionPolicy: "autogen"}.String())
+	a := g.addNode(graphTask, "a")
+	b := g.addNode(graphTask, "b")
+	c := g.addNode(graphTask, "c")
+	topic := g.addNode(graphTopic, "cpu")
+
+	// a and b loop through each other, c only consumes the loopback of a.
+	g.addEdge(raw, a, "reads")
+	g.addEdge(a, agg, "loopback")
+	g.addEdge(agg, wildcard, "feeds")
+	g.addEdge(wildcard, b, "reads")
+	g.addEdge(b, raw, "loopback")
+	g.addEdge(agg, c, "reads")
+	g.addEdge(c, topic, "alerts")
+	g.addEdge(c, topic, "alerts")
+
+	exp := [][]string{{wildcard, raw, agg, a, b}}
+	if got := g.cycles(); !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected cycles:\ngot\n%v\nexp\n%v", got, exp)
+	}
+	cg := g.graph()
+	if len(cg.Nodes) != 7 || len(cg.Edges) != 7 {
+		t.Errorf("unexpected graph size got %d nodes %d edges exp 7 nodes 7 edges", len(cg.Nodes), len(cg.Edges))
+	}
+}
+
+func TestLinksCache(t *testing.T) {
+	task := Task{
+		ID:         "cpu",
+		Type:       StreamTask,
+		TICKscript: "stream|from()|kapacitorLoopback().database('telegraf')",
+		DBRPs:      []DBRP{{Database: "telegraf", RetentionPolicy: "autogen"}},
+		Vars:       map[string]Var{"field": {StringValue: "usage", Type: VarString}},
+	}
+	var c linksCache
+	if _, ok := c.get(task); ok {
+		t.Fatal("unexpected links in empty cache")
+	}
+	c.set(task.ID, links{
+		tickscript: task.TICKscript,
+		taskType:   task.Type,
+		dbrps:      task.DBRPs,
+		vars:       task.Vars,
+		writes:     []stream{{Database: "telegraf"}},
+	})
+	if l, ok := c.get(task); !ok || len(l.writes) != 1 {
+		t.Errorf("expected cached links of unchanged task, got %v %v", l, ok)
+	}
+
+	// Links of a task whose definition changed are recomputed.
+	changed := task
+	changed.Vars = map[string]Var{"field": {StringValue: "idle", Type: VarString}}
+	if _, ok := c.get(changed); ok {
+		t.Error("unexpected cached links of task with changed vars")
+	}
+	// Only the status of the task changed.
+	enabled := task
+	enabled.Status = Enabled
+	if _, ok := c.get(enabled); !ok {
+		t.Error("expected cached links of task with changed status")
+	}
+
+	c.retain(map[string]bool{"mem": true})
+	if _, ok := c.get(task); ok {
+		t.Error("expected links of deleted task to be removed")
+	}
+}
@@ -0,0 +1,348 @@
+package task_store
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	imodels "github.com/influxdata/influxdb/models"
+	"github.com/pkg/errors"
+	"github.com/thingnario/kapacitor/client/v1"
+	"github.com/thingnario/kapacitor/edge"
+	"github.com/thingnario/kapacitor/keyvalue"
+	"github.com/thingnario/kapacitor/models"
+)
+
+const spoolExt = ".spool"
+
+// spooledPoint is a single line of a spool file.
+type spooledPoint struct {
+	Database        string `json:"db"`
+	RetentionPolicy string `json:"rp"`
+	// The point in line protocol
+	Point string `json:"point"`
+}
+
+// pauseBuffer holds the points of a paused task.
+// In spool mode the points are appended to a file and read back in order,
+// in drop mode they are only counted.
+type pauseBuffer struct {
+	mu sync.Mutex
+
+	taskID  string
+	mode    string
+	path    string
+	maxSize int64
+
+	w *os.File
+	f *os.File
+	r *bufio.Reader
+
+	// Bytes of the points in the spool file not yet read
+	size int64
+	// Number of points in the spool file not yet read
+	count   int64
+	dropped int64
+
+	diag Diagnostic
+}
+
+// openPauseBuffer opens the buffer of the task,
+// points left in the spool file, e.g. by a restart while the task was paused, are kept.
+func openPauseBuffer(taskID, mode, dir string, maxSize int64, d Diagnostic) (*pauseBuffer, error) {
+	b := &pauseBuffer{
+		taskID:  taskID,
+		mode:    mode,
+		maxSize: maxSize,
+		diag:    d,
+	}
+	if mode != PauseModeSpool {
+		return b, nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	b.path = filepath.Join(dir, taskID+spoolExt)
+	w, err := os.OpenFile(b.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(b.path)
+	if err != nil {
+		w.Close()
+		return nil, err
+	}
+	b.w = w
+	b.f = f
+
+	// Count the points left from before
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			b.close()
+			return nil, err
+		}
+		b.size += int64(len(line))
+		b.count++
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		b.close()
+		return nil, err
+	}
+	b.r = bufio.NewReader(f)
+	return b, nil
+}
+
+func (b *pauseBuffer) Add(p edge.PointMessage) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.mode != PauseModeSpool {
+		b.dropped++
+		return
+	}
+	line, err := encodeSpooledPoint(p)
+	if err != nil {
+		b.dropped++
+		b.diag.Error("failed to encode point of paused task", err, keyvalue.KV("task", b.taskID))
+		return
+	}
+	if b.size+int64(len(line)) > b.maxSize {
+		b.dropped++
+		return
+	}
+	if _, err := b.w.Write(line); err != nil {
+		b.dropped++
+		b.diag.Error("failed to spool point of paused task", err, keyvalue.KV("task", b.taskID))
+		return
+	}
+	b.size += int64(len(line))
+	b.count++
+}
+
+func (b *pauseBuffer) Next() (edge.PointMessage, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.count == 0 {
+		return nil, nil
+	}
+	// Complete lines are written while holding the lock, so the line cannot be partial.
+	line, err := b.r.ReadBytes('\n')
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read spool of task %s", b.taskID)
+	}
+	b.size -= int64(len(line))
+	b.count--
+	if b.count == 0 {
+		b.truncate()
+	}
+	return decodeSpooledPoint(line)
+}
+
+// truncate empties the drained spool file so that it does not grow while the task is resumed.
+func (b *pauseBuffer) truncate() {
+	if err := b.w.Truncate(0); err != nil {
+		b.diag.Error("failed to truncate spool of task", err, keyvalue.KV("task", b.taskID))
+		return
+	}
+	if _, err := b.f.Seek(0, io.SeekStart); err != nil {
+		b.diag.Error("failed to truncate spool of task", err, keyvalue.KV("task", b.taskID))
+		return
+	}
+	b.r.Reset(b.f)
+}
+
+func (b *pauseBuffer) Len() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.count
+}
+
+func (b *pauseBuffer) stats() *client.PauseStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return &client.PauseStats{
+		Mode:     b.mode,
+		Buffered: b.count,
+		Dropped:  b.dropped,
+	}
+}
+
+func (b *pauseBuffer) close() {
+	if b.w != nil {
+		b.w.Close()
+	}
+	if b.f != nil {
+		b.f.Close()
+	}
+}
+
+// remove closes the buffer and deletes its spool file.
+func (b *pauseBuffer) remove() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.close()
+	if b.path == "" {
+		return nil
+	}
+	if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func encodeSpooledPoint(p edge.PointMessage) ([]byte, error) {
+	mp, err := imodels.NewPoint(p.Name(), imodels.NewTags(p.Tags()), imodels.Fields(p.Fields()), p.Time())
+	if err != nil {
+		return nil, err
+	}
+	line, err := json.Marshal(spooledPoint{
+		Database:        p.Database(),
+		RetentionPolicy: p.RetentionPolicy(),
+		Point:           mp.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(line, '\n'), nil
+}
+
+func decodeSpooledPoint(line []byte) (edge.PointMessage, error) {
+	var sp spooledPoint
+	if err := json.Unmarshal(line, &sp); err != nil {
+		return nil, errors.Wrap(err, "invalid spooled point")
+	}
+	points, err := imodels.ParsePointsString(sp.Point)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid spooled point")
+	}
+	if len(points) != 1 {
+		return nil, fmt.Errorf("invalid spooled point, expected one point got %d", len(points))
+	}
+	mp := points[0]
+	return edge.NewPointMessage(
+		string(mp.Name()),
+		sp.Database,
+		sp.RetentionPolicy,
+		models.Dimensions{},
+		models.Fields(mp.Fields()),
+		models.Tags(mp.Tags().Map()),
+		mp.Time(),
+	), nil
+}
+
+// pauseTask starts buffering the points of the task, the task does not have to be executing yet.
+func (ts *Service) pauseTask(task Task) error {
+	if task.Type != StreamTask {
+		return errors.New("only stream tasks can be paused")
+	}
+	ts.pausesMu.Lock()
+	defer ts.pausesMu.Unlock()
+	b, ok := ts.pauses[task.ID]
+	if !ok {
+		var err error
+		b, err = openPauseBuffer(task.ID, ts.pauseMode, ts.spoolDir, ts.maxSpoolSize, ts.diag)
+		if err != nil {
+			return errors.Wrapf(err, "failed to open spool of task %s", task.ID)
+		}
+		ts.pauses[task.ID] = b
+	}
+	ts.TaskMasterLookup.Main().PauseTask(task.ID, b)
+	return nil
+}
+
+// resumeTask processes the buffered points of the task in order and stops buffering.
+func (ts *Service) resumeTask(id string) error {
+	if err := ts.TaskMasterLookup.Main().ResumeTask(id); err != nil {
+		return errors.Wrapf(err, "failed to resume task %s", id)
+	}
+	ts.removePauseBuffer(id)
+	return nil
+}
+
+// discardPause stops buffering the points of the task and drops the buffered points.
+func (ts *Service) discardPause(id string) {
+	ts.TaskMasterLookup.Main().CancelPause(id)
+	ts.pausesMu.Lock()
+	b, ok := ts.pauses[id]
+	ts.pausesMu.Unlock()
+	if ok {
+		if n := b.Len(); n > 0 {
+			ts.diag.DiscardedPausedPoints(id, n)
+		}
+	}
+	ts.removePauseBuffer(id)
+}
+
+// checkBufferedPoints returns an error and its status code if points are buffered for the task,
+// unless the request explicitly discards them with the discard parameter.
+func (ts *Service) checkBufferedPoints(r *http.Request, id string) (int, error) {
+	switch discard := r.URL.Query().Get("discard"); discard {
+	case "", "false":
+	case "true":
+		return 0, nil
+	default:
+		return http.StatusBadRequest, fmt.Errorf("invalid discard parameter %q", discard)
+	}
+	if stats := ts.pauseStats(id); stats != nil && stats.Buffered > 0 {
+		return http.StatusConflict, fmt.Errorf("task %s has %d buffered points, resume the task first or set discard=true to drop them", id, stats.Buffered)
+	}
+	return 0, nil
+}
+
+func (ts *Service) removePauseBuffer(id string) {
+	ts.pausesMu.Lock()
+	b, ok := ts.pauses[id]
+	delete(ts.pauses, id)
+	ts.pausesMu.Unlock()
+	if !ok {
+		return
+	}
+	if err := b.remove(); err != nil {
+		ts.diag.Error("failed to remove spool of task", err, keyvalue.KV("task", id))
+	}
+}
+
+// pauseStats returns the stats of the points buffered for the task, nil if the task is not paused.
+func (ts *Service) pauseStats(id string) *client.PauseStats {
+	ts.pausesMu.Lock()
+	b, ok := ts.pauses[id]
+	ts.pausesMu.Unlock()
+	if !ok {
+		return nil
+	}
+	return b.stats()
+}
+
+func (ts *Service) closePauseBuffers() {
+	ts.pausesMu.Lock()
+	defer ts.pausesMu.Unlock()
+	for id, b := range ts.pauses {
+		b.mu.Lock()
+		b.close()
+		b.mu.Unlock()
+		delete(ts.pauses, id)
+	}
+}
+
+// clientStatus returns the status of the task as it is shown to clients.
+func clientStatus(t Task) (client.TaskStatus, error) {
+	switch t.Status {
+	case Disabled:
+		return client.Disabled, nil
+	case Enabled:
+		if t.Paused {
+			return client.Paused, nil
+		}
+		return client.Enabled, nil
+	default:
+		return 0, fmt.Errorf("invalid task status %v", t.Status)
+	}
+}
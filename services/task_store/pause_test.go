@@ -0,0 +1,208 @@
+package task_store
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/thingnario/kapacitor/client/v1"
+	"github.com/thingnario/kapacitor/edge"
+	"github.com/thingnario/kapacitor/models"
+)
+
+func newTestPoint(i int) edge.PointMessage {
+	return edge.NewPointMessage(
+		"cpu",
+		"telegraf",
+		"autogen",
+		models.Dimensions{},
+		models.Fields{"value": float64(i), "count": int64(i)},
+		models.Tags{"host": "serverA"},
+		time.Date(2018, 1, 1, 0, 0, i, 0, time.UTC),
+	)
+}
+
+func TestPauseBuffer_Spool(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	b, err := openPauseBuffer("cpu", PauseModeSpool, dir, 1024, diag{t: t})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		b.Add(newTestPoint(i))
+	}
+	b.close()
+
+	// The points survive reopening the spool, e.g. after a restart.
+	b, err = openPauseBuffer("cpu", PauseModeSpool, dir, 1024, diag{t: t})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := b.Len(); got != 3 {
+		t.Fatalf("unexpected number of points after reopening got %d exp 3", got)
+	}
+	// Fill the spool until points are dropped
+	for i := 3; i < 20; i++ {
+		b.Add(newTestPoint(i))
+	}
+	stats := b.stats()
+	if stats.Dropped == 0 {
+		t.Fatal("expected points to be dropped once the spool is full")
+	}
+	if stats.Buffered+stats.Dropped != 20 {
+		t.Errorf("unexpected number of points got %d buffered %d dropped exp 20 in total", stats.Buffered, stats.Dropped)
+	}
+	for i := 0; i < int(stats.Buffered); i++ {
+		p, err := b.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exp := newTestPoint(i); !reflect.DeepEqual(p, exp) {
+			t.Errorf("unexpected point %d got %v exp %v", i, p, exp)
+		}
+	}
+	if p, err := b.Next(); err != nil || p != nil {
+		t.Errorf("expected empty spool got %v %v", p, err)
+	}
+	if err := b.remove(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(b.path); !os.IsNotExist(err) {
+		t.Errorf("expected spool file to be removed, got %v", err)
+	}
+}
+
+func TestPauseBuffer_Resume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	b, err := openPauseBuffer("cpu", PauseModeSpool, dir, 1024, diag{t: t})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.remove()
+	// Fill the spool to its limit
+	i := 0
+	for ; b.stats().Dropped == 0; i++ {
+		b.Add(newTestPoint(i))
+	}
+	buffered := b.Len()
+	dropped := i - 1
+	// next returns the next point and checks that the points are read in order.
+	read := 0
+	next := func() {
+		p, err := b.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		exp := read
+		if exp >= dropped {
+			exp++
+		}
+		if !reflect.DeepEqual(p, newTestPoint(exp)) {
+			t.Fatalf("unexpected point got %v exp %v", p, newTestPoint(exp))
+		}
+		read++
+	}
+
+	// Points keep arriving while the task is resumed,
+	// only the unread points count against the limit.
+	for ; i < 100; i++ {
+		next()
+		b.Add(newTestPoint(i))
+	}
+	if got := b.stats().Dropped; got != 1 {
+		t.Errorf("unexpected number of dropped points got %d exp 1", got)
+	}
+	if got := b.Len(); got != buffered {
+		t.Errorf("unexpected number of buffered points got %d exp %d", got, buffered)
+	}
+	for b.Len() > 0 {
+		next()
+	}
+
+	// The drained spool is emptied and is filled up again.
+	fi, err := os.Stat(b.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Size() != 0 {
+		t.Errorf("expected drained spool to be empty, got %d bytes", fi.Size())
+	}
+	b.Add(newTestPoint(i))
+	next()
+}
+
+func TestPauseBuffer_Drop(t *testing.T) {
+	b, err := openPauseBuffer("cpu", PauseModeDrop, "", 0, diag{t: t})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Add(newTestPoint(0))
+	b.Add(newTestPoint(1))
+	if p, err := b.Next(); err != nil || p != nil {
+		t.Errorf("expected empty buffer got %v %v", p, err)
+	}
+	exp := &client.PauseStats{Mode: PauseModeDrop, Dropped: 2}
+	if got := b.stats(); !reflect.DeepEqual(got, exp) {
+		t.Errorf("unexpected stats got %v exp %v", got, exp)
+	}
+	if err := b.remove(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckBufferedPoints(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	b, err := openPauseBuffer("cpu", PauseModeSpool, dir, 1024, diag{t: t})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.remove()
+	ts := &Service{
+		diag:   diag{t: t},
+		pauses: map[string]*pauseBuffer{"cpu": b},
+	}
+	testCases := []struct {
+		name     string
+		url      string
+		buffered int
+		code     int
+	}{
+		{name: "empty", url: "/tasks/cpu", code: 0},
+		{name: "buffered", url: "/tasks/cpu", buffered: 1, code: http.StatusConflict},
+		{name: "discard", url: "/tasks/cpu?discard=true", buffered: 1, code: 0},
+		{name: "invalid discard", url: "/tasks/cpu?discard=yes", buffered: 1, code: http.StatusBadRequest},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for b.Len() < int64(tc.buffered) {
+				b.Add(newTestPoint(int(b.Len())))
+			}
+			code, err := ts.checkBufferedPoints(httptest.NewRequest("DELETE", tc.url, nil), "cpu")
+			if code != tc.code {
+				t.Errorf("unexpected code got %d exp %d: %v", code, tc.code, err)
+			}
+			if (err != nil) != (tc.code != 0) {
+				t.Errorf("unexpected error %v", err)
+			}
+		})
+	}
+}
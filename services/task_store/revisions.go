@@ -0,0 +1,432 @@
+package task_store
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/thingnario/kapacitor/auth"
+	"github.com/thingnario/kapacitor/client/v1"
+	"github.com/thingnario/kapacitor/keyvalue"
+	"github.com/thingnario/kapacitor/services/httpd"
+)
+
+const (
+	revisionsPath = "revisions"
+	rollbackPath  = "rollback"
+)
+
+func taskRevision(t Task, number int, user string, created time.Time) Revision {
+	return Revision{
+		ID:         t.ID,
+		Number:     number,
+		Type:       t.Type,
+		TICKscript: t.TICKscript,
+		DBRPs:      t.DBRPs,
+		TemplateID: t.TemplateID,
+		Vars:       t.Vars,
+		User:       user,
+		Created:    created,
+	}
+}
+
+func templateRevision(t Template, number int, user string, created time.Time) Revision {
+	return Revision{
+		ID:         t.ID,
+		Number:     number,
+		Type:       t.Type,
+		TICKscript: t.TICKscript,
+		User:       user,
+		Created:    created,
+	}
+}
+
+// sameDefinition reports whether two revisions define the same task or template.
+func (r Revision) sameDefinition(o Revision) bool {
+	return r.Type == o.Type &&
+		r.TICKscript == o.TICKscript &&
+		r.TemplateID == o.TemplateID &&
+		reflect.DeepEqual(r.DBRPs, o.DBRPs) &&
+		(len(r.Vars) == 0 && len(o.Vars) == 0 || reflect.DeepEqual(r.Vars, o.Vars))
+}
+
+// recordRevision records the updated definition as a new revision if it differs from the latest revision.
+// Definitions that existed before revisions were kept get their original definition recorded first.
+// Failures are logged and do not fail the update since the task or template has already been saved.
+func (ts *Service) recordRevision(revisions RevisionDAO, original, updated Revision) {
+	latest, err := revisions.Latest(original.ID)
+	if err == ErrNoRevisionExists {
+		latest = original
+		latest.Number = 1
+		if err := revisions.Create(latest); err != nil {
+			ts.diag.Error("failed to save revision", err, keyvalue.KV("id", original.ID))
+			return
+		}
+	} else if err != nil {
+		ts.diag.Error("failed to get latest revision", err, keyvalue.KV("id", original.ID))
+		return
+	}
+	if original.ID != updated.ID {
+		offset, err := ts.moveRevisions(revisions, original.ID, updated.ID)
+		if err != nil {
+			ts.diag.Error("failed to move revisions during ID change", err,
+				keyvalue.KV("oldID", original.ID), keyvalue.KV("newID", updated.ID))
+			return
+		}
+		latest.Number += offset
+	}
+	if latest.sameDefinition(updated) {
+		return
+	}
+	updated.Number = latest.Number + 1
+	if err := revisions.Create(updated); err != nil {
+		ts.diag.Error("failed to save revision", err, keyvalue.KV("id", updated.ID))
+	}
+}
+
+// createRevision records the definition of a new task or template.
+// The revisions of a deleted task or template with the same ID are kept,
+// the new definition continues their numbering.
+func (ts *Service) createRevision(revisions RevisionDAO, r Revision) {
+	number, err := latestNumber(revisions, r.ID)
+	if err != nil {
+		ts.diag.Error("failed to get latest revision", err, keyvalue.KV("id", r.ID))
+		return
+	}
+	r.Number = number + 1
+	if err := revisions.Create(r); err != nil {
+		ts.diag.Error("failed to save revision", err, keyvalue.KV("id", r.ID))
+	}
+}
+
+// moveRevisions moves the revisions of oldID after the kept revisions of a deleted definition with newID.
+// It returns the number the moved revisions were renumbered by.
+func (ts *Service) moveRevisions(revisions RevisionDAO, oldID, newID string) (int, error) {
+	offset, err := latestNumber(revisions, newID)
+	if err != nil {
+		return 0, err
+	}
+	list, err := revisions.List(oldID, 0, -1)
+	if err != nil {
+		return 0, err
+	}
+	for _, r := range list {
+		r.ID = newID
+		r.Number += offset
+		if err := revisions.Create(r); err != nil {
+			return 0, err
+		}
+	}
+	return offset, revisions.DeleteAll(oldID)
+}
+
+// latestNumber returns the number of the latest revision of id, or 0 if it has no revisions.
+func latestNumber(revisions RevisionDAO, id string) (int, error) {
+	latest, err := revisions.Latest(id)
+	switch err {
+	case nil:
+		return latest.Number, nil
+	case ErrNoRevisionExists:
+		return 0, nil
+	default:
+		return 0, err
+	}
+}
+
+func (ts *Service) convertRevision(r Revision, base string) (client.Revision, error) {
+	var tt client.TaskType
+	switch r.Type {
+	case StreamTask:
+		tt = client.StreamTask
+	case BatchTask:
+		tt = client.BatchTask
+	}
+	var dbrps []client.DBRP
+	if len(r.DBRPs) > 0 {
+		dbrps = make([]client.DBRP, len(r.DBRPs))
+		for i, dbrp := range r.DBRPs {
+			dbrps[i] = client.DBRP{
+				Database:        dbrp.Database,
+				RetentionPolicy: dbrp.RetentionPolicy,
+			}
+		}
+	}
+	vars, err := ts.convertToClientVars(r.Vars)
+	if err != nil {
+		return client.Revision{}, err
+	}
+	return client.Revision{
+		Link: client.Link{
+			Relation: client.Self,
+			Href:     path.Join(httpd.BasePath, base, r.ID, revisionsPath, strconv.Itoa(r.Number)),
+		},
+		ID:         r.ID,
+		Number:     r.Number,
+		Type:       tt,
+		TemplateID: r.TemplateID,
+		DBRPs:      dbrps,
+		TICKscript: r.TICKscript,
+		Vars:       vars,
+		User:       r.User,
+		Created:    r.Created,
+	}, nil
+}
+
+// splitSubPath splits the path of a task or template into its ID and the path of a sub resource, i.e. cpu/revisions/2.
+func splitSubPath(p string) (string, string) {
+	parts := strings.SplitN(p, "/", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+// handleRevisions serves the revisions of a task or template.
+// The sub path is either "revisions" to list all revisions or "revisions/<n>" for a single revision.
+func (ts *Service) handleRevisions(w http.ResponseWriter, r *http.Request, revisions RevisionDAO, base, id, sub string) {
+	parts := strings.Split(sub, "/")
+	if parts[0] != revisionsPath || len(parts) > 2 {
+		httpd.HttpError(w, fmt.Sprintf("unknown path %q", r.URL.Path), true, http.StatusNotFound)
+		return
+	}
+	if len(parts) == 2 {
+		number, err := strconv.Atoi(parts[1])
+		if err != nil {
+			httpd.HttpError(w, fmt.Sprintf("invalid revision number %q", parts[1]), true, http.StatusBadRequest)
+			return
+		}
+		raw, err := revisions.Get(id, number)
+		if err != nil {
+			httpd.HttpError(w, err.Error(), true, http.StatusNotFound)
+			return
+		}
+		rev, err := ts.convertRevision(raw, base)
+		if err != nil {
+			httpd.HttpError(w, err.Error(), true, http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write(httpd.MarshalJSON(rev, true))
+		return
+	}
+
+	var err error
+	offset := int64(0)
+	offsetStr := r.URL.Query().Get("offset")
+	if offsetStr != "" {
+		offset, err = strconv.ParseInt(offsetStr, 10, 64)
+		if err != nil {
+			httpd.HttpError(w, fmt.Sprintf("invalid offset parameter %q must be an integer: %s", offsetStr, err), true, http.StatusBadRequest)
+			return
+		}
+	}
+
+	limit := int64(100)
+	limitStr := r.URL.Query().Get("limit")
+	if limitStr != "" {
+		limit, err = strconv.ParseInt(limitStr, 10, 64)
+		if err != nil {
+			httpd.HttpError(w, fmt.Sprintf("invalid limit parameter %q must be an integer: %s", limitStr, err), true, http.StatusBadRequest)
+			return
+		}
+	}
+
+	raw, err := revisions.List(id, int(offset), int(limit))
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusInternalServerError)
+		return
+	}
+	list := make([]client.Revision, len(raw))
+	for i, rev := range raw {
+		list[i], err = ts.convertRevision(rev, base)
+		if err != nil {
+			httpd.HttpError(w, err.Error(), true, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	type response struct {
+		Revisions []client.Revision `json:"revisions"`
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(httpd.MarshalJSON(response{list}, true))
+}
+
+// decodeRollback reads the revision to roll back to from the request.
+func decodeRollback(w http.ResponseWriter, r *http.Request) (int, bool) {
+	opt := client.RollbackOptions{}
+	if err := json.NewDecoder(r.Body).Decode(&opt); err != nil {
+		httpd.HttpError(w, "invalid JSON", true, http.StatusBadRequest)
+		return 0, false
+	}
+	if opt.Revision <= 0 {
+		httpd.HttpError(w, "must specify a revision", true, http.StatusBadRequest)
+		return 0, false
+	}
+	return opt.Revision, true
+}
+
+func (ts *Service) handleRollbackTask(w http.ResponseWriter, r *http.Request, user auth.User) {
+	id, err := ts.taskIDFromPath(r.URL.Path)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
+		return
+	}
+	id, sub := splitSubPath(id)
+	if sub != rollbackPath {
+		httpd.HttpError(w, fmt.Sprintf("unknown path %q", r.URL.Path), true, http.StatusNotFound)
+		return
+	}
+	number, ok := decodeRollback(w, r)
+	if !ok {
+		return
+	}
+
+	original, err := ts.tasks.Get(id)
+	if err != nil {
+		httpd.HttpError(w, "task does not exist, cannot rollback", true, http.StatusNotFound)
+		return
+	}
+	rev, err := ts.taskRevisions.Get(id, number)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusNotFound)
+		return
+	}
+
+	updated := original
+	updated.Type = rev.Type
+	updated.TICKscript = rev.TICKscript
+	updated.DBRPs = rev.DBRPs
+	updated.Vars = rev.Vars
+	updated.TemplateID = rev.TemplateID
+	if rev.TemplateID != "" {
+		// Templated tasks always use the current definition of their template.
+		template, err := ts.templates.Get(rev.TemplateID)
+		if err != nil {
+			httpd.HttpError(w, fmt.Sprintf("unknown template %s: err: %s", rev.TemplateID, err), true, http.StatusBadRequest)
+			return
+		}
+		updated.Type = template.Type
+		updated.TICKscript = template.TICKscript
+	}
+
+	// Validate task
+	if _, err := ts.newKapacitorTask(updated); err != nil {
+		httpd.HttpError(w, "invalid TICKscript: "+err.Error(), true, http.StatusBadRequest)
+		return
+	}
+
+	if original.TemplateID != updated.TemplateID {
+		if original.TemplateID != "" {
+			if err := ts.templates.DisassociateTask(original.TemplateID, original.ID); err != nil {
+				httpd.HttpError(w, fmt.Sprintf("failed to disassociate task with template: %s", err), true, http.StatusBadRequest)
+				return
+			}
+		}
+		if updated.TemplateID != "" {
+			if err := ts.templates.AssociateTask(updated.TemplateID, updated.ID); err != nil {
+				httpd.HttpError(w, fmt.Sprintf("failed to associate task with template: %s", err), true, http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	updated.Modified = time.Now()
+	if err := ts.tasks.Replace(updated); err != nil {
+		httpd.HttpError(w, fmt.Sprintf("failed to replace task definition: %s", err.Error()), true, http.StatusInternalServerError)
+		return
+	}
+	ts.recordRevision(ts.taskRevisions,
+		taskRevision(original, 0, "", original.Modified),
+		taskRevision(updated, 0, user.Name(), updated.Modified),
+	)
+
+	if updated.Status == Enabled {
+		// Reload the task with the restored definition
+		ts.stopTask(updated.ID)
+		if err := ts.startTask(updated); err != nil {
+			httpd.HttpError(w, err.Error(), true, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	t, err := ts.convertTask(updated, "formatted", "attributes", ts.TaskMasterLookup.Main())
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(httpd.MarshalJSON(t, true))
+}
+
+func (ts *Service) handleRollbackTemplate(w http.ResponseWriter, r *http.Request, user auth.User) {
+	id, err := ts.templateIDFromPath(r.URL.Path)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
+		return
+	}
+	id, sub := splitSubPath(id)
+	if sub != rollbackPath {
+		httpd.HttpError(w, fmt.Sprintf("unknown path %q", r.URL.Path), true, http.StatusNotFound)
+		return
+	}
+	number, ok := decodeRollback(w, r)
+	if !ok {
+		return
+	}
+
+	original, err := ts.templates.Get(id)
+	if err != nil {
+		httpd.HttpError(w, "template does not exist, cannot rollback", true, http.StatusNotFound)
+		return
+	}
+	rev, err := ts.templateRevisions.Get(id, number)
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusNotFound)
+		return
+	}
+
+	updated := original
+	updated.Type = rev.Type
+	updated.TICKscript = rev.TICKscript
+
+	// Validate template
+	if _, err := ts.templateTask(updated); err != nil {
+		httpd.HttpError(w, "invalid TICKscript: "+err.Error(), true, http.StatusBadRequest)
+		return
+	}
+
+	taskIds, err := ts.templates.ListAssociatedTasks(original.ID)
+	if err != nil {
+		httpd.HttpError(w, fmt.Sprintf("error getting associated tasks for template %s: %s", original.ID, err.Error()), true, http.StatusInternalServerError)
+		return
+	}
+
+	updated.Modified = time.Now()
+	if err := ts.templates.Replace(updated); err != nil {
+		httpd.HttpError(w, fmt.Sprintf("failed to replace template definition: %s", err.Error()), true, http.StatusInternalServerError)
+		return
+	}
+	ts.recordRevision(ts.templateRevisions,
+		templateRevision(original, 0, "", original.Modified),
+		templateRevision(updated, 0, user.Name(), updated.Modified),
+	)
+
+	if err := ts.updateAllAssociatedTasks(original, updated, taskIds); err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusInternalServerError)
+		return
+	}
+
+	t, err := ts.convertTemplate(updated, "formatted")
+	if err != nil {
+		httpd.HttpError(w, err.Error(), true, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(httpd.MarshalJSON(t, true))
+}
@@ -0,0 +1,161 @@
+package task_store
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/thingnario/kapacitor/keyvalue"
+	"github.com/thingnario/kapacitor/services/storage/storagetest"
+)
+
+type diag struct {
+	Diagnostic
+	t *testing.T
+}
+
+func (d diag) Error(msg string, err error, ctx ...keyvalue.T) {
+	d.t.Errorf("unexpected error %s: %v", msg, err)
+}
+
+func newRevisionService(t *testing.T) (*Service, RevisionDAO) {
+	revisions, err := newRevisionKV(storagetest.New().Store(taskNamespace), taskRevisionsPrefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Service{diag: diag{t: t}}, revisions
+}
+
+func TestRevisionKV(t *testing.T) {
+	_, revisions := newRevisionService(t)
+	for i := 1; i <= 11; i++ {
+		if err := revisions.Create(Revision{ID: "cpu", Number: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Revisions of a task whose ID shares a prefix must not be included.
+	if err := revisions.Create(Revision{ID: "cpu2", Number: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	latest, err := revisions.Latest("cpu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.Number != 11 {
+		t.Errorf("unexpected latest revision got %d exp 11", latest.Number)
+	}
+	list, err := revisions.List("cpu", 8, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var numbers []int
+	for _, r := range list {
+		numbers = append(numbers, r.Number)
+	}
+	if exp := []int{9, 10, 11}; !reflect.DeepEqual(numbers, exp) {
+		t.Errorf("unexpected revisions got %v exp %v", numbers, exp)
+	}
+
+	if err := revisions.DeleteAll("cpu"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := revisions.Latest("cpu"); err != ErrNoRevisionExists {
+		t.Errorf("unexpected error got %v exp %v", err, ErrNoRevisionExists)
+	}
+	if _, err := revisions.Get("cpu2", 1); err != nil {
+		t.Errorf("unexpected error getting revision of other task: %v", err)
+	}
+}
+
+func TestService_RecordRevision(t *testing.T) {
+	ts, revisions := newRevisionService(t)
+	created := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	original := Task{
+		ID:         "cpu",
+		Type:       StreamTask,
+		TICKscript: "stream|from()",
+		DBRPs:      []DBRP{{Database: "telegraf", RetentionPolicy: "autogen"}},
+		Modified:   created,
+	}
+
+	// Changing only the status does not record a revision,
+	// the original definition of a task that predates revisions is recorded.
+	updated := original
+	updated.Status = Enabled
+	ts.recordRevision(revisions, taskRevision(original, 0, "", original.Modified), taskRevision(updated, 0, "bob", time.Now()))
+	list, err := revisions.List("cpu", 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := []Revision{taskRevision(original, 1, "", created)}; !reflect.DeepEqual(list, exp) {
+		t.Fatalf("unexpected revisions:\ngot\n%+v\nexp\n%+v", list, exp)
+	}
+
+	// Changing the script records a revision
+	original = updated
+	updated.TICKscript = "stream|from().measurement('cpu')"
+	ts.recordRevision(revisions, taskRevision(original, 0, "", original.Modified), taskRevision(updated, 0, "bob", created))
+	latest, err := revisions.Latest("cpu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := taskRevision(updated, 2, "bob", created); !reflect.DeepEqual(latest, exp) {
+		t.Fatalf("unexpected latest revision:\ngot\n%+v\nexp\n%+v", latest, exp)
+	}
+
+	// Renaming a task moves its revisions
+	original = updated
+	updated.ID = "cpu_alert"
+	ts.recordRevision(revisions, taskRevision(original, 0, "", original.Modified), taskRevision(updated, 0, "bob", created))
+	if list, err := revisions.List("cpu", 0, -1); err != nil || len(list) != 0 {
+		t.Errorf("expected no revisions for old ID, got %v %v", list, err)
+	}
+	list, err = revisions.List("cpu_alert", 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].Number != 1 || list[1].Number != 2 || list[1].TICKscript != updated.TICKscript {
+		t.Errorf("unexpected revisions after rename: %+v", list)
+	}
+}
+
+func TestService_CreateRevisionKeepsDeletedRevisions(t *testing.T) {
+	ts, revisions := newRevisionService(t)
+	created := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	task := Task{
+		ID:         "cpu",
+		Type:       StreamTask,
+		TICKscript: "stream|from()",
+	}
+	ts.createRevision(revisions, taskRevision(task, 0, "bob", created))
+
+	// A task created again after it was deleted continues the numbering of its revisions.
+	task.TICKscript = "stream|from().measurement('cpu')"
+	ts.createRevision(revisions, taskRevision(task, 0, "alice", created))
+	list, err := revisions.List("cpu", 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].User != "bob" || list[1].Number != 2 || list[1].User != "alice" {
+		t.Fatalf("unexpected revisions after create: %+v", list)
+	}
+
+	// Renaming a task to the ID of a deleted task moves its revisions after the kept revisions.
+	other := Task{
+		ID:         "mem",
+		Type:       StreamTask,
+		TICKscript: "stream|from().measurement('mem')",
+	}
+	ts.createRevision(revisions, taskRevision(other, 0, "bob", created))
+	renamed := other
+	renamed.ID = "cpu"
+	ts.recordRevision(revisions, taskRevision(other, 0, "", created), taskRevision(renamed, 0, "bob", created))
+	list, err = revisions.List("cpu", 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 3 || list[2].Number != 3 || list[2].TICKscript != other.TICKscript {
+		t.Errorf("unexpected revisions after rename: %+v", list)
+	}
+}
@@ -10,13 +10,16 @@ import (
 	"path/filepath"
 	"regexp"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/boltdb/bolt"
 	"github.com/thingnario/kapacitor"
+	"github.com/thingnario/kapacitor/auth"
 	"github.com/thingnario/kapacitor/client/v1"
 	"github.com/thingnario/kapacitor/keyvalue"
 	"github.com/thingnario/kapacitor/server/vars"
+	alertservice "github.com/thingnario/kapacitor/services/alert"
 	"github.com/thingnario/kapacitor/services/httpd"
 	"github.com/thingnario/kapacitor/services/storage"
 	"github.com/thingnario/kapacitor/tick"
@@ -45,16 +48,22 @@ type Diagnostic interface {
 
 	AlreadyMigrated(entity, id string)
 	Migrated(entity, id string)
+
+	LoopbackCycle(taskID string, cycle []string)
+
+	DiscardedPausedPoints(taskID string, count int64)
 }
 
 type Service struct {
-	oldDBDir         string
-	tasks            TaskDAO
-	templates        TemplateDAO
-	snapshots        SnapshotDAO
-	routes           []httpd.Route
-	snapshotInterval time.Duration
-	StorageService   interface {
+	oldDBDir          string
+	tasks             TaskDAO
+	templates         TemplateDAO
+	snapshots         SnapshotDAO
+	taskRevisions     RevisionDAO
+	templateRevisions RevisionDAO
+	routes            []httpd.Route
+	snapshotInterval  time.Duration
+	StorageService    interface {
 		Store(namespace string) storage.Interface
 		Register(name string, store storage.StoreActioner)
 	}
@@ -68,6 +77,19 @@ type Service struct {
 		Set(*kapacitor.TaskMaster)
 		Delete(*kapacitor.TaskMaster)
 	}
+	AlertService interface {
+		AllHandlerSpecs() []alertservice.HandlerSpec
+	}
+
+	pauseMode    string
+	spoolDir     string
+	maxSpoolSize int64
+	// Buffers of the paused tasks
+	pauses   map[string]*pauseBuffer
+	pausesMu sync.Mutex
+
+	// Links of the tasks in the task graph
+	graphLinks linksCache
 
 	diag Diagnostic
 }
@@ -81,6 +103,10 @@ type taskStore struct {
 func NewService(conf Config, d Diagnostic) *Service {
 	return &Service{
 		snapshotInterval: time.Duration(conf.SnapshotInterval),
+		pauseMode:        conf.PauseMode,
+		spoolDir:         conf.SpoolDir,
+		maxSpoolSize:     int64(conf.MaxSpoolSize),
+		pauses:           make(map[string]*pauseBuffer),
 		diag:             d,
 		oldDBDir:         conf.Dir,
 	}
@@ -91,6 +117,9 @@ const (
 	tasksAPIName = "tasks"
 	// The storage namespace for all task data.
 	taskNamespace = "task_store"
+	// Key prefixes for the revisions of tasks and templates.
+	taskRevisionsPrefix     = "task_revisions"
+	templateRevisionsPrefix = "template_revisions"
 )
 
 func (ts *Service) Open() error {
@@ -104,6 +133,16 @@ func (ts *Service) Open() error {
 	ts.StorageService.Register(tasksAPIName, ts.tasks)
 	ts.templates = newTemplateKV(store)
 	ts.snapshots = newSnapshotKV(store)
+	taskRevisions, err := newRevisionKV(store, taskRevisionsPrefix)
+	if err != nil {
+		return err
+	}
+	ts.taskRevisions = taskRevisions
+	templateRevisions, err := newRevisionKV(store, templateRevisionsPrefix)
+	if err != nil {
+		return err
+	}
+	ts.templateRevisions = templateRevisions
 
 	// Perform migration to new storage service.
 	if err := ts.migrate(); err != nil {
@@ -133,6 +172,11 @@ func (ts *Service) Open() error {
 			Pattern:     tasksPathAnchored,
 			HandlerFunc: ts.handleUpdateTask,
 		},
+		{
+			Method:      "POST",
+			Pattern:     tasksPathAnchored,
+			HandlerFunc: ts.handleRollbackTask,
+		},
 		{
 			Method:      "GET",
 			Pattern:     tasksPath,
@@ -143,6 +187,11 @@ func (ts *Service) Open() error {
 			Pattern:     tasksPath,
 			HandlerFunc: ts.handleCreateTask,
 		},
+		{
+			Method:      "POST",
+			Pattern:     tasksValidatePath,
+			HandlerFunc: ts.handleValidateTask,
+		},
 		{
 			Method:      "GET",
 			Pattern:     templatesPathAnchored,
@@ -164,6 +213,11 @@ func (ts *Service) Open() error {
 			Pattern:     templatesPathAnchored,
 			HandlerFunc: ts.handleUpdateTemplate,
 		},
+		{
+			Method:      "POST",
+			Pattern:     templatesPathAnchored,
+			HandlerFunc: ts.handleRollbackTemplate,
+		},
 		{
 			Method:      "GET",
 			Pattern:     templatesPath,
@@ -174,6 +228,11 @@ func (ts *Service) Open() error {
 			Pattern:     templatesPath,
 			HandlerFunc: ts.handleCreateTemplate,
 		},
+		{
+			Method:      "GET",
+			Pattern:     graphPath,
+			HandlerFunc: ts.handleGraph,
+		},
 	}
 
 	err = ts.HTTPDService.AddRoutes(ts.routes)
@@ -376,6 +435,7 @@ func (ts *Service) migrate() error {
 
 func (ts *Service) Close() error {
 	ts.HTTPDService.DelRoutes(ts.routes)
+	ts.closePauseBuffers()
 	return nil
 }
 
@@ -387,9 +447,32 @@ func (ts *Service) Load(id string) (*kapacitor.Task, error) {
 	return ts.newKapacitorTask(t)
 }
 
+// TaskDefinition returns the TICKscript and vars of a task.
+func (ts *Service) TaskDefinition(id string) (string, client.Vars, error) {
+	t, err := ts.tasks.Get(id)
+	if err != nil {
+		return "", nil, err
+	}
+	vars, err := ts.convertToClientVars(t.Vars)
+	if err != nil {
+		return "", nil, err
+	}
+	return t.TICKscript, vars, nil
+}
+
+// TemplateDefinition returns the TICKscript of a template.
+func (ts *Service) TemplateDefinition(id string) (string, error) {
+	t, err := ts.templates.Get(id)
+	if err != nil {
+		return "", err
+	}
+	return t.TICKscript, nil
+}
+
 func (ts *Service) SaveSnapshot(id string, snapshot *kapacitor.TaskSnapshot) error {
 	s := &Snapshot{
 		NodeSnapshots: snapshot.NodeSnapshots,
+		PipelineHash:  snapshot.PipelineHash,
 	}
 	return ts.snapshots.Put(id, s)
 }
@@ -410,6 +493,7 @@ func (ts *Service) LoadSnapshot(id string) (*kapacitor.TaskSnapshot, error) {
 	}
 	s := &kapacitor.TaskSnapshot{
 		NodeSnapshots: snapshot.NodeSnapshots,
+		PipelineHash:  snapshot.PipelineHash,
 	}
 	return s, nil
 }
@@ -433,6 +517,11 @@ func (ts *Service) handleTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if id, sub := splitSubPath(id); sub != "" {
+		ts.handleRevisions(w, r, ts.taskRevisions, tasksPath, id, sub)
+		return
+	}
+
 	raw, err := ts.tasks.Get(id)
 	if err != nil {
 		httpd.HttpError(w, err.Error(), true, http.StatusNotFound)
@@ -641,12 +730,11 @@ func (ts *Service) handleListTasks(w http.ResponseWriter, r *http.Request) {
 			case "error":
 				value = task.Error
 			case "status":
-				switch task.Status {
-				case Disabled:
-					value = client.Disabled
-				case Enabled:
-					value = client.Enabled
+				if status, err := clientStatus(task); err == nil {
+					value = status
 				}
+			case "pause":
+				value = ts.pauseStats(task.ID)
 			case "created":
 				value = task.Created
 			case "modified":
@@ -677,7 +765,7 @@ func (ts *Service) handleListTasks(w http.ResponseWriter, r *http.Request) {
 
 var validTaskID = regexp.MustCompile(`^[-\._\p{L}0-9]+$`)
 
-func (ts *Service) handleCreateTask(w http.ResponseWriter, r *http.Request) {
+func (ts *Service) handleCreateTask(w http.ResponseWriter, r *http.Request, user auth.User) {
 	task := client.CreateTaskOptions{}
 	dec := json.NewDecoder(r.Body)
 	err := dec.Decode(&task)
@@ -755,6 +843,9 @@ func (ts *Service) handleCreateTask(w http.ResponseWriter, r *http.Request) {
 	switch task.Status {
 	case client.Enabled:
 		newTask.Status = Enabled
+	case client.Paused:
+		newTask.Status = Enabled
+		newTask.Paused = true
 	case client.Disabled:
 		newTask.Status = Disabled
 	default:
@@ -784,6 +875,10 @@ func (ts *Service) handleCreateTask(w http.ResponseWriter, r *http.Request) {
 		httpd.HttpError(w, fmt.Sprintf("invalid task type: %v", tt), true, http.StatusBadRequest)
 		return
 	}
+	if newTask.Paused && newTask.Type != StreamTask {
+		httpd.HttpError(w, "only stream tasks can be paused", true, http.StatusBadRequest)
+		return
+	}
 
 	// Validate task
 	_, err = ts.newKapacitorTask(newTask)
@@ -827,6 +922,7 @@ func (ts *Service) handleCreateTask(w http.ResponseWriter, r *http.Request) {
 		httpd.HttpError(w, err.Error(), true, http.StatusInternalServerError)
 		return
 	}
+	ts.createRevision(ts.taskRevisions, taskRevision(newTask, 1, user.Name(), now))
 
 	// Count new task
 	vars.NumTasksVar.Add(1)
@@ -847,11 +943,12 @@ func (ts *Service) handleCreateTask(w http.ResponseWriter, r *http.Request) {
 		httpd.HttpError(w, err.Error(), true, http.StatusInternalServerError)
 		return
 	}
+	t.Warnings = ts.loopbackCycleWarnings(newTask)
 	w.WriteHeader(http.StatusOK)
 	w.Write(httpd.MarshalJSON(t, true))
 }
 
-func (ts *Service) handleUpdateTask(w http.ResponseWriter, r *http.Request) {
+func (ts *Service) handleUpdateTask(w http.ResponseWriter, r *http.Request, user auth.User) {
 	id, err := ts.taskIDFromPath(r.URL.Path)
 	if err != nil {
 		httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
@@ -871,6 +968,13 @@ func (ts *Service) handleUpdateTask(w http.ResponseWriter, r *http.Request) {
 		httpd.HttpError(w, "task does not exist, cannot update", true, http.StatusNotFound)
 		return
 	}
+	if (task.ID != "" && task.ID != original.ID) || task.Status == client.Disabled {
+		// Renaming or disabling the task drops the points buffered while it was paused.
+		if code, err := ts.checkBufferedPoints(r, original.ID); err != nil {
+			httpd.HttpError(w, err.Error(), true, code)
+			return
+		}
+	}
 	updated := original
 
 	// Set ID if changing
@@ -966,13 +1070,20 @@ func (ts *Service) handleUpdateTask(w http.ResponseWriter, r *http.Request) {
 
 	// Set status
 	previousStatus := updated.Status
+	previouslyPaused := updated.Paused
 	switch task.Status {
 	case client.Enabled:
 		updated.Status = Enabled
+		updated.Paused = false
+	case client.Paused:
+		updated.Status = Enabled
+		updated.Paused = true
 	case client.Disabled:
 		updated.Status = Disabled
+		updated.Paused = false
 	}
 	statusChanged := previousStatus != updated.Status
+	pauseChanged := previouslyPaused != updated.Paused
 
 	// Set vars
 	if len(task.Vars) > 0 {
@@ -993,6 +1104,10 @@ func (ts *Service) handleUpdateTask(w http.ResponseWriter, r *http.Request) {
 		httpd.HttpError(w, fmt.Sprintf("invalid task type: %v", tt), true, http.StatusBadRequest)
 		return
 	}
+	if updated.Paused && updated.Type != StreamTask {
+		httpd.HttpError(w, "only stream tasks can be paused", true, http.StatusBadRequest)
+		return
+	}
 
 	// Validate task
 	_, err = ts.newKapacitorTask(updated)
@@ -1024,6 +1139,7 @@ func (ts *Service) handleUpdateTask(w http.ResponseWriter, r *http.Request) {
 		if original.Status == Enabled && updated.Status == Enabled {
 			// Stop task and start it under new name
 			ts.stopTask(original.ID)
+			ts.discardPause(original.ID)
 			if err := ts.startTask(updated); err != nil {
 				httpd.HttpError(w, err.Error(), true, http.StatusInternalServerError)
 				return
@@ -1035,6 +1151,10 @@ func (ts *Service) handleUpdateTask(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
+	ts.recordRevision(ts.taskRevisions,
+		taskRevision(original, 0, "", original.Modified),
+		taskRevision(updated, 0, user.Name(), now),
+	)
 
 	if statusChanged {
 		// Enable/Disable task
@@ -1049,6 +1169,18 @@ func (ts *Service) handleUpdateTask(w http.ResponseWriter, r *http.Request) {
 		case Disabled:
 			vars.NumEnabledTasksVar.Add(-1)
 			ts.stopTask(original.ID)
+			ts.discardPause(original.ID)
+		}
+	} else if pauseChanged && updated.Status == Enabled {
+		// Pause or resume the executing task
+		if updated.Paused {
+			err = ts.pauseTask(updated)
+		} else {
+			err = ts.resumeTask(updated.ID)
+		}
+		if err != nil {
+			httpd.HttpError(w, err.Error(), true, http.StatusInternalServerError)
+			return
 		}
 	}
 
@@ -1057,6 +1189,7 @@ func (ts *Service) handleUpdateTask(w http.ResponseWriter, r *http.Request) {
 		httpd.HttpError(w, err.Error(), true, http.StatusInternalServerError)
 		return
 	}
+	t.Warnings = ts.loopbackCycleWarnings(updated)
 	w.WriteHeader(http.StatusOK)
 	w.Write(httpd.MarshalJSON(t, true))
 }
@@ -1095,14 +1228,9 @@ func (ts *Service) convertTask(t Task, scriptFormat, dotView string, tm *kapacit
 		errMsg = err.Error()
 	}
 
-	var status client.TaskStatus
-	switch t.Status {
-	case Disabled:
-		status = client.Disabled
-	case Enabled:
-		status = client.Enabled
-	default:
-		return client.Task{}, fmt.Errorf("invalid task status %v", t.Status)
+	status, err := clientStatus(t)
+	if err != nil {
+		return client.Task{}, err
 	}
 
 	var typ client.TaskType
@@ -1143,6 +1271,7 @@ func (ts *Service) convertTask(t Task, scriptFormat, dotView string, tm *kapacit
 		Created:        t.Created,
 		Modified:       t.Modified,
 		LastEnabled:    t.LastEnabled,
+		Pause:          ts.pauseStats(t.ID),
 		Error:          errMsg,
 	}, nil
 }
@@ -1396,12 +1525,26 @@ func (ts *Service) convertToTickVarsFromService(svars map[string]Var) (map[strin
 	return vars, nil
 }
 
+// ConvertToTickVars converts vars of the HTTP API into the vars a task is created with.
+func ConvertToTickVars(cvars client.Vars) (map[string]tick.Var, error) {
+	ts := new(Service)
+	svars, err := ts.convertToServiceVars(cvars)
+	if err != nil {
+		return nil, err
+	}
+	return ts.convertToTickVarsFromService(svars)
+}
+
 func (ts *Service) handleDeleteTask(w http.ResponseWriter, r *http.Request) {
 	id, err := ts.taskIDFromPath(r.URL.Path)
 	if err != nil {
 		httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
 		return
 	}
+	if code, err := ts.checkBufferedPoints(r, id); err != nil {
+		httpd.HttpError(w, err.Error(), true, code)
+		return
+	}
 
 	err = ts.deleteTask(id)
 	if err != nil {
@@ -1412,13 +1555,12 @@ func (ts *Service) handleDeleteTask(w http.ResponseWriter, r *http.Request) {
 }
 
 func (ts *Service) deleteTask(id string) error {
-	// Delete associated snapshot
-	ts.snapshots.Delete(id)
-
 	// Delete task object
 	task, err := ts.tasks.Get(id)
 	if err != nil {
 		if err == ErrNoTaskExists {
+			// Delete associated snapshot
+			ts.snapshots.Delete(id)
 			return nil
 		}
 		return err
@@ -1433,6 +1575,14 @@ func (ts *Service) deleteTask(id string) error {
 	if task.Status == Enabled {
 		vars.NumEnabledTasksVar.Add(-1)
 		ts.TaskMasterLookup.Main().DeleteTask(id)
+		ts.discardPause(id)
+	}
+	// Delete associated snapshot,
+	// after the task is stopped since a stopping task saves a final snapshot.
+	ts.snapshots.Delete(id)
+	// Delete persisted node state, the task may not have run since a restart.
+	if err := ts.TaskMasterLookup.Main().DeleteTaskState(id); err != nil {
+		ts.diag.Error("failed to delete node state of task", err, keyvalue.KV("task", id))
 	}
 	return ts.tasks.Delete(id)
 }
@@ -1490,6 +1640,11 @@ func (ts *Service) handleTemplate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if id, sub := splitSubPath(id); sub != "" {
+		ts.handleRevisions(w, r, ts.templateRevisions, templatesPath, id, sub)
+		return
+	}
+
 	raw, err := ts.templates.Get(id)
 	if err != nil {
 		httpd.HttpError(w, err.Error(), true, http.StatusNotFound)
@@ -1652,7 +1807,7 @@ func (ts *Service) handleListTemplates(w http.ResponseWriter, r *http.Request) {
 
 var validTemplateID = regexp.MustCompile(`^[-\._\p{L}0-9]+$`)
 
-func (ts *Service) handleCreateTemplate(w http.ResponseWriter, r *http.Request) {
+func (ts *Service) handleCreateTemplate(w http.ResponseWriter, r *http.Request, user auth.User) {
 	template := client.CreateTemplateOptions{}
 	dec := json.NewDecoder(r.Body)
 	err := dec.Decode(&template)
@@ -1720,6 +1875,7 @@ func (ts *Service) handleCreateTemplate(w http.ResponseWriter, r *http.Request)
 		httpd.HttpError(w, err.Error(), true, http.StatusInternalServerError)
 		return
 	}
+	ts.createRevision(ts.templateRevisions, templateRevision(newTemplate, 1, user.Name(), now))
 
 	// Return template definition
 	t, err := ts.convertTemplate(newTemplate, "formatted")
@@ -1731,7 +1887,7 @@ func (ts *Service) handleCreateTemplate(w http.ResponseWriter, r *http.Request)
 	w.Write(httpd.MarshalJSON(t, true))
 }
 
-func (ts *Service) handleUpdateTemplate(w http.ResponseWriter, r *http.Request) {
+func (ts *Service) handleUpdateTemplate(w http.ResponseWriter, r *http.Request, user auth.User) {
 	id, err := ts.templateIDFromPath(r.URL.Path)
 	if err != nil {
 		httpd.HttpError(w, err.Error(), true, http.StatusBadRequest)
@@ -1804,6 +1960,10 @@ func (ts *Service) handleUpdateTemplate(w http.ResponseWriter, r *http.Request)
 			return
 		}
 	}
+	ts.recordRevision(ts.templateRevisions,
+		templateRevision(original, 0, "", original.Modified),
+		templateRevision(updated, 0, user.Name(), now),
+	)
 
 	// Update all associated tasks
 	err = ts.updateAllAssociatedTasks(original, updated, taskIds)
@@ -1994,6 +2154,14 @@ func (ts *Service) startTask(task Task) error {
 	// Starting task, remove last error
 	ts.saveLastError(t.ID, "")
 
+	if task.Paused {
+		// Pause before starting so no point reaches the task
+		if err := ts.pauseTask(task); err != nil {
+			ts.saveLastError(t.ID, err.Error())
+			return err
+		}
+	}
+
 	tm := ts.TaskMasterLookup.Main()
 	// Start the task
 	et, err := tm.StartTask(t)
@@ -0,0 +1,115 @@
+package task_store
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/thingnario/kapacitor/client/v1"
+	"github.com/thingnario/kapacitor/services/httpd"
+)
+
+const (
+	tasksValidatePath = tasksPath + "/validate"
+	// ID of validated tasks, it is only used for the DOT graph unless the request provides one.
+	validateTaskID = "validate"
+)
+
+// The tick parser and evaluator embed the position of errors in their messages.
+var errorPosition = regexp.MustCompile(`line (\d+) char (\d+)`)
+
+func validationError(err error) client.ValidationError {
+	e := client.ValidationError{
+		Message: err.Error(),
+	}
+	if m := errorPosition.FindStringSubmatch(e.Message); m != nil {
+		e.Line, _ = strconv.Atoi(m[1])
+		e.Char, _ = strconv.Atoi(m[2])
+	}
+	return e
+}
+
+func (ts *Service) handleValidateTask(w http.ResponseWriter, r *http.Request) {
+	opt := client.ValidateTaskOptions{}
+	if err := json.NewDecoder(r.Body).Decode(&opt); err != nil {
+		httpd.HttpError(w, "invalid JSON", true, http.StatusBadRequest)
+		return
+	}
+	result := ts.validateTask(opt)
+	w.WriteHeader(http.StatusOK)
+	w.Write(httpd.MarshalJSON(result, true))
+}
+
+// validateTask compiles a task the same way as it would be created without storing or starting it.
+func (ts *Service) validateTask(opt client.ValidateTaskOptions) client.ValidateTaskResult {
+	result := client.ValidateTaskResult{}
+	invalid := func(err error) client.ValidateTaskResult {
+		result.Errors = append(result.Errors, validationError(err))
+		return result
+	}
+
+	task := Task{
+		ID:         opt.ID,
+		TICKscript: opt.TICKscript,
+		TemplateID: opt.TemplateID,
+	}
+	if task.ID == "" {
+		task.ID = validateTaskID
+	}
+	if task.TemplateID != "" {
+		template, err := ts.templates.Get(task.TemplateID)
+		if err != nil {
+			return invalid(fmt.Errorf("unknown template %s: err: %s", task.TemplateID, err))
+		}
+		task.TICKscript = template.TICKscript
+	}
+	if task.TICKscript == "" {
+		return invalid(fmt.Errorf("must provide TICKscript or template ID"))
+	}
+
+	pn, err := newProgramNodeFromTickscript(task.TICKscript)
+	if err != nil {
+		return invalid(err)
+	}
+	switch tt := taskTypeFromProgram(pn); tt {
+	case client.StreamTask:
+		task.Type = StreamTask
+	case client.BatchTask:
+		task.Type = BatchTask
+	default:
+		return invalid(fmt.Errorf("invalid task type: %v", tt))
+	}
+	result.Type = taskTypeFromProgram(pn)
+
+	dbrps := dbrpsFromProgram(pn)
+	switch {
+	case len(dbrps) > 0 && len(opt.DBRPs) > 0:
+		return invalid(fmt.Errorf("cannot specify dbrp in both implicitly and explicitly"))
+	case len(dbrps) == 0 && len(opt.DBRPs) == 0:
+		return invalid(fmt.Errorf("must specify dbrp"))
+	case len(dbrps) == 0:
+		dbrps = opt.DBRPs
+	}
+	result.DBRPs = dbrps
+	for _, dbrp := range dbrps {
+		task.DBRPs = append(task.DBRPs, DBRP{
+			Database:        dbrp.Database,
+			RetentionPolicy: dbrp.RetentionPolicy,
+		})
+	}
+
+	task.Vars, err = ts.convertToServiceVars(opt.Vars)
+	if err != nil {
+		return invalid(err)
+	}
+
+	kt, err := ts.newKapacitorTask(task)
+	if err != nil {
+		return invalid(err)
+	}
+	result.Valid = true
+	result.Dot = string(kt.Dot())
+	return result
+}
@@ -0,0 +1,43 @@
+package task_store
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/thingnario/kapacitor/client/v1"
+)
+
+func TestValidationError(t *testing.T) {
+	testCases := []struct {
+		err error
+		exp client.ValidationError
+	}{
+		{
+			err: errors.New(`invalid TICKscript: parser: unexpected ) line 4 char 34 in "var period)". expected: "identifier"`),
+			exp: client.ValidationError{
+				Message: `invalid TICKscript: parser: unexpected ) line 4 char 34 in "var period)". expected: "identifier"`,
+				Line:    4,
+				Char:    34,
+			},
+		},
+		{
+			err: errors.New(`line 2 char 6: no method or property "foo" on *pipeline.StreamNode`),
+			exp: client.ValidationError{
+				Message: `line 2 char 6: no method or property "foo" on *pipeline.StreamNode`,
+				Line:    2,
+				Char:    6,
+			},
+		},
+		{
+			err: errors.New("must specify dbrp"),
+			exp: client.ValidationError{
+				Message: "must specify dbrp",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		if got := validationError(tc.err); got != tc.exp {
+			t.Errorf("unexpected validation error:\ngot\n%+v\nexp\n%+v", got, tc.exp)
+		}
+	}
+}
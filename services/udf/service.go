@@ -1,13 +1,16 @@
 package udf
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"sync"
 	"time"
 
 	"github.com/thingnario/kapacitor"
 	"github.com/thingnario/kapacitor/command"
+	"github.com/thingnario/kapacitor/services/blob"
 	"github.com/thingnario/kapacitor/udf"
 )
 
@@ -22,6 +25,13 @@ type Service struct {
 	infos   map[string]udf.Info
 	diag    Diagnostic
 	mu      sync.RWMutex
+
+	BlobService interface {
+		Create(r io.Reader) (blob.Blob, error)
+		Tag(name, id string) (blob.Tag, error)
+		Resolve(name string) (string, error)
+		Read(id string) ([]byte, error)
+	}
 }
 
 func NewService(c Config, d Diagnostic) *Service {
@@ -72,15 +82,21 @@ func (s *Service) Create(
 	if !ok {
 		return nil, fmt.Errorf("no such UDF %s", name)
 	}
+	var blobStore udf.BlobStore
+	if s.BlobService != nil {
+		blobStore = blobStoreAdapter{s: s}
+	}
 	if conf.Socket != "" {
 		// Create socket UDF
-		return kapacitor.NewUDFSocket(
+		u := kapacitor.NewUDFSocket(
 			taskID, nodeID,
 			kapacitor.NewSocketConn(conf.Socket),
 			d,
 			time.Duration(conf.Timeout),
 			abortCallback,
-		), nil
+		)
+		u.BlobStore = blobStore
+		return u, nil
 	} else {
 		// Create process UDF
 		env := os.Environ()
@@ -92,14 +108,16 @@ func (s *Service) Create(
 			Args: conf.Args,
 			Env:  env,
 		}
-		return kapacitor.NewUDFProcess(
+		u := kapacitor.NewUDFProcess(
 			taskID, nodeID,
 			command.ExecCommander,
 			cmdSpec,
 			d,
 			time.Duration(conf.Timeout),
 			abortCallback,
-		), nil
+		)
+		u.BlobStore = blobStore
+		return u, nil
 	}
 }
 
@@ -134,3 +152,39 @@ func (s *Service) loadUDFInfo(name string) (udf.Info, error) {
 	}
 	return info, nil
 }
+
+// blobStoreAdapter answers the blob requests of UDFs using the blob service.
+type blobStoreAdapter struct {
+	s *Service
+}
+
+func (a blobStoreAdapter) SaveBlob(data []byte, tag string) (string, error) {
+	b, err := a.s.BlobService.Create(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	if tag != "" {
+		if _, err := a.s.BlobService.Tag(tag, b.ID); err != nil {
+			return "", err
+		}
+	}
+	return b.ID, nil
+}
+
+func (a blobStoreAdapter) GetBlob(id, tag string) (string, []byte, error) {
+	if id == "" {
+		if tag == "" {
+			return "", nil, fmt.Errorf("one of blob ID or tag must be specified")
+		}
+		var err error
+		id, err = a.s.BlobService.Resolve(tag)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+	data, err := a.s.BlobService.Read(id)
+	if err != nil {
+		return "", nil, err
+	}
+	return id, data, nil
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/thingnario/kapacitor/edge"
+	"github.com/thingnario/kapacitor/keyvalue"
 	"github.com/thingnario/kapacitor/pipeline"
 	"github.com/thingnario/kapacitor/tick/ast"
 	"github.com/thingnario/kapacitor/tick/stateful"
@@ -13,6 +14,9 @@ import (
 type stateTracker interface {
 	track(t time.Time, inState bool) interface{}
 	reset()
+
+	snapshot() ([]byte, error)
+	restore(data []byte) error
 }
 
 type stateTrackingGroup struct {
@@ -29,9 +33,14 @@ type StateTrackingNode struct {
 	scopePool stateful.ScopePool
 
 	newTracker func() stateTracker
+
+	groups groupCheckpoint
 }
 
-func (n *StateTrackingNode) runStateTracking(_ []byte) error {
+func (n *StateTrackingNode) runStateTracking(snapshot []byte) error {
+	if err := n.groups.restore(snapshot); err != nil {
+		n.diag.Error("failed to restore node snapshot", err)
+	}
 	consumer := edge.NewGroupedConsumer(
 		n.ins[0],
 		n,
@@ -41,12 +50,20 @@ func (n *StateTrackingNode) runStateTracking(_ []byte) error {
 }
 
 func (n *StateTrackingNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
+	g, err := n.groups.add(group.ID, n.newGroup())
+	if err != nil {
+		n.diag.Error("failed to restore group state", err, keyvalue.KV("group", string(group.ID)))
+	}
 	return edge.NewReceiverFromForwardReceiverWithStats(
 		n.outs,
-		edge.NewTimedForwardReceiver(n.timer, n.newGroup()),
+		edge.NewTimedForwardReceiver(n.timer, g),
 	), nil
 }
 
+func (n *StateTrackingNode) snapshot() ([]byte, error) {
+	return n.groups.snapshot()
+}
+
 func (n *StateTrackingNode) newGroup() *stateTrackingGroup {
 	// Create a new tracking group
 	g := &stateTrackingGroup{
@@ -108,6 +125,14 @@ func (g *stateTrackingGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Messag
 }
 func (g *stateTrackingGroup) Done() {}
 
+func (g *stateTrackingGroup) snapshotState() ([]byte, error) {
+	return g.tracker.snapshot()
+}
+
+func (g *stateTrackingGroup) restoreState(data []byte) error {
+	return g.tracker.restore(data)
+}
+
 type stateDurationTracker struct {
 	sd *pipeline.StateDurationNode
 
@@ -130,6 +155,23 @@ func (sdt *stateDurationTracker) track(t time.Time, inState bool) interface{} {
 	return float64(t.Sub(sdt.startTime)) / float64(sdt.sd.Unit)
 }
 
+type stateDurationState struct {
+	StartTime time.Time
+}
+
+func (sdt *stateDurationTracker) snapshot() ([]byte, error) {
+	return encodeState(stateDurationState{StartTime: sdt.startTime})
+}
+
+func (sdt *stateDurationTracker) restore(data []byte) error {
+	var state stateDurationState
+	if err := decodeState(data, &state); err != nil {
+		return err
+	}
+	sdt.startTime = state.StartTime
+	return nil
+}
+
 func newStateDurationNode(et *ExecutingTask, sd *pipeline.StateDurationNode, d NodeDiagnostic) (*StateTrackingNode, error) {
 	if sd.Lambda == nil {
 		return nil, fmt.Errorf("nil expression passed to StateDurationNode")
@@ -168,6 +210,23 @@ func (sct *stateCountTracker) track(t time.Time, inState bool) interface{} {
 	return sct.count
 }
 
+type stateCountState struct {
+	Count int64
+}
+
+func (sct *stateCountTracker) snapshot() ([]byte, error) {
+	return encodeState(stateCountState{Count: sct.count})
+}
+
+func (sct *stateCountTracker) restore(data []byte) error {
+	var state stateCountState
+	if err := decodeState(data, &state); err != nil {
+		return err
+	}
+	sct.count = state.Count
+	return nil
+}
+
 func newStateCountNode(et *ExecutingTask, sc *pipeline.StateCountNode, d NodeDiagnostic) (*StateTrackingNode, error) {
 	if sc.Lambda == nil {
 		return nil, fmt.Errorf("nil expression passed to StateCountNode")
@@ -0,0 +1,361 @@
+package kapacitor
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/thingnario/kapacitor/edge"
+	"github.com/thingnario/kapacitor/expvar"
+	"github.com/thingnario/kapacitor/keyvalue"
+	"github.com/thingnario/kapacitor/models"
+	"github.com/thingnario/kapacitor/pipeline"
+	"github.com/thingnario/kapacitor/tick/ast"
+	"github.com/thingnario/kapacitor/tick/stateful"
+)
+
+type StateWindowNode struct {
+	node
+	s *pipeline.StateWindowNode
+
+	openExpr      stateful.Expression
+	openScopePool stateful.ScopePool
+
+	closeExpr      stateful.Expression
+	closeScopePool stateful.ScopePool
+
+	groups groupCheckpoint
+}
+
+// Create a new StateWindowNode, which windows data while a group is in a state.
+func newStateWindowNode(et *ExecutingTask, s *pipeline.StateWindowNode, d NodeDiagnostic) (*StateWindowNode, error) {
+	if s.OpenWhen == nil || s.CloseWhen == nil {
+		return nil, errors.New("state window node must have openWhen and closeWhen expressions")
+	}
+	openExpr, err := stateful.NewExpression(s.OpenWhen.Expression)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile openWhen expression: %v", err)
+	}
+	closeExpr, err := stateful.NewExpression(s.CloseWhen.Expression)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile closeWhen expression: %v", err)
+	}
+	n := &StateWindowNode{
+		node:           node{Node: s, et: et, diag: d},
+		s:              s,
+		openExpr:       openExpr,
+		openScopePool:  stateful.NewScopePool(ast.FindReferenceVariables(s.OpenWhen.Expression)),
+		closeExpr:      closeExpr,
+		closeScopePool: stateful.NewScopePool(ast.FindReferenceVariables(s.CloseWhen.Expression)),
+	}
+	n.node.runF = n.runStateWindow
+	return n, nil
+}
+
+func (n *StateWindowNode) runStateWindow(snapshot []byte) error {
+	if err := n.groups.restore(snapshot); err != nil {
+		n.diag.Error("failed to restore node snapshot", err)
+	}
+	windows := newStateWindows(n)
+	windows.restore()
+	n.statMap.Set(statCardinalityGauge, windows.cardinality)
+	return edge.NewConsumerWithReceiver(n.ins[0], windows).Consume()
+}
+
+func (n *StateWindowNode) snapshot() ([]byte, error) {
+	return n.groups.snapshot()
+}
+
+func (n *StateWindowNode) DeleteGroup(group models.GroupID) {
+	// Nothing to do
+}
+
+// stateWindows manages the states of all groups.
+// A barrier emits the timed out states of all groups,
+// so that the states of groups that no longer receive data are emitted,
+// including the restored states of groups that received no data since a restart.
+type stateWindows struct {
+	n *StateWindowNode
+
+	groups map[models.GroupID]edge.ForwardReceiver
+	states map[models.GroupID]*stateWindowGroup
+
+	cardinality *expvar.Int
+}
+
+func newStateWindows(n *StateWindowNode) *stateWindows {
+	return &stateWindows{
+		n:           n,
+		groups:      make(map[models.GroupID]edge.ForwardReceiver),
+		states:      make(map[models.GroupID]*stateWindowGroup),
+		cardinality: new(expvar.Int),
+	}
+}
+
+func (s *stateWindows) BeginBatch(edge.BeginBatchMessage) error {
+	return errors.New("stateWindow does not support batch data")
+}
+func (s *stateWindows) BatchPoint(edge.BatchPointMessage) error {
+	return errors.New("stateWindow does not support batch data")
+}
+func (s *stateWindows) EndBatch(edge.EndBatchMessage) error {
+	return errors.New("stateWindow does not support batch data")
+}
+func (s *stateWindows) BufferedBatch(edge.BufferedBatchMessage) error {
+	return errors.New("stateWindow does not support batch data")
+}
+
+func (s *stateWindows) Point(p edge.PointMessage) error {
+	s.n.timer.Start()
+	r, _ := s.group(p.GroupInfo(), p.Name())
+	msg, err := r.Point(p)
+	s.n.timer.Stop()
+	if err != nil {
+		return err
+	}
+	return s.forward([]edge.Message{msg})
+}
+
+// Barrier emits the timed out states of all groups before forwarding the barrier.
+func (s *stateWindows) Barrier(b edge.BarrierMessage) error {
+	s.n.timer.Start()
+	msgs := s.closeTimedOut(b.Time())
+	s.n.timer.Stop()
+	return s.forward(append(msgs, b))
+}
+
+// DeleteGroup emits the open state of the group before the group is deleted.
+func (s *stateWindows) DeleteGroup(d edge.DeleteGroupMessage) error {
+	id := d.GroupID()
+	r, ok := s.groups[id]
+	if !ok {
+		return nil
+	}
+	var msgs []edge.Message
+	s.n.groups.mu.Lock()
+	if g := s.states[id]; g.open() {
+		msgs = append(msgs, g.close())
+	}
+	s.n.groups.mu.Unlock()
+	msg, err := r.DeleteGroup(d)
+	if err != nil {
+		return err
+	}
+	delete(s.groups, id)
+	delete(s.states, id)
+	s.cardinality.Add(-1)
+	return s.forward(append(msgs, msg))
+}
+
+func (s *stateWindows) Done() {
+	for _, r := range s.groups {
+		r.Done()
+	}
+}
+
+// restore recreates the groups of all restored states,
+// so that barriers emit them once they time out even if their groups receive no more data.
+func (s *stateWindows) restore() {
+	for id, data := range s.n.groups.restoredGroups() {
+		var state stateWindowState
+		if err := decodeState(data, &state); err != nil {
+			s.n.diag.Error("failed to restore group state", err, keyvalue.KV("group", string(id)))
+			continue
+		}
+		s.group(edge.GroupInfo{ID: id, Tags: state.Tags, Dimensions: state.Dimensions}, state.Name)
+	}
+}
+
+// group returns the receiver and state of the group, creating them if needed.
+func (s *stateWindows) group(group edge.GroupInfo, name string) (edge.ForwardReceiver, *stateWindowGroup) {
+	if r, ok := s.groups[group.ID]; ok {
+		return r, s.states[group.ID]
+	}
+	g := s.n.newGroup(name, group)
+	r, err := s.n.groups.add(group.ID, g)
+	if err != nil {
+		s.n.diag.Error("failed to restore group state", err, keyvalue.KV("group", string(group.ID)))
+	}
+	s.groups[group.ID] = r
+	s.states[group.ID] = g
+	s.cardinality.Add(1)
+	return r, g
+}
+
+// closeTimedOut returns the batches of the states that timed out at t, ordered by group.
+func (s *stateWindows) closeTimedOut(t time.Time) []edge.Message {
+	if s.n.s.Timeout == 0 {
+		return nil
+	}
+	ids := make([]models.GroupID, 0, len(s.states))
+	for id := range s.states {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	var msgs []edge.Message
+	s.n.groups.mu.Lock()
+	defer s.n.groups.mu.Unlock()
+	for _, id := range ids {
+		if g := s.states[id]; g.open() && g.timedOut(t) {
+			msgs = append(msgs, g.close())
+		}
+	}
+	return msgs
+}
+
+func (s *stateWindows) forward(msgs []edge.Message) error {
+	for _, msg := range msgs {
+		if msg == nil {
+			continue
+		}
+		if err := edge.Forward(s.n.outs, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *StateWindowNode) newGroup(name string, group edge.GroupInfo) *stateWindowGroup {
+	return &stateWindowGroup{
+		n:         n,
+		name:      name,
+		group:     group,
+		openExpr:  n.openExpr.CopyReset(),
+		closeExpr: n.closeExpr.CopyReset(),
+	}
+}
+
+// stateWindowGroup buffers the points of a group while it is in the state.
+type stateWindowGroup struct {
+	n     *StateWindowNode
+	name  string
+	group edge.GroupInfo
+
+	openExpr  stateful.Expression
+	closeExpr stateful.Expression
+
+	start  time.Time
+	last   time.Time
+	points []edge.BatchPointMessage
+}
+
+func (g *stateWindowGroup) BeginBatch(edge.BeginBatchMessage) (edge.Message, error) {
+	return nil, errors.New("stateWindow does not support batch data")
+}
+func (g *stateWindowGroup) BatchPoint(edge.BatchPointMessage) (edge.Message, error) {
+	return nil, errors.New("stateWindow does not support batch data")
+}
+func (g *stateWindowGroup) EndBatch(edge.EndBatchMessage) (edge.Message, error) {
+	return nil, errors.New("stateWindow does not support batch data")
+}
+
+func (g *stateWindowGroup) Point(p edge.PointMessage) (edge.Message, error) {
+	var msg edge.Message
+	if g.open() && g.timedOut(p.Time()) {
+		msg = g.close()
+	}
+
+	if !g.open() {
+		open, err := EvalPredicate(g.openExpr, g.n.openScopePool, p)
+		if err != nil {
+			g.n.diag.Error("error while evaluating openWhen expression", err)
+			return msg, nil
+		}
+		if open {
+			g.start = p.Time()
+			g.add(p)
+		}
+		return msg, nil
+	}
+
+	closed, err := EvalPredicate(g.closeExpr, g.n.closeScopePool, p)
+	if err != nil {
+		g.n.diag.Error("error while evaluating closeWhen expression", err)
+		return nil, nil
+	}
+	g.add(p)
+	if closed {
+		return g.close(), nil
+	}
+	return nil, nil
+}
+
+// Barrier does nothing, timed out states are emitted by stateWindows.
+func (g *stateWindowGroup) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (g *stateWindowGroup) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (g *stateWindowGroup) Done() {}
+
+func (g *stateWindowGroup) add(p edge.PointMessage) {
+	g.points = append(g.points, edge.BatchPointFromPoint(p))
+	g.last = p.Time()
+}
+
+func (g *stateWindowGroup) open() bool {
+	return len(g.points) > 0
+}
+
+func (g *stateWindowGroup) timedOut(t time.Time) bool {
+	return g.n.s.Timeout != 0 && !t.Before(g.start.Add(g.n.s.Timeout))
+}
+
+// close ends the state and returns its points as a batch.
+func (g *stateWindowGroup) close() edge.BufferedBatchMessage {
+	points := g.points
+	g.points = nil
+	return edge.NewBufferedBatchMessage(
+		edge.NewBeginBatchMessage(
+			g.name,
+			g.group.Tags,
+			g.group.Dimensions.ByName,
+			g.last,
+			len(points),
+		),
+		points,
+		edge.NewEndBatchMessage(),
+	)
+}
+
+type stateWindowState struct {
+	// Name and group of the state, to recreate the group after a restart.
+	Name       string
+	Tags       models.Tags
+	Dimensions models.Dimensions
+
+	Start  time.Time
+	Last   time.Time
+	Points []batchPointState
+}
+
+func (g *stateWindowGroup) snapshotState() ([]byte, error) {
+	state := stateWindowState{
+		Name:       g.name,
+		Tags:       g.group.Tags,
+		Dimensions: g.group.Dimensions,
+		Start:      g.start,
+		Last:       g.last,
+	}
+	for _, p := range g.points {
+		state.Points = append(state.Points, newBatchPointState(p))
+	}
+	return encodeState(state)
+}
+
+func (g *stateWindowGroup) restoreState(data []byte) error {
+	var state stateWindowState
+	if err := decodeState(data, &state); err != nil {
+		return err
+	}
+	g.start = state.Start
+	g.last = state.Last
+	g.points = make([]edge.BatchPointMessage, len(state.Points))
+	for i, p := range state.Points {
+		g.points[i] = p.batchPoint()
+	}
+	return nil
+}
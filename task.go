@@ -2,6 +2,9 @@ package kapacitor
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/rand"
@@ -207,7 +210,14 @@ func (et *ExecutingTask) start(ins []edge.StatsEdge, snapshot *TaskSnapshot) err
 			}
 			return nil
 		})
+		// Snapshots saved before the pipeline hash was recorded are only checked by node names.
+		if err == nil && snapshot.PipelineHash != "" && snapshot.PipelineHash != et.pipelineHash() {
+			err = fmt.Errorf("task pipeline changed not using snapshot")
+		}
 		validSnapshot = err == nil
+		if err != nil {
+			et.diag.Error("discarding task snapshot", err)
+		}
 	}
 
 	err := et.walk(func(n Node) error {
@@ -243,6 +253,10 @@ func (et *ExecutingTask) stop() (err error) {
 		return nil
 	})
 	et.wg.Wait()
+	// Checkpoint the final state of the nodes now that they have stopped processing.
+	if et.Task.SnapshotInterval > 0 {
+		et.saveSnapshot()
+	}
 	return
 }
 
@@ -513,6 +527,12 @@ func (et *ExecutingTask) createNode(p pipeline.Node, d NodeDiagnostic) (n Node,
 		n, err = newStateDurationNode(et, t, d)
 	case *pipeline.StateCountNode:
 		n, err = newStateCountNode(et, t, d)
+	case *pipeline.StateWindowNode:
+		n, err = newStateWindowNode(et, t, d)
+	case *pipeline.ResampleNode:
+		n, err = newResampleNode(et, t, d)
+	case *pipeline.AnomalyNode:
+		n, err = newAnomalyNode(et, t, d)
 	case *pipeline.SideloadNode:
 		n, err = newSideloadNode(et, t, d)
 	case *pipeline.BarrierNode:
@@ -528,11 +548,15 @@ func (et *ExecutingTask) createNode(p pipeline.Node, d NodeDiagnostic) (n Node,
 
 type TaskSnapshot struct {
 	NodeSnapshots map[string][]byte
+	// Hash of the pipeline the snapshot was taken from.
+	// A snapshot is not restored if the pipeline has changed.
+	PipelineHash string
 }
 
 func (et *ExecutingTask) Snapshot() (*TaskSnapshot, error) {
 	snapshot := &TaskSnapshot{
 		NodeSnapshots: make(map[string][]byte),
+		PipelineHash:  et.pipelineHash(),
 	}
 	err := et.walk(func(n Node) error {
 		data, err := n.snapshot()
@@ -548,6 +572,84 @@ func (et *ExecutingTask) Snapshot() (*TaskSnapshot, error) {
 	return snapshot, nil
 }
 
+// saveSnapshot snapshots the task and saves the snapshot in the task store.
+func (et *ExecutingTask) saveSnapshot() {
+	snapshot, err := et.Snapshot()
+	if err != nil {
+		et.diag.Error("failed to snapshot task", err)
+		return
+	}
+	size := 0
+	for _, data := range snapshot.NodeSnapshots {
+		size += len(data)
+	}
+	// Only save the snapshot if it has content
+	if size > 0 {
+		err = et.tm.TaskStore.SaveSnapshot(et.Task.ID, snapshot)
+		if err != nil {
+			et.diag.Error("failed to save task snapshot", err)
+		}
+	}
+}
+
+// pipelineHash returns a hash of the pipeline definition, including the properties of each node.
+// Properties with zero values are not part of the hash, so that adding a property
+// to a node does not invalidate the snapshots of existing tasks.
+func (et *ExecutingTask) pipelineHash() string {
+	data, err := json.Marshal(et.Task.Pipeline)
+	if err == nil {
+		var v interface{}
+		if err = json.Unmarshal(data, &v); err == nil {
+			// Maps are marshaled with sorted keys, the result is stable.
+			data, err = json.Marshal(omitZeroValues(v))
+		}
+	}
+	if err != nil {
+		data = et.Task.Dot()
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// omitZeroValues removes the object keys with null, false, zero, empty string, empty array or empty object values.
+func omitZeroValues(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, value := range v {
+			value = omitZeroValues(value)
+			if isZeroJSON(value) {
+				delete(v, k)
+				continue
+			}
+			v[k] = value
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = omitZeroValues(value)
+		}
+	}
+	return v
+}
+
+func isZeroJSON(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case bool:
+		return !v
+	case float64:
+		return v == 0
+	case string:
+		// Zero durations are formatted as 0s.
+		return v == "" || v == "0s"
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
 func (et *ExecutingTask) runSnapshotter() {
 	defer et.wg.Done()
 	// Wait random duration to splay snapshot events across interval
@@ -561,22 +663,7 @@ func (et *ExecutingTask) runSnapshotter() {
 	for {
 		select {
 		case <-ticker.C:
-			snapshot, err := et.Snapshot()
-			if err != nil {
-				et.diag.Error("failed to snapshot task", err)
-				break
-			}
-			size := 0
-			for _, data := range snapshot.NodeSnapshots {
-				size += len(data)
-			}
-			// Only save the snapshot if it has content
-			if size > 0 {
-				err = et.tm.TaskStore.SaveSnapshot(et.Task.ID, snapshot)
-				if err != nil {
-					et.diag.Error("failed to save task snapshot", err)
-				}
-			}
+			et.saveSnapshot()
 		case <-et.stopping:
 			return
 		}
@@ -26,6 +26,7 @@ import (
 	k8s "github.com/thingnario/kapacitor/services/k8s/client"
 	"github.com/thingnario/kapacitor/services/kafka"
 	"github.com/thingnario/kapacitor/services/mqtt"
+	"github.com/thingnario/kapacitor/services/nodestate"
 	"github.com/thingnario/kapacitor/services/opsgenie"
 	"github.com/thingnario/kapacitor/services/opsgenie2"
 	"github.com/thingnario/kapacitor/services/pagerduty"
@@ -197,6 +198,9 @@ type TaskMaster struct {
 		Source(dir string) (sideload.Source, error)
 	}
 
+	// StateStore persists node state across restarts.
+	StateStore nodestate.Store
+
 	Commander command.Commander
 
 	DefaultRetentionPolicy string
@@ -219,6 +223,9 @@ type TaskMaster struct {
 	// we have only the task id, and they are called after the task is deleted from TaskMaster.tasks
 	taskToForkKeys map[string][]forkKey
 
+	// Buffers of the paused tasks, keyed by task id
+	paused map[string]PointBuffer
+
 	// Set of incoming batches
 	batches map[string][]BatchCollector
 
@@ -249,6 +256,7 @@ func NewTaskMaster(id string, info vars.Infoer, d Diagnostic) *TaskMaster {
 		forks:          make(map[forkKey]map[string]edge.Edge),
 		forkStats:      make(map[forkKey]*expvar.Int),
 		taskToForkKeys: make(map[string][]forkKey),
+		paused:         make(map[string]PointBuffer),
 		batches:        make(map[string][]BatchCollector),
 		tasks:          make(map[string]*ExecutingTask),
 		deleteHooks:    make(map[string][]deleteHook),
@@ -257,6 +265,7 @@ func NewTaskMaster(id string, info vars.Infoer, d Diagnostic) *TaskMaster {
 
 		closed:        true,
 		TimingService: noOpTimingService{},
+		StateStore:    nodestate.NewMemStore(),
 	}
 }
 
@@ -290,6 +299,7 @@ func (tm *TaskMaster) New(id string) *TaskMaster {
 	n.K8sService = tm.K8sService
 	n.Commander = tm.Commander
 	n.SideloadService = tm.SideloadService
+	n.StateStore = tm.StateStore
 	return n
 }
 
@@ -482,6 +492,8 @@ func (tm *TaskMaster) StartTask(t *Task) (*ExecutingTask, error) {
 		return nil, errors.New("task does contain any dbrps")
 	}
 	tm.diag.StartingTask(t.ID)
+	// The nodes of the task register their delete hooks again when they run.
+	delete(tm.deleteHooks, t.ID)
 	et, err := NewExecutingTask(tm, t)
 	if err != nil {
 		return nil, err
@@ -577,11 +589,18 @@ func (tm *TaskMaster) stopTask(id string) (err error) {
 // the lock in order to call this function
 func (tm *TaskMaster) deleteTask(id string) {
 	hooks := tm.deleteHooks[id]
+	delete(tm.deleteHooks, id)
 	for _, deleteHook := range hooks {
 		deleteHook(tm)
 	}
 }
 
+// DeleteTaskState deletes the persisted node state of a task.
+// The task does not need to have been started by this task master.
+func (tm *TaskMaster) DeleteTaskState(id string) error {
+	return tm.StateStore.DeletePrefix(nodestate.ChangeDetectTaskPrefix(id))
+}
+
 func (tm *TaskMaster) registerDeleteHookForTask(id string, hook deleteHook) {
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
@@ -701,11 +720,19 @@ func (tm *TaskMaster) forkPoint(p edge.PointMessage) {
 	}
 
 	// Merge the results to the forks map
-	for _, edge := range tm.forks[key] {
+	for id, edge := range tm.forks[key] {
+		if b, ok := tm.paused[id]; ok {
+			b.Add(p)
+			continue
+		}
 		_ = edge.Collect(p)
 	}
 
-	for _, edge := range tm.forks[emptyMeasurementKey] {
+	for id, edge := range tm.forks[emptyMeasurementKey] {
+		if b, ok := tm.paused[id]; ok {
+			b.Add(p)
+			continue
+		}
 		_ = edge.Collect(p)
 	}
 
@@ -863,6 +890,89 @@ func (tm *TaskMaster) delFork(id string) {
 	delete(tm.taskToForkKeys, id)
 }
 
+// PointBuffer holds the points of a paused task until it is resumed.
+type PointBuffer interface {
+	// Add buffers the point, the buffer decides whether to keep or drop it.
+	Add(edge.PointMessage)
+	// Next removes and returns the oldest buffered point, or nil if the buffer is empty.
+	Next() (edge.PointMessage, error)
+	// Len returns the number of buffered points.
+	Len() int64
+}
+
+// PauseTask sends all points for the stream task into b instead of the task.
+// The task keeps running with its state intact.
+// The task does not need to be executing yet, so that a task can be started paused.
+func (tm *TaskMaster) PauseTask(id string, b PointBuffer) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	tm.paused[id] = b
+}
+
+// IsPaused reports whether the points for the task are being buffered.
+func (tm *TaskMaster) IsPaused(id string) bool {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+	_, paused := tm.paused[id]
+	return paused
+}
+
+// ResumeTask sends all buffered points to the task in order
+// and then sends new points to the task again.
+// Points that arrive while resuming are buffered after the existing ones.
+func (tm *TaskMaster) ResumeTask(id string) error {
+	for {
+		done, err := tm.resumeNext(id)
+		if err != nil || done {
+			return err
+		}
+	}
+}
+
+// resumeNext sends the next buffered point to the task,
+// it reports whether the buffer was empty and the task is no longer paused.
+func (tm *TaskMaster) resumeNext(id string) (bool, error) {
+	tm.mu.RLock()
+	b, ok := tm.paused[id]
+	if !ok {
+		tm.mu.RUnlock()
+		return false, fmt.Errorf("task %s is not paused", id)
+	}
+	keys := tm.taskToForkKeys[id]
+	if len(keys) == 0 {
+		tm.mu.RUnlock()
+		return false, fmt.Errorf("task %s is not executing", id)
+	}
+	p, err := b.Next()
+	if err != nil {
+		tm.mu.RUnlock()
+		return false, err
+	}
+	if p != nil {
+		// Hold the read lock so the edge cannot be closed while collecting.
+		err := tm.forks[keys[0]][id].Collect(p)
+		tm.mu.RUnlock()
+		return false, err
+	}
+	tm.mu.RUnlock()
+
+	// The buffer was empty, no points can be added while we hold the write lock.
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	if b.Len() != 0 {
+		return false, nil
+	}
+	delete(tm.paused, id)
+	return true, nil
+}
+
+// CancelPause stops buffering the points for the task without sending the buffered points to it.
+func (tm *TaskMaster) CancelPause(id string) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+	delete(tm.paused, id)
+}
+
 func (tm *TaskMaster) SnapshotTask(id string) (*TaskSnapshot, error) {
 	tm.mu.RLock()
 	et, ok := tm.tasks[id]
@@ -0,0 +1,259 @@
+package ticktest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thingnario/kapacitor"
+	"github.com/thingnario/kapacitor/alert"
+	"github.com/thingnario/kapacitor/edge"
+	"github.com/thingnario/kapacitor/influxdb"
+	"github.com/thingnario/kapacitor/keyvalue"
+	"github.com/thingnario/kapacitor/models"
+	"github.com/thingnario/kapacitor/services/httpd"
+	"github.com/thingnario/kapacitor/uuid"
+)
+
+// recorder implements the diagnostic interfaces of the task master.
+// It records triggered alerts and errors instead of logging them.
+type recorder struct {
+	mu sync.Mutex
+	// Names of the nodes in the order the task created them.
+	nodes  []string
+	alerts map[string][]Alert
+	errors []string
+}
+
+func newRecorder() *recorder {
+	return &recorder{
+		alerts: make(map[string][]Alert),
+	}
+}
+
+func (r *recorder) error(context, msg string, err error, ctx []keyvalue.T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := fmt.Sprintf("%s: %s: %v", context, msg, err)
+	for _, kv := range ctx {
+		s += fmt.Sprintf(" %s=%s", kv.Key, kv.Value)
+	}
+	r.errors = append(r.errors, s)
+}
+
+// Alerts returns the triggered alerts grouped by node in pipeline order.
+func (r *recorder) Alerts() []Alert {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	alerts := []Alert{}
+	for _, n := range r.nodes {
+		alerts = append(alerts, r.alerts[n]...)
+	}
+	return alerts
+}
+
+func (r *recorder) Errors() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.errors...)
+}
+
+func (r *recorder) WithTaskContext(task string) kapacitor.TaskDiagnostic {
+	return taskRecorder{r: r, task: task}
+}
+func (r *recorder) WithTaskMasterContext(tm string) kapacitor.Diagnostic {
+	return r
+}
+func (r *recorder) WithNodeContext(node string) kapacitor.NodeDiagnostic {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.alerts[node]; !ok {
+		r.nodes = append(r.nodes, node)
+		r.alerts[node] = nil
+	}
+	return nodeRecorder{r: r, node: node}
+}
+func (r *recorder) WithEdgeContext(task, parent, child string) kapacitor.EdgeDiagnostic {
+	return edgeRecorder{}
+}
+
+func (r *recorder) TaskMasterOpened() {}
+func (r *recorder) TaskMasterClosed() {}
+
+func (r *recorder) StartingTask(id string) {}
+func (r *recorder) StartedTask(id string)  {}
+
+func (r *recorder) StoppedTask(id string) {}
+func (r *recorder) StoppedTaskWithError(id string, err error) {
+	r.error(id, "task stopped", err, nil)
+}
+
+func (r *recorder) TaskMasterDot(d string) {}
+
+type taskRecorder struct {
+	r    *recorder
+	task string
+}
+
+func (t taskRecorder) WithNodeContext(node string) kapacitor.NodeDiagnostic {
+	return t.r.WithNodeContext(node)
+}
+func (t taskRecorder) Error(msg string, err error, ctx ...keyvalue.T) {
+	t.r.error(t.task, msg, err, ctx)
+}
+
+type nodeRecorder struct {
+	r    *recorder
+	node string
+}
+
+func (n nodeRecorder) Error(msg string, err error, ctx ...keyvalue.T) {
+	n.r.error(n.node, msg, err, ctx)
+}
+func (n nodeRecorder) AlertTriggered(level alert.Level, id string, message string, rows *models.Row) {
+	n.r.mu.Lock()
+	defer n.r.mu.Unlock()
+	n.r.alerts[n.node] = append(n.r.alerts[n.node], Alert{
+		ID:      id,
+		Level:   level,
+		Message: message,
+	})
+}
+func (n nodeRecorder) SettingReplicas(new int, old int, id string)                     {}
+func (n nodeRecorder) StartingBatchQuery(q string)                                     {}
+func (n nodeRecorder) LogPointData(key, prefix string, data edge.PointMessage)         {}
+func (n nodeRecorder) LogBatchData(key, prefix string, data edge.BufferedBatchMessage) {}
+func (n nodeRecorder) UDFLog(s string)                                                 {}
+
+type edgeRecorder struct{}
+
+func (e edgeRecorder) ClosingEdge(collected, emitted int64) {}
+
+// httpdService keeps the routes of the task so httpOut results can be read without serving HTTP.
+type httpdService struct {
+	mu     sync.Mutex
+	routes map[string]httpd.Route
+}
+
+func newHTTPDService() *httpdService {
+	return &httpdService{
+		routes: make(map[string]httpd.Route),
+	}
+}
+
+func (s *httpdService) AddRoutes(routes []httpd.Route) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, r := range routes {
+		s.routes[r.Method+" "+r.Pattern] = r
+	}
+	return nil
+}
+
+// DelRoutes keeps the routes, httpOut nodes remove them when the task stops
+// which happens before the results are read.
+func (s *httpdService) DelRoutes([]httpd.Route) {}
+
+func (s *httpdService) URL() string {
+	return "http://ticktest"
+}
+
+func (s *httpdService) handler(method, pattern string) (func(http.ResponseWriter, *http.Request), bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.routes[method+" "+pattern]
+	if !ok {
+		return nil, false
+	}
+	h, ok := r.HandlerFunc.(func(http.ResponseWriter, *http.Request))
+	return h, ok
+}
+
+// alertService does not send events to any handler,
+// the recorder captures the events when they are triggered.
+type alertService struct {
+	*alert.InhibitorLookup
+}
+
+func (s alertService) RegisterAnonHandler(topic string, h alert.Handler)   {}
+func (s alertService) DeregisterAnonHandler(topic string, h alert.Handler) {}
+func (s alertService) Collect(event alert.Event) error                     { return nil }
+func (s alertService) UpdateEvent(topic string, event alert.EventState) error {
+	return nil
+}
+func (s alertService) EventState(topic, event string) (alert.EventState, bool, error) {
+	return alert.EventState{}, false, nil
+}
+func (s alertService) CloseTopic(topic string) error   { return nil }
+func (s alertService) DeleteTopic(topic string) error  { return nil }
+func (s alertService) RestoreTopic(topic string) error { return nil }
+
+// influxDBService records the points written by influxDBOut nodes.
+type influxDBService struct {
+	mu     sync.Mutex
+	writes []Write
+}
+
+func (s *influxDBService) NewNamedClient(name string) (influxdb.Client, error) {
+	return influxDBClient{s: s}, nil
+}
+
+func (s *influxDBService) Writes() []Write {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Write(nil), s.writes...)
+}
+
+type influxDBClient struct {
+	s *influxDBService
+}
+
+func (c influxDBClient) Ping(ctx context.Context) (time.Duration, string, error) {
+	return 0, "ticktest", nil
+}
+
+func (c influxDBClient) Write(bp influxdb.BatchPoints) error {
+	w := Write{
+		Database:        bp.Database(),
+		RetentionPolicy: bp.RetentionPolicy(),
+	}
+	for _, p := range bp.Points() {
+		w.Points = append(w.Points, string(p.Bytes("ns")))
+	}
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+	c.s.writes = append(c.s.writes, w)
+	return nil
+}
+
+// Query answers the CREATE DATABASE queries of influxDBOut nodes.
+func (c influxDBClient) Query(q influxdb.Query) (*influxdb.Response, error) {
+	if !strings.HasPrefix(q.Command, "CREATE") {
+		return nil, fmt.Errorf("queries are not supported: %s", q.Command)
+	}
+	return &influxdb.Response{}, nil
+}
+
+type taskStore struct{}
+
+func (ts taskStore) SaveSnapshot(id string, snapshot *kapacitor.TaskSnapshot) error { return nil }
+func (ts taskStore) HasSnapshot(id string) bool                                     { return false }
+func (ts taskStore) LoadSnapshot(id string) (*kapacitor.TaskSnapshot, error) {
+	return nil, fmt.Errorf("no snapshot for task %s", id)
+}
+
+type serverInfo struct{}
+
+func (i serverInfo) ClusterID() uuid.UUID    { return uuid.UUID{} }
+func (i serverInfo) ServerID() uuid.UUID     { return uuid.UUID{} }
+func (i serverInfo) Hostname() string        { return "localhost" }
+func (i serverInfo) Version() string         { return "ticktest" }
+func (i serverInfo) Product() string         { return "kapacitor" }
+func (i serverInfo) Platform() string        { return "ticktest" }
+func (i serverInfo) NumTasks() int64         { return 1 }
+func (i serverInfo) NumEnabledTasks() int64  { return 1 }
+func (i serverInfo) NumSubscriptions() int64 { return 0 }
+func (i serverInfo) Uptime() time.Duration   { return 0 }
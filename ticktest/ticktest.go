@@ -0,0 +1,422 @@
+// Package ticktest runs a TICKscript offline against fixture points
+// and compares the alerts and outputs of the task with expectations.
+//
+// The task runs in process with a fake clock, alert handlers are removed from the
+// pipeline so that no notifications are sent and influxDBOut writes are recorded
+// instead of being sent to InfluxDB. Only stream tasks are supported.
+package ticktest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	dbmodels "github.com/influxdata/influxdb/models"
+	"github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/thingnario/kapacitor"
+	"github.com/thingnario/kapacitor/alert"
+	client "github.com/thingnario/kapacitor/client/v1"
+	"github.com/thingnario/kapacitor/clock"
+	"github.com/thingnario/kapacitor/edge"
+	"github.com/thingnario/kapacitor/models"
+	"github.com/thingnario/kapacitor/pipeline"
+	"github.com/thingnario/kapacitor/services/deadman"
+	"github.com/thingnario/kapacitor/services/task_store"
+	"github.com/thingnario/kapacitor/tick/ast"
+)
+
+// DefaultID is the ID of the task when the test does not provide one.
+const DefaultID = "ticktest"
+
+// Test defines a task and the fixture points it is run against.
+type Test struct {
+	// ID of the task, it is part of the alert IDs and messages that use the task name.
+	ID         string
+	TICKscript string
+	// DBRPs of the task, required unless the TICKscript declares them.
+	DBRPs []client.DBRP
+	Vars  client.Vars
+	// Fixture contains the input points in line protocol, one point per line.
+	// Every point must have a timestamp, empty lines and lines starting with '#' are ignored.
+	// The points are written to the first DBRP of the task in the order of their timestamps.
+	Fixture io.Reader
+	// Precision of the fixture timestamps, defaults to nanoseconds.
+	Precision string
+}
+
+// Alert is an alert event triggered by the task.
+type Alert struct {
+	ID      string      `json:"id"`
+	Level   alert.Level `json:"level"`
+	Message string      `json:"message"`
+}
+
+// Write contains the points written by influxDBOut nodes to a database and retention policy.
+type Write struct {
+	Database        string `json:"db"`
+	RetentionPolicy string `json:"rp"`
+	// Points in line protocol.
+	Points []string `json:"points"`
+}
+
+// Expectations define the expected alerts and outputs of a test.
+// Only the sections that are set are compared, an empty section expects no output.
+type Expectations struct {
+	// Alerts in the order they are triggered, grouped by alert node in pipeline order.
+	Alerts []Alert `json:"alerts"`
+	// HTTPOut maps httpOut endpoint names to their final result.
+	HTTPOut map[string]models.Result `json:"http-out"`
+	// InfluxDBOut are the points written by influxDBOut nodes.
+	InfluxDBOut []Write `json:"influxdb-out"`
+	// Precision of the timestamps of the expected influxDBOut points, defaults to nanoseconds.
+	Precision string `json:"precision,omitempty"`
+}
+
+// ReadExpectations reads expectations from YAML or JSON.
+func ReadExpectations(data []byte) (Expectations, error) {
+	exp := Expectations{}
+	if err := yaml.Unmarshal(data, &exp); err != nil {
+		return Expectations{}, err
+	}
+	return exp, nil
+}
+
+// Result is the output of a test run, it has the format of the expectations.
+type Result struct {
+	Alerts      []Alert                  `json:"alerts"`
+	HTTPOut     map[string]models.Result `json:"http-out"`
+	InfluxDBOut []Write                  `json:"influxdb-out"`
+	// Errors reported by the task while processing the points.
+	Errors []string `json:"-"`
+}
+
+// Run runs the test task until all fixture points are processed.
+func Run(t Test) (*Result, error) {
+	id := t.ID
+	if id == "" {
+		id = DefaultID
+	}
+	dbrps, err := taskDBRPs(t.TICKscript, t.DBRPs)
+	if err != nil {
+		return nil, err
+	}
+	vars, err := task_store.ConvertToTickVars(t.Vars)
+	if err != nil {
+		return nil, err
+	}
+	points, err := readFixture(t.Fixture, t.Precision, dbrps[0])
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read fixture")
+	}
+
+	r := newRecorder()
+	httpdService := newHTTPDService()
+	influxDBService := new(influxDBService)
+	tm := kapacitor.NewTaskMaster(id, serverInfo{}, r)
+	tm.HTTPDService = httpdService
+	tm.TaskStore = taskStore{}
+	tm.DeadmanService = deadman.NewService(deadman.NewConfig(), nil)
+	tm.AlertService = alertService{InhibitorLookup: alert.NewInhibitorLookup()}
+	tm.InfluxDBService = influxDBService
+	if err := tm.Open(); err != nil {
+		return nil, err
+	}
+	defer tm.Close()
+
+	task, err := tm.NewTask(id, t.TICKscript, kapacitor.StreamTask, dbrps, 0, vars)
+	if err != nil {
+		return nil, err
+	}
+	endpoints, err := preparePipeline(task.Pipeline)
+	if err != nil {
+		return nil, err
+	}
+	et, err := tm.StartTask(task)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := tm.Stream(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var start, end time.Time
+	if len(points) > 0 {
+		start, end = points[0].Time(), points[len(points)-1].Time()
+	}
+	clck := clock.New(start)
+	pointsC := make(chan edge.PointMessage, len(points))
+	for _, p := range points {
+		pointsC <- p
+	}
+	close(pointsC)
+	replayErr := kapacitor.ReplayStreamFromChan(clck, pointsC, stream, true)
+	clck.Set(end)
+	if err := <-replayErr; err != nil {
+		return nil, err
+	}
+	tm.Drain()
+	et.StopStats()
+	if err := et.Wait(); err != nil {
+		return nil, err
+	}
+
+	result := &Result{
+		HTTPOut: make(map[string]models.Result, len(endpoints)),
+	}
+	for _, endpoint := range endpoints {
+		res, err := httpOutResult(httpdService, path.Join("/tasks/", id, endpoint))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get result of httpOut %q", endpoint)
+		}
+		result.HTTPOut[endpoint] = res
+	}
+	// Closing the task master stops the task which flushes the influxDBOut writes.
+	if err := tm.Close(); err != nil {
+		return nil, err
+	}
+	result.InfluxDBOut, err = normalizeWrites(influxDBService.Writes(), "ns")
+	if err != nil {
+		return nil, err
+	}
+	result.Alerts = r.Alerts()
+	result.Errors = r.Errors()
+	return result, nil
+}
+
+// Diff compares the result with the expectations.
+// It returns a unified diff of each section that does not match, or an empty string if all match.
+func (r *Result) Diff(exp Expectations) (string, error) {
+	var diffs []string
+	if exp.Alerts != nil {
+		d, err := diffSection("alerts", exp.Alerts, r.Alerts)
+		if err != nil {
+			return "", err
+		}
+		diffs = append(diffs, d)
+	}
+	if exp.HTTPOut != nil {
+		got := make(map[string]models.Result, len(exp.HTTPOut))
+		for endpoint := range exp.HTTPOut {
+			if res, ok := r.HTTPOut[endpoint]; ok {
+				got[endpoint] = res
+			}
+		}
+		d, err := diffSection("http-out", exp.HTTPOut, got)
+		if err != nil {
+			return "", err
+		}
+		diffs = append(diffs, d)
+	}
+	if exp.InfluxDBOut != nil {
+		precision := exp.Precision
+		if precision == "" {
+			precision = "ns"
+		}
+		writes, err := normalizeWrites(exp.InfluxDBOut, precision)
+		if err != nil {
+			return "", errors.Wrap(err, "invalid expected influxdb-out points")
+		}
+		d, err := diffSection("influxdb-out", writes, r.InfluxDBOut)
+		if err != nil {
+			return "", err
+		}
+		diffs = append(diffs, d)
+	}
+	return strings.Join(diffs, ""), nil
+}
+
+// diffSection renders both values as YAML and returns their unified diff.
+func diffSection(name string, exp, got interface{}) (string, error) {
+	e, err := yaml.Marshal(map[string]interface{}{name: exp})
+	if err != nil {
+		return "", err
+	}
+	g, err := yaml.Marshal(map[string]interface{}{name: got})
+	if err != nil {
+		return "", err
+	}
+	if bytes.Equal(e, g) {
+		return "", nil
+	}
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(e)),
+		B:        difflib.SplitLines(string(g)),
+		FromFile: "expected",
+		ToFile:   "actual",
+		Context:  3,
+	})
+}
+
+func taskDBRPs(script string, dbrps []client.DBRP) ([]kapacitor.DBRP, error) {
+	p, err := ast.Parse(script)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TICKscript: %v", err)
+	}
+	pn, ok := p.(*ast.ProgramNode)
+	if !ok {
+		return nil, errors.New("invalid TICKscript")
+	}
+	var declared []kapacitor.DBRP
+	for _, n := range pn.Nodes {
+		if d, ok := n.(*ast.DBRPNode); ok {
+			declared = append(declared, kapacitor.DBRP{
+				Database:        d.DB.Reference,
+				RetentionPolicy: d.RP.Reference,
+			})
+		}
+	}
+	switch {
+	case len(declared) > 0 && len(dbrps) > 0:
+		return nil, errors.New("cannot specify dbrp in both implicitly and explicitly")
+	case len(declared) == 0 && len(dbrps) == 0:
+		return nil, errors.New("must specify dbrp")
+	case len(declared) == 0:
+		for _, dbrp := range dbrps {
+			declared = append(declared, kapacitor.DBRP{
+				Database:        dbrp.Database,
+				RetentionPolicy: dbrp.RetentionPolicy,
+			})
+		}
+	}
+	return declared, nil
+}
+
+// preparePipeline removes the alert handlers of the pipeline and returns the httpOut endpoints.
+// Nodes that depend on external systems are rejected.
+func preparePipeline(p *pipeline.Pipeline) ([]string, error) {
+	var endpoints []string
+	err := p.Walk(func(n pipeline.Node) error {
+		switch node := n.(type) {
+		case *pipeline.HTTPPostNode,
+			*pipeline.UDFNode,
+			*pipeline.SideloadNode,
+			*pipeline.K8sAutoscaleNode,
+			*pipeline.SwarmAutoscaleNode,
+			*pipeline.Ec2AutoscaleNode:
+			return fmt.Errorf("%s nodes are not supported", n.Desc())
+		case *pipeline.HTTPOutNode:
+			endpoints = append(endpoints, node.Endpoint)
+		case *pipeline.AlertNode:
+			removeHandlers(node.AlertNodeData)
+		}
+		return nil
+	})
+	return endpoints, err
+}
+
+// removeHandlers clears all handler lists of the alert node.
+func removeHandlers(n *pipeline.AlertNodeData) {
+	v := reflect.ValueOf(n).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Type().Field(i)
+		if f.Type.Kind() == reflect.Slice && strings.HasSuffix(f.Name, "Handlers") {
+			v.Field(i).Set(reflect.Zero(f.Type))
+		}
+	}
+}
+
+func readFixture(r io.Reader, precision string, dbrp kapacitor.DBRP) ([]edge.PointMessage, error) {
+	if r == nil {
+		return nil, nil
+	}
+	var points []edge.PointMessage
+	in := bufio.NewScanner(r)
+	for l := 1; in.Scan(); l++ {
+		line := strings.TrimSpace(in.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		mps, err := dbmodels.ParsePointsWithPrecision([]byte(line), time.Time{}, precision)
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d", l)
+		}
+		for _, mp := range mps {
+			if mp.Time().IsZero() {
+				return nil, fmt.Errorf("line %d: point has no timestamp", l)
+			}
+			points = append(points, edge.NewPointMessage(
+				mp.Name(),
+				dbrp.Database,
+				dbrp.RetentionPolicy,
+				models.Dimensions{},
+				models.Fields(mp.Fields()),
+				models.Tags(mp.Tags().Map()),
+				mp.Time().UTC(),
+			))
+		}
+	}
+	if err := in.Err(); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].Time().Before(points[j].Time())
+	})
+	return points, nil
+}
+
+func httpOutResult(s *httpdService, pattern string) (models.Result, error) {
+	h, ok := s.handler("GET", pattern)
+	if !ok {
+		return models.Result{}, errors.New("endpoint not found")
+	}
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest("GET", pattern, nil))
+	if rec.Code != http.StatusOK {
+		return models.Result{}, fmt.Errorf("unexpected status code %d: %s", rec.Code, rec.Body.String())
+	}
+	res := models.Result{}
+	err := json.Unmarshal(rec.Body.Bytes(), &res)
+	return res, err
+}
+
+// normalizeWrites merges the writes by database and retention policy
+// and formats their points with nanosecond timestamps ordered by time.
+func normalizeWrites(writes []Write, precision string) ([]Write, error) {
+	byDBRP := make(map[kapacitor.DBRP][]dbmodels.Point)
+	for _, w := range writes {
+		dbrp := kapacitor.DBRP{Database: w.Database, RetentionPolicy: w.RetentionPolicy}
+		for _, line := range w.Points {
+			mps, err := dbmodels.ParsePointsWithPrecision([]byte(line), time.Time{}, precision)
+			if err != nil {
+				return nil, err
+			}
+			byDBRP[dbrp] = append(byDBRP[dbrp], mps...)
+		}
+	}
+	normalized := make([]Write, 0, len(byDBRP))
+	for dbrp, points := range byDBRP {
+		sort.Slice(points, func(i, j int) bool {
+			if !points[i].Time().Equal(points[j].Time()) {
+				return points[i].Time().Before(points[j].Time())
+			}
+			return points[i].String() < points[j].String()
+		})
+		w := Write{
+			Database:        dbrp.Database,
+			RetentionPolicy: dbrp.RetentionPolicy,
+		}
+		for _, p := range points {
+			w.Points = append(w.Points, p.String())
+		}
+		normalized = append(normalized, w)
+	}
+	sort.Slice(normalized, func(i, j int) bool {
+		if normalized[i].Database != normalized[j].Database {
+			return normalized[i].Database < normalized[j].Database
+		}
+		return normalized[i].RetentionPolicy < normalized[j].RetentionPolicy
+	})
+	return normalized, nil
+}
@@ -0,0 +1,158 @@
+package ticktest
+
+import (
+	"strings"
+	"testing"
+)
+
+const cpuAlert = `
+dbrp "telegraf"."autogen"
+
+var data = stream
+	|from()
+		.measurement('cpu')
+		.groupBy('host')
+
+data
+	|alert()
+		.id('{{ .TaskName }}:{{ index .Tags "host" }}')
+		.message('{{ .ID }} is {{ .Level }}')
+		.warn(lambda: "usage" > 70)
+		.crit(lambda: "usage" > 90)
+		.stateChangesOnly()
+		.slack()
+
+data
+	|window()
+		.period(10s)
+		.every(10s)
+	|mean('usage')
+	|httpOut('mean')
+	|influxDBOut()
+		.database('downsampled')
+		.retentionPolicy('autogen')
+		.measurement('cpu_mean')
+`
+
+const cpuFixture = `
+# host serverA gets critical, serverB stays ok.
+cpu,host=serverA usage=50 0
+cpu,host=serverB usage=10 0
+cpu,host=serverA usage=75 5
+cpu,host=serverA usage=95 10
+cpu,host=serverB usage=20 10
+cpu,host=serverA usage=30 20
+`
+
+const cpuExpectations = `
+alerts:
+  - id: cpu_alert:serverA
+    level: OK
+    message: cpu_alert:serverA is OK
+  - id: cpu_alert:serverB
+    level: OK
+    message: cpu_alert:serverB is OK
+  - id: cpu_alert:serverA
+    level: WARNING
+    message: cpu_alert:serverA is WARNING
+  - id: cpu_alert:serverA
+    level: CRITICAL
+    message: cpu_alert:serverA is CRITICAL
+  - id: cpu_alert:serverA
+    level: OK
+    message: cpu_alert:serverA is OK
+http-out:
+  mean:
+    series:
+      - name: cpu
+        tags:
+          host: serverA
+        columns: [time, mean]
+        values:
+          - ["1970-01-01T00:00:20Z", 95]
+      - name: cpu
+        tags:
+          host: serverB
+        columns: [time, mean]
+        values:
+          - ["1970-01-01T00:00:10Z", 10]
+influxdb-out:
+  - db: downsampled
+    rp: autogen
+    points:
+      - cpu_mean,host=serverA mean=62.5 10
+      - cpu_mean,host=serverB mean=10 10
+      - cpu_mean,host=serverA mean=95 20
+precision: s
+`
+
+func TestRun(t *testing.T) {
+	exp, err := ReadExpectations([]byte(cpuExpectations))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := Run(Test{
+		ID:         "cpu_alert",
+		TICKscript: cpuAlert,
+		Fixture:    strings.NewReader(cpuFixture),
+		Precision:  "s",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Errors) > 0 {
+		t.Errorf("unexpected task errors: %v", result.Errors)
+	}
+	diff, err := result.Diff(exp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff != "" {
+		t.Errorf("unexpected result:\n%s", diff)
+	}
+
+	// An unexpected alert level is reported as a diff.
+	exp.Alerts[3].Level = exp.Alerts[2].Level
+	diff, err = result.Diff(exp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff, "-  level: WARNING\n+  level: CRITICAL") {
+		t.Errorf("unexpected diff:\n%s", diff)
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	testCases := []struct {
+		name string
+		test Test
+		exp  string
+	}{
+		{
+			name: "missing dbrp",
+			test: Test{TICKscript: "stream|from()|httpOut('out')"},
+			exp:  "must specify dbrp",
+		},
+		{
+			name: "missing timestamp",
+			test: Test{
+				TICKscript: cpuAlert,
+				Fixture:    strings.NewReader("cpu,host=serverA usage=50"),
+			},
+			exp: "line 1: point has no timestamp",
+		},
+		{
+			name: "unsupported node",
+			test: Test{
+				TICKscript: "dbrp \"telegraf\".\"autogen\"\nstream|from()|httpPost('http://localhost')",
+			},
+			exp: "http_post nodes are not supported",
+		},
+	}
+	for _, tc := range testCases {
+		_, err := Run(tc.test)
+		if err == nil || !strings.Contains(err.Error(), tc.exp) {
+			t.Errorf("%s: unexpected error got %v exp %q", tc.name, err, tc.exp)
+		}
+	}
+}
@@ -144,6 +144,9 @@ func (n *UDFNode) snapshot() ([]byte, error) {
 // over STDIN and STDOUT. Lines received over STDERR are logged
 // via normal Kapacitor logging.
 type UDFProcess struct {
+	// Optional store for the blob requests of the process.
+	BlobStore udf.BlobStore
+
 	taskName string
 	nodeName string
 
@@ -224,6 +227,7 @@ func (p *UDFProcess) Open() error {
 		p.abortCallback,
 		cmd.Kill,
 	)
+	p.server.BlobStore = p.BlobStore
 	if err := p.server.Start(); err != nil {
 		return err
 	}
@@ -278,6 +282,9 @@ func (p *UDFProcess) Out() <-chan edge.Message           { return p.server.Out()
 func (p *UDFProcess) Info() (udf.Info, error)            { return p.server.Info() }
 
 type UDFSocket struct {
+	// Optional store for the blob requests of the socket.
+	BlobStore udf.BlobStore
+
 	taskName string
 	nodeName string
 
@@ -332,6 +339,7 @@ func (s *UDFSocket) Open() error {
 		s.abortCallback,
 		func() { s.socket.Close() },
 	)
+	s.server.BlobStore = s.BlobStore
 	return s.server.Start()
 }
 
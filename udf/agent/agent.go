@@ -39,6 +39,16 @@ type Handler interface {
 	Stop()
 }
 
+// BlobHandler is optionally implemented by a Handler that uses the blob store of Kapacitor.
+// Blobs are requested by writing SaveBlobRequest and GetBlobRequest messages to the Responses channel,
+// Kapacitor answers the requests in order.
+type BlobHandler interface {
+	// A blob has been saved.
+	SaveBlobResponse(*SaveBlobResponse) error
+	// A blob has been retrieved.
+	GetBlobResponse(*GetBlobResponse) error
+}
+
 // Go implementation of a Kapacitor UDF agent.
 // This agent is responsible for reading and writing
 // messages over a socket.
@@ -210,6 +220,20 @@ func (a *Agent) readLoop() error {
 			if err != nil {
 				return err
 			}
+		case *Request_SaveBlob:
+			if h, ok := a.Handler.(BlobHandler); ok {
+				err := h.SaveBlobResponse(msg.SaveBlob)
+				if err != nil {
+					return err
+				}
+			}
+		case *Request_GetBlob:
+			if h, ok := a.Handler.(BlobHandler); ok {
+				err := h.GetBlobResponse(msg.GetBlob)
+				if err != nil {
+					return err
+				}
+			}
 		}
 		if res != nil {
 			a.outResponses <- res
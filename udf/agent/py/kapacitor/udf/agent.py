@@ -25,6 +25,9 @@ logger = logging.getLogger()
 # The Handler is called from a single thread, meaning methods will not be called concurrently.
 #
 # To write Points/Batches back to the Agent/Kapacitor use the Agent.write_response method, which is thread safe.
+#
+# Blobs requested via the Agent.save_blob and Agent.get_blob methods are passed to the
+# save_blob_response and get_blob_response methods, in the order they were requested.
 class Handler(object):
     def info(self):
         pass
@@ -40,6 +43,10 @@ class Handler(object):
         pass
     def end_batch(self, end_req):
         pass
+    def save_blob_response(self, save_blob_resp):
+        pass
+    def get_blob_response(self, get_blob_resp):
+        pass
 
 
 # Python implementation of a Kapacitor UDF agent.
@@ -87,6 +94,23 @@ class Agent(object):
         finally:
             self._write_lock.release()
 
+    # Request to save data as a blob in the Kapacitor blob store.
+    # If tag is not empty the blob is tagged with it.
+    # This method is thread safe.
+    def save_blob(self, data, tag=''):
+        response = udf_pb2.Response()
+        response.saveBlob.data = data
+        response.saveBlob.tag = tag
+        self.write_response(response, flush=True)
+
+    # Request a blob from the Kapacitor blob store by its ID or by tag.
+    # This method is thread safe.
+    def get_blob(self, id='', tag=''):
+        response = udf_pb2.Response()
+        response.getBlob.id = id
+        response.getBlob.tag = tag
+        self.write_response(response, flush=True)
+
     # Read requests off stdin
     def _read_loop(self):
         request = udf_pb2.Request()
@@ -122,6 +146,12 @@ class Agent(object):
                     self.handler.point(request.point)
                 elif msg == "end":
                     self.handler.end_batch(request.end)
+                elif msg == "saveBlob":
+                    if hasattr(self.handler, 'save_blob_response'):
+                        self.handler.save_blob_response(request.saveBlob)
+                elif msg == "getBlob":
+                    if hasattr(self.handler, 'get_blob_response'):
+                        self.handler.get_blob_response(request.getBlob)
                 else:
                     logger.error("received unhandled request %s", msg)
             except EOF:
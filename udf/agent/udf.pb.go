@@ -27,6 +27,10 @@ It has these top-level messages:
 	EndBatch
 	Request
 	Response
+	SaveBlobRequest
+	SaveBlobResponse
+	GetBlobRequest
+	GetBlobResponse
 */
 package agent
 
@@ -788,6 +792,8 @@ type Request struct {
 	//	*Request_Keepalive
 	//	*Request_Snapshot
 	//	*Request_Restore
+	//	*Request_SaveBlob
+	//	*Request_GetBlob
 	//	*Request_Begin
 	//	*Request_Point
 	//	*Request_End
@@ -818,6 +824,12 @@ type Request_Snapshot struct {
 type Request_Restore struct {
 	Restore *RestoreRequest `protobuf:"bytes,5,opt,name=restore,oneof"`
 }
+type Request_SaveBlob struct {
+	SaveBlob *SaveBlobResponse `protobuf:"bytes,6,opt,name=saveBlob,oneof"`
+}
+type Request_GetBlob struct {
+	GetBlob *GetBlobResponse `protobuf:"bytes,7,opt,name=getBlob,oneof"`
+}
 type Request_Begin struct {
 	Begin *BeginBatch `protobuf:"bytes,16,opt,name=begin,oneof"`
 }
@@ -833,6 +845,8 @@ func (*Request_Init) isRequest_Message()      {}
 func (*Request_Keepalive) isRequest_Message() {}
 func (*Request_Snapshot) isRequest_Message()  {}
 func (*Request_Restore) isRequest_Message()   {}
+func (*Request_SaveBlob) isRequest_Message()  {}
+func (*Request_GetBlob) isRequest_Message()   {}
 func (*Request_Begin) isRequest_Message()     {}
 func (*Request_Point) isRequest_Message()     {}
 func (*Request_End) isRequest_Message()       {}
@@ -879,6 +893,20 @@ func (m *Request) GetRestore() *RestoreRequest {
 	return nil
 }
 
+func (m *Request) GetSaveBlob() *SaveBlobResponse {
+	if x, ok := m.GetMessage().(*Request_SaveBlob); ok {
+		return x.SaveBlob
+	}
+	return nil
+}
+
+func (m *Request) GetGetBlob() *GetBlobResponse {
+	if x, ok := m.GetMessage().(*Request_GetBlob); ok {
+		return x.GetBlob
+	}
+	return nil
+}
+
 func (m *Request) GetBegin() *BeginBatch {
 	if x, ok := m.GetMessage().(*Request_Begin); ok {
 		return x.Begin
@@ -908,6 +936,8 @@ func (*Request) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) error
 		(*Request_Keepalive)(nil),
 		(*Request_Snapshot)(nil),
 		(*Request_Restore)(nil),
+		(*Request_SaveBlob)(nil),
+		(*Request_GetBlob)(nil),
 		(*Request_Begin)(nil),
 		(*Request_Point)(nil),
 		(*Request_End)(nil),
@@ -943,6 +973,16 @@ func _Request_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
 		if err := b.EncodeMessage(x.Restore); err != nil {
 			return err
 		}
+	case *Request_SaveBlob:
+		b.EncodeVarint(6<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.SaveBlob); err != nil {
+			return err
+		}
+	case *Request_GetBlob:
+		b.EncodeVarint(7<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.GetBlob); err != nil {
+			return err
+		}
 	case *Request_Begin:
 		b.EncodeVarint(16<<3 | proto.WireBytes)
 		if err := b.EncodeMessage(x.Begin); err != nil {
@@ -1008,6 +1048,22 @@ func _Request_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buffer
 		err := b.DecodeMessage(msg)
 		m.Message = &Request_Restore{msg}
 		return true, err
+	case 6: // message.saveBlob
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(SaveBlobResponse)
+		err := b.DecodeMessage(msg)
+		m.Message = &Request_SaveBlob{msg}
+		return true, err
+	case 7: // message.getBlob
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(GetBlobResponse)
+		err := b.DecodeMessage(msg)
+		m.Message = &Request_GetBlob{msg}
+		return true, err
 	case 16: // message.begin
 		if wire != proto.WireBytes {
 			return true, proto.ErrInternalBadWireType
@@ -1066,6 +1122,16 @@ func _Request_OneofSizer(msg proto.Message) (n int) {
 		n += proto.SizeVarint(5<<3 | proto.WireBytes)
 		n += proto.SizeVarint(uint64(s))
 		n += s
+	case *Request_SaveBlob:
+		s := proto.Size(x.SaveBlob)
+		n += proto.SizeVarint(6<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Request_GetBlob:
+		s := proto.Size(x.GetBlob)
+		n += proto.SizeVarint(7<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
 	case *Request_Begin:
 		s := proto.Size(x.Begin)
 		n += proto.SizeVarint(16<<3 | proto.WireBytes)
@@ -1097,6 +1163,8 @@ type Response struct {
 	//	*Response_Snapshot
 	//	*Response_Restore
 	//	*Response_Error
+	//	*Response_SaveBlob
+	//	*Response_GetBlob
 	//	*Response_Begin
 	//	*Response_Point
 	//	*Response_End
@@ -1130,6 +1198,12 @@ type Response_Restore struct {
 type Response_Error struct {
 	Error *ErrorResponse `protobuf:"bytes,6,opt,name=error,oneof"`
 }
+type Response_SaveBlob struct {
+	SaveBlob *SaveBlobRequest `protobuf:"bytes,7,opt,name=saveBlob,oneof"`
+}
+type Response_GetBlob struct {
+	GetBlob *GetBlobRequest `protobuf:"bytes,8,opt,name=getBlob,oneof"`
+}
 type Response_Begin struct {
 	Begin *BeginBatch `protobuf:"bytes,16,opt,name=begin,oneof"`
 }
@@ -1146,6 +1220,8 @@ func (*Response_Keepalive) isResponse_Message() {}
 func (*Response_Snapshot) isResponse_Message()  {}
 func (*Response_Restore) isResponse_Message()   {}
 func (*Response_Error) isResponse_Message()     {}
+func (*Response_SaveBlob) isResponse_Message()  {}
+func (*Response_GetBlob) isResponse_Message()   {}
 func (*Response_Begin) isResponse_Message()     {}
 func (*Response_Point) isResponse_Message()     {}
 func (*Response_End) isResponse_Message()       {}
@@ -1199,6 +1275,20 @@ func (m *Response) GetError() *ErrorResponse {
 	return nil
 }
 
+func (m *Response) GetSaveBlob() *SaveBlobRequest {
+	if x, ok := m.GetMessage().(*Response_SaveBlob); ok {
+		return x.SaveBlob
+	}
+	return nil
+}
+
+func (m *Response) GetGetBlob() *GetBlobRequest {
+	if x, ok := m.GetMessage().(*Response_GetBlob); ok {
+		return x.GetBlob
+	}
+	return nil
+}
+
 func (m *Response) GetBegin() *BeginBatch {
 	if x, ok := m.GetMessage().(*Response_Begin); ok {
 		return x.Begin
@@ -1229,6 +1319,8 @@ func (*Response) XXX_OneofFuncs() (func(msg proto.Message, b *proto.Buffer) erro
 		(*Response_Snapshot)(nil),
 		(*Response_Restore)(nil),
 		(*Response_Error)(nil),
+		(*Response_SaveBlob)(nil),
+		(*Response_GetBlob)(nil),
 		(*Response_Begin)(nil),
 		(*Response_Point)(nil),
 		(*Response_End)(nil),
@@ -1269,6 +1361,16 @@ func _Response_OneofMarshaler(msg proto.Message, b *proto.Buffer) error {
 		if err := b.EncodeMessage(x.Error); err != nil {
 			return err
 		}
+	case *Response_SaveBlob:
+		b.EncodeVarint(7<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.SaveBlob); err != nil {
+			return err
+		}
+	case *Response_GetBlob:
+		b.EncodeVarint(8<<3 | proto.WireBytes)
+		if err := b.EncodeMessage(x.GetBlob); err != nil {
+			return err
+		}
 	case *Response_Begin:
 		b.EncodeVarint(16<<3 | proto.WireBytes)
 		if err := b.EncodeMessage(x.Begin); err != nil {
@@ -1342,6 +1444,22 @@ func _Response_OneofUnmarshaler(msg proto.Message, tag, wire int, b *proto.Buffe
 		err := b.DecodeMessage(msg)
 		m.Message = &Response_Error{msg}
 		return true, err
+	case 7: // message.saveBlob
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(SaveBlobRequest)
+		err := b.DecodeMessage(msg)
+		m.Message = &Response_SaveBlob{msg}
+		return true, err
+	case 8: // message.getBlob
+		if wire != proto.WireBytes {
+			return true, proto.ErrInternalBadWireType
+		}
+		msg := new(GetBlobRequest)
+		err := b.DecodeMessage(msg)
+		m.Message = &Response_GetBlob{msg}
+		return true, err
 	case 16: // message.begin
 		if wire != proto.WireBytes {
 			return true, proto.ErrInternalBadWireType
@@ -1405,6 +1523,16 @@ func _Response_OneofSizer(msg proto.Message) (n int) {
 		n += proto.SizeVarint(6<<3 | proto.WireBytes)
 		n += proto.SizeVarint(uint64(s))
 		n += s
+	case *Response_SaveBlob:
+		s := proto.Size(x.SaveBlob)
+		n += proto.SizeVarint(7<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
+	case *Response_GetBlob:
+		s := proto.Size(x.GetBlob)
+		n += proto.SizeVarint(8<<3 | proto.WireBytes)
+		n += proto.SizeVarint(uint64(s))
+		n += s
 	case *Response_Begin:
 		s := proto.Size(x.Begin)
 		n += proto.SizeVarint(16<<3 | proto.WireBytes)
@@ -1427,6 +1555,134 @@ func _Response_OneofSizer(msg proto.Message) (n int) {
 	return n
 }
 
+// Sent from the process to Kapacitor requesting that a blob be saved in the blob store.
+// Unlike other *Request messages it is sent to Kapacitor from the process.
+type SaveBlobRequest struct {
+	// The content of the blob.
+	Data []byte `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	// Optional tag name to associate with the saved blob.
+	Tag string `protobuf:"bytes,2,opt,name=tag" json:"tag,omitempty"`
+}
+
+func (m *SaveBlobRequest) Reset()                    { *m = SaveBlobRequest{} }
+func (m *SaveBlobRequest) String() string            { return proto.CompactTextString(m) }
+func (*SaveBlobRequest) ProtoMessage()               {}
+func (*SaveBlobRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{19} }
+
+func (m *SaveBlobRequest) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+func (m *SaveBlobRequest) GetTag() string {
+	if m != nil {
+		return m.Tag
+	}
+	return ""
+}
+
+// Respond to the process with the ID of the saved blob.
+type SaveBlobResponse struct {
+	Id    string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+	Tag   string `protobuf:"bytes,2,opt,name=tag" json:"tag,omitempty"`
+	Error string `protobuf:"bytes,3,opt,name=error" json:"error,omitempty"`
+}
+
+func (m *SaveBlobResponse) Reset()                    { *m = SaveBlobResponse{} }
+func (m *SaveBlobResponse) String() string            { return proto.CompactTextString(m) }
+func (*SaveBlobResponse) ProtoMessage()               {}
+func (*SaveBlobResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{20} }
+
+func (m *SaveBlobResponse) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *SaveBlobResponse) GetTag() string {
+	if m != nil {
+		return m.Tag
+	}
+	return ""
+}
+
+func (m *SaveBlobResponse) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+// Sent from the process to Kapacitor requesting a blob from the blob store.
+// The blob is retrieved by its ID or, if the ID is empty, by a tag name.
+type GetBlobRequest struct {
+	Id  string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+	Tag string `protobuf:"bytes,2,opt,name=tag" json:"tag,omitempty"`
+}
+
+func (m *GetBlobRequest) Reset()                    { *m = GetBlobRequest{} }
+func (m *GetBlobRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetBlobRequest) ProtoMessage()               {}
+func (*GetBlobRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{21} }
+
+func (m *GetBlobRequest) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *GetBlobRequest) GetTag() string {
+	if m != nil {
+		return m.Tag
+	}
+	return ""
+}
+
+// Respond to the process with the content of the requested blob.
+type GetBlobResponse struct {
+	Id    string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+	Tag   string `protobuf:"bytes,2,opt,name=tag" json:"tag,omitempty"`
+	Data  []byte `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+	Error string `protobuf:"bytes,4,opt,name=error" json:"error,omitempty"`
+}
+
+func (m *GetBlobResponse) Reset()                    { *m = GetBlobResponse{} }
+func (m *GetBlobResponse) String() string            { return proto.CompactTextString(m) }
+func (*GetBlobResponse) ProtoMessage()               {}
+func (*GetBlobResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{22} }
+
+func (m *GetBlobResponse) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *GetBlobResponse) GetTag() string {
+	if m != nil {
+		return m.Tag
+	}
+	return ""
+}
+
+func (m *GetBlobResponse) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+func (m *GetBlobResponse) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterType((*InfoRequest)(nil), "agent.InfoRequest")
 	proto.RegisterType((*InfoResponse)(nil), "agent.InfoResponse")
@@ -1447,6 +1703,10 @@ func init() {
 	proto.RegisterType((*EndBatch)(nil), "agent.EndBatch")
 	proto.RegisterType((*Request)(nil), "agent.Request")
 	proto.RegisterType((*Response)(nil), "agent.Response")
+	proto.RegisterType((*SaveBlobRequest)(nil), "agent.SaveBlobRequest")
+	proto.RegisterType((*SaveBlobResponse)(nil), "agent.SaveBlobResponse")
+	proto.RegisterType((*GetBlobRequest)(nil), "agent.GetBlobRequest")
+	proto.RegisterType((*GetBlobResponse)(nil), "agent.GetBlobResponse")
 	proto.RegisterEnum("agent.EdgeType", EdgeType_name, EdgeType_value)
 	proto.RegisterEnum("agent.ValueType", ValueType_name, ValueType_value)
 }
@@ -1454,78 +1714,86 @@ func init() {
 func init() { proto.RegisterFile("udf.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 1159 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xc4, 0x57, 0xdd, 0x72, 0xdb, 0x44,
-	0x14, 0xb6, 0x22, 0xcb, 0x96, 0x8e, 0x9d, 0x58, 0xd9, 0x86, 0x56, 0x84, 0x4e, 0x27, 0x88, 0xb6,
-	0x71, 0x42, 0x31, 0x60, 0x60, 0x5a, 0x3a, 0xa5, 0x4c, 0x8c, 0x0d, 0xf6, 0xd0, 0x26, 0x9d, 0x8d,
-	0xdb, 0x7b, 0x39, 0xde, 0xb8, 0x9a, 0x38, 0x92, 0x91, 0xd6, 0x01, 0x73, 0xc5, 0xe3, 0xf0, 0x00,
-	0x3c, 0x04, 0x17, 0x3c, 0x09, 0x33, 0xbc, 0x03, 0xb3, 0x3f, 0x5a, 0xad, 0x6c, 0x43, 0xa6, 0x4c,
-	0x67, 0xb8, 0xd3, 0x9e, 0xf3, 0x9d, 0x9f, 0x3d, 0xbf, 0x2b, 0x70, 0xe6, 0xe3, 0xf3, 0xd6, 0x2c,
-	0x89, 0x69, 0x8c, 0xac, 0x60, 0x42, 0x22, 0xea, 0x6f, 0x42, 0x6d, 0x10, 0x9d, 0xc7, 0x98, 0xfc,
-	0x30, 0x27, 0x29, 0xf5, 0xff, 0x32, 0xa0, 0x2e, 0xce, 0xe9, 0x2c, 0x8e, 0x52, 0x82, 0xee, 0x81,
-	0xf5, 0x63, 0x10, 0xd1, 0xd4, 0x33, 0xf6, 0x8c, 0xe6, 0x56, 0xbb, 0xd1, 0xe2, 0x62, 0xad, 0xde,
-	0x78, 0x42, 0x86, 0x8b, 0x19, 0xc1, 0x82, 0x8b, 0x3e, 0x04, 0x7b, 0x96, 0xc4, 0x57, 0xe1, 0x98,
-	0xa4, 0xde, 0xc6, 0x7a, 0xa4, 0x02, 0xa0, 0xc7, 0x50, 0x8d, 0x67, 0x34, 0x8c, 0xa3, 0xd4, 0x33,
-	0xf7, 0xcc, 0x66, 0xad, 0xbd, 0x27, 0xb1, 0xba, 0xe5, 0xd6, 0x89, 0x80, 0xf4, 0x22, 0x9a, 0x2c,
-	0x70, 0x26, 0xb0, 0xfb, 0x1c, 0xea, 0x3a, 0x03, 0xb9, 0x60, 0x5e, 0x90, 0x05, 0xf7, 0xce, 0xc1,
-	0xec, 0x13, 0xed, 0x83, 0x75, 0x15, 0x4c, 0xe7, 0x84, 0xfb, 0x51, 0x6b, 0x6f, 0x4b, 0xdd, 0x42,
-	0x8a, 0x5b, 0x10, 0xfc, 0xc7, 0x1b, 0x8f, 0x0c, 0xff, 0x29, 0x40, 0xce, 0x40, 0x9f, 0x00, 0x70,
-	0x16, 0xf3, 0x97, 0xdd, 0xd8, 0x6c, 0x6e, 0xb5, 0x5d, 0x29, 0xff, 0x2a, 0x63, 0x60, 0x0d, 0xe3,
-	0x9f, 0xb3, 0xf0, 0x85, 0x54, 0x86, 0x0f, 0xed, 0xe7, 0x37, 0x33, 0xf8, 0xcd, 0x36, 0x0b, 0xd6,
-	0xd5, 0x35, 0xd0, 0x4d, 0xa8, 0xd0, 0x20, 0xbd, 0x18, 0x74, 0xb9, 0x97, 0x0e, 0x96, 0x27, 0x46,
-	0x8f, 0xe2, 0x31, 0x19, 0x74, 0x3d, 0x53, 0xd0, 0xc5, 0xc9, 0xef, 0x43, 0x45, 0xa8, 0x40, 0x08,
-	0xca, 0x51, 0x70, 0x49, 0xe4, 0x8d, 0xf9, 0x37, 0x3a, 0x84, 0x0a, 0xf7, 0x89, 0xc5, 0x9e, 0x59,
-	0x45, 0x05, 0xab, 0xdc, 0x73, 0x2c, 0x11, 0xfe, 0x9f, 0x06, 0xd4, 0x34, 0x3a, 0xba, 0x0b, 0x65,
-	0xba, 0x98, 0x11, 0x99, 0xdf, 0xd5, 0xdb, 0x72, 0x2e, 0xba, 0x03, 0xce, 0x28, 0x8e, 0xa7, 0xaf,
-	0x54, 0x60, 0xed, 0x7e, 0x09, 0xe7, 0x24, 0x74, 0x1b, 0xec, 0x30, 0xa2, 0x82, 0xcd, 0x3c, 0x37,
-	0xfb, 0x25, 0xac, 0x28, 0xc8, 0x87, 0xda, 0x38, 0x9e, 0x8f, 0xa6, 0x44, 0x00, 0xca, 0x7b, 0x46,
-	0xd3, 0xe8, 0x97, 0xb0, 0x4e, 0x64, 0x98, 0x94, 0x26, 0x61, 0x34, 0x11, 0x18, 0x8b, 0x5d, 0x8f,
-	0x61, 0x34, 0x22, 0xba, 0x0f, 0x9b, 0xe3, 0x79, 0x12, 0x28, 0xe7, 0xbd, 0x8a, 0x34, 0x55, 0x24,
-	0x77, 0xaa, 0xb2, 0x04, 0xfc, 0xa7, 0xac, 0x9a, 0x59, 0x7a, 0x64, 0x35, 0x7b, 0x50, 0x4d, 0xe7,
-	0x67, 0x67, 0x24, 0x15, 0xf5, 0x6c, 0xe3, 0xec, 0x88, 0x76, 0xc0, 0x22, 0x49, 0x12, 0x27, 0x32,
-	0x1f, 0xe2, 0xe0, 0x6f, 0x43, 0xe3, 0x34, 0x0a, 0x66, 0xe9, 0xeb, 0x38, 0x4b, 0xb1, 0xdf, 0x02,
-	0x37, 0x27, 0x49, 0xb5, 0xbb, 0x60, 0xa7, 0x92, 0xc6, 0xf5, 0xd6, 0xb1, 0x3a, 0xfb, 0x0f, 0x60,
-	0x0b, 0x93, 0x94, 0xc6, 0x09, 0xc9, 0x8a, 0xe4, 0xdf, 0xd0, 0x47, 0xd0, 0x50, 0xe8, 0xff, 0xe8,
-	0xf3, 0x7d, 0x70, 0xbf, 0x27, 0x64, 0x16, 0x4c, 0xc3, 0x2b, 0x65, 0x12, 0x41, 0x99, 0x86, 0xb2,
-	0x68, 0x4c, 0xcc, 0xbf, 0xfd, 0x7d, 0xd8, 0xd6, 0x70, 0xd2, 0xd8, 0x3a, 0xe0, 0x3d, 0xd8, 0xec,
-	0x31, 0xcd, 0x0a, 0xa4, 0xec, 0x1a, 0xba, 0xdd, 0x3f, 0x0c, 0x80, 0x0e, 0x99, 0x84, 0x51, 0x27,
-	0xa0, 0x67, 0xaf, 0xd7, 0xd6, 0xe9, 0x0e, 0x58, 0x93, 0x24, 0x9e, 0xcf, 0x32, 0x87, 0xf9, 0x01,
-	0x7d, 0x0c, 0x65, 0x1a, 0x4c, 0xb2, 0x59, 0xf0, 0x9e, 0xac, 0xc0, 0x5c, 0x55, 0x6b, 0x18, 0x4c,
-	0xe4, 0x18, 0xe0, 0x40, 0xa6, 0x3a, 0x0d, 0x7f, 0x16, 0x75, 0x64, 0x62, 0xfe, 0xcd, 0x1a, 0x67,
-	0xb4, 0x38, 0x66, 0x06, 0x2d, 0x1e, 0x24, 0x79, 0xda, 0x7d, 0x08, 0x8e, 0x12, 0x5f, 0x33, 0x2c,
-	0x76, 0xf4, 0x61, 0xe1, 0xe8, 0x93, 0xe1, 0xd7, 0x0a, 0x58, 0x2f, 0xe2, 0x30, 0x5a, 0x1b, 0x3c,
-	0x75, 0xbb, 0x0d, 0xed, 0x76, 0xbb, 0x60, 0x8f, 0x03, 0x1a, 0x8c, 0x82, 0x94, 0xc8, 0xee, 0x55,
-	0x67, 0xd4, 0x84, 0x46, 0x42, 0x28, 0x89, 0x58, 0x8d, 0xbe, 0x88, 0xa7, 0xe1, 0xd9, 0x82, 0x7b,
-	0xef, 0xe0, 0x65, 0x72, 0x1e, 0x23, 0x4b, 0x8f, 0xd1, 0x1d, 0x80, 0x71, 0x78, 0x49, 0xa2, 0x94,
-	0xcf, 0x96, 0xca, 0x9e, 0xd9, 0x74, 0xb0, 0x46, 0x41, 0x87, 0x32, 0x86, 0x55, 0x1e, 0xc3, 0x9b,
-	0x32, 0x86, 0xdc, 0xff, 0x95, 0xf0, 0x75, 0xa0, 0x7e, 0x1e, 0x92, 0xe9, 0x38, 0xed, 0xf2, 0xf6,
-	0xf3, 0x6c, 0x2e, 0x73, 0xa7, 0x20, 0xf3, 0xad, 0x06, 0x10, 0xb2, 0x05, 0x19, 0xf4, 0x25, 0x38,
-	0xe2, 0x3c, 0x88, 0xa8, 0xe7, 0x14, 0x12, 0xa7, 0x2b, 0x18, 0x44, 0x54, 0x48, 0xe7, 0xe8, 0xdc,
-	0xfc, 0x29, 0xef, 0x6c, 0x0f, 0xfe, 0xd1, 0xbc, 0x00, 0x14, 0xcc, 0x0b, 0x12, 0x7a, 0x02, 0x20,
-	0xce, 0x9d, 0x38, 0x9e, 0x7a, 0x75, 0xae, 0xe1, 0xf6, 0x1a, 0x0d, 0x8c, 0x2d, 0xe4, 0x35, 0xbc,
-	0x56, 0x2b, 0xb5, 0xb7, 0x52, 0x2b, 0xbb, 0x5f, 0xc3, 0xf6, 0x4a, 0xc0, 0xae, 0x53, 0x60, 0xe8,
-	0x0a, 0x9e, 0xc0, 0x56, 0x31, 0x60, 0xd7, 0x49, 0x9b, 0x6b, 0xcd, 0x6b, 0x01, 0x7b, 0x23, 0xff,
-	0xbf, 0x82, 0xc6, 0x52, 0xbc, 0xae, 0x13, 0xb7, 0xf5, 0x56, 0xf9, 0xdd, 0x00, 0xbb, 0x17, 0x8d,
-	0xdf, 0xb4, 0xef, 0x59, 0x5f, 0x5d, 0x06, 0x3f, 0x89, 0x7d, 0x81, 0xf9, 0x37, 0xfa, 0x48, 0xd6,
-	0x71, 0x99, 0xa7, 0xf4, 0xdd, 0xec, 0x0d, 0x21, 0x95, 0xaf, 0x94, 0xf2, 0x5b, 0xef, 0xfa, 0x5f,
-	0x4c, 0xa8, 0x66, 0x43, 0xb3, 0x09, 0xe5, 0x30, 0x3a, 0x8f, 0xb9, 0x60, 0xbe, 0x53, 0xb5, 0xd7,
-	0x52, 0xbf, 0x84, 0x39, 0x42, 0x20, 0x43, 0x2a, 0x5f, 0x1c, 0x39, 0x52, 0x3d, 0x0c, 0x04, 0x32,
-	0xa4, 0xe8, 0x21, 0x38, 0x17, 0xd9, 0xd0, 0xe5, 0x17, 0xaf, 0xb5, 0x6f, 0x49, 0xf8, 0xf2, 0xd0,
-	0x66, 0x0b, 0x56, 0x61, 0xd1, 0xe7, 0xda, 0xd2, 0x28, 0x73, 0xb9, 0xac, 0xc9, 0x97, 0x16, 0x14,
-	0x5b, 0xbc, 0x19, 0x12, 0x7d, 0x0a, 0xd5, 0x44, 0xac, 0x13, 0x1e, 0xa0, 0x5a, 0xfb, 0x1d, 0x29,
-	0x54, 0x5c, 0x49, 0xfd, 0x12, 0xce, 0x70, 0xe8, 0x00, 0xac, 0x11, 0x1b, 0xbd, 0x9e, 0x5b, 0x78,
-	0x3e, 0xe5, 0xe3, 0xb8, 0x5f, 0xc2, 0x02, 0x81, 0xee, 0x82, 0x35, 0x63, 0xcd, 0xe6, 0x6d, 0x73,
-	0x68, 0x5d, 0x6f, 0x40, 0x86, 0xe2, 0x4c, 0xf4, 0x01, 0x98, 0x24, 0x1a, 0x7b, 0x88, 0x63, 0x1a,
-	0x4b, 0x19, 0xed, 0x97, 0x30, 0xe3, 0x76, 0x1c, 0xa8, 0x5e, 0x92, 0x34, 0x0d, 0x26, 0xc4, 0xff,
-	0xcd, 0x04, 0x5b, 0xad, 0x9a, 0x83, 0x42, 0x0e, 0x6e, 0xac, 0x79, 0x27, 0xaa, 0x24, 0x1c, 0x14,
-	0x92, 0x70, 0xa3, 0x90, 0x04, 0x1d, 0x1a, 0x52, 0xf4, 0x68, 0x35, 0x0b, 0xde, 0x6a, 0x16, 0x94,
-	0x90, 0x96, 0x86, 0x2f, 0x56, 0xd2, 0x70, 0x6b, 0x25, 0x0d, 0x4a, 0x2e, 0xcf, 0x43, 0x7b, 0x39,
-	0x0f, 0x37, 0x97, 0xf3, 0xa0, 0x84, 0x54, 0x22, 0x1e, 0x64, 0x5b, 0xb6, 0xc2, 0x25, 0x76, 0xb2,
-	0xc8, 0xe9, 0xab, 0x98, 0x45, 0x99, 0x83, 0xfe, 0xf7, 0xb4, 0x1d, 0xbe, 0x0f, 0x76, 0xf6, 0xd4,
-	0x47, 0x00, 0x95, 0xd3, 0x21, 0xee, 0x1d, 0x3d, 0x77, 0x4b, 0xc8, 0x01, 0xab, 0x73, 0x34, 0xfc,
-	0xa6, 0xef, 0x1a, 0x87, 0x5d, 0x70, 0xd4, 0xbb, 0x12, 0xd9, 0x50, 0xee, 0x9c, 0x9c, 0x3c, 0x73,
-	0x4b, 0xa8, 0x0a, 0xe6, 0xe0, 0x78, 0xe8, 0x1a, 0x4c, 0xac, 0x7b, 0xf2, 0xb2, 0xf3, 0xac, 0xe7,
-	0x6e, 0x48, 0x15, 0x83, 0xe3, 0xef, 0x5c, 0x13, 0xd5, 0xc1, 0xee, 0xbe, 0xc4, 0x47, 0xc3, 0xc1,
-	0xc9, 0xb1, 0x5b, 0x1e, 0x55, 0xf8, 0xff, 0xcb, 0x67, 0x7f, 0x07, 0x00, 0x00, 0xff, 0xff, 0x71,
-	0xb7, 0xac, 0x48, 0xcc, 0x0c, 0x00, 0x00,
+	// 1287 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xcc, 0x57, 0xdb, 0x72, 0xdb, 0xc4,
+	0x1b, 0xb7, 0x2c, 0xd9, 0x96, 0x3e, 0x3b, 0xb1, 0xb2, 0xcd, 0xbf, 0xd5, 0x3f, 0x74, 0x3a, 0x41,
+	0xf4, 0xe0, 0x86, 0x12, 0xc0, 0xd0, 0x69, 0xe9, 0x94, 0x32, 0x31, 0x36, 0xb5, 0xa1, 0x4d, 0x3a,
+	0x1b, 0xb7, 0x77, 0x5c, 0xc8, 0xd1, 0xc6, 0xd5, 0xc4, 0x91, 0x8c, 0xb4, 0x0e, 0x98, 0x77, 0xe1,
+	0x9e, 0x47, 0xe1, 0x82, 0x27, 0x81, 0xe1, 0x1d, 0x98, 0x3d, 0x48, 0x5a, 0xc9, 0x86, 0x50, 0xa6,
+	0x17, 0xdc, 0x69, 0xbf, 0xfd, 0x7d, 0xe7, 0xd3, 0x0a, 0xac, 0x85, 0x7f, 0xba, 0x3f, 0x8f, 0x23,
+	0x1a, 0xa1, 0x9a, 0x37, 0x25, 0x21, 0x75, 0x37, 0xa0, 0x39, 0x0a, 0x4f, 0x23, 0x4c, 0xbe, 0x5b,
+	0x90, 0x84, 0xba, 0x7f, 0x68, 0xd0, 0x12, 0xe7, 0x64, 0x1e, 0x85, 0x09, 0x41, 0xb7, 0xa0, 0xf6,
+	0xbd, 0x17, 0xd2, 0xc4, 0xd1, 0x76, 0xb5, 0xce, 0x66, 0xb7, 0xbd, 0xcf, 0xd9, 0xf6, 0x07, 0xfe,
+	0x94, 0x8c, 0x97, 0x73, 0x82, 0xc5, 0x2d, 0x7a, 0x1f, 0xcc, 0x79, 0x1c, 0x5d, 0x04, 0x3e, 0x49,
+	0x9c, 0xea, 0x7a, 0x64, 0x06, 0x40, 0x8f, 0xa0, 0x11, 0xcd, 0x69, 0x10, 0x85, 0x89, 0xa3, 0xef,
+	0xea, 0x9d, 0x66, 0x77, 0x57, 0x62, 0x55, 0xcd, 0xfb, 0x47, 0x02, 0x32, 0x08, 0x69, 0xbc, 0xc4,
+	0x29, 0xc3, 0xce, 0x73, 0x68, 0xa9, 0x17, 0xc8, 0x06, 0xfd, 0x8c, 0x2c, 0xb9, 0x75, 0x16, 0x66,
+	0x9f, 0xe8, 0x0e, 0xd4, 0x2e, 0xbc, 0xd9, 0x82, 0x70, 0x3b, 0x9a, 0xdd, 0x2d, 0x29, 0x5b, 0x70,
+	0x71, 0x0d, 0xe2, 0xfe, 0x51, 0xf5, 0xa1, 0xe6, 0x3e, 0x01, 0xc8, 0x2f, 0xd0, 0x47, 0x00, 0xfc,
+	0x8a, 0xd9, 0xcb, 0x3c, 0xd6, 0x3b, 0x9b, 0x5d, 0x5b, 0xf2, 0xbf, 0x4a, 0x2f, 0xb0, 0x82, 0x71,
+	0x4f, 0x59, 0xf8, 0x02, 0x2a, 0xc3, 0x87, 0xee, 0xe4, 0x9e, 0x69, 0xdc, 0xb3, 0x8d, 0x82, 0xf6,
+	0xcc, 0x0d, 0x74, 0x15, 0xea, 0xd4, 0x4b, 0xce, 0x46, 0x7d, 0x6e, 0xa5, 0x85, 0xe5, 0x89, 0xd1,
+	0xc3, 0xc8, 0x27, 0xa3, 0xbe, 0xa3, 0x0b, 0xba, 0x38, 0xb9, 0x43, 0xa8, 0x0b, 0x11, 0x08, 0x81,
+	0x11, 0x7a, 0xe7, 0x44, 0x7a, 0xcc, 0xbf, 0xd1, 0x1e, 0xd4, 0xb9, 0x4d, 0x2c, 0xf6, 0x4c, 0x2b,
+	0x2a, 0x68, 0xe5, 0x96, 0x63, 0x89, 0x70, 0x7f, 0xd3, 0xa0, 0xa9, 0xd0, 0xd1, 0x4d, 0x30, 0xe8,
+	0x72, 0x4e, 0x64, 0x7e, 0x57, 0xbd, 0xe5, 0xb7, 0xe8, 0x06, 0x58, 0x93, 0x28, 0x9a, 0xbd, 0xca,
+	0x02, 0x6b, 0x0e, 0x2b, 0x38, 0x27, 0xa1, 0xeb, 0x60, 0x06, 0x21, 0x15, 0xd7, 0xcc, 0x72, 0x7d,
+	0x58, 0xc1, 0x19, 0x05, 0xb9, 0xd0, 0xf4, 0xa3, 0xc5, 0x64, 0x46, 0x04, 0xc0, 0xd8, 0xd5, 0x3a,
+	0xda, 0xb0, 0x82, 0x55, 0x22, 0xc3, 0x24, 0x34, 0x0e, 0xc2, 0xa9, 0xc0, 0xd4, 0x98, 0x7b, 0x0c,
+	0xa3, 0x10, 0xd1, 0x6d, 0xd8, 0xf0, 0x17, 0xb1, 0x97, 0x19, 0xef, 0xd4, 0xa5, 0xaa, 0x22, 0xb9,
+	0xd7, 0x90, 0x25, 0xe0, 0x3e, 0x81, 0x96, 0x48, 0x8f, 0xac, 0x66, 0x07, 0x1a, 0xc9, 0xe2, 0xe4,
+	0x84, 0x24, 0xa2, 0x9e, 0x4d, 0x9c, 0x1e, 0xd1, 0x36, 0xd4, 0x48, 0x1c, 0x47, 0xb1, 0xcc, 0x87,
+	0x38, 0xb8, 0x5b, 0xd0, 0x3e, 0x0e, 0xbd, 0x79, 0xf2, 0x3a, 0x4a, 0x53, 0xec, 0xee, 0x83, 0x9d,
+	0x93, 0xa4, 0xd8, 0x1d, 0x30, 0x13, 0x49, 0xe3, 0x72, 0x5b, 0x38, 0x3b, 0xbb, 0xf7, 0x60, 0x13,
+	0x93, 0x84, 0x46, 0x31, 0x49, 0x8b, 0xe4, 0xef, 0xd0, 0x07, 0xd0, 0xce, 0xd0, 0xff, 0xd2, 0xe6,
+	0xdb, 0x60, 0x7f, 0x43, 0xc8, 0xdc, 0x9b, 0x05, 0x17, 0x99, 0x4a, 0x04, 0x06, 0x0d, 0x64, 0xd1,
+	0xe8, 0x98, 0x7f, 0xbb, 0x77, 0x60, 0x4b, 0xc1, 0x49, 0x65, 0xeb, 0x80, 0xb7, 0x60, 0x63, 0xc0,
+	0x24, 0x67, 0xa0, 0x4c, 0xaf, 0xa6, 0xea, 0xfd, 0x55, 0x03, 0xe8, 0x91, 0x69, 0x10, 0xf6, 0x3c,
+	0x7a, 0xf2, 0x7a, 0x6d, 0x9d, 0x6e, 0x43, 0x6d, 0x1a, 0x47, 0x8b, 0x79, 0x6a, 0x30, 0x3f, 0xa0,
+	0x0f, 0xc1, 0xa0, 0xde, 0x34, 0x9d, 0x05, 0xef, 0xc8, 0x0a, 0xcc, 0x45, 0xed, 0x8f, 0xbd, 0xa9,
+	0x1c, 0x03, 0x1c, 0xc8, 0x44, 0x27, 0xc1, 0x8f, 0xa2, 0x8e, 0x74, 0xcc, 0xbf, 0x59, 0xe3, 0x4c,
+	0x96, 0x87, 0xde, 0xb9, 0xa8, 0x1c, 0x13, 0xcb, 0xd3, 0xce, 0x03, 0xb0, 0x32, 0xf6, 0x35, 0xc3,
+	0x62, 0x5b, 0x1d, 0x16, 0x96, 0x3a, 0x19, 0x7e, 0xae, 0x43, 0xed, 0x45, 0x14, 0x84, 0x6b, 0x83,
+	0x97, 0x79, 0x57, 0x55, 0xbc, 0xdb, 0x01, 0xd3, 0xf7, 0xa8, 0x37, 0xf1, 0x12, 0x22, 0xbb, 0x37,
+	0x3b, 0xa3, 0x0e, 0xb4, 0x63, 0x42, 0x49, 0xc8, 0x6a, 0xf4, 0x45, 0x34, 0x0b, 0x4e, 0x96, 0xdc,
+	0x7a, 0x0b, 0x97, 0xc9, 0x79, 0x8c, 0x6a, 0x6a, 0x8c, 0x6e, 0x00, 0xf8, 0xc1, 0x39, 0x09, 0x13,
+	0x3e, 0x5b, 0xea, 0xbb, 0x7a, 0xc7, 0xc2, 0x0a, 0x05, 0xed, 0xc9, 0x18, 0x36, 0x78, 0x0c, 0xaf,
+	0xca, 0x18, 0x72, 0xfb, 0x57, 0xc2, 0xd7, 0x83, 0xd6, 0x69, 0x40, 0x66, 0x7e, 0xd2, 0xe7, 0xed,
+	0xe7, 0x98, 0x9c, 0xe7, 0x46, 0x81, 0xe7, 0x2b, 0x05, 0x20, 0x78, 0x0b, 0x3c, 0xe8, 0x33, 0xb0,
+	0xc4, 0x79, 0x14, 0x52, 0xc7, 0x2a, 0x24, 0x4e, 0x15, 0x30, 0x0a, 0xa9, 0xe0, 0xce, 0xd1, 0xb9,
+	0xfa, 0x63, 0xde, 0xd9, 0x0e, 0xfc, 0xa5, 0x7a, 0x01, 0x28, 0xa8, 0x17, 0x24, 0xf4, 0x18, 0x40,
+	0x9c, 0x7b, 0x51, 0x34, 0x73, 0x5a, 0x5c, 0xc2, 0xf5, 0x35, 0x12, 0xd8, 0xb5, 0xe0, 0x57, 0xf0,
+	0x4a, 0xad, 0x34, 0xdf, 0x4a, 0xad, 0xec, 0x7c, 0x01, 0x5b, 0x2b, 0x01, 0xbb, 0x4c, 0x80, 0xa6,
+	0x0a, 0x78, 0x0c, 0x9b, 0xc5, 0x80, 0x5d, 0xc6, 0xad, 0xaf, 0x55, 0xaf, 0x04, 0xec, 0x8d, 0xec,
+	0xff, 0x1c, 0xda, 0xa5, 0x78, 0x5d, 0xc6, 0x6e, 0xaa, 0xad, 0xf2, 0x8b, 0x06, 0xe6, 0x20, 0xf4,
+	0xdf, 0xb4, 0xef, 0x59, 0x5f, 0x9d, 0x7b, 0x3f, 0x88, 0x7d, 0x81, 0xf9, 0x37, 0xfa, 0x40, 0xd6,
+	0xb1, 0xc1, 0x53, 0xfa, 0xff, 0xf4, 0x0d, 0x21, 0x85, 0xaf, 0x94, 0xf2, 0x5b, 0xef, 0xfa, 0xdf,
+	0x75, 0x68, 0xa4, 0x43, 0xb3, 0x03, 0x46, 0x10, 0x9e, 0x46, 0x9c, 0x31, 0xdf, 0xa9, 0xca, 0x6b,
+	0x69, 0x58, 0xc1, 0x1c, 0x21, 0x90, 0x01, 0x75, 0xaa, 0x25, 0x64, 0x40, 0x0b, 0xc8, 0x80, 0xa2,
+	0x07, 0x60, 0x9d, 0xa5, 0x43, 0x97, 0x3b, 0xde, 0xec, 0x5e, 0x93, 0xf0, 0xf2, 0xd0, 0x66, 0x0b,
+	0x36, 0xc3, 0xa2, 0x4f, 0x95, 0xa5, 0x61, 0xec, 0x6a, 0x4a, 0x93, 0x97, 0x16, 0x14, 0x5b, 0xbc,
+	0x29, 0x12, 0x7d, 0x0c, 0x8d, 0x58, 0xac, 0x13, 0x1e, 0xa0, 0x66, 0xf7, 0x7f, 0x92, 0xa9, 0xb8,
+	0x92, 0x86, 0x15, 0x9c, 0xe2, 0xd0, 0x7d, 0x30, 0x13, 0xef, 0x82, 0xf4, 0x66, 0xd1, 0xc4, 0xa9,
+	0x17, 0x0c, 0x3c, 0x96, 0xe4, 0x74, 0x0f, 0x70, 0x4d, 0x92, 0x86, 0xba, 0xd0, 0x98, 0x12, 0xca,
+	0xb9, 0x1a, 0x05, 0xf3, 0x9e, 0x12, 0x5a, 0x62, 0x4a, 0x81, 0xe8, 0x2e, 0xd4, 0x26, 0x6c, 0xca,
+	0x3b, 0x76, 0xe1, 0xa5, 0x96, 0x4f, 0xfe, 0x61, 0x05, 0x0b, 0x04, 0xba, 0x09, 0xb5, 0x39, 0xeb,
+	0x6b, 0x67, 0x8b, 0x43, 0x5b, 0x6a, 0xaf, 0x33, 0x14, 0xbf, 0x44, 0xef, 0x81, 0x4e, 0x42, 0xdf,
+	0x41, 0x1c, 0xd3, 0x2e, 0x15, 0xcf, 0xb0, 0x82, 0xd9, 0x6d, 0xcf, 0x82, 0xc6, 0x39, 0x49, 0x12,
+	0x6f, 0x4a, 0xdc, 0x9f, 0x0c, 0x30, 0xb3, 0xad, 0x76, 0xb7, 0x90, 0xee, 0x2b, 0x6b, 0x9e, 0xa4,
+	0x59, 0xbe, 0xef, 0x16, 0xf2, 0x7d, 0xa5, 0x90, 0x6f, 0x15, 0x1a, 0x50, 0xf4, 0x70, 0x35, 0xe1,
+	0xce, 0x6a, 0xc2, 0x33, 0x26, 0x25, 0xe3, 0xf7, 0x57, 0x32, 0x7e, 0x6d, 0x25, 0xe3, 0x4a, 0x22,
+	0xd2, 0x94, 0x77, 0xcb, 0x29, 0xbf, 0x5a, 0x4e, 0x79, 0x9e, 0x88, 0x34, 0xe7, 0xf7, 0xd2, 0x85,
+	0x2e, 0x12, 0xbe, 0x9d, 0x46, 0x4e, 0xdd, 0xfa, 0x2c, 0xca, 0x1c, 0xc4, 0x4b, 0x31, 0xad, 0x90,
+	0x62, 0xae, 0xf3, 0x0a, 0xc9, 0x4b, 0x51, 0x92, 0x58, 0x29, 0xa6, 0x05, 0x62, 0x16, 0x4a, 0x31,
+	0x2b, 0x90, 0xac, 0x14, 0xff, 0x33, 0xf5, 0xf1, 0x00, 0xda, 0x25, 0x97, 0xd8, 0xd0, 0x62, 0x4b,
+	0x5d, 0x3e, 0xdc, 0xf8, 0x37, 0x1b, 0x30, 0xd4, 0x9b, 0xca, 0x61, 0xc2, 0x3e, 0xdd, 0xaf, 0xc1,
+	0x2e, 0x77, 0x0b, 0xda, 0x84, 0x6a, 0xe0, 0xcb, 0x29, 0x54, 0x0d, 0xfc, 0x55, 0xae, 0xfc, 0x5d,
+	0xa5, 0xab, 0xef, 0xaa, 0x2e, 0x6c, 0x16, 0x43, 0x74, 0xb9, 0x24, 0xf7, 0x5b, 0x68, 0x97, 0xfa,
+	0xee, 0x1f, 0xa8, 0x4f, 0x5d, 0xd3, 0x15, 0xd7, 0x32, 0x93, 0x0c, 0xc5, 0xa4, 0xbd, 0x77, 0xc1,
+	0x4c, 0x7f, 0xeb, 0x10, 0x40, 0xfd, 0x78, 0x8c, 0x07, 0x07, 0xcf, 0xed, 0x0a, 0xb2, 0xa0, 0xd6,
+	0x3b, 0x18, 0x7f, 0x39, 0xb4, 0xb5, 0xbd, 0x3e, 0x58, 0xd9, 0x3f, 0x04, 0x32, 0xc1, 0xe8, 0x1d,
+	0x1d, 0x3d, 0xb3, 0x2b, 0xa8, 0x01, 0xfa, 0xe8, 0x70, 0x6c, 0x6b, 0x8c, 0xad, 0x7f, 0xf4, 0xb2,
+	0xf7, 0x6c, 0x60, 0x57, 0xa5, 0x88, 0xd1, 0xe1, 0x53, 0x5b, 0x47, 0x2d, 0x30, 0xfb, 0x2f, 0xf1,
+	0xc1, 0x78, 0x74, 0x74, 0x68, 0x1b, 0x93, 0x3a, 0xff, 0x57, 0xfd, 0xe4, 0xcf, 0x01, 0x00, 0xe4,
+	0x8a, 0xcd, 0xd8, 0xb8, 0x0e, 0x00, 0x00,
 }
@@ -21,6 +21,19 @@ type Diagnostic interface {
 	UDFLog(msg string)
 }
 
+// BlobStore provides access to the blob store for UDFs.
+type BlobStore interface {
+	// SaveBlob stores data as a blob and returns the ID of the blob.
+	// If tag is not empty the blob is tagged with it.
+	SaveBlob(data []byte, tag string) (string, error)
+	// GetBlob returns the ID and content of a blob,
+	// the blob is retrieved by tag if the id is empty.
+	GetBlob(id, tag string) (string, []byte, error)
+}
+
+// Size of the buffer of blob requests from the UDF awaiting processing.
+const blobRequestsBuffer = 100
+
 // Server provides an implementation for the core communication with UDFs.
 // The Server provides only a partial implementation of udf.Interface as
 // it is expected that setup and teardown will be necessary to create a Server.
@@ -41,7 +54,12 @@ type Diagnostic interface {
 //
 // Calling Init is required to process data.
 // The behavior is undefined if you send points/batches to the Server without calling Init.
+//
+// The UDF may save and get blobs while it is running,
+// blob requests are only answered if the BlobStore is set before calling Start.
 type Server struct {
+	// Optional store for the blob requests of the UDF.
+	BlobStore BlobStore
 
 	// If the processes is Aborted (via Keepalive timeout, etc.)
 	// then no more data will be read off the *In channels.
@@ -91,6 +109,10 @@ type Server struct {
 	snapshotResponse chan *agent.Response
 	restoreResponse  chan *agent.Response
 
+	// Blob requests from the UDF are answered in order by a separate goroutine,
+	// so that reading responses never waits on writing requests.
+	blobRequests chan *agent.Response
+
 	// Buffer up batch messages
 	begin  *agent.BeginBatch
 	points []edge.BatchPointMessage
@@ -122,6 +144,7 @@ func NewServer(
 		initResponse:     make(chan *agent.Response, 1),
 		snapshotResponse: make(chan *agent.Response, 1),
 		restoreResponse:  make(chan *agent.Response, 1),
+		blobRequests:     make(chan *agent.Response, blobRequestsBuffer),
 	}
 
 	return s
@@ -170,9 +193,10 @@ func (s *Server) Start() error {
 		s.ioGroup.Done()
 	}()
 
-	s.requestsGroup.Add(2)
+	s.requestsGroup.Add(3)
 	go s.runKeepalive()
 	go s.watchKeepalive()
+	go s.runBlobRequests()
 
 	return nil
 }
@@ -383,6 +407,58 @@ func (s *Server) runKeepalive() {
 	}
 }
 
+// answer the blob requests of the UDF in the order they were received.
+func (s *Server) runBlobRequests() {
+	defer s.requestsGroup.Done()
+	for {
+		select {
+		case response := <-s.blobRequests:
+			req := s.handleBlobRequest(response)
+			select {
+			case s.requests <- req:
+			case <-s.aborting:
+				return
+			}
+		case <-s.stopping:
+			return
+		}
+	}
+}
+
+func (s *Server) handleBlobRequest(response *agent.Response) *agent.Request {
+	switch msg := response.Message.(type) {
+	case *agent.Response_SaveBlob:
+		resp := &agent.SaveBlobResponse{
+			Tag: msg.SaveBlob.Tag,
+		}
+		if s.BlobStore == nil {
+			resp.Error = "blob store not available"
+		} else if id, err := s.BlobStore.SaveBlob(msg.SaveBlob.Data, msg.SaveBlob.Tag); err != nil {
+			s.diag.Error("failed to save blob", err)
+			resp.Error = err.Error()
+		} else {
+			resp.Id = id
+		}
+		return &agent.Request{Message: &agent.Request_SaveBlob{SaveBlob: resp}}
+	case *agent.Response_GetBlob:
+		resp := &agent.GetBlobResponse{
+			Id:  msg.GetBlob.Id,
+			Tag: msg.GetBlob.Tag,
+		}
+		if s.BlobStore == nil {
+			resp.Error = "blob store not available"
+		} else if id, data, err := s.BlobStore.GetBlob(msg.GetBlob.Id, msg.GetBlob.Tag); err != nil {
+			resp.Error = err.Error()
+		} else {
+			resp.Id = id
+			resp.Data = data
+		}
+		return &agent.Request{Message: &agent.Request_GetBlob{GetBlob: resp}}
+	default:
+		panic(fmt.Sprintf("unexpected blob request message %T", msg))
+	}
+}
+
 // Abort the process if a keepalive timeout is reached.
 func (s *Server) watchKeepalive() {
 	// Defer functions are called LIFO.
@@ -693,6 +769,15 @@ func (s *Server) handleResponse(response *agent.Response) error {
 		s.doResponse(response, s.snapshotResponse)
 	case *agent.Response_Restore:
 		s.doResponse(response, s.restoreResponse)
+	case *agent.Response_SaveBlob, *agent.Response_GetBlob:
+		select {
+		case s.blobRequests <- response:
+		case <-s.stopping:
+			// Blob requests are no longer answered once the server is stopping.
+			s.diag.Error("dropped blob request", ErrServerStopped)
+		case <-s.aborting:
+			return s.err
+		}
 	case *agent.Response_Error:
 		s.diag.Error("received error message", errors.New(msg.Error.Error))
 		return errors.New(msg.Error.Error)
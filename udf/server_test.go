@@ -545,3 +545,84 @@ func TestUDF_StartInitBatchStop(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+type blobStore map[string][]byte
+
+func (s blobStore) SaveBlob(data []byte, tag string) (string, error) {
+	id := "id-" + string(data)
+	s[id] = data
+	if tag != "" {
+		s[tag] = data
+	}
+	return id, nil
+}
+
+func (s blobStore) GetBlob(id, tag string) (string, []byte, error) {
+	if id == "" {
+		id = tag
+	}
+	data, ok := s[id]
+	if !ok {
+		return "", nil, errors.New("no blob exists")
+	}
+	return id, data, nil
+}
+
+func TestUDF_SaveGetBlob(t *testing.T) {
+	u := udf_test.NewIO()
+	d := kapacitorDiag.WithNodeContext("TestUDF_SaveGetBlob")
+	s := udf.NewServer("testTask", "testNode", u.Out(), u.In(), d, 0, nil, nil)
+	s.BlobStore = blobStore{}
+	s.Start()
+
+	u.Responses <- &agent.Response{
+		Message: &agent.Response_SaveBlob{
+			SaveBlob: &agent.SaveBlobRequest{Data: []byte("model"), Tag: "latest"},
+		},
+	}
+	u.Responses <- &agent.Response{
+		Message: &agent.Response_GetBlob{
+			GetBlob: &agent.GetBlobRequest{Tag: "latest"},
+		},
+	}
+	u.Responses <- &agent.Response{
+		Message: &agent.Response_GetBlob{
+			GetBlob: &agent.GetBlobRequest{Id: "missing"},
+		},
+	}
+
+	// Blob requests are answered in order.
+	req := <-u.Requests
+	save, ok := req.Message.(*agent.Request_SaveBlob)
+	if !ok {
+		t.Fatalf("expected save blob message got %T", req.Message)
+	}
+	if exp := (&agent.SaveBlobResponse{Id: "id-model", Tag: "latest"}); !reflect.DeepEqual(save.SaveBlob, exp) {
+		t.Errorf("unexpected save blob response got %v exp %v", save.SaveBlob, exp)
+	}
+	req = <-u.Requests
+	get, ok := req.Message.(*agent.Request_GetBlob)
+	if !ok {
+		t.Fatalf("expected get blob message got %T", req.Message)
+	}
+	if exp := (&agent.GetBlobResponse{Id: "latest", Tag: "latest", Data: []byte("model")}); !reflect.DeepEqual(get.GetBlob, exp) {
+		t.Errorf("unexpected get blob response got %v exp %v", get.GetBlob, exp)
+	}
+	req = <-u.Requests
+	get, ok = req.Message.(*agent.Request_GetBlob)
+	if !ok {
+		t.Fatalf("expected get blob message got %T", req.Message)
+	}
+	if get.GetBlob.Error == "" {
+		t.Error("expected error for missing blob")
+	}
+
+	close(u.Responses)
+	s.Stop()
+	// read all requests and wait till the chan is closed
+	for range u.Requests {
+	}
+	if err := <-u.ErrC; err != nil {
+		t.Error(err)
+	}
+}
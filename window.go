@@ -3,9 +3,12 @@ package kapacitor
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/thingnario/kapacitor/edge"
+	"github.com/thingnario/kapacitor/expvar"
+	"github.com/thingnario/kapacitor/keyvalue"
 	"github.com/thingnario/kapacitor/models"
 	"github.com/thingnario/kapacitor/pipeline"
 )
@@ -13,12 +16,14 @@ import (
 type WindowNode struct {
 	node
 	w *pipeline.WindowNode
+
+	groups groupCheckpoint
 }
 
 // Create a new  WindowNode, which windows data for a period of time and emits the window.
 func newWindowNode(et *ExecutingTask, n *pipeline.WindowNode, d NodeDiagnostic) (*WindowNode, error) {
-	if n.Period == 0 && n.PeriodCount == 0 {
-		return nil, errors.New("window node must have either a non zero period or non zero period count")
+	if n.Period == 0 && n.PeriodCount == 0 && n.SessionGap == 0 {
+		return nil, errors.New("window node must have either a non zero period, period count or session gap")
 	}
 	wn := &WindowNode{
 		w:    n,
@@ -28,7 +33,16 @@ func newWindowNode(et *ExecutingTask, n *pipeline.WindowNode, d NodeDiagnostic)
 	return wn, nil
 }
 
-func (n *WindowNode) runWindow([]byte) (err error) {
+func (n *WindowNode) runWindow(snapshot []byte) (err error) {
+	if err := n.groups.restore(snapshot); err != nil {
+		n.diag.Error("failed to restore node snapshot", err)
+	}
+	if n.w.SessionGap != 0 {
+		sessions := newWindowSessions(n)
+		sessions.restore()
+		n.statMap.Set(statCardinalityGauge, sessions.cardinality)
+		return edge.NewConsumerWithReceiver(n.ins[0], sessions).Consume()
+	}
 	consumer := edge.NewGroupedConsumer(n.ins[0], n)
 	n.statMap.Set(statCardinalityGauge, consumer.CardinalityVar())
 	err = consumer.Consume()
@@ -36,21 +50,29 @@ func (n *WindowNode) runWindow([]byte) (err error) {
 }
 
 func (n *WindowNode) NewGroup(group edge.GroupInfo, first edge.PointMeta) (edge.Receiver, error) {
-	r, err := n.newWindow(group, first)
+	w, err := n.newWindow(group, first)
 	if err != nil {
 		return nil, err
 	}
+	r, err := n.groups.add(group.ID, w)
+	if err != nil {
+		n.diag.Error("failed to restore group state", err, keyvalue.KV("group", string(group.ID)))
+	}
 	return edge.NewReceiverFromForwardReceiverWithStats(
 		n.outs,
 		edge.NewTimedForwardReceiver(n.timer, r),
 	), nil
 }
 
+func (n *WindowNode) snapshot() ([]byte, error) {
+	return n.groups.snapshot()
+}
+
 func (n *WindowNode) DeleteGroup(group models.GroupID) {
 	// Nothing to do
 }
 
-func (n *WindowNode) newWindow(group edge.GroupInfo, first edge.PointMeta) (edge.ForwardReceiver, error) {
+func (n *WindowNode) newWindow(group edge.GroupInfo, first edge.PointMeta) (groupState, error) {
 	switch {
 	case n.w.Period != 0:
 		return newWindowByTime(
@@ -185,6 +207,34 @@ func (w *windowByTime) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, err
 }
 func (w *windowByTime) Done() {}
 
+type windowByTimeState struct {
+	NextEmit time.Time
+	Points   []pointState
+}
+
+func (w *windowByTime) snapshotState() ([]byte, error) {
+	state := windowByTimeState{
+		NextEmit: w.nextEmit,
+	}
+	w.buf.each(func(p edge.PointMessage) {
+		state.Points = append(state.Points, newPointState(p))
+	})
+	return encodeState(state)
+}
+
+func (w *windowByTime) restoreState(data []byte) error {
+	var state windowByTimeState
+	if err := decodeState(data, &state); err != nil {
+		return err
+	}
+	w.nextEmit = state.NextEmit
+	w.buf = &windowTimeBuffer{diag: w.diag}
+	for _, p := range state.Points {
+		w.buf.insert(p.point())
+	}
+	return nil
+}
+
 func (w *windowByTime) Point(p edge.PointMessage) (msg edge.Message, err error) {
 	if w.every == 0 {
 		// Insert point before.
@@ -329,6 +379,25 @@ func (b *windowTimeBuffer) purge(oldest time.Time, inclusive bool) {
 	}
 }
 
+// Calls f for each point in the buffer, in insertion order.
+func (b *windowTimeBuffer) each(f func(p edge.PointMessage)) {
+	if b.size == 0 {
+		return
+	}
+	if b.stop > b.start {
+		for _, p := range b.window[b.start:b.stop] {
+			f(p)
+		}
+		return
+	}
+	for _, p := range b.window[b.start:] {
+		f(p)
+	}
+	for _, p := range b.window[:b.stop] {
+		f(p)
+	}
+}
+
 // Returns a copy of the current buffer.
 // TODO(nathanielc): Optimize this function use buffered vs unbuffered batch messages.
 func (b *windowTimeBuffer) points() []edge.BatchPointMessage {
@@ -414,6 +483,43 @@ func (w *windowByCount) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, er
 }
 func (w *windowByCount) Done() {}
 
+type windowByCountState struct {
+	Points   []batchPointState
+	NextEmit int
+	Count    int
+}
+
+func (w *windowByCount) snapshotState() ([]byte, error) {
+	state := windowByCountState{
+		NextEmit: w.nextEmit,
+		Count:    w.count,
+	}
+	for _, p := range w.points() {
+		state.Points = append(state.Points, newBatchPointState(p))
+	}
+	return encodeState(state)
+}
+
+func (w *windowByCount) restoreState(data []byte) error {
+	var state windowByCountState
+	if err := decodeState(data, &state); err != nil {
+		return err
+	}
+	if len(state.Points) > w.period {
+		return fmt.Errorf("snapshot has %d points, more than the window period count %d", len(state.Points), w.period)
+	}
+	w.buf = make([]edge.BatchPointMessage, w.period)
+	for i, p := range state.Points {
+		w.buf[i] = p.batchPoint()
+	}
+	w.start = 0
+	w.size = len(state.Points)
+	w.stop = w.size % w.period
+	w.nextEmit = state.NextEmit
+	w.count = state.Count
+	return nil
+}
+
 func (w *windowByCount) Point(p edge.PointMessage) (msg edge.Message, err error) {
 	w.buf[w.stop] = edge.BatchPointFromPoint(p)
 	w.stop = (w.stop + 1) % w.period
@@ -468,3 +574,310 @@ func (w *windowByCount) points() []edge.BatchPointMessage {
 	}
 	return points
 }
+
+// windowSessions manages the session windows of all groups.
+// Sessions are closed by the task clock, the time of the latest message received by the node,
+// so that a session of a group closes even if the group itself receives no more data.
+type windowSessions struct {
+	n *WindowNode
+
+	groups   map[models.GroupID]edge.ForwardReceiver
+	sessions map[models.GroupID]*windowBySession
+
+	now time.Time
+	// Earliest time an open session may close, zero if no session is open.
+	nextClose time.Time
+
+	cardinality *expvar.Int
+}
+
+func newWindowSessions(n *WindowNode) *windowSessions {
+	return &windowSessions{
+		n:           n,
+		groups:      make(map[models.GroupID]edge.ForwardReceiver),
+		sessions:    make(map[models.GroupID]*windowBySession),
+		cardinality: new(expvar.Int),
+	}
+}
+
+func (s *windowSessions) BeginBatch(edge.BeginBatchMessage) error {
+	return errors.New("window does not support batch data")
+}
+func (s *windowSessions) BatchPoint(edge.BatchPointMessage) error {
+	return errors.New("window does not support batch data")
+}
+func (s *windowSessions) EndBatch(edge.EndBatchMessage) error {
+	return errors.New("window does not support batch data")
+}
+func (s *windowSessions) BufferedBatch(edge.BufferedBatchMessage) error {
+	return errors.New("window does not support batch data")
+}
+
+func (s *windowSessions) Point(p edge.PointMessage) error {
+	s.n.timer.Start()
+	r, w := s.group(p.GroupInfo(), p.Name())
+	// Close the sessions that ended before the point, including the session of its group.
+	msgs := s.advance(p.Time())
+	_, err := r.Point(p)
+	s.schedule(w)
+	s.n.timer.Stop()
+	if err != nil {
+		return err
+	}
+	return s.forward(msgs)
+}
+
+func (s *windowSessions) Barrier(b edge.BarrierMessage) error {
+	s.n.timer.Start()
+	msgs := s.advance(b.Time())
+	s.n.timer.Stop()
+	return s.forward(append(msgs, b))
+}
+
+// DeleteGroup emits the open session of the group before the group is deleted.
+func (s *windowSessions) DeleteGroup(d edge.DeleteGroupMessage) error {
+	id := d.GroupID()
+	r, ok := s.groups[id]
+	if !ok {
+		return nil
+	}
+	var msgs []edge.Message
+	s.n.groups.mu.Lock()
+	if batch, ok := s.sessions[id].close(); ok {
+		msgs = append(msgs, batch)
+	}
+	s.n.groups.mu.Unlock()
+	msg, err := r.DeleteGroup(d)
+	if err != nil {
+		return err
+	}
+	delete(s.groups, id)
+	delete(s.sessions, id)
+	s.cardinality.Add(-1)
+	return s.forward(append(msgs, msg))
+}
+
+func (s *windowSessions) Done() {
+	for _, r := range s.groups {
+		r.Done()
+	}
+}
+
+// restore recreates the groups of all restored sessions,
+// so that the task clock closes them even if their groups receive no more data.
+func (s *windowSessions) restore() {
+	for id, data := range s.n.groups.restoredGroups() {
+		var state windowBySessionState
+		if err := decodeState(data, &state); err != nil {
+			s.n.diag.Error("failed to restore group state", err, keyvalue.KV("group", string(id)))
+			continue
+		}
+		s.group(edge.GroupInfo{ID: id, Tags: state.Tags, Dimensions: state.Dimensions}, state.Name)
+	}
+}
+
+// group returns the receiver and session of the group, creating them if needed.
+func (s *windowSessions) group(group edge.GroupInfo, name string) (edge.ForwardReceiver, *windowBySession) {
+	if r, ok := s.groups[group.ID]; ok {
+		return r, s.sessions[group.ID]
+	}
+	w := newWindowBySession(name, group, s.n.w.SessionGap, s.n.w.MaxSessionLength)
+	r, err := s.n.groups.add(group.ID, w)
+	if err != nil {
+		s.n.diag.Error("failed to restore group state", err, keyvalue.KV("group", string(group.ID)))
+	}
+	s.groups[group.ID] = r
+	s.sessions[group.ID] = w
+	s.cardinality.Add(1)
+	// A restored session may already be open.
+	s.schedule(w)
+	return r, w
+}
+
+// schedule makes sure the open session of w is closed once the task clock passes its deadline.
+func (s *windowSessions) schedule(w *windowBySession) {
+	if !w.open() {
+		return
+	}
+	if d := w.deadline(); s.nextClose.IsZero() || d.Before(s.nextClose) {
+		s.nextClose = d
+	}
+}
+
+// advance moves the task clock to t and returns the batches of the sessions that closed,
+// ordered by their deadline and group.
+func (s *windowSessions) advance(t time.Time) []edge.Message {
+	if !t.After(s.now) {
+		return nil
+	}
+	s.now = t
+	if s.nextClose.IsZero() || s.now.Before(s.nextClose) {
+		return nil
+	}
+
+	var closed []*windowBySession
+	s.nextClose = time.Time{}
+	for _, w := range s.sessions {
+		if !w.open() {
+			continue
+		}
+		if w.deadline().After(s.now) {
+			s.schedule(w)
+			continue
+		}
+		closed = append(closed, w)
+	}
+	sort.Slice(closed, func(i, j int) bool {
+		di, dj := closed[i].deadline(), closed[j].deadline()
+		if !di.Equal(dj) {
+			return di.Before(dj)
+		}
+		return closed[i].group.ID < closed[j].group.ID
+	})
+
+	msgs := make([]edge.Message, 0, len(closed))
+	s.n.groups.mu.Lock()
+	defer s.n.groups.mu.Unlock()
+	for _, w := range closed {
+		if batch, ok := w.close(); ok {
+			msgs = append(msgs, batch)
+		}
+	}
+	return msgs
+}
+
+func (s *windowSessions) forward(msgs []edge.Message) error {
+	for _, msg := range msgs {
+		if err := edge.Forward(s.n.outs, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// windowBySession buffers the points of the current session of a group.
+// The session is closed by windowSessions.
+type windowBySession struct {
+	name  string
+	group edge.GroupInfo
+
+	gap       time.Duration
+	maxLength time.Duration
+
+	start time.Time
+	last  time.Time
+
+	points []edge.BatchPointMessage
+}
+
+func newWindowBySession(name string, group edge.GroupInfo, gap, maxLength time.Duration) *windowBySession {
+	return &windowBySession{
+		name:      name,
+		group:     group,
+		gap:       gap,
+		maxLength: maxLength,
+	}
+}
+
+func (w *windowBySession) BeginBatch(edge.BeginBatchMessage) (edge.Message, error) {
+	return nil, errors.New("window does not support batch data")
+}
+func (w *windowBySession) BatchPoint(edge.BatchPointMessage) (edge.Message, error) {
+	return nil, errors.New("window does not support batch data")
+}
+func (w *windowBySession) EndBatch(edge.EndBatchMessage) (edge.Message, error) {
+	return nil, errors.New("window does not support batch data")
+}
+func (w *windowBySession) Barrier(b edge.BarrierMessage) (edge.Message, error) {
+	return b, nil
+}
+func (w *windowBySession) DeleteGroup(d edge.DeleteGroupMessage) (edge.Message, error) {
+	return d, nil
+}
+func (w *windowBySession) Done() {}
+
+func (w *windowBySession) Point(p edge.PointMessage) (edge.Message, error) {
+	if !w.open() {
+		w.start = p.Time()
+		w.last = p.Time()
+	}
+	w.points = append(w.points, edge.BatchPointFromPoint(p))
+	if p.Time().After(w.last) {
+		w.last = p.Time()
+	}
+	return nil, nil
+}
+
+func (w *windowBySession) open() bool {
+	return len(w.points) > 0
+}
+
+// deadline returns the time at which the open session closes unless more points arrive.
+func (w *windowBySession) deadline() time.Time {
+	d := w.last.Add(w.gap)
+	if w.maxLength != 0 {
+		if max := w.start.Add(w.maxLength); max.Before(d) {
+			d = max
+		}
+	}
+	return d
+}
+
+// close ends the open session and returns its points as a batch with the time of the last point.
+func (w *windowBySession) close() (edge.BufferedBatchMessage, bool) {
+	if !w.open() {
+		return nil, false
+	}
+	points := w.points
+	w.points = nil
+	return edge.NewBufferedBatchMessage(
+		edge.NewBeginBatchMessage(
+			w.name,
+			w.group.Tags,
+			w.group.Dimensions.ByName,
+			w.last,
+			len(points),
+		),
+		points,
+		edge.NewEndBatchMessage(),
+	), true
+}
+
+type windowBySessionState struct {
+	// Name and group of the session, to recreate the group after a restart.
+	Name       string
+	Tags       models.Tags
+	Dimensions models.Dimensions
+
+	Start  time.Time
+	Last   time.Time
+	Points []batchPointState
+}
+
+func (w *windowBySession) snapshotState() ([]byte, error) {
+	state := windowBySessionState{
+		Name:       w.name,
+		Tags:       w.group.Tags,
+		Dimensions: w.group.Dimensions,
+		Start:      w.start,
+		Last:       w.last,
+	}
+	for _, p := range w.points {
+		state.Points = append(state.Points, newBatchPointState(p))
+	}
+	return encodeState(state)
+}
+
+func (w *windowBySession) restoreState(data []byte) error {
+	var state windowBySessionState
+	if err := decodeState(data, &state); err != nil {
+		return err
+	}
+	w.start = state.Start
+	w.last = state.Last
+	w.points = make([]edge.BatchPointMessage, len(state.Points))
+	for i, p := range state.Points {
+		w.points[i] = p.batchPoint()
+	}
+	return nil
+}